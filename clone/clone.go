@@ -84,6 +84,10 @@ type Enzyme struct {
 	Skip            int
 	OverheadLength  int
 	RecognitionSite string
+	// BlockedBy are the names of the methylases, like "Dam" or "Dcm", that
+	// keep the enzyme from cutting a recognition site when they methylate a
+	// base of it. Other methylases are assumed not to block the enzyme.
+	BlockedBy []string
 }
 
 // EnzymeManager manager for Enzymes. Allows for management of enzymes throughout the lifecyle of your
@@ -136,6 +140,12 @@ func (enzymeManager EnzymeManager) GetEnzymeByName(name string) (Enzyme, error)
 
 // CutWithEnzyme cuts a given sequence with an enzyme represented by an Enzyme struct.
 func CutWithEnzyme(part Part, directional bool, enzyme Enzyme) []Fragment {
+	return cutWithEnzyme(part, directional, enzyme, nil)
+}
+
+// cutWithEnzyme cuts a sequence grown in a host with the given methylation
+// profile, which may be nil for unmethylated DNA.
+func cutWithEnzyme(part Part, directional bool, enzyme Enzyme, host MethylationProfile) []Fragment {
 	var fragmentSequences []string
 	var sequence string
 	if part.Circular {
@@ -147,17 +157,20 @@ func CutWithEnzyme(part Part, directional bool, enzyme Enzyme) []Fragment {
 	// Check for palindromes
 	palindromic := checks.IsPalindromic(enzyme.RecognitionSite)
 
+	// Recognition sites overlapping bases methylated by a blocking methylase can't be cut
+	methylated := host.blocking(enzyme).methylatedBases(sequence)
+
 	// Find and define overhangs
 	var overhangs []Overhang
 	var forwardOverhangs []Overhang
 	var reverseOverhangs []Overhang
-	forwardCuts := enzyme.RegexpFor.FindAllStringIndex(sequence, -1)
+	forwardCuts := unblockedSites(enzyme.RegexpFor.FindAllStringIndex(sequence, -1), methylated)
 	for _, forwardCut := range forwardCuts {
 		forwardOverhangs = append(forwardOverhangs, Overhang{Length: enzyme.OverheadLength, Position: forwardCut[1] + enzyme.Skip, Forward: true, RecognitionSitePlusSkipLength: len(enzyme.RecognitionSite) + enzyme.Skip})
 	}
 	// Palindromic enzymes won't need reverseCuts
 	if !palindromic {
-		reverseCuts := enzyme.RegexpRev.FindAllStringIndex(sequence, -1)
+		reverseCuts := unblockedSites(enzyme.RegexpRev.FindAllStringIndex(sequence, -1), methylated)
 		for _, reverseCut := range reverseCuts {
 			reverseOverhangs = append(reverseOverhangs, Overhang{Length: enzyme.OverheadLength, Position: reverseCut[0] - enzyme.Skip, Forward: false, RecognitionSitePlusSkipLength: len(enzyme.RecognitionSite) + enzyme.Skip})
 		}
@@ -354,11 +367,12 @@ func GoldenGate(sequences []Part, cuttingEnzyme Enzyme) (openConstructs []string
 	return openconstructs, infiniteloops
 }
 
-// GetBaseRestrictionEnzymes return a basic slice of common enzymes used in Golden Gate Assembly. Eventually, we want to get the data for this map from ftp://ftp.neb.com/pub/rebase
+// GetBaseRestrictionEnzymes return a basic slice of common enzymes used in Golden Gate Assembly.
+// Any other enzyme can be converted from a REBASE data dump with the rebase package.
 func GetBaseRestrictionEnzymes() []Enzyme {
 	return []Enzyme{
-		{"BsaI", regexp.MustCompile("GGTCTC"), regexp.MustCompile("GAGACC"), 1, 4, "GGTCTC"},
-		{"BbsI", regexp.MustCompile("GAAGAC"), regexp.MustCompile("GTCTTC"), 2, 4, "GAAGAC"},
-		{"BtgZI", regexp.MustCompile("GCGATG"), regexp.MustCompile("CATCGC"), 10, 4, "GCGATG"},
+		{"BsaI", regexp.MustCompile("GGTCTC"), regexp.MustCompile("GAGACC"), 1, 4, "GGTCTC", []string{"Dcm", "CpG"}},
+		{"BbsI", regexp.MustCompile("GAAGAC"), regexp.MustCompile("GTCTTC"), 2, 4, "GAAGAC", nil},
+		{"BtgZI", regexp.MustCompile("GCGATG"), regexp.MustCompile("CATCGC"), 10, 4, "GCGATG", nil},
 	}
 }
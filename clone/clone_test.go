@@ -244,3 +244,108 @@ func BenchmarkGoldenGate3Parts(b *testing.B) {
 
 	benchmarkGoldenGate(b, enzymeManager, []Part{fragment1, fragment2, popen})
 }
+
+func TestMethylationProfile_methylatedBases(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    MethylationProfile
+		sequence   string
+		methylated []int
+	}{
+		{name: "no profile", profile: nil, sequence: "GATC"},
+		{name: "Dam", profile: MethylationProfile{Dam}, sequence: "AGATCA", methylated: []int{2, 3}},
+		{name: "overlapping Dam", profile: MethylationProfile{Dam}, sequence: "GATCGATC", methylated: []int{1, 2, 5, 6}},
+		{name: "Dcm", profile: DamDcm, sequence: "ACCTGGA", methylated: []int{2, 4}},
+		{name: "EcoKI", profile: MethylationProfile{EcoKI}, sequence: "AACGGGGGGGTGC", methylated: []int{1, 10}},
+		{name: "EcoKI reverse", profile: MethylationProfile{EcoKI}, sequence: "GCACCCCCCCGTT", methylated: []int{2, 11}},
+		{name: "CpG", profile: MethylationProfile{CpG}, sequence: "ACGT", methylated: []int{1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []int
+			for position, isMethylated := range test.profile.methylatedBases(test.sequence) {
+				if isMethylated {
+					got = append(got, position)
+				}
+			}
+			if len(got) != len(test.methylated) {
+				t.Fatalf("methylatedBases() = %v, want %v", got, test.methylated)
+			}
+			for index := range got {
+				if got[index] != test.methylated[index] {
+					t.Fatalf("methylatedBases() = %v, want %v", got, test.methylated)
+				}
+			}
+		})
+	}
+}
+
+func TestCutWithEnzymeMethylated(t *testing.T) {
+	regexpXbaI, err := SiteRegexp("TCTAGA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xbaI := Enzyme{Name: "XbaI", RegexpFor: regexpXbaI, RegexpRev: regexpXbaI, Skip: -5, OverheadLength: 4, RecognitionSite: "TCTAGA", BlockedBy: []string{"Dam"}}
+	// The second XbaI site overlaps GATC, so it is blocked by Dam methylation.
+	part := Part{Sequence: "AAAAAAAAAATCTAGACCCCCCCCCCTCTAGATCGGGGGGGGGG"}
+
+	unmethylated := CutWithEnzyme(part, false, xbaI)
+	if len(unmethylated) != 1 || unmethylated[0].ForwardOverhang != "CTAG" {
+		t.Errorf("Unexpected unmethylated fragments %+v", unmethylated)
+	}
+
+	methylated := CutWithEnzymeMethylated(part, false, xbaI, DamDcm)
+	if len(methylated) != 2 || methylated[0].Sequence != "ACCCCCCCCCCTCTAGATCGGGGGGGGGG" || methylated[1].Sequence != "AAAAAAAAAAT" {
+		t.Errorf("Unexpected methylated fragments %+v", methylated)
+	}
+
+	// CpG methylation doesn't overlap either site.
+	cpg := CutWithEnzymeMethylated(part, false, xbaI, MethylationProfile{CpG})
+	if len(cpg) != len(unmethylated) || cpg[0] != unmethylated[0] {
+		t.Errorf("CpG methylation should not change the fragments, got %+v", cpg)
+	}
+
+	// Without BlockedBy, the enzyme isn't known to be sensitive to Dam.
+	insensitiveXbaI := xbaI
+	insensitiveXbaI.BlockedBy = nil
+	insensitive := CutWithEnzymeMethylated(part, false, insensitiveXbaI, DamDcm)
+	if len(insensitive) != len(unmethylated) || insensitive[0] != unmethylated[0] {
+		t.Errorf("Methylation should not block an enzyme without BlockedBy, got %+v", insensitive)
+	}
+
+	enzymeManager := NewEnzymeManager([]Enzyme{xbaI})
+	if _, err := enzymeManager.CutWithEnzymeByNameMethylated(part, false, "EcoFake", DamDcm); err == nil {
+		t.Errorf("CutWithEnzymeByNameMethylated should have failed when looking for fake restriction enzyme EcoFake")
+	}
+}
+
+func TestCutWithEnzymeMethylated_damInsensitive(t *testing.T) {
+	regexpBamHI, err := SiteRegexp("GGATCC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bamHI := Enzyme{Name: "BamHI", RegexpFor: regexpBamHI, RegexpRev: regexpBamHI, Skip: -5, OverheadLength: 4, RecognitionSite: "GGATCC", BlockedBy: []string{"CpG"}}
+	// Every BamHI site contains GATC, which Dam methylates, yet BamHI still cuts it.
+	part := Part{Sequence: "AAAAAAAAAAGGATCCAAAAAAAAAA"}
+	want := CutWithEnzyme(part, false, bamHI)
+	if len(want) != 2 {
+		t.Fatalf("Unexpected unmethylated fragments %+v", want)
+	}
+	got := CutWithEnzymeMethylated(part, false, bamHI, DamDcm)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("CutWithEnzymeMethylated() in a Dam+ host = %+v, want %+v", got, want)
+	}
+}
+
+func TestSiteRegexp(t *testing.T) {
+	regexp, err := SiteRegexp("GTMKAC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regexp.String() != "GT[AC][GT]AC" {
+		t.Errorf("SiteRegexp() = %s, want GT[AC][GT]AC", regexp)
+	}
+	if _, err := SiteRegexp("GAZTTC"); err == nil {
+		t.Errorf("SiteRegexp() should fail for non IUPAC bases")
+	}
+}
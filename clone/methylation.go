@@ -0,0 +1,227 @@
+package clone
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Methylation sensitive cutting begins here.
+
+Plasmids are usually grown in E. coli strains that methylate their DNA. Dam
+methylates the adenine in GATC and Dcm the second cytosine in CCWGG. Many
+restriction enzymes can't cut a recognition site that overlaps such a
+methylated base, which is a classic reason for a digest to go wrong, for
+example XbaI sites followed by GATC in a plasmid from a dam+ strain.
+
+Which methylases block an enzyme differs from enzyme to enzyme: XbaI can't
+cut TCTAGA followed by TC in a dam+ strain, but BamHI cuts GGATCC even
+though it always contains a methylated GATC. A MethylationProfile describes
+the methylases of the host a sequence was grown in, Enzyme.BlockedBy lists
+the methylases an enzyme is sensitive to, and CutWithEnzymeMethylated skips
+the recognition sites methylated by a methylase that is in both.
+
+******************************************************************************/
+
+// MethylationType is the kind of base modification made by a methylase. The
+// values are the ones REBASE uses in its methylation site notation.
+type MethylationType int
+
+const (
+	// N4Methylcytosine is written as (4) in REBASE.
+	N4Methylcytosine MethylationType = 4
+	// C5Methylcytosine is written as (5) in REBASE.
+	C5Methylcytosine MethylationType = 5
+	// N6Methyladenine is written as (6) in REBASE.
+	N6Methyladenine MethylationType = 6
+)
+
+// Methylation is a methylated base within a recognition site.
+//
+// Position follows REBASE: it is the 1-based position of the base in the
+// recognition site, and negative positions are on the complementary strand,
+// counted from its 5' end. A Position of 0 means the methylated base is not
+// known.
+type Methylation struct {
+	Position int             `json:"position"`
+	Type     MethylationType `json:"type"`
+}
+
+// Methylase is a DNA methyltransferase that methylates the bases of
+// Methylations wherever Site is found, on both strands.
+type Methylase struct {
+	Name         string        `json:"name"`
+	Site         string        `json:"site"`
+	Methylations []Methylation `json:"methylations"`
+}
+
+// MethylationProfile is the set of methylases active in a host strain.
+type MethylationProfile []Methylase
+
+// Common methylases of E. coli hosts, and the CpG methylation of mammalian
+// cells (or of M.SssI treated DNA).
+var (
+	Dam   = Methylase{Name: "Dam", Site: "GATC", Methylations: []Methylation{{Position: 2, Type: N6Methyladenine}}}
+	Dcm   = Methylase{Name: "Dcm", Site: "CCWGG", Methylations: []Methylation{{Position: 2, Type: C5Methylcytosine}}}
+	EcoKI = Methylase{Name: "EcoKI", Site: "AACNNNNNNGTGC", Methylations: []Methylation{{Position: 2, Type: N6Methyladenine}, {Position: -3, Type: N6Methyladenine}}}
+	CpG   = Methylase{Name: "CpG", Site: "CG", Methylations: []Methylation{{Position: 1, Type: C5Methylcytosine}}}
+)
+
+// DamDcm is the methylation profile of common E. coli K-12 cloning strains,
+// like DH5alpha or TOP10. DH5alpha and TOP10 don't methylate with EcoKI,
+// but strains like MG1655 do and can use
+// MethylationProfile{Dam, Dcm, EcoKI}.
+var DamDcm = MethylationProfile{Dam, Dcm}
+
+// iupacBases maps the IUPAC ambiguity codes used in recognition sites to the
+// bases they stand for.
+var iupacBases = map[rune]string{
+	'A': "A",
+	'C': "C",
+	'G': "G",
+	'T': "T",
+	'R': "AG",
+	'Y': "CT",
+	'M': "AC",
+	'K': "GT",
+	'S': "CG",
+	'W': "AT",
+	'B': "CGT",
+	'D': "AGT",
+	'H': "ACT",
+	'V': "ACG",
+	'N': "ACGT",
+}
+
+// SiteRegexp compiles a recognition site, which may contain IUPAC ambiguity
+// codes, into a regular expression like the ones of Enzyme.
+func SiteRegexp(site string) (*regexp.Regexp, error) {
+	var expression strings.Builder
+	for _, base := range strings.ToUpper(site) {
+		bases, ok := iupacBases[base]
+		if !ok {
+			return nil, fmt.Errorf("%q is not an IUPAC base", base)
+		}
+		if len(bases) == 1 {
+			expression.WriteString(bases)
+		} else {
+			expression.WriteString("[" + bases + "]")
+		}
+	}
+	return regexp.Compile(expression.String())
+}
+
+// siteMatches checks if window, which has the length of site, is matched by
+// the IUPAC recognition site.
+func siteMatches(site, window string) bool {
+	for index, base := range site {
+		if !strings.ContainsRune(iupacBases[base], rune(window[index])) {
+			return false
+		}
+	}
+	return true
+}
+
+// methylatedBases marks every base pair of sequence that is methylated on
+// either strand by the methylases of the profile. It returns nil for an
+// empty profile.
+func (profile MethylationProfile) methylatedBases(sequence string) []bool {
+	if len(profile) == 0 {
+		return nil
+	}
+	methylated := make([]bool, len(sequence))
+	for _, methylase := range profile {
+		site := strings.ToUpper(methylase.Site)
+		reverseSite := transform.ReverseComplement(site)
+		siteLength := len(site)
+		// Sites of methylases may overlap each other, like GATCGATC, so every
+		// position is checked instead of using non-overlapping regexp matches.
+		for start := 0; start+siteLength <= len(sequence); start++ {
+			window := sequence[start : start+siteLength]
+			forward := siteMatches(site, window)
+			// The site on the other strand puts the methylations in reverse.
+			reverse := siteMatches(reverseSite, window)
+			for _, methylation := range methylase.Methylations {
+				position := methylation.Position
+				if position == 0 || position > siteLength || -position > siteLength {
+					continue
+				}
+				if forward {
+					if position > 0 {
+						methylated[start+position-1] = true
+					} else {
+						methylated[start+siteLength+position] = true
+					}
+				}
+				if reverse {
+					if position > 0 {
+						methylated[start+siteLength-position] = true
+					} else {
+						methylated[start-position-1] = true
+					}
+				}
+			}
+		}
+	}
+	return methylated
+}
+
+// blocking returns the methylases of the profile that block enzyme.
+func (profile MethylationProfile) blocking(enzyme Enzyme) MethylationProfile {
+	var blocking MethylationProfile
+	for _, methylase := range profile {
+		for _, name := range enzyme.BlockedBy {
+			if methylase.Name == name {
+				blocking = append(blocking, methylase)
+				break
+			}
+		}
+	}
+	return blocking
+}
+
+// unblockedSites removes the recognition site matches that contain a
+// methylated base.
+func unblockedSites(matches [][]int, methylated []bool) [][]int {
+	if methylated == nil {
+		return matches
+	}
+	var unblocked [][]int
+	for _, match := range matches {
+		blocked := false
+		for position := match[0]; position < match[1]; position++ {
+			if methylated[position] {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			unblocked = append(unblocked, match)
+		}
+	}
+	return unblocked
+}
+
+// CutWithEnzymeMethylated cuts a sequence like CutWithEnzyme, but as if it
+// was grown in a host with the methylation profile host. Recognition sites
+// that overlap a base methylated by one of the host's methylases listed in
+// the enzyme's BlockedBy are not cut. Enzymes without BlockedBy cut just like
+// they do with CutWithEnzyme.
+func CutWithEnzymeMethylated(part Part, directional bool, enzyme Enzyme, host MethylationProfile) []Fragment {
+	return cutWithEnzyme(part, directional, enzyme, host)
+}
+
+// CutWithEnzymeByNameMethylated is the CutWithEnzymeMethylated counterpart of
+// CutWithEnzymeByName.
+func (enzymeManager EnzymeManager) CutWithEnzymeByNameMethylated(part Part, directional bool, name string, host MethylationProfile) ([]Fragment, error) {
+	enzyme, err := enzymeManager.GetEnzymeByName(name)
+	if err != nil {
+		return []Fragment{}, err
+	}
+	return CutWithEnzymeMethylated(part, directional, enzyme, host), nil
+}
@@ -0,0 +1,39 @@
+LOCUS       NP_000509                147 aa            linear   PRI 26-SEP-2023
+DEFINITION  hemoglobin subunit beta [Homo sapiens].
+ACCESSION   NP_000509
+VERSION     NP_000509.1
+DBSOURCE    REFSEQ: accession NM_000518.5
+KEYWORDS    RefSeq; MANE Select.
+SOURCE      Homo sapiens (human)
+  ORGANISM  Homo sapiens
+            Eukaryota; Metazoa; Chordata; Craniata; Vertebrata; Euteleostomi;
+            Mammalia; Eutheria; Euarchontoglires; Primates; Haplorrhini;
+            Catarrhini; Hominidae; Homo.
+FEATURES             Location/Qualifiers
+     source          1..147
+                     /organism="Homo sapiens"
+                     /db_xref="taxon:9606"
+                     /chromosome="11"
+                     /map="11p15.4"
+     Protein         1..147
+                     /product="hemoglobin subunit beta"
+                     /calculated_mol_wt=15867
+     Region          3..147
+                     /region_name="Hb-beta_like"
+                     /note="Hemoglobin beta-like chains; cd08925"
+                     /db_xref="CDD:271282"
+     Site            order(64,93)
+                     /site_type="other"
+                     /note="heme binding site [chemical binding]"
+                     /db_xref="CDD:271282"
+     CDS             1..147
+                     /gene="HBB"
+                     /gene_synonym="beta-globin; CD113t-C"
+                     /coded_by="NM_000518.5:51..494"
+                     /db_xref="CCDS:CCDS7753.1"
+                     /db_xref="GeneID:3043"
+ORIGIN      
+        1 mvhltpeeks avtalwgkvn vdevggealg rllvvypwtq rffesfgdls tpdavmgnpk
+       61 vkahgkkvlg afsdglahld nlkgtfatls elhcdklhvd penfrllgnv lvcvlahhfg
+      121 keftppvqaa yqkvvagvan alahkyh
+//
@@ -0,0 +1,327 @@
+/*
+Package ab1 provides a parser for Sanger sequencing chromatograms in the ABIF
+(.ab1) format.
+
+Sanger sequencing is how most clones get verified, and sequencing providers
+return the results as .ab1 files written by Applied Biosystems instruments.
+ABIF is a binary format. A file starts with the "ABIF" magic and a version,
+followed by the root directory entry. The root entry points to a directory of
+28 byte entries, each describing a tagged piece of data:
+
+	| name (4) | number (4) | type (2) | element size (2) | elements (4) | data size (4) | data offset (4) | handle (4) |
+
+All numbers are big endian. Data of 4 bytes or less is stored in the data
+offset field itself instead of being pointed to. The entries we care about
+are:
+
+	PBAS 2: the basecalls
+	PCON 2: the quality value of each basecall
+	PLOC 2: the location of the peak of each basecall within the traces
+	FWO_ 1: the base of each trace channel, usually GATC
+	DATA 1-4: the raw trace channels
+	DATA 9-12: the analyzed trace channels, which are the ones shown by
+	           chromatogram viewers and the ones peak locations refer to
+	SMPL 1: the sample name
+
+PBAS 1, PCON 1 and PLOC 1 are used when the basecaller versions are missing.
+All other entries are skipped.
+
+Chromatograms can be converted to fastq.Fastq with Fastq, so that the fastq
+tooling of poly can be used on them.
+
+The format is described by Applied Biosystems in "Applied Biosystems Genetic
+Analysis Data File Format". The Biopython implementation was used as a
+reference.
+*/
+package ab1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bebop/poly/io/fastq"
+)
+
+var readAllFn = io.ReadAll
+
+// abifMagic is the magic string at the start of ABIF files.
+const abifMagic = "ABIF"
+
+// Sizes of the parts of an ABIF file.
+const (
+	headerSize = 6 // the magic and the version
+	entrySize  = 28
+)
+
+// Element types of the strings in directory entries.
+const (
+	elementPString int16 = 18
+	elementCString int16 = 19
+)
+
+// defaultBaseOrder is the channel order of ABI instruments, used when a file
+// has no FWO_ entry.
+const defaultBaseOrder = "GATC"
+
+var (
+	errNotAB1      = errors.New("not an ab1 file: missing ABIF magic")
+	errNoBasecalls = errors.New("ab1 file does not contain basecalls")
+)
+
+// Traces holds the signal of each of the four channels of a chromatogram,
+// one value per scan.
+type Traces struct {
+	A []int `json:"a"`
+	C []int `json:"c"`
+	G []int `json:"g"`
+	T []int `json:"t"`
+}
+
+// Chromatogram is a Sanger sequencing read parsed from an ab1 file.
+type Chromatogram struct {
+	SampleName string `json:"sample_name"`
+	Sequence   string `json:"sequence"`
+	// Quality holds the phred quality value of each base of Sequence. It is
+	// nil for files without quality values.
+	Quality []int `json:"quality"`
+	// PeakLocations holds the scan of Traces at which the peak of each base
+	// of Sequence is found.
+	PeakLocations []int  `json:"peak_locations"`
+	Traces        Traces `json:"traces"`
+	RawTraces     Traces `json:"raw_traces"`
+}
+
+// tag identifies a directory entry by its name and number, like PBAS 2.
+type tag struct {
+	name   string
+	number int32
+}
+
+// entry is a directory entry together with its data.
+type entry struct {
+	elementType int16
+	elementSize int16
+	elements    int32
+	data        []byte
+}
+
+// Parse takes in a reader representing an ab1 file and parses it into a
+// Chromatogram.
+func Parse(r io.Reader) (Chromatogram, error) {
+	file, err := readAllFn(r)
+	if err != nil {
+		return Chromatogram{}, err
+	}
+	entries, err := parseDirectory(file)
+	if err != nil {
+		return Chromatogram{}, err
+	}
+
+	var chromatogram Chromatogram
+	basecalls, ok := firstEntry(entries, "PBAS", 2, 1)
+	if !ok {
+		return Chromatogram{}, errNoBasecalls
+	}
+	chromatogram.Sequence = strings.ToUpper(string(basecalls.data))
+
+	if quality, ok := firstEntry(entries, "PCON", 2, 1); ok {
+		if chromatogram.Quality, err = integers(quality); err != nil {
+			return Chromatogram{}, fmt.Errorf("Failed to read quality values: %w", err)
+		}
+		if len(chromatogram.Quality) != len(chromatogram.Sequence) {
+			return Chromatogram{}, fmt.Errorf("got %d quality values for %d basecalls", len(chromatogram.Quality), len(chromatogram.Sequence))
+		}
+	}
+	if peaks, ok := firstEntry(entries, "PLOC", 2, 1); ok {
+		if chromatogram.PeakLocations, err = integers(peaks); err != nil {
+			return Chromatogram{}, fmt.Errorf("Failed to read peak locations: %w", err)
+		}
+	}
+	if sampleName, ok := entries[tag{"SMPL", 1}]; ok {
+		chromatogram.SampleName = text(sampleName)
+	}
+
+	baseOrder := defaultBaseOrder
+	if order, ok := entries[tag{"FWO_", 1}]; ok {
+		baseOrder = strings.ToUpper(string(order.data))
+	}
+	if chromatogram.RawTraces, err = parseTraces(entries, baseOrder, 1); err != nil {
+		return Chromatogram{}, err
+	}
+	if chromatogram.Traces, err = parseTraces(entries, baseOrder, 9); err != nil {
+		return Chromatogram{}, err
+	}
+	return chromatogram, nil
+}
+
+// parseDirectory reads all directory entries of an ABIF file.
+func parseDirectory(file []byte) (map[tag]entry, error) {
+	if len(file) < headerSize+entrySize || string(file[:len(abifMagic)]) != abifMagic {
+		return nil, errNotAB1
+	}
+	root, _, err := parseEntry(file, file[headerSize:headerSize+entrySize])
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read root directory: %w", err)
+	}
+	if root.elements < 0 || int(root.elements)*entrySize > len(root.data) {
+		return nil, fmt.Errorf("root directory of %d bytes can't hold %d entries", len(root.data), root.elements)
+	}
+	entries := make(map[tag]entry, root.elements)
+	for index := 0; index < int(root.elements); index++ {
+		entry, entryTag, err := parseEntry(file, root.data[index*entrySize:(index+1)*entrySize])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read directory entry %d: %w", index, err)
+		}
+		entries[entryTag] = entry
+	}
+	return entries, nil
+}
+
+// parseEntry reads a single 28 byte directory entry and looks up its data in
+// the file.
+func parseEntry(file []byte, raw []byte) (entry, tag, error) {
+	entryTag := tag{name: string(raw[0:4]), number: int32(binary.BigEndian.Uint32(raw[4:8]))}
+	parsed := entry{
+		elementType: int16(binary.BigEndian.Uint16(raw[8:10])),
+		elementSize: int16(binary.BigEndian.Uint16(raw[10:12])),
+		elements:    int32(binary.BigEndian.Uint32(raw[12:16])),
+	}
+	dataSize := binary.BigEndian.Uint32(raw[16:20])
+	// Small data is stored in the offset field itself.
+	if dataSize <= 4 {
+		parsed.data = raw[20 : 20+dataSize]
+		return parsed, entryTag, nil
+	}
+	dataOffset := binary.BigEndian.Uint32(raw[20:24])
+	if uint64(dataOffset)+uint64(dataSize) > uint64(len(file)) {
+		return entry{}, entryTag, fmt.Errorf("data of %s %d at offset %d with %d bytes is out of the file", entryTag.name, entryTag.number, dataOffset, dataSize)
+	}
+	parsed.data = file[dataOffset : dataOffset+dataSize]
+	return parsed, entryTag, nil
+}
+
+// firstEntry returns the entry with the given name and the first of the
+// numbers that is found.
+func firstEntry(entries map[tag]entry, name string, numbers ...int32) (entry, bool) {
+	for _, number := range numbers {
+		if found, ok := entries[tag{name, number}]; ok {
+			return found, true
+		}
+	}
+	return entry{}, false
+}
+
+// integers decodes the elements of an entry of 1, 2 or 4 byte integers.
+func integers(numeric entry) ([]int, error) {
+	size := int(numeric.elementSize)
+	if size != 1 && size != 2 && size != 4 {
+		return nil, fmt.Errorf("unsupported element size %d", size)
+	}
+	if numeric.elements < 0 || len(numeric.data) < int(numeric.elements)*size {
+		return nil, fmt.Errorf("%d bytes can't hold %d elements of %d bytes", len(numeric.data), numeric.elements, size)
+	}
+	values := make([]int, numeric.elements)
+	for index := range values {
+		element := numeric.data[index*size : (index+1)*size]
+		switch size {
+		case 1:
+			values[index] = int(element[0])
+		case 2:
+			values[index] = int(int16(binary.BigEndian.Uint16(element)))
+		case 4:
+			values[index] = int(int32(binary.BigEndian.Uint32(element)))
+		}
+	}
+	return values, nil
+}
+
+// text decodes a string entry. pStrings start with their length, cStrings
+// end with a null byte.
+func text(textual entry) string {
+	data := textual.data
+	switch textual.elementType {
+	case elementPString:
+		if len(data) > 0 && int(data[0]) < len(data) {
+			data = data[1 : 1+int(data[0])]
+		}
+	case elementCString:
+		data = []byte(strings.TrimRight(string(data), "\x00"))
+	}
+	return string(data)
+}
+
+// parseTraces reads the four trace channels starting at DATA firstNumber.
+func parseTraces(entries map[tag]entry, baseOrder string, firstNumber int32) (Traces, error) {
+	var traces Traces
+	if len(baseOrder) != 4 {
+		return Traces{}, fmt.Errorf("expected 4 trace channels, got base order %q", baseOrder)
+	}
+	for index, base := range baseOrder {
+		channel, ok := entries[tag{"DATA", firstNumber + int32(index)}]
+		if !ok {
+			continue
+		}
+		values, err := integers(channel)
+		if err != nil {
+			return Traces{}, fmt.Errorf("Failed to read trace DATA %d: %w", firstNumber+int32(index), err)
+		}
+		switch base {
+		case 'A':
+			traces.A = values
+		case 'C':
+			traces.C = values
+		case 'G':
+			traces.G = values
+		case 'T':
+			traces.T = values
+		default:
+			return Traces{}, fmt.Errorf("unknown base %q in base order %q", base, baseOrder)
+		}
+	}
+	return traces, nil
+}
+
+// Read reads an ab1 file from path and returns a Chromatogram. If the file
+// has no sample name, its name is used instead.
+func Read(path string) (Chromatogram, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Chromatogram{}, err
+	}
+	defer file.Close()
+	chromatogram, err := Parse(file)
+	if err != nil {
+		return Chromatogram{}, err
+	}
+	if chromatogram.SampleName == "" {
+		chromatogram.SampleName = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return chromatogram, nil
+}
+
+// Fastq converts a Chromatogram into a fastq.Fastq with the sample name as
+// identifier. Quality values are phred+33 encoded and capped at 93, the
+// highest value fastq can store. Chromatograms without quality values get a
+// quality of 0 for every base.
+func (chromatogram Chromatogram) Fastq() fastq.Fastq {
+	quality := make([]byte, len(chromatogram.Sequence))
+	for index := range quality {
+		var value int
+		if index < len(chromatogram.Quality) {
+			value = min(max(chromatogram.Quality[index], 0), 93)
+		}
+		quality[index] = byte(value + 33)
+	}
+	return fastq.Fastq{
+		// fastq identifiers end at the first space.
+		Identifier: strings.Join(strings.Fields(chromatogram.SampleName), "_"),
+		Optionals:  make(map[string]string),
+		Sequence:   chromatogram.Sequence,
+		Quality:    string(quality),
+	}
+}
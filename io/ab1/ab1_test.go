@@ -0,0 +1,200 @@
+package ab1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testEntry is a directory entry used to build ABIF files in tests.
+type testEntry struct {
+	name        string
+	number      int32
+	elementType int16
+	elementSize int16
+	elements    int32
+	data        []byte
+}
+
+// abif builds an ABIF file with the given directory entries.
+func abif(entries ...testEntry) []byte {
+	var data, directory bytes.Buffer
+	const header = 128
+	for _, entry := range entries {
+		directory.WriteString(entry.name)
+		for _, field := range []any{entry.number, entry.elementType, entry.elementSize, entry.elements, int32(len(entry.data))} {
+			_ = binary.Write(&directory, binary.BigEndian, field)
+		}
+		if len(entry.data) <= 4 {
+			directory.Write(append(entry.data, make([]byte, 4-len(entry.data))...))
+		} else {
+			_ = binary.Write(&directory, binary.BigEndian, int32(header+data.Len()))
+			data.Write(entry.data)
+		}
+		directory.Write(make([]byte, 4))
+	}
+	file := bytes.NewBufferString(abifMagic)
+	for _, field := range []any{int16(101), []byte("tdir"), int32(1), int16(1023), int16(entrySize), int32(len(entries)), int32(directory.Len()), int32(header + data.Len()), int32(0)} {
+		_ = binary.Write(file, binary.BigEndian, field)
+	}
+	file.Write(make([]byte, header-file.Len()))
+	file.Write(data.Bytes())
+	file.Write(directory.Bytes())
+	return file.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	chromatogram, err := Read("data/example.ab1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chromatogram.SampleName != "GFP fwd 01" {
+		t.Errorf("SampleName = %q, want %q", chromatogram.SampleName, "GFP fwd 01")
+	}
+	if chromatogram.Sequence != "ATGGCTAGCAAAGGAGAAGAACTTTTCACT" {
+		t.Errorf("Sequence = %s", chromatogram.Sequence)
+	}
+	if len(chromatogram.Quality) != 30 || chromatogram.Quality[0] != 8 || chromatogram.Quality[10] != 62 {
+		t.Errorf("Quality = %v", chromatogram.Quality)
+	}
+	if len(chromatogram.PeakLocations) != 30 || chromatogram.PeakLocations[0] != 10 || chromatogram.PeakLocations[29] != 358 {
+		t.Errorf("PeakLocations = %v", chromatogram.PeakLocations)
+	}
+	for _, traces := range []Traces{chromatogram.Traces, chromatogram.RawTraces} {
+		for _, channel := range [][]int{traces.A, traces.C, traces.G, traces.T} {
+			if len(channel) != 368 {
+				t.Fatalf("trace channel has %d scans, want 368", len(channel))
+			}
+		}
+	}
+	if chromatogram.RawTraces.G[0] != 150 {
+		t.Errorf("RawTraces.G[0] = %d, want 150", chromatogram.RawTraces.G[0])
+	}
+
+	// The channel of each basecall has the highest signal at its peak.
+	for index, base := range chromatogram.Sequence {
+		peak := chromatogram.PeakLocations[index]
+		signals := map[rune]int{'A': chromatogram.Traces.A[peak], 'C': chromatogram.Traces.C[peak], 'G': chromatogram.Traces.G[peak], 'T': chromatogram.Traces.T[peak]}
+		for otherBase, signal := range signals {
+			if otherBase != base && signal >= signals[base] {
+				t.Errorf("base %d (%c) has a higher %c signal at its peak", index, base, otherBase)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	basecalls := testEntry{name: "PBAS", number: 1, elementType: 2, elementSize: 1, elements: 5, data: []byte("acgtn")}
+	tests := []struct {
+		name    string
+		file    []byte
+		want    Chromatogram
+		wantErr bool
+	}{
+		{
+			name: "minimal",
+			file: abif(basecalls),
+			want: Chromatogram{Sequence: "ACGTN"},
+		},
+		{
+			name: "cString sample name and custom base order",
+			file: abif(
+				basecalls,
+				testEntry{name: "SMPL", number: 1, elementType: elementCString, elementSize: 1, elements: 6, data: []byte("clone\x00")},
+				testEntry{name: "FWO_", number: 1, elementType: 2, elementSize: 1, elements: 4, data: []byte("ACGT")},
+				testEntry{name: "DATA", number: 9, elementType: 4, elementSize: 2, elements: 1, data: []byte{0, 7}},
+				testEntry{name: "DATA", number: 12, elementType: 4, elementSize: 2, elements: 1, data: []byte{0xFF, 0xFF}},
+			),
+			want: Chromatogram{SampleName: "clone", Sequence: "ACGTN", Traces: Traces{A: []int{7}, T: []int{-1}}},
+		},
+		{name: "not ab1", file: []byte("LOCUS       puc19.gbk               2686 bp DNA     circular     22-OCT-2019"), wantErr: true},
+		{name: "no basecalls", file: abif(testEntry{name: "SMPL", number: 1, elementType: elementPString, elementSize: 1, elements: 2, data: []byte{1, 'a'}}), wantErr: true},
+		{name: "quality length", file: abif(basecalls, testEntry{name: "PCON", number: 2, elementType: 2, elementSize: 1, elements: 2, data: []byte{20, 30}}), wantErr: true},
+		{name: "element size", file: abif(basecalls, testEntry{name: "PLOC", number: 2, elementType: 4, elementSize: 3, elements: 2, data: []byte{0, 0, 1, 0, 0, 2}}), wantErr: true},
+		{name: "too many elements", file: abif(basecalls, testEntry{name: "PLOC", number: 2, elementType: 4, elementSize: 2, elements: 4, data: []byte{0, 0, 1, 0, 0, 2}}), wantErr: true},
+		{name: "base order", file: abif(basecalls, testEntry{name: "FWO_", number: 1, elementType: 2, elementSize: 1, elements: 3, data: []byte("ACG")}), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(bytes.NewReader(test.file))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %t", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.SampleName != test.want.SampleName || got.Sequence != test.want.Sequence || len(got.Traces.A) != len(test.want.Traces.A) || len(got.Traces.T) != len(test.want.Traces.T) {
+				t.Fatalf("Parse() = %+v, want %+v", got, test.want)
+			}
+			if len(got.Traces.A) > 0 && (got.Traces.A[0] != test.want.Traces.A[0] || got.Traces.T[0] != test.want.Traces.T[0]) {
+				t.Errorf("Parse() traces = %+v, want %+v", got.Traces, test.want.Traces)
+			}
+		})
+	}
+}
+
+func TestParse_truncated(t *testing.T) {
+	file, err := os.ReadFile("data/example.ab1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The directory is at the end of the file, so cutting the end removes it.
+	if _, err := Parse(bytes.NewReader(file[:len(file)-100])); err == nil {
+		t.Errorf("Parse() of a truncated file should fail")
+	}
+	// Cutting the data in the middle makes the entries point out of the file.
+	truncated := append(append([]byte{}, file[:1000]...), file[len(file)-28*20:]...)
+	if _, err := Parse(bytes.NewReader(truncated)); err == nil || !strings.Contains(err.Error(), "out of the file") {
+		t.Errorf("Parse() error = %v, want an out of the file error", err)
+	}
+}
+
+func TestParse_readError(t *testing.T) {
+	readErr := errors.New("fake error")
+	oldReadAllFn := readAllFn
+	readAllFn = func(r io.Reader) ([]byte, error) {
+		return nil, readErr
+	}
+	defer func() {
+		readAllFn = oldReadAllFn
+	}()
+	if _, err := Parse(strings.NewReader("")); !errors.Is(err, readErr) {
+		t.Errorf("Parse() error = %v, want %v", err, readErr)
+	}
+}
+
+func TestRead_sampleNameFromPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clone_42.ab1")
+	if err := os.WriteFile(path, abif(testEntry{name: "PBAS", number: 2, elementType: 2, elementSize: 1, elements: 2, data: []byte("AC")}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chromatogram, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chromatogram.SampleName != "clone_42" {
+		t.Errorf("SampleName = %q, want clone_42", chromatogram.SampleName)
+	}
+
+	if _, err := Read("data/missing.ab1"); err == nil {
+		t.Errorf("Read() of a missing file should fail")
+	}
+}
+
+func TestChromatogram_Fastq(t *testing.T) {
+	chromatogram := Chromatogram{SampleName: "my clone", Sequence: "ACGT", Quality: []int{0, 40, 100, -1}}
+	read := chromatogram.Fastq()
+	if read.Identifier != "my_clone" || read.Sequence != "ACGT" || read.Quality != "!I~!" {
+		t.Errorf("Fastq() = %+v", read)
+	}
+
+	withoutQuality := Chromatogram{Sequence: "AC"}.Fastq()
+	if withoutQuality.Quality != "!!" {
+		t.Errorf("Fastq() quality = %q, want !!", withoutQuality.Quality)
+	}
+}
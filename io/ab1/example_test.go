@@ -0,0 +1,32 @@
+package ab1_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/ab1"
+	"github.com/bebop/poly/io/fastq"
+)
+
+// This example reads a Sanger chromatogram and prints its basecalls and the
+// trace signal at the peak of the first base.
+func Example_basic() {
+	chromatogram, _ := ab1.Read("data/example.ab1")
+	fmt.Println(chromatogram.Sequence)
+
+	peak := chromatogram.PeakLocations[0]
+	fmt.Println(chromatogram.Traces.A[peak], chromatogram.Traces.T[peak])
+	// Output:
+	// ATGGCTAGCAAAGGAGAAGAACTTTTCACT
+	// 360 0
+}
+
+func ExampleChromatogram_Fastq() {
+	chromatogram, _ := ab1.Read("data/example.ab1")
+	fastqBytes, _ := fastq.Build([]fastq.Fastq{chromatogram.Fastq()})
+	fmt.Print(string(fastqBytes))
+	// Output:
+	// @GFP_fwd_01
+	// ATGGCTAGCAAAGGAGAAGAACTTTTCACT
+	// +
+	// )-5@GNU[]^___^__]_\[XUPIE?:4/*
+}
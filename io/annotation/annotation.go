@@ -0,0 +1,251 @@
+/*
+Package annotation provides a format agnostic model of annotated sequences.
+
+genbank, gff and polyjson each describe a sequence with features annotated on
+it, but each of them has its own Meta, Feature and Location types. This package
+defines a common Record that all of them can be converted to and from, and the
+AnnotatedSequence interface that they implement, so that packages like codon
+can work with any of them.
+
+Converting to a Record is done with the Records method of each format, while
+converting back is done with the FromRecord (or FromRecords) function of each
+format package. Fields that a format has no place for, like the references of
+a genbank file or the score of a gff feature, travel along in the Extras of
+Records and Features as values of that format's own types, like a
+genbank.Meta. Every format takes back the Extras of its own types and keeps
+the others, so converting a genbank file to gff and back gives the original
+genbank file. Extras only live in memory and are not written to files.
+*/
+package annotation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bebop/poly/transform"
+)
+
+// AnnotatedSequence is implemented by formats that hold sequences with
+// annotated features, like genbank.Genbank, gff.Gff and polyjson.Poly.
+type AnnotatedSequence interface {
+	// Records returns a Record for every sequence. Most formats hold a single
+	// sequence, but a gff file may describe many.
+	Records() []Record
+}
+
+// Record is a single sequence and its features.
+type Record struct {
+	Name                 string    `json:"name"`
+	Description          string    `json:"description"`
+	Sequence             string    `json:"sequence"`
+	SequenceHash         string    `json:"sequence_hash"`
+	SequenceHashFunction string    `json:"hash_function"`
+	Circular             bool      `json:"circular"`
+	Features             []Feature `json:"features"`
+	// Extras holds metadata of the record that only some formats have a place
+	// for, like the Meta of a genbank file with its references.
+	Extras []any `json:"-"`
+}
+
+// Feature is a feature annotated on a Record. Fields that only exist in some
+// formats are left empty by the others.
+type Feature struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"` // polyjson
+	Description string `json:"description"`
+	// Qualifiers are the genbank qualifiers, gff attributes and polyjson tags
+	// of the feature, in order.
+	Qualifiers           []Qualifier `json:"qualifiers"`
+	Location             Location    `json:"location"`
+	SequenceHash         string      `json:"sequence_hash"`
+	SequenceHashFunction string      `json:"hash_function"`
+	Sequence             string      `json:"sequence"` // a copy of the feature's sequence some formats store.
+	// Extras holds fields of the feature that only some formats have a place
+	// for, like the score of a gff feature.
+	Extras []any `json:"-"`
+}
+
+// Qualifier is a key of a feature with its values. A key may be used by more
+// than one Qualifier of a feature, like the /db_xref qualifiers of a genbank
+// feature, and may have more than one value, like the Dbxref attribute of a
+// gff feature. Qualifiers with nil Values are flags, like /pseudo in genbank.
+type Qualifier struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// Location is a 0-based, half-open location of a feature, just like a Go
+// slice. Complement means the reverse complement, and a Location with
+// SubLocations is made up of its SubLocations.
+//
+// The remaining fields come from the INSDC location grammar used by genbank.
+// Order and Bond are like Join, except that the order of the SubLocations is
+// unknown or that they are linked residues. Between is a site between the
+// bases Start and Start+1, in which case End equals Start. WithinRange is a
+// single unknown base between Start and End, and RemoteAccession is set when
+// the location is on another entry.
+type Location struct {
+	Start             int        `json:"start"`
+	End               int        `json:"end"`
+	Complement        bool       `json:"complement"`
+	Join              bool       `json:"join"`
+	FivePrimePartial  bool       `json:"five_prime_partial"`
+	ThreePrimePartial bool       `json:"three_prime_partial"`
+	SubLocations      []Location `json:"sub_locations"`
+	Order             bool       `json:"order"`
+	Bond              bool       `json:"bond"`
+	Between           bool       `json:"between"`
+	WithinRange       bool       `json:"within_range"`
+	RemoteAccession   string     `json:"remote_accession"`
+}
+
+// ErrRemoteLocation is returned by LocationSequence when a location is on
+// another entry, whose sequence is not available.
+var ErrRemoteLocation = errors.New("location refers to a remote entry")
+
+// ErrUncertainLocation is returned by LocationSequence for a single base
+// within a range, since it is unknown which base it is.
+var ErrUncertainLocation = errors.New("location is a single base at an uncertain position")
+
+// GetSequence returns the sequence of a feature of the Record.
+func (record Record) GetSequence(feature Feature) (string, error) {
+	return LocationSequence(record.Sequence, feature.Location)
+}
+
+// LocationSequence returns the part of sequence described by location. It is
+// shared by the GetSequence methods of the format packages. SubLocations of
+// join, order and bond locations are concatenated, and a site between two
+// bases is an empty sequence. Locations on other entries return an error
+// wrapping ErrRemoteLocation rather than a part of the wrong sequence.
+func LocationSequence(sequence string, location Location) (string, error) {
+	var sequenceBuffer bytes.Buffer
+
+	switch {
+	case location.RemoteAccession != "":
+		return "", fmt.Errorf("%w %s", ErrRemoteLocation, location.RemoteAccession)
+	case location.WithinRange && len(location.SubLocations) == 0:
+		return "", fmt.Errorf("%w: %d.%d", ErrUncertainLocation, location.Start+1, location.End)
+	case location.Between && len(location.SubLocations) == 0:
+		// End is before Start for sites like 100^1 that span the origin of circular sequences.
+		if location.Start < 0 || location.Start > len(sequence) {
+			return "", fmt.Errorf("location %d^%d is out of bounds for a sequence of length %d", location.Start, location.End+1, len(sequence))
+		}
+		return "", nil
+	}
+
+	if len(location.SubLocations) == 0 {
+		if location.Start < 0 || location.End > len(sequence) || location.Start > location.End {
+			return "", fmt.Errorf("location %d..%d is out of bounds for a sequence of length %d", location.Start+1, location.End, len(sequence))
+		}
+		sequenceBuffer.WriteString(sequence[location.Start:location.End])
+	} else {
+		for _, subLocation := range location.SubLocations {
+			subSequence, err := LocationSequence(sequence, subLocation)
+			if err != nil {
+				return "", err
+			}
+			sequenceBuffer.WriteString(subSequence)
+		}
+	}
+
+	// reverse complements resulting string if needed.
+	if location.Complement {
+		return transform.ReverseComplement(sequenceBuffer.String()), nil
+	}
+	return sequenceBuffer.String(), nil
+}
+
+// QualifierMap converts qualifiers to a map of comma separated values, like
+// gff attributes and polyjson tags. Maps lose the order of the qualifiers,
+// and joining values loses where one ends if it contains a comma, so the
+// qualifiers are added to the returned extras when MapQualifiers couldn't
+// restore them from the map. Split is passed on to MapQualifiers.
+func QualifierMap(qualifiers []Qualifier, extras []any, split bool) (map[string]string, []any) {
+	attributes := make(map[string]string, len(qualifiers))
+	for _, qualifier := range qualifiers {
+		value := strings.Join(qualifier.Values, ",")
+		if previous, ok := attributes[qualifier.Key]; ok {
+			value = previous + "," + value
+		}
+		attributes[qualifier.Key] = value
+	}
+	extras = CopyExtras(extras)
+	restored, _ := MapQualifiers(attributes, nil, split)
+	if !reflect.DeepEqual(expandQualifiers(restored), expandQualifiers(qualifiers)) {
+		extras = append(extras, append([]Qualifier(nil), qualifiers...))
+	}
+	return attributes, extras
+}
+
+// MapQualifiers converts a map made by QualifierMap back to qualifiers. The
+// qualifiers kept in extras are used if they still match attributes.
+// Otherwise every key becomes a qualifier, in sorted order, with its value
+// split at commas if split is true. The returned extras are those that are
+// left.
+func MapQualifiers(attributes map[string]string, extras []any, split bool) ([]Qualifier, []any) {
+	if qualifiers, remaining, ok := TakeExtra[[]Qualifier](extras); ok {
+		extras = remaining
+		if stored, _ := QualifierMap(qualifiers, nil, split); maps.Equal(stored, attributes) {
+			return qualifiers, extras
+		}
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var qualifiers []Qualifier
+	for _, key := range keys {
+		values := []string{attributes[key]}
+		if split {
+			values = strings.Split(attributes[key], ",")
+		}
+		qualifiers = append(qualifiers, Qualifier{Key: key, Values: values})
+	}
+	return qualifiers, CopyExtras(extras)
+}
+
+// expandQualifiers splits qualifiers with several values into a qualifier
+// for every value, which is how genbank stores them.
+func expandQualifiers(qualifiers []Qualifier) []Qualifier {
+	expanded := make([]Qualifier, 0, len(qualifiers))
+	for _, qualifier := range qualifiers {
+		if len(qualifier.Values) <= 1 {
+			expanded = append(expanded, qualifier)
+			continue
+		}
+		for _, value := range qualifier.Values {
+			expanded = append(expanded, Qualifier{Key: qualifier.Key, Values: []string{value}})
+		}
+	}
+	return expanded
+}
+
+// TakeExtra returns the first of extras that is a T, like the Meta that a
+// format kept in a Record, along with the other extras. The last return value
+// is false if none of extras is a T.
+func TakeExtra[T any](extras []any) (T, []any, bool) {
+	for index, extra := range extras {
+		if value, ok := extra.(T); ok {
+			remaining := append(CopyExtras(extras[:index]), extras[index+1:]...)
+			return value, CopyExtras(remaining), true
+		}
+	}
+	var zero T
+	return zero, CopyExtras(extras), false
+}
+
+// CopyExtras copies extras so that converted records and features do not
+// share them with the original. It returns nil for empty extras.
+func CopyExtras(extras []any) []any {
+	if len(extras) == 0 {
+		return nil
+	}
+	return append([]any(nil), extras...)
+}
@@ -0,0 +1,96 @@
+package annotation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocationSequence(t *testing.T) {
+	sequence := "AAACCCGGGTTT"
+	tests := []struct {
+		name     string
+		location Location
+		want     string
+		wantErr  bool
+	}{
+		{"simple", Location{Start: 3, End: 6}, "CCC", false},
+		{"complement", Location{Start: 0, End: 4, Complement: true}, "GTTT", false},
+		{"join", Location{Join: true, SubLocations: []Location{{Start: 0, End: 2}, {Start: 10, End: 12}}}, "AATT", false},
+		{"complement join", Location{Join: true, Complement: true, SubLocations: []Location{{Start: 0, End: 2}, {Start: 3, End: 5}}}, "GGTT", false},
+		{"join with complement", Location{Join: true, SubLocations: []Location{{Start: 0, End: 2}, {Start: 3, End: 5, Complement: true}}}, "AAGG", false},
+		{"out of bounds", Location{Start: 10, End: 13}, "", true},
+		{"start after end", Location{Start: 6, End: 3}, "", true},
+		{"sub location out of bounds", Location{Join: true, SubLocations: []Location{{Start: 0, End: 2}, {Start: 11, End: 20}}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LocationSequence(sequence, tt.location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LocationSequence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LocationSequence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualifierMap(t *testing.T) {
+	tests := []struct {
+		name       string
+		qualifiers []Qualifier
+		split      bool
+		want       map[string]string
+		wantKept   bool
+	}{
+		{"sorted", []Qualifier{{"gene", []string{"lacZ"}}, {"note", []string{"a", "b"}}}, true, map[string]string{"gene": "lacZ", "note": "a,b"}, false},
+		{"unsorted", []Qualifier{{"note", []string{"a"}}, {"gene", []string{"lacZ"}}}, true, map[string]string{"gene": "lacZ", "note": "a"}, true},
+		{"repeated key", []Qualifier{{"note", []string{"a"}}, {"note", []string{"b"}}}, true, map[string]string{"note": "a,b"}, false},
+		{"comma in value", []Qualifier{{"note", []string{"a, b"}}}, true, map[string]string{"note": "a, b"}, true},
+		{"comma in value without splitting", []Qualifier{{"note", []string{"a, b"}}}, false, map[string]string{"note": "a, b"}, false},
+		{"flag", []Qualifier{{"pseudo", nil}}, true, map[string]string{"pseudo": ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes, extras := QualifierMap(tt.qualifiers, []any{"kept"}, tt.split)
+			if !reflect.DeepEqual(attributes, tt.want) {
+				t.Errorf("QualifierMap() = %v, want %v", attributes, tt.want)
+			}
+			if _, _, kept := TakeExtra[[]Qualifier](extras); kept != tt.wantKept {
+				t.Errorf("QualifierMap() kept the qualifiers in extras: %v, want %v", kept, tt.wantKept)
+			}
+			qualifiers, extras := MapQualifiers(attributes, extras, tt.split)
+			if !reflect.DeepEqual(expandQualifiers(qualifiers), expandQualifiers(tt.qualifiers)) {
+				t.Errorf("MapQualifiers() = %v, want %v", qualifiers, tt.qualifiers)
+			}
+			if !reflect.DeepEqual(extras, []any{"kept"}) {
+				t.Errorf("MapQualifiers() left extras %v", extras)
+			}
+		})
+	}
+}
+
+func TestTakeExtra(t *testing.T) {
+	extras := []any{"a", 1, "b"}
+	value, remaining, ok := TakeExtra[int](extras)
+	if !ok || value != 1 || !reflect.DeepEqual(remaining, []any{"a", "b"}) {
+		t.Errorf("TakeExtra() = %v, %v, %v, want 1, [a b], true", value, remaining, ok)
+	}
+	if !reflect.DeepEqual(extras, []any{"a", 1, "b"}) {
+		t.Errorf("TakeExtra() changed extras to %v", extras)
+	}
+	if _, remaining, ok := TakeExtra[float64](extras); ok || !reflect.DeepEqual(remaining, extras) {
+		t.Errorf("TakeExtra() = %v, %v, want all extras and false", remaining, ok)
+	}
+}
+
+func TestMapQualifiers_changedAttributes(t *testing.T) {
+	// Qualifiers kept in extras are dropped once the attributes were edited.
+	attributes, extras := QualifierMap([]Qualifier{{"note", []string{"a"}}, {"gene", []string{"lacZ"}}}, nil, true)
+	attributes["gene"] = "lacY"
+	qualifiers, extras := MapQualifiers(attributes, extras, true)
+	want := []Qualifier{{"gene", []string{"lacY"}}, {"note", []string{"a"}}}
+	if !reflect.DeepEqual(qualifiers, want) || len(extras) != 0 {
+		t.Errorf("MapQualifiers() = %v, %v, want %v and no extras", qualifiers, extras, want)
+	}
+}
@@ -0,0 +1,47 @@
+package annotation_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/annotation"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/io/gff"
+	"github.com/bebop/poly/io/polyjson"
+)
+
+// This example converts a genbank file to gff and polyjson, and counts the
+// CDS features of each of them through the AnnotatedSequence interface.
+func Example_basic() {
+	gbk, _ := genbank.Read("../../data/puc19.gbk")
+	gffSequence := gff.FromRecords(gbk.Records()...)
+	polySequence := polyjson.FromRecord(gffSequence.Records()[0])
+
+	for _, sequence := range []annotation.AnnotatedSequence{gbk, gffSequence, polySequence} {
+		cdsCount := 0
+		for _, record := range sequence.Records() {
+			for _, feature := range record.Features {
+				if feature.Type == "CDS" {
+					cdsCount++
+				}
+			}
+		}
+		fmt.Printf("%T %d\n", sequence, cdsCount)
+	}
+	// Output:
+	// genbank.Genbank 2
+	// gff.Gff 2
+	// polyjson.Poly 2
+}
+
+func ExampleLocationSequence() {
+	location := annotation.Location{
+		Join: true,
+		SubLocations: []annotation.Location{
+			{Start: 0, End: 3},
+			{Start: 6, End: 9, Complement: true},
+		},
+	}
+	sequence, _ := annotation.LocationSequence("ATGAAACCC", location)
+	fmt.Println(sequence)
+	// Output: ATGGGG
+}
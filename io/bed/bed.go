@@ -0,0 +1,455 @@
+/*
+Package bed contains BED parsers and writers.
+
+BED (Browser Extensible Data) is a tab separated format for storing genomic
+intervals, like the regions shown in a genome browser track. Every line is a
+single interval with 3 required and 9 optional columns:
+
+	```
+	chr1	999	5000	tx1	960	+	1199	4900	255,0,0	2	1000,1000,	0,3001,
+	```
+
+	1.  chrom: The name of the sequence
+	2.  chromStart: Start of the interval (indexed at 0)
+	3.  chromEnd: End of the interval (not included in the interval)
+	4.  name: The name of the interval
+	5.  score: A score between 0 and 1000
+	6.  strand: +, - or .
+	7.  thickStart: Start of the thickly drawn part, like a coding region
+	8.  thickEnd: End of the thickly drawn part
+	9.  itemRgb: The display color, like 255,0,0
+	10. blockCount: The amount of blocks, like exons
+	11. blockSizes: Comma separated sizes of the blocks
+	12. blockStarts: Comma separated starts of the blocks, relative to chromStart
+
+The specification can be found here: https://samtools.github.io/hts-specs/BEDv1.pdf
+
+This package provides a parser and writer for BED files, and converters between
+BED records and gff features.
+*/
+package bed
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/gff"
+)
+
+// Record is a single interval of a BED file. Coordinates are stored as they
+// are in the file: 0-based, with End not included in the interval.
+type Record struct {
+	Chromosome  string `json:"chromosome"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Name        string `json:"name"`
+	Score       int    `json:"score"`
+	Strand      string `json:"strand"`
+	ThickStart  int    `json:"thick_start"`
+	ThickEnd    int    `json:"thick_end"`
+	ItemRGB     string `json:"item_rgb"`
+	BlockSizes  []int  `json:"block_sizes"`
+	BlockStarts []int  `json:"block_starts"` // relative to Start
+	// Columns is the amount of columns of the record (3 to 12). If it is 0,
+	// the writer only writes the columns that are needed for the set fields.
+	Columns int `json:"columns"`
+}
+
+// Parse parses a given BED file into an array of Records.
+func Parse(r io.Reader) ([]Record, error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	parser := NewParser(r, maxLineSize)
+	return parser.ParseAll()
+}
+
+// Parser is a BED parser.
+type Parser struct {
+	reader bufio.Reader
+	line   uint
+}
+
+// NewParser creates a parser from an io.Reader for BED data.
+func NewParser(r io.Reader, maxLineSize int) *Parser {
+	return &Parser{
+		reader: *bufio.NewReaderSize(r, maxLineSize),
+	}
+}
+
+// ParseAll parses all records in underlying reader only returning non-EOF errors.
+// It returns all valid records up to error if encountered.
+func (parser *Parser) ParseAll() ([]Record, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// ParseN parses up to maxRecords records from the Parser's underlying reader.
+// ParseN does not return EOF if encountered.
+// If an non-EOF error is encountered it returns it and all correctly parsed records up to then.
+func (parser *Parser) ParseN(maxRecords int) (records []Record, err error) {
+	for counter := 0; counter < maxRecords; counter++ {
+		record, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ParseNext parses the next record of a BED file. Comments (#), empty lines,
+// and browser and track lines are skipped.
+// ParseNext returns an EOF if encountered.
+func (parser *Parser) ParseNext() (Record, error) {
+	for {
+		if _, err := parser.reader.Peek(1); err != nil {
+			// Early return on error. Probably will be EOF.
+			return Record{}, err
+		}
+		lineBytes, err := parser.reader.ReadSlice('\n')
+		parser.line++
+		if err != nil && !errors.Is(err, io.EOF) {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				return Record{}, fmt.Errorf("line %d too large for buffer, use larger maxLineSize: %w", parser.line, err)
+			}
+			return Record{}, err
+		}
+		line := strings.TrimRight(string(lineBytes), "\r\n")
+		if len(line) == 0 || line[0] == '#' || strings.HasPrefix(line, "browser") || strings.HasPrefix(line, "track") {
+			continue
+		}
+		record, err := parseRecord(line)
+		if err != nil {
+			return Record{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+		}
+		return record, nil
+	}
+}
+
+// parseRecord parses a single BED line.
+func parseRecord(line string) (Record, error) {
+	values := strings.Split(line, "\t")
+	if len(values) < 3 || len(values) > 12 {
+		return Record{}, fmt.Errorf("Got %d values, expected 3 to 12.", len(values))
+	}
+	record := Record{Chromosome: values[0], Columns: len(values)}
+	var err error
+	if record.Start, err = strconv.Atoi(values[1]); err != nil {
+		return Record{}, fmt.Errorf("Failed to convert chromStart '%s': %w", values[1], err)
+	}
+	if record.End, err = strconv.Atoi(values[2]); err != nil {
+		return Record{}, fmt.Errorf("Failed to convert chromEnd '%s': %w", values[2], err)
+	}
+	if record.Start < 0 || record.End < record.Start {
+		return Record{}, fmt.Errorf("invalid interval %d-%d", record.Start, record.End)
+	}
+	// thickStart and thickEnd default to the whole interval.
+	record.ThickStart, record.ThickEnd = record.Start, record.End
+	for index := 3; index < len(values); index++ {
+		value := values[index]
+		switch index {
+		case 3:
+			record.Name = value
+		case 4:
+			if value != "." {
+				if record.Score, err = strconv.Atoi(value); err != nil {
+					return Record{}, fmt.Errorf("Failed to convert score '%s': %w", value, err)
+				}
+			}
+		case 5:
+			if value != "+" && value != "-" && value != "." {
+				return Record{}, fmt.Errorf("strand must be +, - or . Got: %s", value)
+			}
+			record.Strand = value
+		case 6:
+			if record.ThickStart, err = strconv.Atoi(value); err != nil {
+				return Record{}, fmt.Errorf("Failed to convert thickStart '%s': %w", value, err)
+			}
+		case 7:
+			if record.ThickEnd, err = strconv.Atoi(value); err != nil {
+				return Record{}, fmt.Errorf("Failed to convert thickEnd '%s': %w", value, err)
+			}
+		case 8:
+			record.ItemRGB = value
+		case 9, 10:
+			// blockCount, blockSizes and blockStarts only make sense together.
+			if len(values) != 12 {
+				return Record{}, fmt.Errorf("blockCount, blockSizes and blockStarts must all be given. Got %d columns", len(values))
+			}
+		case 11:
+			if err = record.parseBlocks(values[9], values[10], value); err != nil {
+				return Record{}, err
+			}
+		}
+	}
+	return record, nil
+}
+
+// parseBlocks parses and checks the blockCount, blockSizes and blockStarts columns.
+func (record *Record) parseBlocks(countString string, sizesString string, startsString string) error {
+	blockCount, err := strconv.Atoi(countString)
+	if err != nil {
+		return fmt.Errorf("Failed to convert blockCount '%s': %w", countString, err)
+	}
+	if record.BlockSizes, err = parseIntegerList(sizesString); err != nil {
+		return fmt.Errorf("Failed to convert blockSizes '%s': %w", sizesString, err)
+	}
+	if record.BlockStarts, err = parseIntegerList(startsString); err != nil {
+		return fmt.Errorf("Failed to convert blockStarts '%s': %w", startsString, err)
+	}
+	if len(record.BlockSizes) != blockCount || len(record.BlockStarts) != blockCount {
+		return fmt.Errorf("blockCount is %d, but got %d blockSizes and %d blockStarts", blockCount, len(record.BlockSizes), len(record.BlockStarts))
+	}
+	for blockIndex := range record.BlockStarts {
+		if record.BlockStarts[blockIndex] < 0 || record.Start+record.BlockStarts[blockIndex]+record.BlockSizes[blockIndex] > record.End {
+			return fmt.Errorf("block %d lies outside of the interval %d-%d", blockIndex+1, record.Start, record.End)
+		}
+	}
+	return nil
+}
+
+// parseIntegerList parses a comma separated list of integers, which may end with a comma.
+func parseIntegerList(list string) ([]int, error) {
+	list = strings.TrimSuffix(list, ",")
+	if list == "" {
+		return nil, nil
+	}
+	values := strings.Split(list, ",")
+	integers := make([]int, len(values))
+	for index, value := range values {
+		integer, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		integers[index] = integer
+	}
+	return integers, nil
+}
+
+// Reset discards all data in buffer and resets state.
+func (parser *Parser) Reset(r io.Reader) {
+	parser.reader.Reset(r)
+	parser.line = 0
+}
+
+/******************************************************************************
+
+Start of gff conversion functions
+
+******************************************************************************/
+
+// Feature converts a BED record into a gff feature. The chromosome becomes the
+// feature's Name, as in gff files, and the BED name is stored in the Name
+// attribute. Records with more than one block get a joined location with one
+// sub-location per block. thickStart, thickEnd and itemRgb have no place in a
+// gff feature, so they are dropped.
+func (record Record) Feature() gff.Feature {
+	feature := gff.Feature{
+		Name:       record.Chromosome,
+		Source:     "bed",
+		Type:       "region",
+		Score:      ".",
+		Strand:     ".",
+		Phase:      ".",
+		Attributes: make(map[string]string),
+		Location: gff.Location{
+			Start:      record.Start,
+			End:        record.End,
+			Complement: record.Strand == "-",
+		},
+	}
+	if record.columns() >= 5 {
+		feature.Score = strconv.Itoa(record.Score)
+	}
+	if record.Strand != "" {
+		feature.Strand = record.Strand
+	}
+	if record.Name != "" {
+		feature.Attributes["Name"] = record.Name
+	}
+	if len(record.BlockStarts) > 1 {
+		feature.Location.Join = true
+		for blockIndex, blockStart := range record.BlockStarts {
+			feature.Location.SubLocations = append(feature.Location.SubLocations, gff.Location{
+				Start:      record.Start + blockStart,
+				End:        record.Start + blockStart + record.BlockSizes[blockIndex],
+				Complement: feature.Location.Complement,
+			})
+		}
+	}
+	return feature
+}
+
+// FromFeature converts a gff feature into a BED record. The Name attribute,
+// or ID if there is no Name, becomes the BED name. Sub-locations become
+// blocks. Nested sub-locations are flattened.
+func FromFeature(feature gff.Feature) (Record, error) {
+	record := Record{
+		Chromosome: feature.Name,
+		Start:      feature.Location.Start,
+		End:        feature.Location.End,
+		Name:       feature.Attributes["Name"],
+	}
+	if record.Name == "" {
+		record.Name = feature.Attributes["ID"]
+	}
+	switch {
+	case feature.Strand == "+" || feature.Strand == "-":
+		record.Strand = feature.Strand
+	case feature.Location.Complement:
+		record.Strand = "-"
+	}
+	if feature.Score != "" && feature.Score != "." {
+		score, err := strconv.ParseFloat(feature.Score, 64)
+		if err != nil {
+			return Record{}, fmt.Errorf("Failed to convert score '%s' of feature: %w", feature.Score, err)
+		}
+		record.Score = int(math.Round(score))
+	}
+	blocks := leafLocations(feature.Location)
+	if len(blocks) > 1 {
+		sort.Slice(blocks, func(i, j int) bool { return blocks[i].Start < blocks[j].Start })
+		record.Start, record.End = blocks[0].Start, blocks[0].End
+		for _, block := range blocks {
+			record.End = max(record.End, block.End)
+		}
+		for _, block := range blocks {
+			record.BlockStarts = append(record.BlockStarts, block.Start-record.Start)
+			record.BlockSizes = append(record.BlockSizes, block.End-block.Start)
+		}
+	}
+	record.ThickStart, record.ThickEnd = record.Start, record.End
+	if record.End < record.Start {
+		return Record{}, fmt.Errorf("invalid feature location %d-%d", record.Start, record.End)
+	}
+	return record, nil
+}
+
+// leafLocations returns the locations without sub-locations that make up a location.
+func leafLocations(location gff.Location) []gff.Location {
+	if len(location.SubLocations) == 0 {
+		return []gff.Location{location}
+	}
+	var leaves []gff.Location
+	for _, subLocation := range location.SubLocations {
+		leaves = append(leaves, leafLocations(subLocation)...)
+	}
+	return leaves
+}
+
+/******************************************************************************
+
+Start of  Read functions
+
+******************************************************************************/
+
+// Read reads a BED file into an array of Records.
+func Read(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+Start of  Write functions
+
+******************************************************************************/
+
+// columns returns the amount of columns to write for a record.
+func (record Record) columns() int {
+	switch {
+	case record.Columns != 0:
+		return record.Columns
+	case len(record.BlockStarts) > 0:
+		return 12
+	case record.ItemRGB != "":
+		return 9
+	case record.ThickStart != record.Start || record.ThickEnd != record.End:
+		return 8
+	case record.Strand != "":
+		return 6
+	case record.Score != 0:
+		return 5
+	case record.Name != "":
+		return 4
+	}
+	return 3
+}
+
+// WriteRecords writes a BED record array to an io.Writer.
+func WriteRecords(records []Record, w io.Writer) error {
+	for _, record := range records {
+		columns := record.columns()
+		if columns < 3 || columns > 12 || columns == 10 || columns == 11 {
+			return fmt.Errorf("record %s:%d-%d can not be written with %d columns", record.Chromosome, record.Start, record.End, columns)
+		}
+		if len(record.BlockSizes) != len(record.BlockStarts) {
+			return fmt.Errorf("record %s:%d-%d has %d blockSizes and %d blockStarts", record.Chromosome, record.Start, record.End, len(record.BlockSizes), len(record.BlockStarts))
+		}
+		strand := record.Strand
+		if strand == "" {
+			strand = "."
+		}
+		itemRGB := record.ItemRGB
+		if itemRGB == "" {
+			itemRGB = "0"
+		}
+		values := []string{
+			record.Chromosome,
+			strconv.Itoa(record.Start),
+			strconv.Itoa(record.End),
+			record.Name,
+			strconv.Itoa(record.Score),
+			strand,
+			strconv.Itoa(record.ThickStart),
+			strconv.Itoa(record.ThickEnd),
+			itemRGB,
+			strconv.Itoa(len(record.BlockSizes)),
+			joinIntegers(record.BlockSizes),
+			joinIntegers(record.BlockStarts),
+		}
+		if _, err := w.Write([]byte(strings.Join(values[:columns], "\t") + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinIntegers joins integers with commas, including a trailing comma like UCSC does.
+func joinIntegers(integers []int) string {
+	var list strings.Builder
+	for _, integer := range integers {
+		list.WriteString(strconv.Itoa(integer) + ",")
+	}
+	return list.String()
+}
+
+// Build builds a BED file from an array of Records.
+func Build(records []Record) ([]byte, error) {
+	var bedBuffer bytes.Buffer
+	err := WriteRecords(records, &bedBuffer)
+	return bedBuffer.Bytes(), err
+}
+
+// Write writes a BED record array to a file.
+func Write(records []Record, path string) error {
+	bedBytes, err := Build(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bedBytes, 0644)
+}
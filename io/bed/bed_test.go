@@ -0,0 +1,167 @@
+package bed
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/gff"
+)
+
+func TestParse(t *testing.T) {
+	records, err := Read("data/example.bed")
+	if err != nil {
+		t.Fatalf("Failed to read example.bed. Got error: %s", err)
+	}
+	expected := []Record{
+		{Chromosome: "chr22", Start: 1000, End: 5000, Name: "cloneA", Score: 960, Strand: "+", ThickStart: 1000, ThickEnd: 5000, ItemRGB: "0", BlockSizes: []int{567, 488}, BlockStarts: []int{0, 3512}, Columns: 12},
+		{Chromosome: "chr22", Start: 2000, End: 6000, Name: "cloneB", Score: 900, Strand: "-", ThickStart: 2000, ThickEnd: 6000, ItemRGB: "0", BlockSizes: []int{433, 399}, BlockStarts: []int{0, 3601}, Columns: 12},
+		{Chromosome: "chr22", Start: 7000, End: 7500, Name: "peak1", Score: 500, Strand: ".", ThickStart: 7100, ThickEnd: 7400, ItemRGB: "255,0,0", Columns: 9},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected records %+v. Got: %+v", expected, records)
+	}
+
+	records, err = Parse(strings.NewReader("# comment\nchr1\t0\t100\n\nchr1\t5\t10\tname\n"))
+	if err != nil || len(records) != 2 || records[0].Columns != 3 || records[1].Name != "name" {
+		t.Errorf("Failed to parse short records. Got: %+v, %v", records, err)
+	}
+}
+
+func TestParseExceptions(t *testing.T) {
+	badLines := []string{
+		"chr1\t0\n",                                           // too few columns
+		"chr1\tzero\t100\n",                                   // bad start
+		"chr1\t0\tend\n",                                      // bad end
+		"chr1\t100\t0\n",                                      // end before start
+		"chr1\t0\t100\tname\thigh\n",                          // bad score
+		"chr1\t0\t100\tname\t0\tup\n",                         // bad strand
+		"chr1\t0\t100\tname\t0\t+\tx\t100\n",                  // bad thickStart
+		"chr1\t0\t100\tname\t0\t+\t0\tx\n",                    // bad thickEnd
+		"chr1\t0\t100\tname\t0\t+\t0\t100\t0\t2\n",            // incomplete blocks
+		"chr1\t0\t100\tname\t0\t+\t0\t100\t0\t2\t10,10\t0,\n", // blockCount mismatch
+		"chr1\t0\t100\tname\t0\t+\t0\t100\t0\t1\t10\t95\n",    // block outside interval
+		"chr1\t0\t100\tname\t0\t+\t0\t100\t0\t1\tx\t0\n",      // bad blockSizes
+	}
+	for _, badLine := range badLines {
+		if _, err := Parse(strings.NewReader(badLine)); err == nil {
+			t.Errorf("Test should have failed parsing %q", badLine)
+		}
+	}
+	file, _ := os.Open("data/example.bed")
+	defer file.Close()
+	parser := NewParser(file, 32)
+	if _, err := parser.ParseAll(); err == nil {
+		t.Errorf("Should have encountered a maxLine error")
+	}
+	parser.Reset(strings.NewReader("chr1\t0\t100\n"))
+	if records, err := parser.ParseAll(); err != nil || len(records) != 1 {
+		t.Errorf("Failed to parse after Reset. Got error: %s", err)
+	}
+}
+
+func TestFeature(t *testing.T) {
+	records, _ := Read("data/example.bed")
+	feature := records[1].Feature()
+	expectedLocation := gff.Location{
+		Start:      2000,
+		End:        6000,
+		Complement: true,
+		Join:       true,
+		SubLocations: []gff.Location{
+			{Start: 2000, End: 2433, Complement: true},
+			{Start: 5601, End: 6000, Complement: true},
+		},
+	}
+	if !reflect.DeepEqual(feature.Location, expectedLocation) {
+		t.Errorf("Expected location %+v. Got: %+v", expectedLocation, feature.Location)
+	}
+	if feature.Name != "chr22" || feature.Attributes["Name"] != "cloneB" || feature.Score != "900" || feature.Strand != "-" {
+		t.Errorf("Wrong fields converted: %+v", feature)
+	}
+
+	for _, record := range records[:2] {
+		converted, err := FromFeature(record.Feature())
+		if err != nil {
+			t.Errorf("Failed to convert feature back. Got error: %s", err)
+		}
+		converted.ItemRGB, converted.Columns = record.ItemRGB, record.Columns
+		if !reflect.DeepEqual(converted, record) {
+			t.Errorf("Record changed after converting to a feature and back. Expected %+v. Got: %+v", record, converted)
+		}
+	}
+}
+
+func TestFromFeature(t *testing.T) {
+	// Nested and unsorted sub-locations are flattened and sorted.
+	feature := gff.Feature{
+		Name:       "chr1",
+		Score:      "12.6",
+		Attributes: map[string]string{"ID": "gene1"},
+		Location: gff.Location{
+			Start:      10,
+			End:        100,
+			Complement: true,
+			SubLocations: []gff.Location{
+				{Start: 80, End: 100},
+				{SubLocations: []gff.Location{{Start: 10, End: 20}, {Start: 40, End: 50}}},
+			},
+		},
+	}
+	record, err := FromFeature(feature)
+	if err != nil {
+		t.Fatalf("Failed to convert feature. Got error: %s", err)
+	}
+	expected := Record{Chromosome: "chr1", Start: 10, End: 100, Name: "gene1", Score: 13, Strand: "-", ThickStart: 10, ThickEnd: 100, BlockSizes: []int{10, 10, 20}, BlockStarts: []int{0, 30, 70}}
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("Expected record %+v. Got: %+v", expected, record)
+	}
+
+	feature.Score = "high"
+	if _, err = FromFeature(feature); err == nil {
+		t.Errorf("Test should have failed converting a feature with a non numeric score")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	bedBytes, _ := os.ReadFile("data/example.bed")
+	records, _ := Read("data/example.bed")
+	builtBytes, err := Build(records)
+	if err != nil {
+		t.Fatalf("Failed to build bed. Got error: %s", err)
+	}
+	// browser and track lines are not kept
+	expected := strings.SplitN(string(bedBytes), "\n", 3)[2]
+	if string(builtBytes) != expected {
+		t.Errorf("Built bed differs from example.bed. Got:\n%s", builtBytes)
+	}
+
+	// Without Columns, only the needed columns are written.
+	builtBytes, _ = Build([]Record{{Chromosome: "chr1", Start: 0, End: 10, ThickEnd: 10}, {Chromosome: "chr1", Start: 0, End: 10, ThickEnd: 10, Strand: "-"}})
+	if string(builtBytes) != "chr1\t0\t10\nchr1\t0\t10\t\t0\t-\n" {
+		t.Errorf("Unexpected minimal records. Got: %q", builtBytes)
+	}
+
+	if _, err = Build([]Record{{Chromosome: "chr1", End: 10, Columns: 10}}); err == nil {
+		t.Errorf("Test should have failed building a record with 10 columns")
+	}
+	if _, err = Build([]Record{{Chromosome: "chr1", End: 10, BlockSizes: []int{1}}}); err == nil {
+		t.Errorf("Test should have failed building a record with mismatched blocks")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	records, _ := Read("data/example.bed")
+	path := t.TempDir() + "/example.bed"
+	if err := Write(records, path); err != nil {
+		t.Fatalf("Failed to write bed. Got error: %s", err)
+	}
+	writtenRecords, err := Read(path)
+	if err != nil || !reflect.DeepEqual(records, writtenRecords) {
+		t.Errorf("Records changed after writing and reading. Got error: %v", err)
+	}
+	if _, err = Read("data/doesntexist.bed"); err == nil {
+		t.Errorf("Should have failed to read non-existent file")
+	}
+}
@@ -0,0 +1,27 @@
+package bed_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/bed"
+)
+
+// ExampleRead shows basic usage for Read.
+func ExampleRead() {
+	records, _ := bed.Read("data/example.bed")
+	fmt.Println(records[0].Name, records[0].Start, records[0].End)
+	//Output:
+	//cloneA 1000 5000
+}
+
+// ExampleRecord_Feature shows how blocks become joined gff sub-locations.
+func ExampleRecord_Feature() {
+	records, _ := bed.Read("data/example.bed")
+	feature := records[0].Feature()
+	for _, subLocation := range feature.Location.SubLocations {
+		fmt.Println(subLocation.Start, subLocation.End)
+	}
+	//Output:
+	//1000 1567
+	//4512 5000
+}
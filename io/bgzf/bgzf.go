@@ -0,0 +1,405 @@
+/*
+Package bgzf provides readers and writers for the blocked gzip format (BGZF).
+
+Plain gzip files can not be read from the middle, since every byte depends on
+all bytes before it. BGZF fixes this by compressing data in independent
+blocks of at most 64KiB, each of which is a complete gzip member. Since a
+BGZF file is just a series of gzip members, every gzip reader can read it,
+including gzip.NewReader and `zcat`.
+
+Every block stores its own compressed size in a gzip extra field, so a reader
+can jump from block to block without decompressing them. A position in a BGZF
+file is given as a virtual offset, which combines the offset of a block in the
+compressed file with the offset of a byte in the uncompressed block.
+
+BGZF is used by BAM, tabix indexed VCF and bgzipped fasta files. The
+specification can be found in section 4.1 of the SAM specification:
+https://samtools.github.io/hts-specs/SAMv1.pdf
+*/
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+BGZF blocks look like this:
+
+	ID1 ID2 CM FLG  MTIME  XFL OS  XLEN           gzip header with extra field
+	31  139 8  4    0      0   255 6
+	SI1 SI2 SLEN BSIZE                              BGZF extra subfield
+	66  67  2    total block size - 1
+	CDATA                                           raw deflate data
+	CRC32 ISIZE                                     gzip footer
+
+A BGZF file ends with an empty block, the EOF marker, so that truncated files
+can be detected.
+
+******************************************************************************/
+
+// MaxBlockSize is the maximum size of a compressed BGZF block.
+const MaxBlockSize = 0x10000
+
+// maxDataSize is the maximum amount of uncompressed data written to a single
+// block. It is a bit smaller than MaxBlockSize so that incompressible data
+// still fits into a block. This is the same limit htslib uses.
+const maxDataSize = 0xff00
+
+// headerSize is the size of a BGZF block header including the extra field.
+const headerSize = 18
+
+// footerSize is the size of the CRC32 and ISIZE fields at the end of a block.
+const footerSize = 8
+
+// EOFMarker is the empty block every BGZF file ends with.
+var EOFMarker = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00, 0x42, 0x43,
+	0x02, 0x00, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// ErrNoEOFMarker is returned by Reader when a file ends without the EOF
+// marker, which usually means the file was truncated.
+var ErrNoEOFMarker = errors.New("bgzf: file ends without EOF marker, it may be truncated")
+
+// VirtualOffset is a position in a BGZF file. The upper 48 bits are the
+// offset of a block in the compressed file, the lower 16 bits are the offset
+// of a byte within the uncompressed data of that block.
+type VirtualOffset uint64
+
+// NewVirtualOffset returns the virtual offset of the byte at dataOffset in the
+// block that starts at blockOffset in the compressed file.
+func NewVirtualOffset(blockOffset int64, dataOffset int) VirtualOffset {
+	return VirtualOffset(uint64(blockOffset)<<16 | uint64(dataOffset&0xffff))
+}
+
+// BlockOffset returns the offset of the block in the compressed file.
+func (offset VirtualOffset) BlockOffset() int64 {
+	return int64(offset >> 16)
+}
+
+// DataOffset returns the offset within the uncompressed data of the block.
+func (offset VirtualOffset) DataOffset() int {
+	return int(offset & 0xffff)
+}
+
+// String returns the virtual offset as blockOffset:dataOffset.
+func (offset VirtualOffset) String() string {
+	return fmt.Sprintf("%d:%d", offset.BlockOffset(), offset.DataOffset())
+}
+
+/******************************************************************************
+
+Start of Reader functions
+
+******************************************************************************/
+
+// Reader decompresses a BGZF file block by block. If the underlying reader is
+// an io.Seeker, it can seek to virtual offsets. It is initialized with NewReader.
+type Reader struct {
+	reader io.Reader
+	// blockOffset is the offset of the current block in the compressed file.
+	blockOffset int64
+	// nextBlockOffset is the offset of the block after the current block.
+	nextBlockOffset int64
+	// block holds the uncompressed data of the current block.
+	block    []byte
+	position int
+	// lastBlockEmpty is set if the last block read was empty, like the EOF marker.
+	lastBlockEmpty bool
+	compressed     []byte
+	inflater       io.ReadCloser
+}
+
+// NewReader returns a Reader that decompresses the BGZF file in r. The first
+// block is read immediately to check that r is a BGZF file.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{reader: r, compressed: make([]byte, MaxBlockSize)}
+	if err := reader.readBlock(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("bgzf: empty file: %w", io.ErrUnexpectedEOF)
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Read reads uncompressed data into p.
+func (reader *Reader) Read(p []byte) (int, error) {
+	for reader.position == len(reader.block) {
+		if err := reader.readBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, reader.block[reader.position:])
+	reader.position += n
+	return n, nil
+}
+
+// VirtualOffset returns the virtual offset of the next byte Read will return.
+func (reader *Reader) VirtualOffset() VirtualOffset {
+	if reader.position == len(reader.block) {
+		// the next byte is at the start of the next block.
+		return NewVirtualOffset(reader.nextBlockOffset, 0)
+	}
+	return NewVirtualOffset(reader.blockOffset, reader.position)
+}
+
+// Seek moves the reader to a virtual offset, like one returned by
+// VirtualOffset or Writer.VirtualOffset. The underlying reader must be an
+// io.Seeker.
+func (reader *Reader) Seek(offset VirtualOffset) error {
+	seeker, ok := reader.reader.(io.Seeker)
+	if !ok {
+		return errors.New("bgzf: Seek requires the underlying reader to be an io.Seeker")
+	}
+	if offset.BlockOffset() != reader.blockOffset {
+		if _, err := seeker.Seek(offset.BlockOffset(), io.SeekStart); err != nil {
+			return err
+		}
+		reader.nextBlockOffset = offset.BlockOffset()
+		if err := reader.readBlock(); err != nil {
+			return err
+		}
+	}
+	if offset.DataOffset() > len(reader.block) {
+		return fmt.Errorf("bgzf: virtual offset %s is beyond the end of its block of %d bytes", offset, len(reader.block))
+	}
+	reader.position = offset.DataOffset()
+	return nil
+}
+
+// readBlock reads and decompresses the next block.
+func (reader *Reader) readBlock() error {
+	blockSize, err := readCompressedBlock(reader.reader, reader.compressed)
+	if err != nil {
+		if errors.Is(err, io.EOF) && !reader.lastBlockEmpty && reader.nextBlockOffset > 0 {
+			return ErrNoEOFMarker
+		}
+		return err
+	}
+	reader.block, err = decompressBlock(&reader.inflater, reader.compressed[:blockSize], reader.block)
+	if err != nil {
+		return fmt.Errorf("bgzf: block at offset %d: %w", reader.nextBlockOffset, err)
+	}
+	reader.blockOffset = reader.nextBlockOffset
+	reader.nextBlockOffset += int64(blockSize)
+	reader.position = 0
+	reader.lastBlockEmpty = len(reader.block) == 0
+	return nil
+}
+
+// readCompressedBlock reads a single compressed block from r into buffer and returns its size.
+// It returns io.EOF if r is at its end.
+func readCompressedBlock(r io.Reader, buffer []byte) (int, error) {
+	header := buffer[:headerSize]
+	if n, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) && n == 0 {
+			return 0, io.EOF
+		}
+		return 0, fmt.Errorf("bgzf: truncated block header: %w", io.ErrUnexpectedEOF)
+	}
+	if header[0] != 0x1f || header[1] != 0x8b || header[2] != 8 || header[3]&4 == 0 {
+		return 0, errors.New("bgzf: not a BGZF block, invalid gzip header")
+	}
+	extraLength := int(binary.LittleEndian.Uint16(header[10:12]))
+	if extraLength < 6 {
+		return 0, errors.New("bgzf: not a BGZF block, missing BC extra subfield")
+	}
+	// the extra field may contain other subfields in addition to the BC subfield.
+	extra := make([]byte, extraLength)
+	copy(extra, header[12:])
+	if extraLength > 6 {
+		if _, err := io.ReadFull(r, extra[6:]); err != nil {
+			return 0, fmt.Errorf("bgzf: truncated block header: %w", io.ErrUnexpectedEOF)
+		}
+	}
+	blockSize := -1
+	for position := 0; position+4 <= len(extra); {
+		subfieldLength := int(binary.LittleEndian.Uint16(extra[position+2:]))
+		if extra[position] == 'B' && extra[position+1] == 'C' && subfieldLength == 2 && position+6 <= len(extra) {
+			blockSize = int(binary.LittleEndian.Uint16(extra[position+4:])) + 1
+			break
+		}
+		position += 4 + subfieldLength
+	}
+	if blockSize == -1 {
+		return 0, errors.New("bgzf: not a BGZF block, missing BC extra subfield")
+	}
+	dataStart := 12 + extraLength
+	if blockSize < dataStart+footerSize {
+		return 0, fmt.Errorf("bgzf: invalid block size %d", blockSize)
+	}
+	// the data starts right after the extra field, which may be longer than the standard 6 bytes.
+	copy(buffer[12:], extra)
+	if _, err := io.ReadFull(r, buffer[dataStart:blockSize]); err != nil {
+		return 0, fmt.Errorf("bgzf: truncated block: %w", io.ErrUnexpectedEOF)
+	}
+	return blockSize, nil
+}
+
+// decompressBlock inflates a complete block into output and checks its CRC32
+// and size. The inflater is created on first use and reused afterwards.
+func decompressBlock(inflater *io.ReadCloser, block []byte, output []byte) ([]byte, error) {
+	extraLength := int(binary.LittleEndian.Uint16(block[10:12]))
+	data := block[12+extraLength : len(block)-footerSize]
+	footer := block[len(block)-footerSize:]
+	checksum := binary.LittleEndian.Uint32(footer)
+	size := int(binary.LittleEndian.Uint32(footer[4:]))
+	if size > MaxBlockSize {
+		return nil, fmt.Errorf("invalid uncompressed size %d", size)
+	}
+	if *inflater == nil {
+		*inflater = flate.NewReader(bytes.NewReader(data))
+	} else if err := (*inflater).(flate.Resetter).Reset(bytes.NewReader(data), nil); err != nil {
+		return nil, err
+	}
+	if cap(output) < size {
+		output = make([]byte, size)
+	}
+	output = output[:size]
+	if _, err := io.ReadFull(*inflater, output); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(output) != checksum {
+		return nil, errors.New("checksum mismatch")
+	}
+	return output, nil
+}
+
+/******************************************************************************
+
+Start of Writer functions
+
+******************************************************************************/
+
+// Writer compresses data into BGZF blocks. Close must be called to write the
+// last block and the EOF marker. It is initialized with NewWriter.
+type Writer struct {
+	writer     io.Writer
+	compressor *flate.Writer
+	// data holds the uncompressed data of the current block.
+	data []byte
+	// offset is the amount of compressed bytes written so far.
+	offset             int64
+	compressed         bytes.Buffer
+	index              Index
+	uncompressedOffset int64
+	closed             bool
+}
+
+// NewWriter returns a Writer that writes BGZF blocks to w with the default compression level.
+func NewWriter(w io.Writer) *Writer {
+	writer, _ := NewWriterLevel(w, flate.DefaultCompression)
+	return writer
+}
+
+// NewWriterLevel returns a Writer that writes BGZF blocks to w with the given
+// compression level, see compress/flate for the valid levels.
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	writer := &Writer{writer: w, data: make([]byte, 0, maxDataSize)}
+	var err error
+	writer.compressor, err = flate.NewWriter(&writer.compressed, level)
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Write compresses p. Blocks are written to the underlying writer once they are full.
+func (writer *Writer) Write(p []byte) (int, error) {
+	if writer.closed {
+		return 0, errors.New("bgzf: write to closed Writer")
+	}
+	written := 0
+	for len(p) > 0 {
+		n := copy(writer.data[len(writer.data):maxDataSize], p)
+		writer.data = writer.data[:len(writer.data)+n]
+		p = p[n:]
+		written += n
+		if len(writer.data) == maxDataSize {
+			if err := writer.Flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// VirtualOffset returns the virtual offset of the next byte that will be written.
+func (writer *Writer) VirtualOffset() VirtualOffset {
+	return NewVirtualOffset(writer.offset, len(writer.data))
+}
+
+// Flush writes the current block, even if it is not full. Flushing makes sure
+// that the data written next starts at the beginning of a block.
+func (writer *Writer) Flush() error {
+	if len(writer.data) == 0 {
+		return nil
+	}
+	if err := writer.writeBlock(writer.data); err != nil {
+		return err
+	}
+	writer.data = writer.data[:0]
+	return nil
+}
+
+// Close flushes the last block and writes the EOF marker. It does not close the underlying writer.
+func (writer *Writer) Close() error {
+	if writer.closed {
+		return nil
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	writer.closed = true
+	_, err := writer.writer.Write(EOFMarker)
+	return err
+}
+
+// Index returns the gzi index of all blocks written so far.
+func (writer *Writer) Index() Index {
+	return writer.index
+}
+
+// writeBlock compresses data into a single block and writes it out.
+func (writer *Writer) writeBlock(data []byte) error {
+	writer.compressed.Reset()
+	writer.compressed.Write(make([]byte, headerSize))
+	writer.compressor.Reset(&writer.compressed)
+	if _, err := writer.compressor.Write(data); err != nil {
+		return err
+	}
+	if err := writer.compressor.Close(); err != nil {
+		return err
+	}
+	writer.compressed.Write(binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(data)), uint32(len(data))))
+	block := writer.compressed.Bytes()
+	if len(block) > MaxBlockSize {
+		// incompressible data can grow a tiny bit, in which case it is split into two blocks.
+		half := len(data) / 2
+		if err := writer.writeBlock(data[:half]); err != nil {
+			return err
+		}
+		return writer.writeBlock(data[half:])
+	}
+	copy(block, EOFMarker[:headerSize])
+	binary.LittleEndian.PutUint16(block[16:], uint16(len(block)-1))
+
+	if writer.offset > 0 {
+		writer.index = append(writer.index, IndexEntry{CompressedOffset: writer.offset, UncompressedOffset: writer.uncompressedOffset})
+	}
+	if _, err := writer.writer.Write(block); err != nil {
+		return err
+	}
+	writer.offset += int64(len(block))
+	writer.uncompressedOffset += int64(len(data))
+	return nil
+}
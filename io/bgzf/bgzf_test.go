@@ -0,0 +1,190 @@
+package bgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// testData returns data that is partly compressible and partly random, so
+// that it spans several blocks.
+func testData() []byte {
+	random := rand.New(rand.NewSource(9))
+	var data bytes.Buffer
+	for data.Len() < 300000 {
+		data.WriteString(">chromosome\nACGTACGTTTGACAGATTACA\n")
+		randomBytes := make([]byte, random.Intn(5000))
+		random.Read(randomBytes)
+		data.Write(randomBytes)
+	}
+	return data.Bytes()
+}
+
+func compress(t *testing.T, data []byte) ([]byte, *Writer) {
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return compressed.Bytes(), writer
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := testData()
+	compressed, _ := compress(t, data)
+	if !bytes.HasSuffix(compressed, EOFMarker) {
+		t.Errorf("Compressed data does not end with the EOF marker")
+	}
+
+	reader, err := NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, decompressed) {
+		t.Errorf("Decompressed data does not match the original data")
+	}
+
+	// BGZF files are valid gzip files.
+	gzipReader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err = io.ReadAll(gzipReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, decompressed) {
+		t.Errorf("gzip decompressed data does not match the original data")
+	}
+}
+
+func TestIncompressibleData(t *testing.T) {
+	data := make([]byte, 3*maxDataSize)
+	rand.New(rand.NewSource(1)).Read(data)
+	compressed, _ := compress(t, data)
+	reader, err := NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, decompressed) {
+		t.Errorf("Decompressed data does not match the original data")
+	}
+}
+
+func TestSeek(t *testing.T) {
+	data := testData()
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	// remember the virtual offset of every 1000th byte while writing.
+	offsets := make(map[int]VirtualOffset)
+	for position := 0; position < len(data); position += 1000 {
+		offsets[position] = writer.VirtualOffset()
+		if _, err := writer.Write(data[position:min(position+1000, len(data))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// seek backwards, so that every seek jumps.
+	for position := (len(data) - 1) / 1000 * 1000; position >= 0; position -= 1000 {
+		if err = reader.Seek(offsets[position]); err != nil {
+			t.Fatalf("Seek(%s) failed: %s", offsets[position], err)
+		}
+		if reader.VirtualOffset() != offsets[position] && offsets[position].DataOffset() != 0 {
+			t.Errorf("VirtualOffset() = %s after Seek(%s)", reader.VirtualOffset(), offsets[position])
+		}
+		chunk := make([]byte, min(1500, len(data)-position))
+		if _, err = io.ReadFull(reader, chunk); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(chunk, data[position:position+len(chunk)]) {
+			t.Errorf("Read after Seek(%s) returned wrong data", offsets[position])
+		}
+	}
+
+	unseekable, err := NewReader(bytes.NewBuffer(compressed.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = unseekable.Seek(offsets[0]); err == nil {
+		t.Errorf("Seek on an io.Reader that is not an io.Seeker should fail")
+	}
+}
+
+func TestVirtualOffset(t *testing.T) {
+	offset := NewVirtualOffset(123456789, 4321)
+	if offset.BlockOffset() != 123456789 || offset.DataOffset() != 4321 {
+		t.Errorf("Got %s, want 123456789:4321", offset)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	compressed, _ := compress(t, testData())
+
+	var plainGzip bytes.Buffer
+	gzipWriter := gzip.NewWriter(&plainGzip)
+	_, _ = gzipWriter.Write([]byte("ACGT"))
+	_ = gzipWriter.Close()
+
+	corrupted := bytes.Clone(compressed)
+	corrupted[100] ^= 0xff
+
+	tests := []struct {
+		name       string
+		compressed []byte
+		wantErr    error
+	}{
+		{"empty file", nil, io.ErrUnexpectedEOF},
+		{"plain gzip", plainGzip.Bytes(), nil},
+		{"truncated block", compressed[:len(compressed)/2], io.ErrUnexpectedEOF},
+		{"missing EOF marker", compressed[:len(compressed)-len(EOFMarker)], ErrNoEOFMarker},
+		{"corrupted block", corrupted, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewReader(bytes.NewReader(tt.compressed))
+			if err == nil {
+				_, err = io.ReadAll(reader)
+			}
+			if err == nil {
+				t.Fatalf("Expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Got error %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	writer := NewWriter(io.Discard)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("ACGT")); err == nil {
+		t.Errorf("Write after Close should fail")
+	}
+	if _, err := NewWriterLevel(io.Discard, 42); err == nil {
+		t.Errorf("NewWriterLevel with an invalid level should fail")
+	}
+}
@@ -0,0 +1,42 @@
+package bgzf_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/bebop/poly/io/bgzf"
+)
+
+// This example shows how to jump back to a position in a BGZF file with virtual offsets.
+func Example_basic() {
+	var compressed bytes.Buffer
+	writer := bgzf.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(">first\nACGT\n"))
+	secondRecord := writer.VirtualOffset() // remember where the second record starts
+	_, _ = writer.Write([]byte(">second\nGATTACA\n"))
+	_ = writer.Close()
+
+	reader, _ := bgzf.NewReader(bytes.NewReader(compressed.Bytes()))
+	_ = reader.Seek(secondRecord)
+	record, _ := io.ReadAll(reader)
+	fmt.Print(string(record))
+	// Output:
+	// >second
+	// GATTACA
+}
+
+// This example shows how to read uncompressed offsets from a BGZF file with a gzi index.
+func ExampleReaderAt() {
+	var compressed bytes.Buffer
+	writer := bgzf.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(">first\nACGT\n>second\nGATTACA\n"))
+	_ = writer.Close()
+
+	// the index can also be built from the file with BuildIndex or read from a .gzi file with ParseIndex.
+	readerAt := bgzf.NewReaderAt(bytes.NewReader(compressed.Bytes()), writer.Index())
+	sequence := make([]byte, 7)
+	_, _ = readerAt.ReadAt(sequence, 20)
+	fmt.Println(string(sequence))
+	// Output: GATTACA
+}
@@ -0,0 +1,193 @@
+package bgzf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+gzi index begins here.
+
+Virtual offsets are great if you already know them, for example from a BAM
+index, but a fasta index (.fai) stores plain offsets into the uncompressed
+file. To translate those, samtools writes a .gzi index next to bgzipped
+fasta files, which holds the compressed and uncompressed offset of every
+block except the first (which always starts at 0, 0):
+
+	number of entries  uint64
+	entries            (repeated)
+		compressed offset    uint64
+		uncompressed offset  uint64
+
+All numbers are little endian. With a gzi index, ReaderAt turns a BGZF file
+into an io.ReaderAt of the uncompressed data, which is all fasta.Fetcher needs.
+
+******************************************************************************/
+
+// IndexEntry holds the compressed and uncompressed offsets of the start of a block.
+type IndexEntry struct {
+	CompressedOffset   int64
+	UncompressedOffset int64
+}
+
+// Index is a samtools compatible gzi index of the blocks of a BGZF file. The
+// first block is not included, since it always starts at offset 0.
+type Index []IndexEntry
+
+// VirtualOffset converts an offset in the uncompressed data to a virtual offset.
+func (index Index) VirtualOffset(uncompressedOffset int64) VirtualOffset {
+	blockOffset, dataOffset := index.locate(uncompressedOffset)
+	return NewVirtualOffset(blockOffset, int(dataOffset))
+}
+
+// locate returns the offset of the block that holds an uncompressed offset
+// and the offset within that block. Unlike a virtual offset, the offset
+// within the block is not limited to 16 bits, so offsets beyond the end of
+// the file can be detected.
+func (index Index) locate(uncompressedOffset int64) (blockOffset int64, dataOffset int64) {
+	// find the last block that starts at or before the offset.
+	entryIndex := sort.Search(len(index), func(i int) bool { return index[i].UncompressedOffset > uncompressedOffset }) - 1
+	if entryIndex < 0 {
+		return 0, uncompressedOffset
+	}
+	entry := index[entryIndex]
+	return entry.CompressedOffset, uncompressedOffset - entry.UncompressedOffset
+}
+
+// BuildIndex builds a gzi index of a BGZF file. Only the block headers and
+// footers are read, so blocks are not decompressed.
+func BuildIndex(r io.Reader) (Index, error) {
+	reader := bufio.NewReader(r)
+	buffer := make([]byte, MaxBlockSize)
+	var index Index
+	var compressedOffset, uncompressedOffset int64
+	for {
+		blockSize, err := readCompressedBlock(reader, buffer)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("bgzf: block at offset %d: %w", compressedOffset, err)
+		}
+		dataSize := int64(binary.LittleEndian.Uint32(buffer[blockSize-4:]))
+		// empty blocks, like the EOF marker, do not hold any data to look up.
+		if compressedOffset > 0 && dataSize > 0 {
+			index = append(index, IndexEntry{CompressedOffset: compressedOffset, UncompressedOffset: uncompressedOffset})
+		}
+		compressedOffset += int64(blockSize)
+		uncompressedOffset += dataSize
+	}
+	return index, nil
+}
+
+// ParseIndex parses a samtools .gzi index file.
+func ParseIndex(r io.Reader) (Index, error) {
+	reader := bufio.NewReader(r)
+	var entryCount uint64
+	if err := binary.Read(reader, binary.LittleEndian, &entryCount); err != nil {
+		return nil, fmt.Errorf("bgzf: failed to read number of gzi index entries: %w", err)
+	}
+	index := make(Index, 0, min(entryCount, 1<<20))
+	for entryNumber := uint64(0); entryNumber < entryCount; entryNumber++ {
+		var offsets [2]uint64
+		if err := binary.Read(reader, binary.LittleEndian, &offsets); err != nil {
+			return nil, fmt.Errorf("bgzf: failed to read gzi index entry %d of %d: %w", entryNumber+1, entryCount, err)
+		}
+		index = append(index, IndexEntry{CompressedOffset: int64(offsets[0]), UncompressedOffset: int64(offsets[1])})
+	}
+	return index, nil
+}
+
+// WriteIndex writes an index in the samtools .gzi format.
+func WriteIndex(index Index, output io.Writer) error {
+	writer := bufio.NewWriter(output)
+	if err := binary.Write(writer, binary.LittleEndian, uint64(len(index))); err != nil {
+		return err
+	}
+	for _, entry := range index {
+		if err := binary.Write(writer, binary.LittleEndian, [2]uint64{uint64(entry.CompressedOffset), uint64(entry.UncompressedOffset)}); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+/******************************************************************************
+
+Start of ReaderAt functions
+
+******************************************************************************/
+
+// ReaderAt provides random access to the uncompressed data of a BGZF file
+// using its gzi index. The last decompressed block is cached, so reading
+// small, close regions is cheap. It is initialized with NewReaderAt.
+type ReaderAt struct {
+	reader io.ReaderAt
+	index  Index
+
+	// mutex guards the cache, since ReadAt may be called in parallel.
+	mutex       sync.Mutex
+	blockOffset int64
+	block       []byte
+	cached      bool
+	compressed  []byte
+	inflater    io.ReadCloser
+}
+
+// NewReaderAt returns a ReaderAt that reads the BGZF file r, usually an
+// *os.File, using an index built with BuildIndex, parsed with ParseIndex or
+// returned by Writer.Index.
+func NewReaderAt(r io.ReaderAt, index Index) *ReaderAt {
+	return &ReaderAt{reader: r, index: index, compressed: make([]byte, MaxBlockSize)}
+}
+
+// ReadAt reads len(p) bytes of uncompressed data starting at offset.
+func (readerAt *ReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errors.New("bgzf: negative offset")
+	}
+	readerAt.mutex.Lock()
+	defer readerAt.mutex.Unlock()
+	n := 0
+	for n < len(p) {
+		blockOffset, dataOffset := readerAt.index.locate(offset + int64(n))
+		if err := readerAt.loadBlock(blockOffset); err != nil {
+			return n, err
+		}
+		if dataOffset >= int64(len(readerAt.block)) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], readerAt.block[dataOffset:])
+	}
+	return n, nil
+}
+
+// loadBlock decompresses the block at blockOffset, unless it is already cached.
+func (readerAt *ReaderAt) loadBlock(blockOffset int64) error {
+	if readerAt.cached && readerAt.blockOffset == blockOffset {
+		return nil
+	}
+	readerAt.cached = false
+	section := io.NewSectionReader(readerAt.reader, blockOffset, MaxBlockSize)
+	blockSize, err := readCompressedBlock(section, readerAt.compressed)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return fmt.Errorf("bgzf: block at offset %d: %w", blockOffset, err)
+	}
+	readerAt.block, err = decompressBlock(&readerAt.inflater, readerAt.compressed[:blockSize], readerAt.block)
+	if err != nil {
+		return fmt.Errorf("bgzf: block at offset %d: %w", blockOffset, err)
+	}
+	readerAt.blockOffset = blockOffset
+	readerAt.cached = true
+	return nil
+}
@@ -0,0 +1,89 @@
+package bgzf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIndex(t *testing.T) {
+	data := testData()
+	compressed, writer := compress(t, data)
+	if len(writer.Index()) < 4 {
+		t.Fatalf("Expected at least 4 blocks, got index %v", writer.Index())
+	}
+
+	index, err := BuildIndex(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(writer.Index(), index); diff != "" {
+		t.Errorf("BuildIndex does not match the index of the Writer (-writer +built):\n%s", diff)
+	}
+
+	var indexBuffer bytes.Buffer
+	if err = WriteIndex(index, &indexBuffer); err != nil {
+		t.Fatal(err)
+	}
+	if indexBuffer.Len() != 8+16*len(index) {
+		t.Errorf("gzi index has %d bytes, want %d", indexBuffer.Len(), 8+16*len(index))
+	}
+	parsedIndex, err := ParseIndex(&indexBuffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(index, parsedIndex); diff != "" {
+		t.Errorf("ParseIndex mismatch (-want +got):\n%s", diff)
+	}
+	if _, err = ParseIndex(bytes.NewReader([]byte{2, 0, 0, 0, 0, 0, 0, 0, 1})); err == nil {
+		t.Errorf("ParseIndex of a truncated index should fail")
+	}
+
+	// virtual offsets of the index should point to the same data as the reader.
+	reader, err := NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range []int64{0, 1, 65279, 65280, 100000, int64(len(data)) - 1} {
+		if err = reader.Seek(index.VirtualOffset(position)); err != nil {
+			t.Fatal(err)
+		}
+		next := make([]byte, 1)
+		if _, err = io.ReadFull(reader, next); err != nil {
+			t.Fatal(err)
+		}
+		if next[0] != data[position] {
+			t.Errorf("Byte at virtual offset %s does not match byte %d", index.VirtualOffset(position), position)
+		}
+	}
+}
+
+func TestReaderAt(t *testing.T) {
+	data := testData()
+	compressed, writer := compress(t, data)
+	readerAt := NewReaderAt(bytes.NewReader(compressed), writer.Index())
+
+	for _, region := range [][2]int{{0, 10}, {65270, 65290}, {1000, 140000}, {len(data) - 5, len(data)}} {
+		got := make([]byte, region[1]-region[0])
+		n, err := readerAt.ReadAt(got, int64(region[0]))
+		if err != nil && !(err == io.EOF && n == len(got)) {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data[region[0]:region[1]]) {
+			t.Errorf("ReadAt(%d, %d) returned wrong data", region[0], region[1])
+		}
+	}
+
+	beyondEnd := make([]byte, 10)
+	if n, err := readerAt.ReadAt(beyondEnd, int64(len(data))-5); n != 5 || err != io.EOF {
+		t.Errorf("ReadAt beyond the end returned %d, %v, want 5, EOF", n, err)
+	}
+	if _, err := readerAt.ReadAt(beyondEnd, int64(len(data))+100000); err != io.EOF {
+		t.Errorf("ReadAt far beyond the end returned %v, want EOF", err)
+	}
+	if _, err := readerAt.ReadAt(beyondEnd, -1); err == nil {
+		t.Errorf("ReadAt with a negative offset should fail")
+	}
+}
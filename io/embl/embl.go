@@ -0,0 +1,707 @@
+/*
+Package embl provides EMBL parsers and writers.
+
+The EMBL flat file format is used by the European Nucleotide Archive (ENA). It
+stores the same information as GenBank, but every line starts with a two
+letter line code instead of GenBank's keywords:
+
+	```
+	ID   X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.
+	XX
+	AC   X56734; S46826;
+	XX
+	DE   Trifolium repens mRNA for non-cyanogenic beta-glucosidase
+	XX
+	FH   Key             Location/Qualifiers
+	FT   CDS             14..1495
+	FT                   /product="beta-glucosidase"
+	XX
+	SQ   Sequence 1859 BP; 609 A; 314 C; 355 G; 581 T; 0 other;
+	     aaacaaacca aatatggatt ttattgtagc catatttgct ctgtttgtta ttagctcatt        60
+	//
+	```
+
+Instead of introducing a new data model, EMBL files are parsed into and built
+from genbank.Genbank structs. This way EMBL records work with every function
+that takes a genbank.Genbank, like codon.UpdateWeightsWithSequence and
+Feature.GetSequence.
+
+The EMBL line codes are mapped onto the Genbank struct like this:
+
+	ID: Locus (name, topology, molecule type, division, length) and the Version
+	AC: Accession (space separated, like in GenBank)
+	DT: Date (one line per DT line) and Locus.ModificationDate (the last DT date)
+	DE: Definition
+	KW: Keywords
+	OS: Source, and Organism without the common name in parentheses
+	OC: Taxonomy
+	RN-RL: References
+	CC: Other["COMMENT"]
+	FT: Features
+	SQ: Sequence
+
+All other line codes, like DR and OG, are stored in Meta.Other under their line
+code, with one line per EMBL line. The data class of the ID line is stored in
+Other["DATA_CLASS"] unless it is the standard STD.
+
+The specification can be found here: https://ftp.ebi.ac.uk/pub/databases/embl/doc/usrman.txt
+*/
+package embl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/mitchellh/go-wordwrap"
+)
+
+// lineWidth is the maximum width of an EMBL line.
+const lineWidth = 80
+
+// qualifierIndex is where feature locations and qualifiers start on an FT line.
+const qualifierIndex = 21
+
+// dataClassKey is the Meta.Other key of the ID line's data class.
+const dataClassKey = "DATA_CLASS"
+
+// unquotedQualifiers are the qualifiers whose values are not quoted.
+var unquotedQualifiers = map[string]bool{
+	"anticodon":        true,
+	"citation":         true,
+	"codon_start":      true,
+	"compare":          true,
+	"direction":        true,
+	"estimated_length": true,
+	"mod_base":         true,
+	"number":           true,
+	"rpt_type":         true,
+	"rpt_unit_range":   true,
+	"tag_peptide":      true,
+	"transl_except":    true,
+	"transl_table":     true,
+}
+
+// Precompiled regular expressions:
+var (
+	basesRangeRegex = regexp.MustCompile(`(\d+) to (\d+)`)
+	commonNameRegex = regexp.MustCompile(` \([^()]*\)$`)
+)
+
+/******************************************************************************
+
+EMBL parsing begins here.
+
+******************************************************************************/
+
+// Parse takes in a reader representing a single EMBL file and parses it into a Genbank struct.
+func Parse(r io.Reader) (genbank.Genbank, error) {
+	records, err := ParseMultiNth(r, 1)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	if len(records) == 0 {
+		return genbank.Genbank{}, fmt.Errorf("no EMBL record found")
+	}
+	return records[0], nil
+}
+
+// ParseMulti takes in a reader representing a multi EMBL file and parses it into a slice of Genbank structs.
+func ParseMulti(r io.Reader) ([]genbank.Genbank, error) {
+	return ParseMultiNth(r, -1)
+}
+
+// recordParser holds the state of the EMBL record that is currently being parsed.
+type recordParser struct {
+	record        *genbank.Genbank
+	lines         map[string][]string // collected lines of the simple line codes
+	references    [][][2]string       // line code and content of every reference line
+	features      []featureLines
+	sequence      strings.Builder
+	inSequence    bool
+	hasQualifiers bool
+}
+
+// featureLines holds the raw location and qualifiers of a feature.
+type featureLines struct {
+	key        string
+	location   string
+	qualifiers [][2]string // qualifier name and raw value (with quotes)
+}
+
+// ParseMultiNth takes in a reader representing a multi EMBL file and parses the first n records into a slice of Genbank structs.
+func ParseMultiNth(r io.Reader, count int) ([]genbank.Genbank, error) {
+	scanner := bufio.NewScanner(r)
+	// sequence and feature lines are at most 80 characters, but some lines, like CC lines, may be longer.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var records []genbank.Genbank
+	var parser *recordParser
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if count >= 0 && len(records) >= count {
+			break
+		}
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if parser == nil {
+			// keep scanning until we find the start of the next record
+			if strings.HasPrefix(line, "ID ") {
+				parser = &recordParser{record: &genbank.Genbank{}, lines: make(map[string][]string)}
+				parser.record.Meta.Other = make(map[string]string)
+				if err := parser.parseID(line); err != nil {
+					return records, fmt.Errorf("Error on line %d: %w", lineNum, err)
+				}
+			}
+			continue
+		}
+		if line == "//" {
+			record, err := parser.finish()
+			if err != nil {
+				return records, fmt.Errorf("Error in record ending on line %d: %w", lineNum, err)
+			}
+			records = append(records, record)
+			parser = nil
+			continue
+		}
+		if parser.inSequence {
+			if len(line) > 0 && line[0] != ' ' {
+				return records, fmt.Errorf("Error on line %d: expected sequence or //. Got: %s", lineNum, line)
+			}
+			for _, character := range line {
+				if ('a' <= character && character <= 'z') || ('A' <= character && character <= 'Z') || character == '*' {
+					parser.sequence.WriteRune(character)
+				}
+			}
+			continue
+		}
+		if len(line) < 2 {
+			return records, fmt.Errorf("Error on line %d: line too short to contain a line code. Got: %q", lineNum, line)
+		}
+		lineCode := line[:2]
+		var content string
+		if len(line) > 5 {
+			content = line[5:]
+		}
+		switch lineCode {
+		case "XX", "FH":
+		case "SQ":
+			parser.inSequence = true
+		case "RN":
+			parser.references = append(parser.references, [][2]string{{lineCode, content}})
+		case "RC", "RP", "RX", "RG", "RA", "RT", "RL":
+			if len(parser.references) == 0 {
+				return records, fmt.Errorf("Error on line %d: %s line without RN line", lineNum, lineCode)
+			}
+			lastReference := len(parser.references) - 1
+			parser.references[lastReference] = append(parser.references[lastReference], [2]string{lineCode, content})
+		case "FT":
+			if err := parser.parseFeatureLine(content); err != nil {
+				return records, fmt.Errorf("Error on line %d: %w", lineNum, err)
+			}
+		default:
+			parser.lines[lineCode] = append(parser.lines[lineCode], strings.TrimSpace(content))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return records, err
+	}
+	if parser != nil {
+		return records, fmt.Errorf("EMBL record %s ended without //", parser.record.Meta.Locus.Name)
+	}
+	return records, nil
+}
+
+// parseID parses an ID line, like `ID   X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.`
+func (parser *recordParser) parseID(line string) error {
+	if len(line) < 5 {
+		return fmt.Errorf("ID line too short. Got: %s", line)
+	}
+	fields := strings.Split(strings.TrimSuffix(strings.TrimSpace(line[5:]), "."), ";")
+	for index := range fields {
+		fields[index] = strings.TrimSpace(fields[index])
+	}
+	if len(fields) < 2 {
+		return fmt.Errorf("ID line must have semicolon separated fields. Got: %s", line)
+	}
+	locus := &parser.record.Meta.Locus
+	locus.Name = fields[0]
+	lengthFields := strings.Fields(fields[len(fields)-1])
+	if len(lengthFields) != 2 || lengthFields[1] != "BP" {
+		return fmt.Errorf("ID line must end with the sequence length in BP. Got: %s", line)
+	}
+	locus.SequenceLength = lengthFields[0]
+	locus.SequenceCoding = "bp"
+	// The remaining fields are the sequence version, topology, molecule type, data class and division.
+	var others []string
+	for _, field := range fields[1 : len(fields)-1] {
+		switch {
+		case strings.HasPrefix(field, "SV "):
+			parser.record.Meta.Version = locus.Name + "." + strings.TrimPrefix(field, "SV ")
+		case field == "linear" || field == "circular":
+			locus.Circular = field == "circular"
+		default:
+			others = append(others, field)
+		}
+	}
+	if len(others) != 3 {
+		return fmt.Errorf("ID line must contain molecule type, data class and taxonomic division. Got: %s", line)
+	}
+	locus.MoleculeType = others[0]
+	if others[1] != "STD" {
+		parser.record.Meta.Other[dataClassKey] = others[1]
+	}
+	locus.GenbankDivision = others[2]
+	return nil
+}
+
+// parseFeatureLine parses the content of a single FT line.
+func (parser *recordParser) parseFeatureLine(content string) error {
+	if len(content) > 0 && content[0] != ' ' {
+		key := strings.Fields(content)[0]
+		parser.features = append(parser.features, featureLines{key: key, location: strings.TrimSpace(content[len(key):])})
+		parser.hasQualifiers = false
+		return nil
+	}
+	if len(parser.features) == 0 {
+		return fmt.Errorf("FT continuation line without feature: %s", content)
+	}
+	feature := &parser.features[len(parser.features)-1]
+	text := strings.TrimSpace(content)
+	if parser.hasQualifiers {
+		lastQualifier := &feature.qualifiers[len(feature.qualifiers)-1]
+		// An odd amount of quotes means that the qualifier value is still open.
+		if strings.Count(lastQualifier[1], `"`)%2 == 1 {
+			separator := " "
+			if lastQualifier[0] == "translation" {
+				separator = ""
+			}
+			lastQualifier[1] += separator + text
+			return nil
+		}
+	}
+	if strings.HasPrefix(text, "/") {
+		name, value, _ := strings.Cut(text[1:], "=")
+		feature.qualifiers = append(feature.qualifiers, [2]string{name, value})
+		parser.hasQualifiers = true
+		return nil
+	}
+	if parser.hasQualifiers {
+		return fmt.Errorf("unexpected continuation of unquoted qualifier /%s: %s", feature.qualifiers[len(feature.qualifiers)-1][0], text)
+	}
+	// locations may span multiple lines
+	feature.location += text
+	return nil
+}
+
+// finish converts all collected lines into a Genbank struct.
+func (parser *recordParser) finish() (genbank.Genbank, error) {
+	record := parser.record
+	meta := &record.Meta
+	for lineCode, lines := range parser.lines {
+		switch lineCode {
+		case "AC":
+			var accessions []string
+			for _, line := range lines {
+				for _, accession := range strings.Split(line, ";") {
+					if accession = strings.TrimSpace(accession); accession != "" {
+						accessions = append(accessions, accession)
+					}
+				}
+			}
+			meta.Accession = strings.Join(accessions, " ")
+		case "DT":
+			meta.Date = strings.Join(lines, "\n")
+			if dateFields := strings.Fields(lines[len(lines)-1]); len(dateFields) > 0 {
+				meta.Locus.ModificationDate = dateFields[0]
+			}
+		case "DE":
+			meta.Definition = strings.Join(lines, " ")
+		case "KW":
+			meta.Keywords = strings.Join(lines, " ")
+		case "OS":
+			meta.Source = strings.Join(lines, " ")
+			meta.Organism = commonNameRegex.ReplaceAllString(meta.Source, "")
+		case "OC":
+			taxonomy := strings.TrimSuffix(strings.Join(lines, " "), ".")
+			for _, taxon := range strings.Split(taxonomy, ";") {
+				if taxon = strings.TrimSpace(taxon); taxon != "" {
+					meta.Taxonomy = append(meta.Taxonomy, taxon)
+				}
+			}
+		case "CC":
+			meta.Other["COMMENT"] = strings.Join(lines, "\n")
+		default:
+			meta.Other[lineCode] = strings.Join(lines, "\n")
+		}
+	}
+
+	for _, referenceLines := range parser.references {
+		reference, err := parseReference(referenceLines)
+		if err != nil {
+			return genbank.Genbank{}, err
+		}
+		meta.References = append(meta.References, reference)
+	}
+
+	for _, lines := range parser.features {
+		location, err := genbank.ParseLocation(lines.location)
+		if err != nil {
+			return genbank.Genbank{}, fmt.Errorf("Failed to parse location %s of %s feature: %w", lines.location, lines.key, err)
+		}
+		feature := genbank.Feature{Type: lines.key, Location: location}
+		for _, qualifier := range lines.qualifiers {
+			value := qualifier[1]
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				value = strings.ReplaceAll(value[1:len(value)-1], `""`, `"`)
+			}
+			// qualifiers without a value, like /pseudo, have no quotes either.
+			feature.Qualifiers = append(feature.Qualifiers, genbank.Qualifier{Key: qualifier[0], Value: value, Flag: qualifier[1] == ""})
+		}
+		if err = record.AddFeature(&feature); err != nil {
+			return genbank.Genbank{}, err
+		}
+	}
+
+	record.Sequence = parser.sequence.String()
+	if meta.Locus.SequenceLength != strconv.Itoa(len(record.Sequence)) {
+		return genbank.Genbank{}, fmt.Errorf("ID line says %s has %s BP, but its sequence has %d", meta.Locus.Name, meta.Locus.SequenceLength, len(record.Sequence))
+	}
+	return *record, nil
+}
+
+// parseReference converts the lines of a single reference into a Reference.
+func parseReference(referenceLines [][2]string) (genbank.Reference, error) {
+	var reference genbank.Reference
+	values := make(map[string][]string)
+	for _, line := range referenceLines {
+		values[line[0]] = append(values[line[0]], strings.TrimSpace(line[1]))
+	}
+	reference.Remark = strings.Join(values["RC"], " ")
+	if ranges := values["RP"]; len(ranges) > 0 {
+		var basesRanges []string
+		for _, basesRange := range strings.Split(strings.Join(ranges, ""), ",") {
+			start, end, found := strings.Cut(strings.TrimSpace(basesRange), "-")
+			if !found {
+				return genbank.Reference{}, fmt.Errorf("malformed RP line: %s", strings.Join(ranges, ""))
+			}
+			basesRanges = append(basesRanges, start+" to "+end)
+		}
+		reference.Range = "(bases " + strings.Join(basesRanges, "; ") + ")"
+	}
+	for _, crossReference := range values["RX"] {
+		database, identifier, _ := strings.Cut(crossReference, ";")
+		if database == "PUBMED" {
+			reference.PubMed = strings.TrimSuffix(strings.TrimSpace(identifier), ".")
+		}
+	}
+	reference.Consortium = strings.TrimSuffix(strings.Join(values["RG"], " "), ";")
+	reference.Authors = strings.TrimSuffix(strings.Join(values["RA"], " "), ";")
+	title := strings.TrimSuffix(strings.Join(values["RT"], " "), ";")
+	reference.Title = strings.TrimSuffix(strings.TrimPrefix(title, `"`), `"`)
+	reference.Journal = strings.Join(values["RL"], " ")
+	return reference, nil
+}
+
+/******************************************************************************
+
+EMBL building begins here.
+
+******************************************************************************/
+
+// Build builds an EMBL byte slice to be written out to db or file.
+func Build(record genbank.Genbank) ([]byte, error) {
+	return BuildMulti([]genbank.Genbank{record})
+}
+
+// BuildMulti builds a multi EMBL byte slice to be written out to db or file.
+func BuildMulti(records []genbank.Genbank) ([]byte, error) {
+	var emblBuffer bytes.Buffer
+	for _, record := range records {
+		meta := record.Meta
+		accessions := strings.Fields(meta.Accession)
+
+		// building the ID line
+		name := meta.Locus.Name
+		if len(accessions) > 0 && accessions[0] != "." {
+			name = accessions[0]
+		}
+		idFields := []string{name}
+		if _, version, _ := strings.Cut(meta.Version, "."); version != "" {
+			idFields = append(idFields, "SV "+version)
+		}
+		topology := "linear"
+		if meta.Locus.Circular {
+			topology = "circular"
+		}
+		dataClass := meta.Other[dataClassKey]
+		if dataClass == "" {
+			dataClass = "STD"
+		}
+		// EMBL requires a molecule type and division, so GenBank records that lack them get the EMBL defaults.
+		moleculeType := meta.Locus.MoleculeType
+		if moleculeType == "" {
+			moleculeType = "unassigned DNA"
+		}
+		division := meta.Locus.GenbankDivision
+		if division == "" {
+			division = "UNC"
+		}
+		idFields = append(idFields, topology, moleculeType, dataClass, division, strconv.Itoa(len(record.Sequence))+" BP.")
+		emblBuffer.WriteString("ID   " + strings.Join(idFields, "; ") + "\n")
+		emblBuffer.WriteString("XX\n")
+
+		if len(accessions) > 0 {
+			writeLines(&emblBuffer, "AC", wrap(strings.Join(accessions, "; ")+";"))
+			emblBuffer.WriteString("XX\n")
+		}
+		writeOtherLines(&emblBuffer, meta.Other, "PR")
+
+		dates := meta.Date
+		if dates == "" {
+			dates = meta.Locus.ModificationDate
+		}
+		if dates != "" {
+			writeLines(&emblBuffer, "DT", strings.Split(dates, "\n"))
+			emblBuffer.WriteString("XX\n")
+		}
+
+		definition := meta.Definition
+		if definition == "" {
+			definition = "."
+		}
+		writeLines(&emblBuffer, "DE", wrap(definition))
+		emblBuffer.WriteString("XX\n")
+
+		keywords := meta.Keywords
+		if keywords == "" {
+			keywords = "."
+		}
+		writeLines(&emblBuffer, "KW", wrap(keywords))
+		emblBuffer.WriteString("XX\n")
+
+		source := meta.Source
+		if source == "" {
+			source = meta.Organism
+		}
+		if source != "" || len(meta.Taxonomy) > 0 {
+			writeLines(&emblBuffer, "OS", wrap(source))
+			if len(meta.Taxonomy) > 0 {
+				writeLines(&emblBuffer, "OC", wrap(strings.Join(meta.Taxonomy, "; ")+"."))
+			}
+			if organelle, ok := meta.Other["OG"]; ok {
+				writeLines(&emblBuffer, "OG", strings.Split(organelle, "\n"))
+			}
+			emblBuffer.WriteString("XX\n")
+		}
+
+		for referenceIndex, reference := range meta.References {
+			buildReference(&emblBuffer, referenceIndex+1, reference)
+		}
+
+		writeOtherLines(&emblBuffer, meta.Other, "DR")
+		if comment, ok := meta.Other["COMMENT"]; ok {
+			var commentLines []string
+			for _, line := range strings.Split(comment, "\n") {
+				commentLines = append(commentLines, wrap(line)...)
+			}
+			writeLines(&emblBuffer, "CC", commentLines)
+			emblBuffer.WriteString("XX\n")
+		}
+		// all other two letter line codes, except CO which goes after the features
+		var otherCodes []string
+		for key := range meta.Other {
+			if len(key) == 2 && key == strings.ToUpper(key) && !strings.Contains("PR OG DR CO", key) {
+				otherCodes = append(otherCodes, key)
+			}
+		}
+		sort.Strings(otherCodes)
+		for _, otherCode := range otherCodes {
+			writeOtherLines(&emblBuffer, meta.Other, otherCode)
+		}
+
+		if len(record.Features) > 0 {
+			emblBuffer.WriteString("FH   Key             Location/Qualifiers\nFH\n")
+			for _, feature := range record.Features {
+				emblBuffer.WriteString(BuildFeatureString(feature))
+			}
+			emblBuffer.WriteString("XX\n")
+		}
+		writeOtherLines(&emblBuffer, meta.Other, "CO")
+
+		buildSequence(&emblBuffer, record.Sequence)
+		emblBuffer.WriteString("//\n")
+	}
+	return emblBuffer.Bytes(), nil
+}
+
+// wrap wraps text so that it fits on EMBL lines.
+func wrap(text string) []string {
+	return strings.Split(wordwrap.WrapString(text, lineWidth-5), "\n")
+}
+
+// writeLines writes lines prefixed with a line code.
+func writeLines(emblBuffer *bytes.Buffer, lineCode string, lines []string) {
+	for _, line := range lines {
+		emblBuffer.WriteString(strings.TrimRight(lineCode+"   "+line, " ") + "\n")
+	}
+}
+
+// writeOtherLines writes the lines of a line code stored in Meta.Other, followed by XX.
+func writeOtherLines(emblBuffer *bytes.Buffer, other map[string]string, lineCode string) {
+	if value, ok := other[lineCode]; ok {
+		writeLines(emblBuffer, lineCode, strings.Split(value, "\n"))
+		emblBuffer.WriteString("XX\n")
+	}
+}
+
+// buildReference writes the RN to RL lines of a reference.
+func buildReference(emblBuffer *bytes.Buffer, number int, reference genbank.Reference) {
+	emblBuffer.WriteString("RN   [" + strconv.Itoa(number) + "]\n")
+	if reference.Remark != "" {
+		writeLines(emblBuffer, "RC", wrap(reference.Remark))
+	}
+	if basesRanges := basesRangeRegex.FindAllStringSubmatch(reference.Range, -1); len(basesRanges) > 0 {
+		var ranges []string
+		for _, basesRange := range basesRanges {
+			ranges = append(ranges, basesRange[1]+"-"+basesRange[2])
+		}
+		writeLines(emblBuffer, "RP", wrap(strings.Join(ranges, ", ")))
+	}
+	if reference.PubMed != "" {
+		writeLines(emblBuffer, "RX", []string{"PUBMED; " + reference.PubMed + "."})
+	}
+	if reference.Consortium != "" {
+		writeLines(emblBuffer, "RG", wrap(reference.Consortium))
+	}
+	if reference.Authors != "" {
+		writeLines(emblBuffer, "RA", wrap(reference.Authors+";"))
+	}
+	title := ";"
+	if reference.Title != "" {
+		title = `"` + reference.Title + `";`
+	}
+	writeLines(emblBuffer, "RT", wrap(title))
+	writeLines(emblBuffer, "RL", wrap(reference.Journal))
+	emblBuffer.WriteString("XX\n")
+}
+
+// BuildFeatureString builds the FT lines of a feature. Qualifiers are written in the order of feature.Qualifiers.
+func BuildFeatureString(feature genbank.Feature) string {
+	var featureString strings.Builder
+	location := feature.Location.GbkLocationString
+	if location == "" {
+		location = genbank.BuildLocationString(feature.Location)
+	}
+	// long locations are broken after commas
+	var locationLines []string
+	for len(location) > lineWidth-qualifierIndex {
+		breakIndex := strings.LastIndex(location[:lineWidth-qualifierIndex], ",")
+		if breakIndex == -1 {
+			break
+		}
+		locationLines = append(locationLines, location[:breakIndex+1])
+		location = location[breakIndex+1:]
+	}
+	locationLines = append(locationLines, location)
+	for lineIndex, locationLine := range locationLines {
+		key := ""
+		if lineIndex == 0 {
+			key = feature.Type
+		}
+		featureString.WriteString(fmt.Sprintf("FT   %-16s%s\n", key, locationLine))
+	}
+
+	for _, featureQualifier := range feature.Qualifiers {
+		key, value := featureQualifier.Key, featureQualifier.Value
+		qualifier := "/" + key
+		switch {
+		case featureQualifier.Flag:
+		case unquotedQualifiers[key]:
+			qualifier += "=" + value
+		default:
+			qualifier += `="` + strings.ReplaceAll(value, `"`, `""`) + `"`
+		}
+		var qualifierLines []string
+		if key == "translation" {
+			// translations have no spaces, so they are simply cut into lines.
+			for len(qualifier) > lineWidth-qualifierIndex {
+				qualifierLines = append(qualifierLines, qualifier[:lineWidth-qualifierIndex])
+				qualifier = qualifier[lineWidth-qualifierIndex:]
+			}
+			qualifierLines = append(qualifierLines, qualifier)
+		} else {
+			qualifierLines = strings.Split(wordwrap.WrapString(qualifier, lineWidth-qualifierIndex), "\n")
+		}
+		for _, qualifierLine := range qualifierLines {
+			featureString.WriteString("FT                   " + qualifierLine + "\n")
+		}
+	}
+	return featureString.String()
+}
+
+// buildSequence writes the SQ line and the sequence in blocks of 10, 60 per line.
+func buildSequence(emblBuffer *bytes.Buffer, sequence string) {
+	counts := make(map[byte]int)
+	for index := 0; index < len(sequence); index++ {
+		counts[sequence[index]|0x20]++ // lower case
+	}
+	other := len(sequence) - counts['a'] - counts['c'] - counts['g'] - counts['t']
+	emblBuffer.WriteString(fmt.Sprintf("SQ   Sequence %d BP; %d A; %d C; %d G; %d T; %d other;\n", len(sequence), counts['a'], counts['c'], counts['g'], counts['t'], other))
+	for lineStart := 0; lineStart < len(sequence); lineStart += 60 {
+		lineEnd := min(lineStart+60, len(sequence))
+		var blocks []string
+		for blockStart := lineStart; blockStart < lineEnd; blockStart += 10 {
+			blocks = append(blocks, sequence[blockStart:min(blockStart+10, lineEnd)])
+		}
+		emblBuffer.WriteString(fmt.Sprintf("     %-65s %9d\n", strings.Join(blocks, " "), lineEnd))
+	}
+}
+
+/******************************************************************************
+
+EMBL read and write functions begin here.
+
+******************************************************************************/
+
+// Read reads an EMBL file from path and returns a Genbank struct.
+func Read(path string) (genbank.Genbank, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// ReadMulti reads a multi EMBL file from path and parses it into a slice of Genbank structs.
+func ReadMulti(path string) ([]genbank.Genbank, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseMulti(file)
+}
+
+// Write takes a Genbank struct and a path string and writes out an EMBL record to that path.
+func Write(record genbank.Genbank, path string) error {
+	embl, err := Build(record)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, embl, 0644)
+}
+
+// WriteMulti takes a slice of Genbank structs and a path string and writes out a multi EMBL record to that path.
+func WriteMulti(records []genbank.Genbank, path string) error {
+	embl, err := BuildMulti(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, embl, 0644)
+}
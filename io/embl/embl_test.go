@@ -0,0 +1,200 @@
+package embl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/synthesis/codon"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var ignoreParent = cmpopts.IgnoreFields(genbank.Feature{}, "ParentSequence")
+
+func TestRead(t *testing.T) {
+	record, err := Read("data/example.embl")
+	if err != nil {
+		t.Fatalf("Failed to read example.embl: %s", err)
+	}
+	meta := record.Meta
+	wantLocus := genbank.Locus{Name: "XX000001", SequenceLength: "150", MoleculeType: "genomic DNA", GenbankDivision: "SYN", ModificationDate: "15-JUN-2021", SequenceCoding: "bp", Circular: true}
+	if diff := cmp.Diff(wantLocus, meta.Locus); diff != "" {
+		t.Errorf("Locus mismatch (-want +got):\n%s", diff)
+	}
+	if meta.Version != "XX000001.2" {
+		t.Errorf("Version = %q, want XX000001.2", meta.Version)
+	}
+	if meta.Definition != "Synthetic construct pExample, complete sequence with a small open reading frame." {
+		t.Errorf("Unexpected definition %q", meta.Definition)
+	}
+	if diff := cmp.Diff([]string{"other sequences", "artificial sequences"}, meta.Taxonomy); diff != "" {
+		t.Errorf("Taxonomy mismatch (-want +got):\n%s", diff)
+	}
+	if meta.Other["DR"] != "MD5; 0123456789abcdef0123456789abcdef." {
+		t.Errorf("Unexpected DR line %q", meta.Other["DR"])
+	}
+	if meta.Other["COMMENT"] != "This is an example record written for the poly test suite.\nIt spans two comment lines." {
+		t.Errorf("Unexpected comment %q", meta.Other["COMMENT"])
+	}
+
+	wantReference := genbank.Reference{Authors: "Doe J., Roe R.", Title: "An example plasmid for testing EMBL parsers", Journal: "Journal of Examples 1:1-10(2020).", PubMed: "12345678", Range: "(bases 1 to 150)"}
+	if diff := cmp.Diff(wantReference, meta.References[0]); diff != "" {
+		t.Errorf("Reference mismatch (-want +got):\n%s", diff)
+	}
+	if meta.References[1].Journal != "Submitted (02-MAR-2020) to the INSDC. Example Institute, Example Street 1, Example City, EXAMPLE." {
+		t.Errorf("Unexpected journal %q", meta.References[1].Journal)
+	}
+
+	if len(record.Features) != 3 {
+		t.Fatalf("Got %d features, want 3", len(record.Features))
+	}
+	cds := record.Features[1]
+	if cds.Attributes()["note"] != `a made up open reading frame whose note is long enough to wrap onto a second line and has "quotes"` {
+		t.Errorf("Unexpected note %q", cds.Attributes()["note"])
+	}
+	if cds.Attributes()["codon_start"] != "1" {
+		t.Errorf("Unexpected codon_start %q", cds.Attributes()["codon_start"])
+	}
+	cdsSequence, err := cds.GetSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := codon.NewTranslationTable(11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translation, err := table.Translate(cdsSequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translation != cds.Attributes()["translation"]+"*" {
+		t.Errorf("CDS translates to %q, want %q", translation, cds.Attributes()["translation"]+"*")
+	}
+
+	split := record.Features[2]
+	if _, ok := split.Qualifier("pseudo"); !ok {
+		t.Errorf("Expected /pseudo qualifier")
+	}
+	splitSequence, err := split.GetSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if splitSequence != "actgga"+"atcgaaccagccacaagaaac" {
+		t.Errorf("Unexpected join sequence %q", splitSequence)
+	}
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	original, err := os.ReadFile("data/example.embl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := Parse(strings.NewReader(string(original)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := Build(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(original), string(built)); diff != "" {
+		t.Errorf("Build does not reproduce the original file (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenbankConversion(t *testing.T) {
+	gbk, err := genbank.Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpDataDir, err := os.MkdirTemp("", "data-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDataDir)
+	path := filepath.Join(tmpDataDir, "puc19.embl")
+	if err = Write(gbk, path); err != nil {
+		t.Fatal(err)
+	}
+	record, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Sequence != gbk.Sequence {
+		t.Errorf("Sequence changed while converting to EMBL")
+	}
+	if diff := cmp.Diff(gbk.Features, record.Features, ignoreParent); diff != "" {
+		t.Errorf("Features changed while converting to EMBL (-genbank +embl):\n%s", diff)
+	}
+	for index, feature := range record.Features {
+		if feature.Location.Start > feature.Location.End {
+			continue // GetSequence does not support features that span the origin
+		}
+		got, _ := feature.GetSequence()
+		want, _ := gbk.Features[index].GetSequence()
+		if got != want {
+			t.Errorf("GetSequence of feature %d differs: got %q, want %q", index, got, want)
+		}
+	}
+	if diff := cmp.Diff(gbk.Meta.References, record.Meta.References); diff != "" {
+		t.Errorf("References changed while converting to EMBL (-genbank +embl):\n%s", diff)
+	}
+
+	table, err := codon.NewTranslationTable(11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = table.UpdateWeightsWithSequence(record); err != nil {
+		t.Errorf("UpdateWeightsWithSequence failed on EMBL record: %s", err)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	record, err := Read("data/example.embl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := record
+	second.Meta.Locus.Circular = false
+	built, err := BuildMulti([]genbank.Genbank{record, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ParseMulti(strings.NewReader(string(built)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Got %d records, want 2", len(records))
+	}
+	if diff := cmp.Diff(second, records[1], ignoreParent); diff != "" {
+		t.Errorf("Second record mismatch (-want +got):\n%s", diff)
+	}
+	records, err = ParseMultiNth(strings.NewReader(string(built)), 1)
+	if err != nil || len(records) != 1 {
+		t.Errorf("ParseMultiNth(1) returned %d records and error %v", len(records), err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"bad ID", "ID   XX000001\n//\n"},
+		{"missing end", "ID   XX000001; linear; DNA; STD; SYN; 4 BP.\nSQ   Sequence 4 BP;\n     acgt 4\n"},
+		{"wrong length", "ID   XX000001; linear; DNA; STD; SYN; 5 BP.\nSQ   Sequence 4 BP;\n     acgt 4\n//\n"},
+		{"bad location", "ID   XX000001; linear; DNA; STD; SYN; 4 BP.\nFT   CDS             join(1..2,\nSQ   Sequence 4 BP;\n     acgt 4\n//\n"},
+		{"orphan reference line", "ID   XX000001; linear; DNA; STD; SYN; 4 BP.\nRA   Doe J.;\nSQ   Sequence 4 BP;\n     acgt 4\n//\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
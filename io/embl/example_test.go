@@ -0,0 +1,32 @@
+package embl_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bebop/poly/io/embl"
+	"github.com/bebop/poly/io/genbank"
+)
+
+func Example_basic() {
+	record, _ := embl.Read("data/example.embl")
+	fmt.Println(record.Meta.Locus.Name, record.Meta.Locus.Circular, len(record.Sequence))
+
+	for _, feature := range record.Features {
+		sequence, _ := feature.GetSequence()
+		fmt.Println(feature.Type, genbank.BuildLocationString(feature.Location), len(sequence))
+	}
+	// Output:
+	// XX000001 true 150
+	// source 1..150 150
+	// CDS 11..73 63
+	// misc_feature join(5..10,complement(100..120)) 27
+}
+
+func ExampleBuild() {
+	gbk, _ := genbank.Read("../../data/puc19.gbk")
+	emblBytes, _ := embl.Build(gbk)
+	record, _ := embl.Parse(bytes.NewReader(emblBytes))
+	fmt.Println(record.Sequence == gbk.Sequence, len(record.Features) == len(gbk.Features))
+	// Output: true true
+}
@@ -1,6 +1,11 @@
 package io_test
 
 import (
+	"errors"
+	"fmt"
+	"io"
+
+	polyio "github.com/bebop/poly/io"
 	"github.com/bebop/poly/io/fasta"
 	"github.com/bebop/poly/io/genbank"
 	"github.com/bebop/poly/io/gff"
@@ -30,3 +35,31 @@ func Example() {
 	// 2. If you want to convert from one format to another (e.g. genbank to polyjson), you can easily do so with a for-loop and some field mapping.
 	// 3. Every file format is unique but they all share a common interface so you can use them with almost every native function in Poly.
 }
+
+func ExampleRead() {
+	// the format and compression are detected from the contents of the file.
+	records, _ := polyio.Read("../data/flatGbk_test.seq.gz")
+	fmt.Println(records.Compression, records.Format, len(records.Genbank))
+	// Output: gzip genbank 2
+}
+
+func ExampleOpen() {
+	parser, _ := polyio.Open("fasta/data/base.fasta")
+	defer parser.Close()
+
+	for {
+		record, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		switch record := record.(type) {
+		case fasta.Fasta:
+			fmt.Println(len(record.Sequence))
+		case genbank.Genbank:
+			fmt.Println(record.Meta.Locus.Name)
+		}
+	}
+	// Output:
+	// 284
+	// 149
+}
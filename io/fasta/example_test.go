@@ -112,3 +112,23 @@ func ExampleParser() {
 	// MCHU - Calmodulin - Human, rabbit, bovine, rat, and chicken
 	// EOF
 }
+
+// ExampleFetcher shows how to fetch a region of a record from an indexed fasta file.
+func ExampleFetcher() {
+	file, _ := os.Open("data/base.fasta")
+	defer file.Close()
+
+	// usually the index is read from a .fai file with ParseIndex.
+	index, _ := fasta.BuildIndex(file)
+	fetcher := fasta.NewFetcher(file, index)
+
+	region, _ := fetcher.FetchRegion("MCHU:1-10")
+	fmt.Println(region.Name, region.Sequence)
+
+	// Fetch uses 0-based, half-open coordinates instead.
+	region, _ = fetcher.Fetch("MCHU", 0, 10)
+	fmt.Println(region.Name, region.Sequence)
+	// Output:
+	// MCHU:1-10 ADQLTEEQIA
+	// MCHU:1-10 ADQLTEEQIA
+}
@@ -22,6 +22,8 @@ import (
 	"os"
 	"strings"
 	"unsafe"
+
+	"github.com/bebop/poly/io/bgzf"
 )
 
 /******************************************************************************
@@ -320,7 +322,8 @@ func ReadConcurrent(path string, sequences chan<- Fasta) {
 	}()
 }
 
-// ReadGz reads a gzipped  file into an array of Fasta structs.
+// ReadGz reads a gzipped file into an array of Fasta structs. Since BGZF
+// files are valid gzip files, bgzipped files can be read with ReadGz as well.
 func ReadGz(path string) ([]Fasta, error) {
 	file, err := openFn(path)
 	if err != nil {
@@ -385,3 +388,25 @@ func Write(fastas []Fasta, path string) error {
 	}
 	return os.WriteFile(path, fastaBytes, 0644)
 }
+
+// WriteBgzf writes a fasta array to a BGZF compressed file. Unlike plain gzip
+// files, BGZF files can be indexed and read from the middle, see package bgzf.
+func WriteBgzf(fastas []Fasta, path string) error {
+	fastaBytes, err := buildFn(fastas)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bgzf.NewWriter(file)
+	if _, err = writer.Write(fastaBytes); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
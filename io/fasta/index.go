@@ -0,0 +1,335 @@
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Fasta index begins here.
+
+Parse and Parser read whole records into memory, which is fine for plasmids
+and proteins, but not for pulling a couple kilobases out of a multi-gigabyte
+reference genome. samtools solves this with the .fai index, a tab separated
+file with one line per record:
+
+	NAME	LENGTH	OFFSET	LINEBASES	LINEWIDTH
+
+	NAME:      the name of the record, up to the first whitespace
+	LENGTH:    the total number of bases of the record
+	OFFSET:    the byte offset of the first base of the record
+	LINEBASES: the number of bases on each line
+	LINEWIDTH: the number of bytes of each line, including the newline
+
+Since every line of a record (except the last) must have the same length, the
+byte offset of any base can be calculated from these numbers, so we can read
+any region of a record directly with an io.ReaderAt.
+
+https://www.htslib.org/doc/faidx.html
+
+******************************************************************************/
+
+// IndexEntry holds the location and line layout of a single fasta record.
+type IndexEntry struct {
+	Name      string
+	Length    int64
+	Offset    int64
+	LineBases int64
+	LineWidth int64
+}
+
+// Index maps record names to their location in a fasta file.
+type Index struct {
+	Entries []IndexEntry
+	names   map[string]int
+}
+
+// add adds an entry to the index. Names must be unique.
+func (index *Index) add(entry IndexEntry) error {
+	if index.names == nil {
+		index.names = make(map[string]int)
+	}
+	if _, ok := index.names[entry.Name]; ok {
+		return fmt.Errorf("Duplicate name '%s' found while indexing", entry.Name)
+	}
+	index.names[entry.Name] = len(index.Entries)
+	index.Entries = append(index.Entries, entry)
+	return nil
+}
+
+// Lookup returns the index entry of a record name.
+func (index Index) Lookup(name string) (IndexEntry, bool) {
+	entryIndex, ok := index.names[name]
+	if !ok {
+		return IndexEntry{}, false
+	}
+	return index.Entries[entryIndex], true
+}
+
+// BuildIndex builds a samtools compatible index from a fasta file. Lines may
+// be of any length, but all sequence lines of a record except the last must
+// have the same length. Empty lines are only allowed at the end of a record.
+func BuildIndex(r io.Reader) (Index, error) {
+	reader := bufio.NewReader(r)
+	var (
+		index    Index
+		entry    *IndexEntry
+		offset   int64
+		finished bool // whether the current record already had a short or empty line
+	)
+	for lineNumber := 1; ; lineNumber++ {
+		lineStart, width, bases, err := readIndexLine(reader)
+		if err != nil {
+			return Index{}, err
+		}
+		if width == 0 {
+			break
+		}
+		offset += width
+
+		if lineStart[0] == '>' {
+			if entry != nil {
+				if err = index.add(*entry); err != nil {
+					return Index{}, err
+				}
+			}
+			name := strings.Fields(string(lineStart[1:]))
+			if len(name) == 0 {
+				return Index{}, fmt.Errorf("Error on line %d: fasta record without name", lineNumber)
+			}
+			entry = &IndexEntry{Name: name[0], Offset: offset}
+			finished = false
+			continue
+		}
+		if entry == nil {
+			if bases == 0 {
+				continue
+			}
+			return Index{}, fmt.Errorf("Error on line %d: sequence found before the first fasta name", lineNumber)
+		}
+		switch {
+		case bases == 0:
+			finished = true
+		case finished:
+			return Index{}, fmt.Errorf("Error on line %d: different line length in record %s. Only the last line of a record may be shorter", lineNumber, entry.Name)
+		case entry.LineBases == 0:
+			entry.LineBases = bases
+			entry.LineWidth = width
+		case bases > entry.LineBases:
+			return Index{}, fmt.Errorf("Error on line %d: different line length in record %s. Expected at most %d bases, got %d", lineNumber, entry.Name, entry.LineBases, bases)
+		case bases < entry.LineBases:
+			finished = true
+		case width != entry.LineWidth:
+			return Index{}, fmt.Errorf("Error on line %d: different line endings in record %s", lineNumber, entry.Name)
+		}
+		entry.Length += bases
+	}
+	if entry != nil {
+		if err := index.add(*entry); err != nil {
+			return Index{}, err
+		}
+	}
+	return index, nil
+}
+
+// readIndexLine reads a line of any length and returns its start, its width
+// in bytes and the number of bytes without the line ending. A width of 0
+// means the end of the file was reached.
+func readIndexLine(reader *bufio.Reader) (lineStart []byte, width int64, bases int64, err error) {
+	var previous byte // the byte before the newline
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, 0, 0, err
+		}
+		if lineStart == nil && len(line) > 0 {
+			// the buffer is reused by the next ReadSlice, so the start is copied.
+			lineStart = append([]byte(nil), line[:min(len(line), 1024)]...)
+		}
+		width += int64(len(line))
+		if len(line) > 1 {
+			previous = line[len(line)-2]
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			if len(line) > 0 {
+				previous = line[len(line)-1]
+			}
+			continue
+		}
+		bases = width
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			bases--
+			if previous == '\r' && bases > 0 {
+				bases--
+			}
+		}
+		return lineStart, width, bases, nil
+	}
+}
+
+// ParseIndex parses a samtools .fai index file.
+func ParseIndex(r io.Reader) (Index, error) {
+	var index Index
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		// fastq indexes have an additional QUALOFFSET column, which we do not need.
+		if len(fields) != 5 && len(fields) != 6 {
+			return Index{}, fmt.Errorf("Error on line %d: expected 5 tab separated fields, got %d", lineNumber, len(fields))
+		}
+		entry := IndexEntry{Name: fields[0]}
+		numbers := []*int64{&entry.Length, &entry.Offset, &entry.LineBases, &entry.LineWidth}
+		for fieldIndex, number := range numbers {
+			value, err := strconv.ParseInt(fields[fieldIndex+1], 10, 64)
+			if err != nil {
+				return Index{}, fmt.Errorf("Error on line %d: %w", lineNumber, err)
+			}
+			*number = value
+		}
+		if entry.Length > 0 && (entry.LineBases <= 0 || entry.LineWidth < entry.LineBases) {
+			return Index{}, fmt.Errorf("Error on line %d: invalid line layout %d bases in %d bytes", lineNumber, entry.LineBases, entry.LineWidth)
+		}
+		if err := index.add(entry); err != nil {
+			return Index{}, fmt.Errorf("Error on line %d: %w", lineNumber, err)
+		}
+	}
+	return index, scanner.Err()
+}
+
+// WriteIndex writes an index in the samtools .fai format.
+func WriteIndex(index Index, output io.Writer) error {
+	writer := bufio.NewWriter(output)
+	for _, entry := range index.Entries {
+		if _, err := fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\n", entry.Name, entry.Length, entry.Offset, entry.LineBases, entry.LineWidth); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+/******************************************************************************
+
+Start of Fetch functions
+
+******************************************************************************/
+
+// Fetcher reads records and regions of records from an indexed fasta file
+// without parsing the rest of the file. It is initialized with NewFetcher.
+type Fetcher struct {
+	reader io.ReaderAt
+	index  Index
+}
+
+// NewFetcher returns a Fetcher that reads from r, usually an *os.File, using
+// an index built with BuildIndex or parsed with ParseIndex.
+func NewFetcher(r io.ReaderAt, index Index) *Fetcher {
+	return &Fetcher{reader: r, index: index}
+}
+
+// FetchRecord returns the entire record with the given name. The name of the
+// returned Fasta is the name in the index, without the rest of the header line.
+func (fetcher *Fetcher) FetchRecord(name string) (Fasta, error) {
+	entry, ok := fetcher.index.Lookup(name)
+	if !ok {
+		return Fasta{}, fmt.Errorf("record '%s' not found in index", name)
+	}
+	sequence, err := fetcher.fetch(entry, 0, entry.Length)
+	if err != nil {
+		return Fasta{}, err
+	}
+	return Fasta{Name: name, Sequence: sequence}, nil
+}
+
+// Fetch returns the bases from start to end of the record with the given
+// name. Coordinates are 0-based and half-open, just like Go slices, so
+// Fetch("chr7", 0, 10) returns the first 10 bases of chr7. The name of the
+// returned Fasta is the region in 1-based samtools notation, like chr7:1-10.
+func (fetcher *Fetcher) Fetch(name string, start, end int64) (Fasta, error) {
+	entry, ok := fetcher.index.Lookup(name)
+	if !ok {
+		return Fasta{}, fmt.Errorf("record '%s' not found in index", name)
+	}
+	if start < 0 || end > entry.Length || start > end {
+		return Fasta{}, fmt.Errorf("region %d-%d is out of bounds for record '%s' of length %d", start, end, name, entry.Length)
+	}
+	sequence, err := fetcher.fetch(entry, start, end)
+	if err != nil {
+		return Fasta{}, err
+	}
+	return Fasta{Name: fmt.Sprintf("%s:%d-%d", name, start+1, end), Sequence: sequence}, nil
+}
+
+// FetchRegion returns a region given in 1-based, inclusive samtools
+// notation, like chr7:1,000,000-1,002,000. Just like samtools, the end
+// (chr7:1,000,000-) or the whole range (chr7) may be left out. An end beyond
+// the end of the record is clipped to the end of the record.
+func (fetcher *Fetcher) FetchRegion(region string) (Fasta, error) {
+	// names may contain colons, so an exact match takes precedence.
+	if _, ok := fetcher.index.Lookup(region); ok {
+		return fetcher.FetchRecord(region)
+	}
+	colonIndex := strings.LastIndex(region, ":")
+	if colonIndex == -1 {
+		return Fasta{}, fmt.Errorf("record '%s' not found in index", region)
+	}
+	name := region[:colonIndex]
+	entry, ok := fetcher.index.Lookup(name)
+	if !ok {
+		return Fasta{}, fmt.Errorf("record '%s' not found in index", name)
+	}
+	startString, endString, _ := strings.Cut(strings.ReplaceAll(region[colonIndex+1:], ",", ""), "-")
+	start, err := strconv.ParseInt(startString, 10, 64)
+	if err != nil || start < 1 {
+		return Fasta{}, fmt.Errorf("invalid start in region '%s'", region)
+	}
+	end := entry.Length
+	if endString != "" {
+		end, err = strconv.ParseInt(endString, 10, 64)
+		if err != nil || end < start {
+			return Fasta{}, fmt.Errorf("invalid end in region '%s'", region)
+		}
+	}
+	end = min(end, entry.Length)
+	if start > end {
+		return Fasta{}, fmt.Errorf("region '%s' starts after the end of record '%s' of length %d", region, name, entry.Length)
+	}
+	return fetcher.Fetch(name, start-1, end)
+}
+
+// fetch reads the bases from start to end of a record, removing line breaks.
+func (fetcher *Fetcher) fetch(entry IndexEntry, start, end int64) (string, error) {
+	if start == end {
+		return "", nil
+	}
+	startOffset := fetcher.offset(entry, start)
+	endOffset := fetcher.offset(entry, end-1) + 1
+	data := make([]byte, endOffset-startOffset)
+	// ReadAt may return an EOF together with all requested bytes at the end of the file.
+	if n, err := fetcher.reader.ReadAt(data, startOffset); n != len(data) {
+		return "", fmt.Errorf("Failed to fetch %s:%d-%d. Got error: %w", entry.Name, start+1, end, err)
+	}
+	sequence := make([]byte, 0, end-start)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		sequence = append(sequence, bytes.TrimSuffix(line, []byte("\r"))...)
+	}
+	if int64(len(sequence)) != end-start {
+		return "", fmt.Errorf("Fetched %d bases instead of %d for %s:%d-%d. Is the index up to date?", len(sequence), end-start, entry.Name, start+1, end)
+	}
+	return string(sequence), nil
+}
+
+// offset returns the byte offset of a 0-based position of a record.
+func (fetcher *Fetcher) offset(entry IndexEntry, position int64) int64 {
+	return entry.Offset + position/entry.LineBases*entry.LineWidth + position%entry.LineBases
+}
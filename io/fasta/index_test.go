@@ -0,0 +1,244 @@
+package fasta
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/bgzf"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestBuildIndex(t *testing.T) {
+	file, err := os.Open("data/base.fasta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	index, err := BuildIndex(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IndexEntry{
+		{Name: "gi|5524211|gb|AAD44166.1|", Length: 284, Offset: 66, LineBases: 70, LineWidth: 71},
+		{Name: "MCHU", Length: 149, Offset: 417, LineBases: 64, LineWidth: 65},
+	}
+	if diff := cmp.Diff(want, index.Entries); diff != "" {
+		t.Errorf("BuildIndex mismatch (-want +got):\n%s", diff)
+	}
+
+	// writing and parsing the index again should give the same index.
+	var indexBuffer bytes.Buffer
+	if err = WriteIndex(index, &indexBuffer); err != nil {
+		t.Fatal(err)
+	}
+	if indexBuffer.String() != "gi|5524211|gb|AAD44166.1|\t284\t66\t70\t71\nMCHU\t149\t417\t64\t65\n" {
+		t.Errorf("Unexpected index file:\n%s", indexBuffer.String())
+	}
+	parsedIndex, err := ParseIndex(&indexBuffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(index, parsedIndex, cmpopts.IgnoreUnexported(Index{})); diff != "" {
+		t.Errorf("ParseIndex mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildIndexLongLinesAndCRLF(t *testing.T) {
+	sequence := strings.Repeat("ACGT", 50000)
+	input := ">long\r\n" + sequence + "\r\n>short description\r\nAC\r\nG\r\n"
+	index, err := BuildIndex(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IndexEntry{
+		{Name: "long", Length: 200000, Offset: 7, LineBases: 200000, LineWidth: 200002},
+		{Name: "short", Length: 3, Offset: 200029, LineBases: 2, LineWidth: 4},
+	}
+	if diff := cmp.Diff(want, index.Entries); diff != "" {
+		t.Errorf("BuildIndex mismatch (-want +got):\n%s", diff)
+	}
+	fetcher := NewFetcher(strings.NewReader(input), index)
+	region, err := fetcher.Fetch("long", 199990, 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if region.Sequence != sequence[199990:] {
+		t.Errorf("Fetch = %s, want %s", region.Sequence, sequence[199990:])
+	}
+	record, err := fetcher.FetchRecord("short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Sequence != "ACG" {
+		t.Errorf("FetchRecord = %s, want ACG", record.Sequence)
+	}
+}
+
+func TestBuildIndexErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"sequence before name", "ACGT\n>a\nACGT\n"},
+		{"longer line", ">a\nACG\nACGT\n"},
+		{"line after short line", ">a\nACGT\nAC\nACGT\n"},
+		{"line after empty line", ">a\nACGT\n\nACGT\n"},
+		{"different line endings", ">a\nACGT\nACGT\r\nA\n"},
+		{"duplicate name", ">a\nACGT\n>a\nACGT\n"},
+		{"empty name", ">\nACGT\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildIndex(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+func TestParseIndexErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"too few fields", "a\t4\t3\t4\n"},
+		{"not a number", "a\t4\tthree\t4\t5\n"},
+		{"invalid layout", "a\t4\t3\t0\t5\n"},
+		{"duplicate name", "a\t4\t3\t4\t5\na\t4\t3\t4\t5\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseIndex(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	file, err := os.Open("data/base.fasta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	index, err := BuildIndex(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fastas, err := Read("data/base.fasta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(file, index)
+
+	// every region, including the ones that span lines, should match the parsed sequence.
+	sequence := fastas[0].Sequence
+	for start := 0; start < len(sequence); start += 13 {
+		for _, end := range []int{start, start + 1, start + 70, start + 141, len(sequence)} {
+			end = min(end, len(sequence))
+			region, err := fetcher.Fetch("gi|5524211|gb|AAD44166.1|", int64(start), int64(end))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if region.Sequence != sequence[start:end] {
+				t.Errorf("Fetch(%d, %d) = %s, want %s", start, end, region.Sequence, sequence[start:end])
+			}
+		}
+	}
+
+	record, err := fetcher.FetchRecord("MCHU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Fasta{Name: "MCHU", Sequence: fastas[1].Sequence}, record); diff != "" {
+		t.Errorf("FetchRecord mismatch (-want +got):\n%s", diff)
+	}
+
+	regions := []struct {
+		region string
+		want   Fasta
+	}{
+		{"MCHU", Fasta{Name: "MCHU", Sequence: fastas[1].Sequence}},
+		{"MCHU:1-10", Fasta{Name: "MCHU:1-10", Sequence: fastas[1].Sequence[:10]}},
+		{"MCHU:1,40-1,45", Fasta{Name: "MCHU:140-145", Sequence: fastas[1].Sequence[139:145]}},
+		{"MCHU:140", Fasta{Name: "MCHU:140-149", Sequence: fastas[1].Sequence[139:]}},
+		{"MCHU:140-", Fasta{Name: "MCHU:140-149", Sequence: fastas[1].Sequence[139:]}},
+		{"MCHU:140-1000", Fasta{Name: "MCHU:140-149", Sequence: fastas[1].Sequence[139:]}},
+	}
+	for _, region := range regions {
+		got, err := fetcher.FetchRegion(region.region)
+		if err != nil {
+			t.Errorf("FetchRegion(%s) returned error: %s", region.region, err)
+			continue
+		}
+		if diff := cmp.Diff(region.want, got); diff != "" {
+			t.Errorf("FetchRegion(%s) mismatch (-want +got):\n%s", region.region, diff)
+		}
+	}
+
+	for _, region := range []string{"chr1", "chr1:1-10", "MCHU:0-10", "MCHU:10-5", "MCHU:150-160", "MCHU:a-b"} {
+		if _, err := fetcher.FetchRegion(region); err == nil {
+			t.Errorf("FetchRegion(%s) should return an error", region)
+		}
+	}
+	if _, err := fetcher.Fetch("MCHU", 10, 150); err == nil {
+		t.Errorf("Fetch beyond the end of a record should return an error")
+	}
+	if _, err := fetcher.FetchRecord("chr1"); err == nil {
+		t.Errorf("FetchRecord of a missing record should return an error")
+	}
+}
+
+func TestFetchBgzf(t *testing.T) {
+	fastas, err := Parse(strings.NewReader(uniprotFasta[:200000]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "uniprot.fasta.gz")
+	if err = WriteBgzf(fastas, path); err != nil {
+		t.Fatal(err)
+	}
+	readFastas, err := ReadGz(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Build does not end the last record with a newline, so the parser drops it.
+	if diff := cmp.Diff(fastas[:len(fastas)-1], readFastas); diff != "" {
+		t.Fatalf("ReadGz of a bgzipped file mismatch (-want +got):\n%s", diff)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	blockIndex, err := bgzf.BuildIndex(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fasta index of a bgzipped file holds offsets into the uncompressed data.
+	reader, err := bgzf.NewReader(io.NewSectionReader(file, 0, math.MaxInt64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := BuildIndex(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(bgzf.NewReaderAt(file, blockIndex), index)
+	for entryIndex, entry := range index.Entries {
+		record, err := fetcher.FetchRecord(entry.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.Sequence != fastas[entryIndex].Sequence {
+			t.Errorf("FetchRecord(%s) from a bgzipped file returned the wrong sequence", entry.Name)
+		}
+	}
+}
@@ -62,3 +62,71 @@ func ExampleParser() {
 	//990e110e-5e50-41a2-8ad5-92044d4465b8
 	//EOF
 }
+
+func ExamplePipeline() {
+	reads := "@adapter\nACGTACGTCTGTCTCTTATACAC\n+\nIIIIIIIIIIIIIIIIIIIIIII\n" +
+		"@polyG\nACGTACGTGGGGGGGG\n+\nIIIIIIIIIIIIIIII\n" +
+		"@bad\nACGTACGTACGT\n+\n############\n"
+	pipeline := fastq.NewPipeline(
+		fastq.AdapterTrim3Prime("CTGTCTCTTATACACATCT", 2, 5), // the Nextera adapter
+		fastq.PolyGTrim(5),
+		fastq.MeanQualityFilter(20),
+		fastq.LengthFilter(5, 1000),
+	)
+	parser := fastq.NewParser(strings.NewReader(reads), 2*32*1024)
+	trimmed, _ := pipeline.ParseAll(parser)
+	for _, read := range trimmed {
+		fmt.Println(read.Identifier, read.Sequence)
+	}
+	fmt.Print(pipeline.Report())
+	//Output:
+	//adapter ACGTACGT
+	//polyG ACGTACGT
+	//reads: 3 in, 2 kept
+	//bases: 51 in, 16 kept
+	//step                                   reads  trimmed  removed  bases trimmed
+	//3' adapter trim CTGTCTCTTATACACATCT        3        1        0             15
+	//poly-G trim (5 bases)                      3        1        0              8
+	//mean quality filter (Q20)                  3        0        1              0
+	//length filter (5-1000 bases)               2        0        0              0
+}
+
+func ExamplePairedPipeline() {
+	read1, _ := os.Open("data/paired_R1.fastq")
+	defer read1.Close()
+	read2, _ := os.Open("data/paired_R2.fastq")
+	defer read2.Close()
+	parser := fastq.NewPairedParser(read1, read2, 2*32*1024)
+
+	// Both mates are trimmed and filtered on their own, but are kept together.
+	steps := []fastq.Step{
+		fastq.AdapterTrim3Prime("CTGTCTCTTATACACATCT", 2, 5),
+		fastq.MeanQualityFilter(20),
+		fastq.LengthFilter(20, 1000),
+	}
+	pipeline := fastq.NewPairedPipeline(fastq.NewPipeline(steps...), fastq.NewPipeline(steps...))
+
+	var interleaved, orphans strings.Builder
+	writer := fastq.NewInterleavedWriter(&interleaved)
+	writer.Orphans1, writer.Orphans2 = &orphans, &orphans
+	for {
+		pair, err := pipeline.ParseNext(parser)
+		if err != nil {
+			break
+		}
+		_ = writer.Write(pair)
+	}
+	report := pipeline.Report()
+	fmt.Printf("%d pairs, %d kept, %d orphans\n", report.Pairs, report.Kept, report.Read1Orphans+report.Read2Orphans)
+	fmt.Print(orphans.String())
+	//Output:
+	//3 pairs, 1 kept, 2 orphans
+	//@EAS139:136:FC706VJ:2:2104:15343:197456 2:N:0:ATCACG
+	//GCATGCATGCAACGTGATCGATCG
+	//+
+	//IIIIIIIIIIIIIIIIIIIIIIII
+	//@EAS139:136:FC706VJ:2:2104:15343:197511 1:N:0:ATCACG
+	//TTGACCGATTGCAGGTACCATGGTACGATCAGTCAACGTA
+	//+
+	//IIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIIII
+}
@@ -7,7 +7,8 @@ the sequence identifier begins with @ instead of >, and includes quality
 values for a sequence.
 
 This package provides a parser and writer for working with Fastq formatted
-sequencing data.
+sequencing data, as well as a Pipeline of steps for trimming and filtering
+reads and a parser, writer and pipeline for paired-end reads.
 */
 package fastq
 
@@ -21,6 +22,8 @@ import (
 	"math"
 	"os"
 	"strings"
+
+	"github.com/bebop/poly/io/bgzf"
 )
 
 /******************************************************************************
@@ -160,9 +163,9 @@ func (parser *Parser) ParseNext() (Fastq, int64, error) {
 	seqIdentifier = lineSplits[0][1:]
 	optionals = make(map[string]string)
 	for _, optionalDatum := range lineSplits[1:] {
-		optionalSplits := strings.Split(optionalDatum, "=")
-		optionalKey := optionalSplits[0]
-		optionalValue := optionalSplits[1]
+		// Comments that aren't key=value pairs, like the 1:N:0:ATCACG of Casava
+		// 1.8 headers, are kept as keys without a value.
+		optionalKey, optionalValue, _ := strings.Cut(optionalDatum, "=")
 		optionals[optionalKey] = optionalValue
 	}
 
@@ -226,7 +229,8 @@ Start of  Read functions
 
 ******************************************************************************/
 
-// ReadGz reads a gzipped file into an array of Fastq structs.
+// ReadGz reads a gzipped file into an array of Fastq structs. Since BGZF
+// files are valid gzip files, bgzipped files can be read with ReadGz as well.
 func ReadGz(path string) ([]Fastq, error) {
 	file, err := openFn(path)
 	if err != nil {
@@ -266,8 +270,10 @@ func Build(fastqs []Fastq) ([]byte, error) {
 		for key, val := range fastq.Optionals {
 			fastqString.WriteString(" ")
 			fastqString.WriteString(key)
-			fastqString.WriteString("=")
-			fastqString.WriteString(val)
+			if val != "" {
+				fastqString.WriteString("=")
+				fastqString.WriteString(val)
+			}
 		}
 		fastqString.WriteString("\n")
 
@@ -287,3 +293,25 @@ func Write(fastqs []Fastq, path string) error {
 	fastqBytes, _ := buildFn(fastqs) //  fastq.Build returns only nil errors.
 	return os.WriteFile(path, fastqBytes, 0644)
 }
+
+// WriteBgzf writes a fastq array to a BGZF compressed file. Unlike plain gzip
+// files, BGZF files can be indexed and read from the middle, see package bgzf.
+func WriteBgzf(fastqs []Fastq, path string) error {
+	fastqBytes, err := buildFn(fastqs)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bgzf.NewWriter(file)
+	if _, err = writer.Write(fastqBytes); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -2,6 +2,8 @@ package fastq
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -64,3 +66,24 @@ func TestParseExceptions(t *testing.T) {
 	testException(t, "data/nanosavseq_noplus.fastq", "no plus EOF")
 	testException(t, "data/nanosavseq_noquality2.fastq", "no quality EOF")
 }
+
+func TestWriteBgzf(t *testing.T) {
+	fastqs, err := Read("data/nanosavseq.fastq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "nanosavseq.fastq.gz")
+	if err = WriteBgzf(fastqs, path); err != nil {
+		t.Fatal(err)
+	}
+	bgzfFastqs, err := ReadGz(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fastqs, bgzfFastqs) {
+		t.Errorf("Reading a bgzipped fastq did not return the original fastqs")
+	}
+	if err = WriteBgzf(fastqs, filepath.Join(t.TempDir(), "missing", "dir.fastq.gz")); err == nil {
+		t.Errorf("WriteBgzf to a missing directory should fail")
+	}
+}
@@ -0,0 +1,346 @@
+package fastq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Paired-end reads begin here.
+
+Paired-end sequencing reads both ends of every DNA fragment, which gives two
+reads, or mates, per fragment. Illumina runs come as two files, R1 and R2,
+holding the first and second mates in the same order, or as a single
+interleaved file in which the second mate follows the first.
+
+The mates of a pair share their identifier, save for a suffix marking the
+mate. Older pipelines add /1 and /2 to the identifier:
+
+	@HWUSI-EAS100R:6:73:941:1973#0/1
+
+while Casava 1.8 and later put the mate in a comment after the identifier:
+
+	@EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG
+
+PairedParser reads pairs from two files in lockstep or from one interleaved
+file, and checks that both mates of every pair have the same identifier once
+those suffixes are removed. PairedWriter writes pairs back out, interleaved
+or split.
+
+Trimming and filtering mates on their own breaks the pairing as soon as only
+one mate of a pair is removed. PairedPipeline runs each mate through its own
+Pipeline and keeps what is left of a pair together: the remaining mate of a
+pair becomes an orphan, which PairedWriter writes to separate outputs for
+orphaned first and second mates.
+
+******************************************************************************/
+
+// ErrMismatchedPair is returned for pairs of reads that aren't mates.
+var ErrMismatchedPair = errors.New("reads of pair are not mates")
+
+// Pair holds the two mates of a paired-end read. A mate that was removed by
+// a PairedPipeline is left empty.
+type Pair struct {
+	Read1 Fastq `json:"read1"`
+	Read2 Fastq `json:"read2"`
+}
+
+// Orphan returns the only mate left of a pair whose other mate was removed.
+// It returns false for pairs with both mates.
+func (pair Pair) Orphan() (Fastq, bool) {
+	switch {
+	case pair.Read1.Sequence == "" && pair.Read2.Sequence != "":
+		return pair.Read2, true
+	case pair.Read2.Sequence == "" && pair.Read1.Sequence != "":
+		return pair.Read1, true
+	}
+	return Fastq{}, false
+}
+
+// PairIdentifier returns the identifier a read shares with its mate, which is
+// its identifier without a /1 or /2 suffix. Casava 1.8 mate comments are
+// parsed into Optionals, and are left out as well.
+func PairIdentifier(read Fastq) string {
+	identifier, _, _ := strings.Cut(read.Identifier, " ")
+	if strings.HasSuffix(identifier, "/1") || strings.HasSuffix(identifier, "/2") {
+		identifier = identifier[:len(identifier)-2]
+	}
+	return identifier
+}
+
+// casavaRegex matches the Casava 1.8 comment of a read, capturing its mate.
+var casavaRegex = regexp.MustCompile(`^([12]):[YN]:\d+:`)
+
+// mate returns 1 or 2 for reads marked as the first or second mate by a /1 or
+// /2 suffix or a Casava 1.8 comment, and 0 for unmarked reads.
+func mate(read Fastq) int {
+	identifier, comment, _ := strings.Cut(read.Identifier, " ")
+	switch {
+	case strings.HasSuffix(identifier, "/1"):
+		return 1
+	case strings.HasSuffix(identifier, "/2"):
+		return 2
+	}
+	comments := []string{comment}
+	for key, value := range read.Optionals {
+		if value == "" {
+			comments = append(comments, key)
+		}
+	}
+	for _, comment := range comments {
+		if match := casavaRegex.FindStringSubmatch(comment); match != nil {
+			return int(match[1][0] - '0')
+		}
+	}
+	return 0
+}
+
+// check returns an error if the reads of a pair aren't mates.
+func (pair Pair) check() error {
+	identifier1, identifier2 := PairIdentifier(pair.Read1), PairIdentifier(pair.Read2)
+	if identifier1 != identifier2 {
+		return fmt.Errorf("%w: %s and %s", ErrMismatchedPair, pair.Read1.Identifier, pair.Read2.Identifier)
+	}
+	if mate1, mate2 := mate(pair.Read1), mate(pair.Read2); mate1 == 2 || mate2 == 1 {
+		return fmt.Errorf("%w: %s is marked as mate %d and %s as mate %d", ErrMismatchedPair, pair.Read1.Identifier, mate1, pair.Read2.Identifier, mate2)
+	}
+	return nil
+}
+
+// PairedParser parses pairs of reads from two fastq files in lockstep, or
+// from a single interleaved fastq file. It is initialized with
+// NewPairedParser or NewInterleavedParser.
+type PairedParser struct {
+	read1 *Parser
+	// read2 is nil for interleaved files.
+	read2 *Parser
+	pairs int
+}
+
+// NewPairedParser returns a PairedParser that reads the first mates from
+// read1 and the second mates from read2.
+func NewPairedParser(read1, read2 io.Reader, maxLineSize int) *PairedParser {
+	return &PairedParser{
+		read1: NewParser(read1, maxLineSize),
+		read2: NewParser(read2, maxLineSize),
+	}
+}
+
+// NewInterleavedParser returns a PairedParser that reads pairs from r, in
+// which every first mate is followed by its second mate.
+func NewInterleavedParser(r io.Reader, maxLineSize int) *PairedParser {
+	return &PairedParser{read1: NewParser(r, maxLineSize)}
+}
+
+// ParseNext reads the next pair. It returns an error wrapping
+// ErrMismatchedPair if the reads aren't mates, an error wrapping
+// io.ErrUnexpectedEOF if one of the mates is missing at the end of the input,
+// and EOF once all pairs have been read.
+func (parser *PairedParser) ParseNext() (Pair, error) {
+	read1, _, err := parser.read1.ParseNext()
+	if errors.Is(err, io.EOF) && parser.read2 != nil {
+		// Both files have to end together.
+		read2, _, read2Err := parser.read2.ParseNext()
+		if read2Err == nil {
+			return Pair{}, fmt.Errorf("read 1 input ended after %d pairs, before %s: %w", parser.pairs, read2.Identifier, io.ErrUnexpectedEOF)
+		}
+		if !errors.Is(read2Err, io.EOF) {
+			return Pair{}, read2Err
+		}
+	}
+	if err != nil {
+		return Pair{}, err
+	}
+
+	mates := parser.read2
+	if mates == nil {
+		mates = parser.read1
+	}
+	read2, _, err := mates.ParseNext()
+	if errors.Is(err, io.EOF) {
+		return Pair{}, fmt.Errorf("read 2 input ended after %d pairs, before the mate of %s: %w", parser.pairs, read1.Identifier, io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return Pair{}, err
+	}
+	pair := Pair{Read1: read1, Read2: read2}
+	if err = pair.check(); err != nil {
+		return Pair{}, fmt.Errorf("pair %d: %w", parser.pairs+1, err)
+	}
+	parser.pairs++
+	return pair, nil
+}
+
+// ParseN parses up to maxPairs pairs. ParseN does not return EOF if
+// encountered. If a non-EOF error is encountered it returns it and all
+// correctly parsed pairs up to then.
+func (parser *PairedParser) ParseN(maxPairs int) (pairs []Pair, err error) {
+	for counter := 0; counter < maxPairs; counter++ {
+		pair, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return pairs, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// ParseAll parses all pairs, only returning non-EOF errors.
+func (parser *PairedParser) ParseAll() ([]Pair, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// PairedReport summarizes the pairs processed by a PairedPipeline.
+type PairedReport struct {
+	Pairs int `json:"pairs"`
+	// Kept is the number of pairs of which both mates were kept.
+	Kept int `json:"kept"`
+	// Read1Orphans is the number of pairs of which only the first mate was
+	// kept, and Read2Orphans those of which only the second mate was kept.
+	Read1Orphans int `json:"read1_orphans"`
+	Read2Orphans int `json:"read2_orphans"`
+	// Removed is the number of pairs of which both mates were removed.
+	Removed int `json:"removed"`
+	// Read1 and Read2 are the reports of the pipelines of each mate.
+	Read1 Report `json:"read1"`
+	Read2 Report `json:"read2"`
+}
+
+// PairedPipeline trims and filters pairs, running each mate through its own
+// Pipeline. A PairedPipeline is not safe for concurrent use.
+type PairedPipeline struct {
+	read1, read2 *Pipeline
+	report       PairedReport
+}
+
+// NewPairedPipeline returns a PairedPipeline that runs the first mates
+// through read1 and the second mates through read2. Mates often need
+// different steps, like trimming different adapters.
+func NewPairedPipeline(read1, read2 *Pipeline) *PairedPipeline {
+	return &PairedPipeline{read1: read1, read2: read2}
+}
+
+// Process runs both mates of a pair through their pipelines and returns the
+// processed pair, with removed mates left empty, and whether any mate was
+// kept.
+func (pipeline *PairedPipeline) Process(pair Pair) (Pair, bool) {
+	pipeline.report.Pairs++
+	read1, keep1 := pipeline.read1.Process(pair.Read1)
+	read2, keep2 := pipeline.read2.Process(pair.Read2)
+	switch {
+	case keep1 && keep2:
+		pipeline.report.Kept++
+	case keep1:
+		pipeline.report.Read1Orphans++
+	case keep2:
+		pipeline.report.Read2Orphans++
+	default:
+		pipeline.report.Removed++
+		return Pair{}, false
+	}
+	return Pair{Read1: read1, Read2: read2}, true
+}
+
+// ParseNext parses pairs from parser until a mate of one is kept by the
+// pipeline and returns the processed pair, which may be an orphan. It returns
+// EOF once parser is exhausted.
+func (pipeline *PairedPipeline) ParseNext(parser *PairedParser) (Pair, error) {
+	for {
+		pair, err := parser.ParseNext()
+		if err != nil {
+			return Pair{}, err
+		}
+		if pair, keep := pipeline.Process(pair); keep {
+			return pair, nil
+		}
+	}
+}
+
+// ParseAll parses all pairs of parser and returns those of which a mate was
+// kept by the pipeline, only returning non-EOF errors.
+func (pipeline *PairedPipeline) ParseAll(parser *PairedParser) ([]Pair, error) {
+	var pairs []Pair
+	for {
+		pair, err := pipeline.ParseNext(parser)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return pairs, err
+		}
+		pairs = append(pairs, pair)
+	}
+}
+
+// Report returns what the pipeline has done so far.
+func (pipeline *PairedPipeline) Report() PairedReport {
+	report := pipeline.report
+	report.Read1 = pipeline.read1.Report()
+	report.Read2 = pipeline.read2.Report()
+	return report
+}
+
+// PairedWriter writes pairs of reads interleaved into a single writer, or
+// split into a writer for each mate. It is initialized with
+// NewInterleavedWriter or NewSplitWriter.
+type PairedWriter struct {
+	read1, read2 io.Writer
+	// Orphans1 receives the first mates left of pairs whose second mate was
+	// removed, and Orphans2 the second mates left of pairs whose first mate
+	// was removed. Both may be the same writer, and orphans are dropped if
+	// theirs is nil.
+	Orphans1, Orphans2 io.Writer
+}
+
+// NewInterleavedWriter returns a PairedWriter that writes every first mate
+// followed by its second mate to w.
+func NewInterleavedWriter(w io.Writer) *PairedWriter {
+	return &PairedWriter{read1: w, read2: w}
+}
+
+// NewSplitWriter returns a PairedWriter that writes the first mates to read1
+// and the second mates to read2.
+func NewSplitWriter(read1, read2 io.Writer) *PairedWriter {
+	return &PairedWriter{read1: read1, read2: read2}
+}
+
+// Write writes a pair. Orphans are written to Orphans1 or Orphans2, and pairs
+// without any mates are skipped.
+func (writer *PairedWriter) Write(pair Pair) error {
+	switch {
+	case pair.Read1.Sequence == "" && pair.Read2.Sequence == "":
+		return nil
+	case pair.Read2.Sequence == "":
+		return writeOrphan(writer.Orphans1, pair.Read1)
+	case pair.Read1.Sequence == "":
+		return writeOrphan(writer.Orphans2, pair.Read2)
+	}
+	if err := writeRead(writer.read1, pair.Read1); err != nil {
+		return err
+	}
+	return writeRead(writer.read2, pair.Read2)
+}
+
+// writeOrphan writes an orphan to w, or drops it if w is nil.
+func writeOrphan(w io.Writer, orphan Fastq) error {
+	if w == nil {
+		return nil
+	}
+	return writeRead(w, orphan)
+}
+
+// writeRead writes a single read to w.
+func writeRead(w io.Writer, read Fastq) error {
+	fastqBytes, _ := Build([]Fastq{read}) // fastq.Build returns only nil errors.
+	_, err := w.Write(fastqBytes)
+	return err
+}
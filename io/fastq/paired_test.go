@@ -0,0 +1,153 @@
+package fastq
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const maxPairedLineSize = 2 * 32 * 1024
+
+func TestPairIdentifier(t *testing.T) {
+	tests := []struct {
+		read       Fastq
+		identifier string
+		mate       int
+	}{
+		{Fastq{Identifier: "HWUSI-EAS100R:6:73:941:1973#0/1"}, "HWUSI-EAS100R:6:73:941:1973#0", 1},
+		{Fastq{Identifier: "HWUSI-EAS100R:6:73:941:1973#0/2"}, "HWUSI-EAS100R:6:73:941:1973#0", 2},
+		{Fastq{Identifier: "EAS139:136:FC706VJ:2:2104:15343:197393", Optionals: map[string]string{"1:Y:18:ATCACG": ""}}, "EAS139:136:FC706VJ:2:2104:15343:197393", 1},
+		{Fastq{Identifier: "EAS139:136:FC706VJ:2:2104:15343:197393 2:N:0:ATCACG"}, "EAS139:136:FC706VJ:2:2104:15343:197393", 2},
+		{Fastq{Identifier: "read", Optionals: map[string]string{"read": "2"}}, "read", 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.identifier, PairIdentifier(test.read))
+		assert.Equal(t, test.mate, mate(test.read))
+	}
+}
+
+func TestPairedParser(t *testing.T) {
+	read1, err := os.Open("data/paired_R1.fastq")
+	if err != nil {
+		t.Fatalf("Failed to open paired_R1.fastq: %s", err)
+	}
+	defer read1.Close()
+	read2, err := os.Open("data/paired_R2.fastq")
+	if err != nil {
+		t.Fatalf("Failed to open paired_R2.fastq: %s", err)
+	}
+	defer read2.Close()
+	pairs, err := NewPairedParser(read1, read2, maxPairedLineSize).ParseAll()
+	assert.NoError(t, err)
+	assert.Len(t, pairs, 3)
+	assert.Equal(t, "EAS139:136:FC706VJ:2:2104:15343:197393", pairs[0].Read1.Identifier)
+	assert.Equal(t, map[string]string{"2:N:0:ATCACG": ""}, pairs[0].Read2.Optionals)
+	assert.Equal(t, "CCTAAGGTTTACTATTTGATCGATACTGCTTTGAACCCCA", pairs[0].Read2.Sequence)
+
+	// Interleaving the pairs gives the same pairs back.
+	var interleaved bytes.Buffer
+	writer := NewInterleavedWriter(&interleaved)
+	for _, pair := range pairs {
+		assert.NoError(t, writer.Write(pair))
+	}
+	interleavedPairs, err := NewInterleavedParser(&interleaved, maxPairedLineSize).ParseAll()
+	assert.NoError(t, err)
+	assert.Equal(t, pairs, interleavedPairs)
+}
+
+func TestPairedParser_errors(t *testing.T) {
+	const (
+		first  = "@a/1\nACGT\n+\nIIII\n"
+		second = "@a/2\nACGT\n+\nIIII\n"
+		other  = "@b/2\nACGT\n+\nIIII\n"
+	)
+	tests := []struct {
+		name   string
+		parser *PairedParser
+		err    error
+		pairs  int
+	}{
+		{"mismatched identifiers", NewPairedParser(strings.NewReader(first+first), strings.NewReader(second+other), maxPairedLineSize), ErrMismatchedPair, 1},
+		{"swapped mates", NewPairedParser(strings.NewReader(second), strings.NewReader(first), maxPairedLineSize), ErrMismatchedPair, 0},
+		{"read 1 ends early", NewPairedParser(strings.NewReader(first), strings.NewReader(second+second), maxPairedLineSize), io.ErrUnexpectedEOF, 1},
+		{"read 2 ends early", NewPairedParser(strings.NewReader(first+first), strings.NewReader(second), maxPairedLineSize), io.ErrUnexpectedEOF, 1},
+		{"unpaired interleaved read", NewInterleavedParser(strings.NewReader(first+second+first), maxPairedLineSize), io.ErrUnexpectedEOF, 1},
+		{"shifted interleaved reads", NewInterleavedParser(strings.NewReader(first+other), maxPairedLineSize), ErrMismatchedPair, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pairs, err := test.parser.ParseAll()
+			assert.True(t, errors.Is(err, test.err), "unexpected error: %v", err)
+			assert.Len(t, pairs, test.pairs)
+		})
+	}
+
+	_, err := NewPairedParser(strings.NewReader(first), strings.NewReader("@a/2\nACGT\n"), maxPairedLineSize).ParseAll()
+	assert.Error(t, err)
+}
+
+func TestPairedPipeline(t *testing.T) {
+	const (
+		read1 = "@a/1\nACGTACGT\n+\nIIIIIIII\n" +
+			"@b/1\nACGTACGT\n+\nIIIIIIII\n" +
+			"@c/1\nACGT\n+\nIIII\n" +
+			"@d/1\nACGT\n+\nIIII\n"
+		read2 = "@a/2\nTTGGCCAA\n+\nIIIIIIII\n" +
+			"@b/2\nTTGG\n+\nIIII\n" +
+			"@c/2\nTTGGCCAA\n+\nIIIIIIII\n" +
+			"@d/2\nTTGG\n+\nIIII\n"
+	)
+	pipeline := NewPairedPipeline(NewPipeline(LengthFilter(5, 100)), NewPipeline(LengthFilter(5, 100)))
+	pairs, err := pipeline.ParseAll(NewPairedParser(strings.NewReader(read1), strings.NewReader(read2), maxPairedLineSize))
+	assert.NoError(t, err)
+	assert.Len(t, pairs, 3)
+
+	orphan, ok := pairs[1].Orphan()
+	assert.True(t, ok)
+	assert.Equal(t, "b/1", orphan.Identifier)
+	orphan, ok = pairs[2].Orphan()
+	assert.True(t, ok)
+	assert.Equal(t, "c/2", orphan.Identifier)
+	_, ok = pairs[0].Orphan()
+	assert.False(t, ok)
+
+	report := pipeline.Report()
+	assert.Equal(t, 4, report.Pairs)
+	assert.Equal(t, 1, report.Kept)
+	assert.Equal(t, 1, report.Read1Orphans)
+	assert.Equal(t, 1, report.Read2Orphans)
+	assert.Equal(t, 1, report.Removed)
+	assert.Equal(t, 2, report.Read1.Kept)
+	assert.Equal(t, 2, report.Read2.Kept)
+
+	var split1, split2, orphans1, orphans2 bytes.Buffer
+	writer := NewSplitWriter(&split1, &split2)
+	for _, pair := range pairs {
+		assert.NoError(t, writer.Write(pair))
+	}
+	assert.Equal(t, "@a/1\nACGTACGT\n+\nIIIIIIII\n", split1.String())
+	assert.Equal(t, "@a/2\nTTGGCCAA\n+\nIIIIIIII\n", split2.String())
+
+	writer.Orphans1, writer.Orphans2 = &orphans1, &orphans2
+	for _, pair := range pairs {
+		assert.NoError(t, writer.Write(pair))
+	}
+	assert.Equal(t, "@b/1\nACGTACGT\n+\nIIIIIIII\n", orphans1.String())
+	assert.Equal(t, "@c/2\nTTGGCCAA\n+\nIIIIIIII\n", orphans2.String())
+	assert.NoError(t, writer.Write(Pair{}))
+
+	_, err = pipeline.ParseAll(NewInterleavedParser(strings.NewReader("@a/1\nACGTACGT\n+\nIIIIIIII\n"), maxPairedLineSize))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func TestBuildCasava(t *testing.T) {
+	fastqs, err := Parse(strings.NewReader("@EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG\nACGT\n+\nIIII\n"))
+	assert.NoError(t, err)
+	built, _ := Build(fastqs)
+	assert.Equal(t, "@EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG\nACGT\n+\nIIII\n", string(built))
+}
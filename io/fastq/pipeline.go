@@ -0,0 +1,371 @@
+package fastq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Read trimming and filtering begins here.
+
+Reads straight off a sequencer still carry adapters, low quality ends and
+artifacts like the poly-G tails of two-color Illumina chemistry, none of
+which belong to the sequenced DNA. Before using the reads, those have to be
+trimmed off and reads that end up too short or too bad have to be thrown
+out.
+
+A Pipeline runs every read through a list of Steps. Each step either returns
+a (possibly trimmed) read to pass on to the next step or removes the read.
+Steps are plain structs holding a function, so custom steps can be mixed with
+the ones provided here:
+
+	trimming:  SlidingWindowTrim, MottTrim, AdapterTrim3Prime,
+	           AdapterTrim5Prime, PolyATrim, PolyGTrim
+	filtering: LengthFilter, MeanQualityFilter, NFilter
+
+Pipelines stream reads from a Parser with ParseNext and ParseAll, while
+keeping a Report of how many reads and bases every step trimmed or removed.
+
+Quality values are expected to be phred+33 encoded, like those of all current
+Illumina and nanopore sequencers.
+
+******************************************************************************/
+
+// Step is a single operation of a Pipeline.
+type Step struct {
+	// Name identifies the step in a Report.
+	Name string
+	// Process returns the processed read and whether it should be kept.
+	Process func(Fastq) (Fastq, bool)
+}
+
+// StepReport counts what a single step of a Pipeline did.
+type StepReport struct {
+	Name string `json:"name"`
+	// Reads is the number of reads that reached the step.
+	Reads int `json:"reads"`
+	// Trimmed is the number of reads the step made shorter.
+	Trimmed int `json:"trimmed"`
+	// Removed is the number of reads the step removed.
+	Removed int `json:"removed"`
+	// BasesTrimmed is the number of bases trimmed off the reads that were kept.
+	BasesTrimmed int `json:"bases_trimmed"`
+}
+
+// Report summarizes the reads processed by a Pipeline.
+type Report struct {
+	Reads    int          `json:"reads"`
+	Kept     int          `json:"kept"`
+	BasesIn  int          `json:"bases_in"`
+	BasesOut int          `json:"bases_out"`
+	Steps    []StepReport `json:"steps"`
+}
+
+// String formats a report as a table, with a row for every step.
+func (report Report) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "reads: %d in, %d kept\n", report.Reads, report.Kept)
+	fmt.Fprintf(&builder, "bases: %d in, %d kept\n", report.BasesIn, report.BasesOut)
+	nameWidth := len("step")
+	for _, step := range report.Steps {
+		nameWidth = max(nameWidth, len(step.Name))
+	}
+	fmt.Fprintf(&builder, "%-*s  %7s  %7s  %7s  %13s\n", nameWidth, "step", "reads", "trimmed", "removed", "bases trimmed")
+	for _, step := range report.Steps {
+		fmt.Fprintf(&builder, "%-*s  %7d  %7d  %7d  %13d\n", nameWidth, step.Name, step.Reads, step.Trimmed, step.Removed, step.BasesTrimmed)
+	}
+	return builder.String()
+}
+
+// Pipeline trims and filters reads with a list of steps. A Pipeline is not
+// safe for concurrent use.
+type Pipeline struct {
+	steps  []Step
+	report Report
+}
+
+// NewPipeline returns a Pipeline that runs the steps in order.
+func NewPipeline(steps ...Step) *Pipeline {
+	pipeline := &Pipeline{steps: steps}
+	pipeline.report.Steps = make([]StepReport, len(steps))
+	for index, step := range steps {
+		pipeline.report.Steps[index].Name = step.Name
+	}
+	return pipeline
+}
+
+// Process runs a read through all steps of the pipeline and returns the
+// processed read and whether it was kept. Reads that are trimmed down to
+// nothing are removed by the step that trimmed them.
+func (pipeline *Pipeline) Process(read Fastq) (Fastq, bool) {
+	pipeline.report.Reads++
+	pipeline.report.BasesIn += len(read.Sequence)
+	for index, step := range pipeline.steps {
+		stepReport := &pipeline.report.Steps[index]
+		stepReport.Reads++
+		processed, keep := step.Process(read)
+		if !keep || processed.Sequence == "" {
+			stepReport.Removed++
+			return Fastq{}, false
+		}
+		if trimmed := len(read.Sequence) - len(processed.Sequence); trimmed > 0 {
+			stepReport.Trimmed++
+			stepReport.BasesTrimmed += trimmed
+		}
+		read = processed
+	}
+	pipeline.report.Kept++
+	pipeline.report.BasesOut += len(read.Sequence)
+	return read, true
+}
+
+// ParseNext parses reads from parser until one is kept by the pipeline and
+// returns it processed. It returns EOF once parser is exhausted.
+func (pipeline *Pipeline) ParseNext(parser *Parser) (Fastq, error) {
+	for {
+		read, _, err := parser.ParseNext()
+		if err != nil {
+			return Fastq{}, err
+		}
+		if read, keep := pipeline.Process(read); keep {
+			return read, nil
+		}
+	}
+}
+
+// ParseAll parses all reads of parser and returns those kept by the
+// pipeline, only returning non-EOF errors.
+func (pipeline *Pipeline) ParseAll(parser *Parser) ([]Fastq, error) {
+	var reads []Fastq
+	for {
+		read, err := pipeline.ParseNext(parser)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return reads, err
+		}
+		reads = append(reads, read)
+	}
+}
+
+// Report returns what the pipeline has done so far.
+func (pipeline *Pipeline) Report() Report {
+	report := pipeline.report
+	report.Steps = append([]StepReport(nil), report.Steps...)
+	return report
+}
+
+// trim returns the part of a read from start up to end.
+func trim(read Fastq, start, end int) Fastq {
+	read.Sequence = read.Sequence[min(start, len(read.Sequence)):min(end, len(read.Sequence))]
+	read.Quality = read.Quality[min(start, len(read.Quality)):min(end, len(read.Quality))]
+	return read
+}
+
+// phred returns the phred score of a phred+33 encoded quality value.
+func phred(quality byte) int {
+	return max(int(quality)-33, 0)
+}
+
+// SlidingWindowTrim trims the 3' end of reads once the mean quality of a
+// window of windowSize bases drops below minQuality. The read is cut at the
+// start of the first such window, keeping the bases of the window up to its
+// first base below minQuality.
+func SlidingWindowTrim(windowSize, minQuality int) Step {
+	return Step{
+		Name: fmt.Sprintf("sliding window trim (%d bases, Q%d)", windowSize, minQuality),
+		Process: func(read Fastq) (Fastq, bool) {
+			window := min(max(windowSize, 1), len(read.Quality))
+			sum := 0
+			for _, quality := range []byte(read.Quality[:window]) {
+				sum += phred(quality)
+			}
+			for start := 0; start+window <= len(read.Quality); start++ {
+				if start > 0 {
+					sum += phred(read.Quality[start+window-1]) - phred(read.Quality[start-1])
+				}
+				if sum < minQuality*window {
+					end := start
+					for end < start+window && phred(read.Quality[end]) >= minQuality {
+						end++
+					}
+					return trim(read, 0, end), true
+				}
+			}
+			return read, true
+		},
+	}
+}
+
+// MottTrim trims both ends of reads with the modified Mott algorithm, which
+// keeps the part of a read with the highest sum of quality - cutoff over its
+// bases. Reads without any base above cutoff are trimmed down to nothing.
+func MottTrim(cutoff int) Step {
+	return Step{
+		Name: fmt.Sprintf("mott trim (Q%d)", cutoff),
+		Process: func(read Fastq) (Fastq, bool) {
+			var best, bestStart, bestEnd, sum, start int
+			for index := range []byte(read.Quality) {
+				sum += phred(read.Quality[index]) - cutoff
+				if sum <= 0 {
+					sum = 0
+					start = index + 1
+					continue
+				}
+				if sum > best {
+					best, bestStart, bestEnd = sum, start, index+1
+				}
+			}
+			return trim(read, bestStart, bestEnd), true
+		},
+	}
+}
+
+// adapterStart returns where an adapter starts in a sequence, or -1 if it
+// isn't found. The adapter may run past the end of the sequence as long as at
+// least minOverlap of its bases are in the sequence. Full adapters may have
+// up to maxMismatches mismatches, partial adapters a proportional part of
+// those. N matches any base.
+func adapterStart(sequence, adapter string, maxMismatches, minOverlap int) int {
+	minOverlap = min(max(minOverlap, 1), len(adapter))
+	for start := 0; start+minOverlap <= len(sequence); start++ {
+		overlap := min(len(adapter), len(sequence)-start)
+		allowed := maxMismatches * overlap / len(adapter)
+		mismatches := 0
+		for index := 0; index < overlap && mismatches <= allowed; index++ {
+			base, adapterBase := sequence[start+index], adapter[index]
+			if base != adapterBase && base != 'N' && adapterBase != 'N' {
+				mismatches++
+			}
+		}
+		if mismatches <= allowed {
+			return start
+		}
+	}
+	return -1
+}
+
+// reverse reverses a string of bases.
+func reverse(sequence string) string {
+	reversed := []byte(sequence)
+	for left, right := 0, len(reversed)-1; left < right; left, right = left+1, right-1 {
+		reversed[left], reversed[right] = reversed[right], reversed[left]
+	}
+	return string(reversed)
+}
+
+// AdapterTrim3Prime removes a 3' adapter, and everything following it, from
+// reads. Adapters are found with up to maxMismatches mismatches, and may be
+// cut off by the end of the read as long as at least minOverlap of their
+// bases are in the read. Adapters running past the end of the read are
+// allowed a proportional part of maxMismatches.
+func AdapterTrim3Prime(adapter string, maxMismatches, minOverlap int) Step {
+	adapter = strings.ToUpper(adapter)
+	return Step{
+		Name: "3' adapter trim " + adapter,
+		Process: func(read Fastq) (Fastq, bool) {
+			if adapter == "" {
+				return read, true
+			}
+			start := adapterStart(strings.ToUpper(read.Sequence), adapter, maxMismatches, minOverlap)
+			if start < 0 {
+				return read, true
+			}
+			return trim(read, 0, start), true
+		},
+	}
+}
+
+// AdapterTrim5Prime removes a 5' adapter, and everything preceding it, from
+// reads. It finds adapters like AdapterTrim3Prime, except that adapters may
+// be cut off by the start of the read.
+func AdapterTrim5Prime(adapter string, maxMismatches, minOverlap int) Step {
+	adapter = strings.ToUpper(adapter)
+	reversedAdapter := reverse(adapter)
+	return Step{
+		Name: "5' adapter trim " + adapter,
+		Process: func(read Fastq) (Fastq, bool) {
+			if adapter == "" {
+				return read, true
+			}
+			reversedStart := adapterStart(reverse(strings.ToUpper(read.Sequence)), reversedAdapter, maxMismatches, minOverlap)
+			if reversedStart < 0 {
+				return read, true
+			}
+			return trim(read, len(read.Sequence)-reversedStart, len(read.Sequence)), true
+		},
+	}
+}
+
+// polyTrim returns a step trimming a 3' run of at least minLength of base.
+func polyTrim(name string, base byte, minLength int) Step {
+	return Step{
+		Name: fmt.Sprintf("%s trim (%d bases)", name, minLength),
+		Process: func(read Fastq) (Fastq, bool) {
+			end := len(read.Sequence)
+			for end > 0 && (read.Sequence[end-1]|0x20) == (base|0x20) {
+				end--
+			}
+			if len(read.Sequence)-end < max(minLength, 1) {
+				return read, true
+			}
+			return trim(read, 0, end), true
+		},
+	}
+}
+
+// PolyATrim trims poly-A tails of at least minLength bases off the 3' end of
+// reads.
+func PolyATrim(minLength int) Step {
+	return polyTrim("poly-A", 'A', minLength)
+}
+
+// PolyGTrim trims runs of at least minLength Gs off the 3' end of reads.
+// Two-color Illumina sequencers, like the NextSeq and NovaSeq, read a lack of
+// signal as G, which ends many reads with a poly-G tail.
+func PolyGTrim(minLength int) Step {
+	return polyTrim("poly-G", 'G', minLength)
+}
+
+// LengthFilter keeps reads of minimum up to and including maximum bases.
+func LengthFilter(minimum, maximum int) Step {
+	return Step{
+		Name: fmt.Sprintf("length filter (%d-%d bases)", minimum, maximum),
+		Process: func(read Fastq) (Fastq, bool) {
+			return read, minimum <= len(read.Sequence) && len(read.Sequence) <= maximum
+		},
+	}
+}
+
+// MeanQualityFilter keeps reads with a mean phred quality of at least
+// minimum.
+func MeanQualityFilter(minimum float64) Step {
+	return Step{
+		Name: fmt.Sprintf("mean quality filter (Q%g)", minimum),
+		Process: func(read Fastq) (Fastq, bool) {
+			if read.Quality == "" {
+				return read, false
+			}
+			sum := 0
+			for _, quality := range []byte(read.Quality) {
+				sum += phred(quality)
+			}
+			return read, float64(sum)/float64(len(read.Quality)) >= minimum
+		},
+	}
+}
+
+// NFilter keeps reads with at most maximum Ns.
+func NFilter(maximum int) Step {
+	return Step{
+		Name: fmt.Sprintf("N filter (%d)", maximum),
+		Process: func(read Fastq) (Fastq, bool) {
+			return read, strings.Count(strings.ToUpper(read.Sequence), "N") <= maximum
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package fastq
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRead returns a read with a quality of quality for every base.
+func newRead(sequence string, quality byte) Fastq {
+	return Fastq{Identifier: "read", Sequence: sequence, Quality: strings.Repeat(string(quality), len(sequence))}
+}
+
+func TestSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     Step
+		read     Fastq
+		expected Fastq
+		keep     bool
+	}{
+		{"sliding window keeps good read", SlidingWindowTrim(4, 20), newRead("ACGTACGT", 'I'), newRead("ACGTACGT", 'I'), true},
+		{"sliding window trims bad end", SlidingWindowTrim(4, 20), Fastq{Sequence: "ACGTACGTAC", Quality: "IIIIII5!!!"}, Fastq{Sequence: "ACGTACG", Quality: "IIIIII5"}, true},
+		{"sliding window shorter than window", SlidingWindowTrim(10, 20), Fastq{Sequence: "ACG", Quality: "!!!"}, Fastq{Sequence: "", Quality: ""}, true},
+		{"mott trims both ends", MottTrim(20), Fastq{Sequence: "ACGTACGTAC", Quality: "!!IIII+III"}, Fastq{Sequence: "GTACGTAC", Quality: "IIII+III"}, true},
+		{"mott stops at bad stretch", MottTrim(20), Fastq{Sequence: "ACGTACGTAC", Quality: "III!!!!III"}, Fastq{Sequence: "ACG", Quality: "III"}, true},
+		{"3' adapter", AdapterTrim3Prime("AGATCGGAAG", 1, 3), newRead("ACGTACGTAGATCGGAAGTTT", 'I'), newRead("ACGTACGT", 'I'), true},
+		{"3' adapter with mismatch", AdapterTrim3Prime("agatcggaag", 1, 3), newRead("ACGTACGTAGATCCGAAGTTT", 'I'), newRead("ACGTACGT", 'I'), true},
+		{"3' adapter with too many mismatches", AdapterTrim3Prime("AGATCGGAAG", 1, 3), newRead("ACGTACGTAGTTCCGAAGTTT", 'I'), newRead("ACGTACGTAGTTCCGAAGTTT", 'I'), true},
+		{"3' partial adapter", AdapterTrim3Prime("AGATCGGAAG", 1, 3), newRead("ACGTACGTAGATC", 'I'), newRead("ACGTACGT", 'I'), true},
+		{"3' partial adapter too short", AdapterTrim3Prime("AGATCGGAAG", 1, 3), newRead("ACGTACGTCAG", 'I'), newRead("ACGTACGTCAG", 'I'), true},
+		{"5' adapter", AdapterTrim5Prime("TTTCCC", 0, 3), newRead("AATTTCCCACGT", 'I'), newRead("ACGT", 'I'), true},
+		{"5' partial adapter", AdapterTrim5Prime("TTTCCC", 0, 3), newRead("CCCACGT", 'I'), newRead("ACGT", 'I'), true},
+		{"5' adapter removing read", AdapterTrim5Prime("TTTCCC", 0, 3), newRead("ACGTTTTCCC", 'I'), newRead("", 'I'), true},
+		{"poly-A", PolyATrim(4), newRead("ACGTAAAAaa", 'I'), newRead("ACGT", 'I'), true},
+		{"short poly-A", PolyATrim(4), newRead("ACGTAAA", 'I'), newRead("ACGTAAA", 'I'), true},
+		{"poly-G", PolyGTrim(3), newRead("ACGTGGGG", 'I'), newRead("ACGT", 'I'), true},
+		{"length filter keeps", LengthFilter(2, 4), newRead("ACGT", 'I'), newRead("ACGT", 'I'), true},
+		{"length filter too short", LengthFilter(2, 4), newRead("A", 'I'), newRead("A", 'I'), false},
+		{"length filter too long", LengthFilter(2, 4), newRead("ACGTA", 'I'), newRead("ACGTA", 'I'), false},
+		{"mean quality keeps", MeanQualityFilter(25), Fastq{Sequence: "AC", Quality: "+I"}, Fastq{Sequence: "AC", Quality: "+I"}, true},
+		{"mean quality removes", MeanQualityFilter(25.5), Fastq{Sequence: "AC", Quality: "+I"}, Fastq{Sequence: "AC", Quality: "+I"}, false},
+		{"N filter keeps", NFilter(1), newRead("ACNT", 'I'), newRead("ACNT", 'I'), true},
+		{"N filter removes", NFilter(1), newRead("ANnT", 'I'), newRead("ANnT", 'I'), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			read, keep := test.step.Process(test.read)
+			assert.Equal(t, test.keep, keep)
+			assert.Equal(t, test.expected, read)
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline := NewPipeline(PolyATrim(3), AdapterTrim3Prime("CTGTCTCTTATA", 1, 5), LengthFilter(5, 100))
+	input := "@adapter\nACGTACGTCTGTCTCTTATACAC\n+\nIIIIIIIIIIIIIIIIIIIIIII\n" +
+		"@short\nACGAAAA\n+\nIIIIIII\n" +
+		"@untouched\nACGTACGT\n+\nIIIIIIII\n" +
+		"@tail\nACGTACGTAAACTGTC\n+\nIIIIIIIIIIIIIIII\n"
+	reads, err := pipeline.ParseAll(NewParser(strings.NewReader(input), 2*32*1024))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"adapter", "untouched", "tail"}, []string{reads[0].Identifier, reads[1].Identifier, reads[2].Identifier})
+	assert.Equal(t, "ACGTACGT", reads[0].Sequence)
+	assert.Equal(t, "IIIIIIII", reads[0].Quality)
+	assert.Equal(t, "ACGTACGTAAA", reads[2].Sequence)
+
+	assert.Equal(t, Report{
+		Reads:    4,
+		Kept:     3,
+		BasesIn:  54,
+		BasesOut: 27,
+		Steps: []StepReport{
+			{Name: "poly-A trim (3 bases)", Reads: 4, Trimmed: 1, BasesTrimmed: 4},
+			{Name: "3' adapter trim CTGTCTCTTATA", Reads: 4, Trimmed: 2, BasesTrimmed: 20},
+			{Name: "length filter (5-100 bases)", Reads: 4, Removed: 1},
+		},
+	}, pipeline.Report())
+
+	// Reports are copies.
+	report := pipeline.Report()
+	report.Steps[0].Reads = 0
+	assert.Equal(t, 4, pipeline.Report().Steps[0].Reads)
+
+	// Reads trimmed down to nothing are removed.
+	pipeline = NewPipeline(MottTrim(20))
+	_, keep := pipeline.Process(newRead("ACGT", '!'))
+	assert.False(t, keep)
+	assert.Equal(t, 1, pipeline.Report().Steps[0].Removed)
+}
+
+func TestPipeline_ParseAll(t *testing.T) {
+	file, err := os.Open("data/nanosavseq.fastq")
+	if err != nil {
+		t.Fatalf("Failed to open nanosavseq.fastq: %s", err)
+	}
+	defer file.Close()
+	pipeline := NewPipeline(MeanQualityFilter(10))
+	reads, err := pipeline.ParseAll(NewParser(file, 2*32*1024))
+	assert.NoError(t, err)
+	report := pipeline.Report()
+	assert.Equal(t, 4, report.Reads)
+	assert.Equal(t, len(reads), report.Kept)
+
+	_, err = pipeline.ParseAll(NewParser(strings.NewReader("@read\nACGT\n"), 2*32*1024))
+	assert.Error(t, err)
+}
+
+func TestReport_String(t *testing.T) {
+	report := Report{Reads: 2, Kept: 1, BasesIn: 8, BasesOut: 3, Steps: []StepReport{{Name: "poly-A trim (3 bases)", Reads: 2, Trimmed: 1, BasesTrimmed: 1}, {Name: "N filter (0)", Reads: 2, Removed: 1}}}
+	expected := "reads: 2 in, 1 kept\n" +
+		"bases: 8 in, 3 kept\n" +
+		"step                     reads  trimmed  removed  bases trimmed\n" +
+		"poly-A trim (3 bases)        2        1        0              1\n" +
+		"N filter (0)                 2        0        1              0\n"
+	assert.Equal(t, expected, report.String())
+}
@@ -0,0 +1,163 @@
+package genbank
+
+import (
+	"strconv"
+
+	"github.com/bebop/poly/io/annotation"
+)
+
+/******************************************************************************
+
+Conversion to and from annotation.Record begins here.
+
+******************************************************************************/
+
+// Records returns the Genbank as a single annotation.Record, which makes
+// Genbank an annotation.AnnotatedSequence. The Meta, with metadata that a
+// Record has no field for like references and keywords, is kept in its
+// Extras, and so is the Location of a feature whose location string is
+// written in another form than BuildLocationString's, like the legacy
+// 687..3158> for 687..>3158.
+func (sequence Genbank) Records() []annotation.Record {
+	meta := sequence.Meta
+	meta.Extras = nil
+	record := annotation.Record{
+		Name:                 sequence.Meta.Locus.Name,
+		Description:          sequence.Meta.Definition,
+		Sequence:             sequence.Sequence,
+		SequenceHash:         sequence.Meta.SequenceHash,
+		SequenceHashFunction: sequence.Meta.SequenceHashFunction,
+		Circular:             sequence.Meta.Locus.Circular,
+		Extras:               append(annotation.CopyExtras(sequence.Meta.Extras), meta),
+	}
+	for _, feature := range sequence.Features {
+		extras := annotation.CopyExtras(feature.Extras)
+		if locationString := feature.Location.GbkLocationString; locationString != "" && locationString != BuildLocationString(feature.Location) {
+			extras = append(extras, feature.Location)
+		}
+		record.Features = append(record.Features, annotation.Feature{
+			Type:                 feature.Type,
+			Description:          feature.Description,
+			Qualifiers:           toAnnotationQualifiers(feature.Qualifiers),
+			Location:             toAnnotationLocation(feature.Location),
+			SequenceHash:         feature.SequenceHash,
+			SequenceHashFunction: feature.SequenceHashFunction,
+			Sequence:             feature.Sequence,
+			Extras:               extras,
+		})
+	}
+	return []annotation.Record{record}
+}
+
+// FromRecord converts an annotation.Record, for example one of a gff or
+// polyjson file, to a Genbank. Records that were converted from a Genbank
+// get their original Meta back. Otherwise metadata that the Record does not
+// hold, like references or the molecule type, is left empty.
+func FromRecord(record annotation.Record) Genbank {
+	sequence := Genbank{Sequence: record.Sequence}
+	meta, extras, ok := annotation.TakeExtra[Meta](record.Extras)
+	if ok {
+		sequence.Meta = meta
+	} else {
+		sequence.Meta.Locus.SequenceCoding = "bp"
+		sequence.Meta.Other = make(map[string]string)
+	}
+	sequence.Meta.Locus.Name = record.Name
+	sequence.Meta.Locus.SequenceLength = strconv.Itoa(len(record.Sequence))
+	sequence.Meta.Locus.Circular = record.Circular
+	sequence.Meta.Definition = record.Description
+	sequence.Meta.SequenceHash = record.SequenceHash
+	sequence.Meta.SequenceHashFunction = record.SequenceHashFunction
+	sequence.Meta.Extras = extras
+	for _, recordFeature := range record.Features {
+		location, extras, ok := annotation.TakeExtra[Location](recordFeature.Extras)
+		feature := Feature{
+			Type:                 recordFeature.Type,
+			Description:          recordFeature.Description,
+			Qualifiers:           fromAnnotationQualifiers(recordFeature.Qualifiers),
+			Location:             fromAnnotationLocation(recordFeature.Location),
+			SequenceHash:         recordFeature.SequenceHash,
+			SequenceHashFunction: recordFeature.SequenceHashFunction,
+			Sequence:             recordFeature.Sequence,
+			Extras:               extras,
+		}
+		// The location string is only kept if the location wasn't changed.
+		if ok && BuildLocationString(location) == BuildLocationString(feature.Location) {
+			feature.Location.GbkLocationString = location.GbkLocationString
+		}
+		_ = sequence.AddFeature(&feature)
+	}
+	return sequence
+}
+
+// toAnnotationQualifiers converts Qualifiers to annotation.Qualifiers, keeping
+// every qualifier and its place.
+func toAnnotationQualifiers(qualifiers []Qualifier) []annotation.Qualifier {
+	var converted []annotation.Qualifier
+	for _, qualifier := range qualifiers {
+		if qualifier.Flag {
+			converted = append(converted, annotation.Qualifier{Key: qualifier.Key})
+			continue
+		}
+		converted = append(converted, annotation.Qualifier{Key: qualifier.Key, Values: []string{qualifier.Value}})
+	}
+	return converted
+}
+
+// fromAnnotationQualifiers converts annotation.Qualifiers to Qualifiers.
+// Qualifiers with several values, like gff attributes, become a qualifier for
+// every value.
+func fromAnnotationQualifiers(qualifiers []annotation.Qualifier) []Qualifier {
+	var converted []Qualifier
+	for _, qualifier := range qualifiers {
+		if len(qualifier.Values) == 0 {
+			converted = append(converted, Qualifier{Key: qualifier.Key, Flag: true})
+		}
+		for _, value := range qualifier.Values {
+			converted = append(converted, Qualifier{Key: qualifier.Key, Value: value})
+		}
+	}
+	return converted
+}
+
+// toAnnotationLocation converts a Location to an annotation.Location.
+func toAnnotationLocation(location Location) annotation.Location {
+	converted := annotation.Location{
+		Start:             location.Start,
+		End:               location.End,
+		Complement:        location.Complement,
+		Join:              location.Join,
+		FivePrimePartial:  location.FivePrimePartial,
+		ThreePrimePartial: location.ThreePrimePartial,
+		Order:             location.Order,
+		Bond:              location.Bond,
+		Between:           location.Between,
+		WithinRange:       location.WithinRange,
+		RemoteAccession:   location.RemoteAccession,
+	}
+	for _, subLocation := range location.SubLocations {
+		converted.SubLocations = append(converted.SubLocations, toAnnotationLocation(subLocation))
+	}
+	return converted
+}
+
+// fromAnnotationLocation converts an annotation.Location to a Location.
+func fromAnnotationLocation(location annotation.Location) Location {
+	converted := Location{
+		Start:             location.Start,
+		End:               location.End,
+		Complement:        location.Complement,
+		Join:              location.Join,
+		FivePrimePartial:  location.FivePrimePartial,
+		ThreePrimePartial: location.ThreePrimePartial,
+		Order:             location.Order,
+		Bond:              location.Bond,
+		Between:           location.Between,
+		WithinRange:       location.WithinRange,
+		RemoteAccession:   location.RemoteAccession,
+	}
+	for _, subLocation := range location.SubLocations {
+		converted.SubLocations = append(converted.SubLocations, fromAnnotationLocation(subLocation))
+	}
+	return converted
+}
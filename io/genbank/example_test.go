@@ -14,8 +14,8 @@ import (
 func Example_basic() {
 	sequences, _ := genbank.Read("../../data/puc19.gbk")
 	for _, feature := range sequences.Features {
-		if feature.Attributes["gene"] == "bla" {
-			fmt.Println(feature.Attributes["note"])
+		if feature.Attributes()["gene"] == "bla" {
+			fmt.Println(feature.Attributes()["note"])
 		}
 	}
 	// Output: confers resistance to ampicillin, carbenicillin, andrelated antibiotics
@@ -110,6 +110,26 @@ func ExampleParseMulti() {
 	// Output: 05-FEB-1999
 }
 
+func ExampleParser() {
+	file, _ := os.Open("../../data/multiGbk_test.seq")
+	defer file.Close()
+
+	// only one record is kept in memory at a time.
+	parser := genbank.NewParser(file, 2*32*1024)
+	for {
+		sequence, err := parser.ParseNext()
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(sequence.Meta.Locus.Name, len(sequence.Sequence))
+	}
+	// Output:
+	// AB000100 2992
+	// AB000106 1343
+	// EOF
+}
+
 func ExampleGenbank_AddFeature() {
 	// Sequence for greenflourescent protein (GFP) that we're using as test data for this example.
 	gfpSequence := "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
@@ -139,6 +159,16 @@ func ExampleGenbank_AddFeature() {
 	// Output: true
 }
 
+func ExampleFeature_QualifierValues() {
+	sequence, _ := genbank.Read("../../data/repeated_qualifiers.gbk")
+	for _, feature := range sequence.Features {
+		if feature.Type == "CDS" {
+			fmt.Println(feature.QualifierValues("EC_number"))
+		}
+	}
+	// Output: [2.7.1.1 2.7.1.2]
+}
+
 func ExampleFeature_GetSequence() {
 	// Sequence for greenflourescent protein (GFP) that we're using as test data for this example.
 	gfpSequence := "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
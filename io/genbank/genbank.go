@@ -7,20 +7,27 @@ sequences.
 
 This package provides a parser and writer to convert between the GenBank file
 format and the more general Genbank struct.
+
+NCBI protein records, known as GenPept, share the same format. Their lengths
+are given in amino acids (aa) rather than base pairs, which is recorded in the
+ResidueType of their Locus.
 */
 package genbank
 
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/io/annotation"
 	"github.com/lunny/log"
 	"github.com/mitchellh/go-wordwrap"
 )
@@ -50,6 +57,7 @@ type Meta struct {
 	Definition           string            `json:"definition"`
 	Accession            string            `json:"accession"`
 	Version              string            `json:"version"`
+	DBSource             string            `json:"db_source"` // the database a GenPept record comes from, like "REFSEQ: accession NM_000518.5".
 	Keywords             string            `json:"keywords"`
 	Organism             string            `json:"organism"`
 	Source               string            `json:"source"`
@@ -62,18 +70,40 @@ type Meta struct {
 	Name                 string            `json:"name"`
 	SequenceHash         string            `json:"sequence_hash"`
 	SequenceHashFunction string            `json:"hash_function"`
+	// Extras holds metadata of other formats that genbank has no place for,
+	// kept when converting from an annotation.Record.
+	Extras []any `json:"-"`
 }
 
 // Feature holds the information for a feature in a Genbank file and other annotated sequence files.
+//
+// Qualifiers replaced the Attributes map, which could hold only a single value
+// per qualifier and lost their order. Code that read feature.Attributes["gene"]
+// can use feature.Attributes()["gene"] or feature.Qualifier("gene"), and code
+// that wrote to the map can use SetQualifier, AddQualifier or QualifiersFromMap.
 type Feature struct {
-	Type                 string            `json:"type"`
-	Description          string            `json:"description"`
-	Attributes           map[string]string `json:"attributes"`
-	SequenceHash         string            `json:"sequence_hash"`
-	SequenceHashFunction string            `json:"hash_function"`
-	Sequence             string            `json:"sequence"`
-	Location             Location          `json:"location"`
-	ParentSequence       *Genbank          `json:"-"`
+	Type                 string      `json:"type"`
+	Description          string      `json:"description"`
+	Qualifiers           []Qualifier `json:"qualifiers"`
+	SequenceHash         string      `json:"sequence_hash"`
+	SequenceHashFunction string      `json:"hash_function"`
+	Sequence             string      `json:"sequence"`
+	Location             Location    `json:"location"`
+	// Extras holds fields of other formats that genbank has no place for,
+	// like the score of a gff feature, kept when converting from an
+	// annotation.Record.
+	Extras         []any    `json:"-"`
+	ParentSequence *Genbank `json:"-"`
+}
+
+// Qualifier is a single /key="value" qualifier of a Feature. A key may occur
+// several times in a Feature, like /db_xref or /EC_number often do.
+type Qualifier struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Flag is set for qualifiers without a value or =, like /pseudo, which
+	// tells them apart from qualifiers with an empty value, like /note="".
+	Flag bool `json:"flag,omitempty"`
 }
 
 // Reference holds information for one reference in a Meta struct.
@@ -89,15 +119,27 @@ type Reference struct {
 
 // Locus holds Locus information in a Meta struct.
 type Locus struct {
-	Name             string `json:"name"`
-	SequenceLength   string `json:"sequence_length"`
-	MoleculeType     string `json:"molecule_type"`
-	GenbankDivision  string `json:"genbank_division"`
-	ModificationDate string `json:"modification_date"`
-	SequenceCoding   string `json:"sequence_coding"`
-	Circular         bool   `json:"circular"`
+	Name             string      `json:"name"`
+	SequenceLength   string      `json:"sequence_length"`
+	MoleculeType     string      `json:"molecule_type"`
+	GenbankDivision  string      `json:"genbank_division"`
+	ModificationDate string      `json:"modification_date"`
+	SequenceCoding   string      `json:"sequence_coding"`
+	ResidueType      ResidueType `json:"residue_type"`
+	Circular         bool        `json:"circular"`
 }
 
+// ResidueType is the type of residues a sequence is made of. It is stored in
+// the LOCUS line as the unit of the sequence length.
+type ResidueType int
+
+const (
+	// NucleotideResidues are the base pairs (bp) of GenBank records.
+	NucleotideResidues ResidueType = iota
+	// AminoAcidResidues are the amino acids (aa) of GenPept protein records.
+	AminoAcidResidues
+)
+
 // Location is a struct that holds the location of a feature.
 type Location struct {
 	Start             int        `json:"start"`
@@ -108,6 +150,11 @@ type Location struct {
 	ThreePrimePartial bool       `json:"three_prime_partial"`
 	GbkLocationString string     `json:"gbk_location_string"`
 	SubLocations      []Location `json:"sub_locations"`
+	Order             bool       `json:"order"`            // order(): the order of the SubLocations is unknown.
+	Bond              bool       `json:"bond"`             // bond(): SubLocations are residues linked by a bond, mostly in proteins.
+	Between           bool       `json:"between"`          // 123^124: a site between two bases. End is the base after the site minus one.
+	WithinRange       bool       `json:"within_range"`     // 102.110: a single base somewhere within the range.
+	RemoteAccession   string     `json:"remote_accession"` // J00194.1:100..202: the location is on another entry.
 }
 
 // BaseCount is a struct that holds the base counts for a sequence.
@@ -116,6 +163,31 @@ type BaseCount struct {
 	Count int
 }
 
+// unquotedQualifiers are the qualifiers whose values are not quoted in the
+// INSDC feature table, like /codon_start=1.
+var unquotedQualifiers = map[string]bool{
+	"anticodon":         true,
+	"calculated_mol_wt": true,
+	"citation":          true,
+	"codon_start":       true,
+	"compare":           true,
+	"direction":         true,
+	"estimated_length":  true,
+	"mod_base":          true,
+	"number":            true,
+	"rpt_type":          true,
+	"rpt_unit_range":    true,
+	"tag_peptide":       true,
+	"transl_except":     true,
+	"transl_table":      true,
+}
+
+// unspacedQualifiers are the qualifiers whose values hold no spaces, like
+// sequences, and are wrapped at the line width rather than between words.
+var unspacedQualifiers = map[string]bool{
+	"translation": true,
+}
+
 // Precompiled regular expressions:
 var (
 	basePairRegex         = regexp.MustCompile(` \d* \w{2} `)
@@ -132,35 +204,112 @@ func (sequence *Genbank) AddFeature(feature *Feature) error {
 	return nil
 }
 
-// GetSequence returns the sequence of a feature.
+// GetSequence returns the sequence of a feature. For GenPept records, this is
+// the protein sequence of a feature like a Region or Site.
 func (feature Feature) GetSequence() (string, error) {
-	return getFeatureSequence(feature, feature.Location)
+	if feature.ParentSequence.Meta.Locus.ResidueType == AminoAcidResidues && hasComplement(feature.Location) {
+		return "", fmt.Errorf("%s feature at %s is complemented, but the record is a protein", feature.Type, BuildLocationString(feature.Location))
+	}
+	return annotation.LocationSequence(feature.ParentSequence.Sequence, toAnnotationLocation(feature.Location))
+}
+
+// hasComplement returns whether a location or any of its SubLocations is complemented.
+func hasComplement(location Location) bool {
+	if location.Complement {
+		return true
+	}
+	for _, subLocation := range location.SubLocations {
+		if hasComplement(subLocation) {
+			return true
+		}
+	}
+	return false
 }
 
-// getFeatureSequence takes a feature and location object and returns a sequence string.
-func getFeatureSequence(feature Feature, location Location) (string, error) {
-	var sequenceBuffer bytes.Buffer
-	var sequenceString string
-	parentSequence := feature.ParentSequence.Sequence
+// Qualifier returns the first value of a qualifier and whether the feature
+// has the qualifier at all.
+func (feature Feature) Qualifier(key string) (string, bool) {
+	for _, qualifier := range feature.Qualifiers {
+		if qualifier.Key == key {
+			return qualifier.Value, true
+		}
+	}
+	return "", false
+}
 
-	if len(location.SubLocations) == 0 {
-		sequenceBuffer.WriteString(parentSequence[location.Start:location.End])
-	} else {
-		for _, subLocation := range location.SubLocations {
-			sequence, _ := getFeatureSequence(feature, subLocation)
+// QualifierValues returns all values of a qualifier in the order they appear.
+func (feature Feature) QualifierValues(key string) []string {
+	var values []string
+	for _, qualifier := range feature.Qualifiers {
+		if qualifier.Key == key {
+			values = append(values, qualifier.Value)
+		}
+	}
+	return values
+}
+
+// AddQualifier adds a qualifier after the existing ones, keeping any
+// qualifiers with the same key.
+func (feature *Feature) AddQualifier(key string, value string) {
+	feature.Qualifiers = append(feature.Qualifiers, Qualifier{Key: key, Value: value})
+}
 
-			sequenceBuffer.WriteString(sequence)
+// SetQualifier sets a qualifier to a single value. The first qualifier with
+// the key keeps its position and any others are removed. A new qualifier is
+// added at the end.
+func (feature *Feature) SetQualifier(key string, value string) {
+	var qualifiers []Qualifier
+	found := false
+	for _, qualifier := range feature.Qualifiers {
+		if qualifier.Key != key {
+			qualifiers = append(qualifiers, qualifier)
+		} else if !found {
+			qualifiers = append(qualifiers, Qualifier{Key: key, Value: value})
+			found = true
 		}
 	}
+	if !found {
+		qualifiers = append(qualifiers, Qualifier{Key: key, Value: value})
+	}
+	feature.Qualifiers = qualifiers
+}
 
-	// reverse complements resulting string if needed.
-	if location.Complement {
-		sequenceString = transform.ReverseComplement(sequenceBuffer.String())
-	} else {
-		sequenceString = sequenceBuffer.String()
+// RemoveQualifier removes all qualifiers with the key.
+func (feature *Feature) RemoveQualifier(key string) {
+	var qualifiers []Qualifier
+	for _, qualifier := range feature.Qualifiers {
+		if qualifier.Key != key {
+			qualifiers = append(qualifiers, qualifier)
+		}
+	}
+	feature.Qualifiers = qualifiers
+}
+
+// Attributes returns the qualifiers of a feature as a map of their first
+// values, which is what the former Attributes field held.
+func (feature Feature) Attributes() map[string]string {
+	attributes := make(map[string]string, len(feature.Qualifiers))
+	for _, qualifier := range feature.Qualifiers {
+		if _, ok := attributes[qualifier.Key]; !ok {
+			attributes[qualifier.Key] = qualifier.Value
+		}
 	}
+	return attributes
+}
 
-	return sequenceString, nil
+// QualifiersFromMap converts a map of qualifiers, like the former Attributes
+// field, to Qualifiers sorted by key.
+func QualifiersFromMap(attributes map[string]string) []Qualifier {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	qualifiers := make([]Qualifier, 0, len(keys))
+	for _, key := range keys {
+		qualifiers = append(qualifiers, Qualifier{Key: key, Value: attributes[key]})
+	}
+	return qualifiers
 }
 
 // Read reads a GBK file from path and returns a Genbank struct.
@@ -238,7 +387,11 @@ func BuildMulti(sequences []Genbank) ([]byte, error) {
 		fivespace := generateWhiteSpace(subMetaIndex)
 
 		// building locus
-		locusData := locus.Name + fivespace + locus.SequenceLength + " bp" + fivespace + locus.MoleculeType + fivespace + shape + fivespace + locus.GenbankDivision + fivespace + locus.ModificationDate
+		residueUnit := "bp"
+		if locus.ResidueType == AminoAcidResidues {
+			residueUnit = "aa"
+		}
+		locusData := locus.Name + fivespace + locus.SequenceLength + " " + residueUnit + fivespace + locus.MoleculeType + fivespace + shape + fivespace + locus.GenbankDivision + fivespace + locus.ModificationDate
 		locusString := "LOCUS       " + locusData + "\n"
 		gbkString.WriteString(locusString)
 
@@ -252,6 +405,10 @@ func BuildMulti(sequences []Genbank) ([]byte, error) {
 		versionString := buildMetaString("VERSION", sequence.Meta.Version)
 		gbkString.WriteString(versionString)
 
+		if sequence.Meta.DBSource != "" {
+			gbkString.WriteString(buildMetaString("DBSOURCE", sequence.Meta.DBSource))
+		}
+
 		keywordsString := buildMetaString("KEYWORDS", sequence.Meta.Keywords)
 		gbkString.WriteString(keywordsString)
 
@@ -369,6 +526,9 @@ func Parse(r io.Reader) (Genbank, error) {
 	if err != nil {
 		return Genbank{}, err
 	}
+	if len(genbankSlice) == 0 {
+		return Genbank{}, errors.New("No genbank record found")
+	}
 
 	return genbankSlice[0], err
 }
@@ -389,7 +549,7 @@ type parseLoopParameters struct {
 	quoteActive      bool
 	attribute        string
 	attributeValue   string
-	emptyAttribute   bool
+	flagAttribute    bool
 	sequenceBuilder  strings.Builder
 	parseStep        string
 	genbank          Genbank // since we are scanning lines we need a Genbank struct to store the data outside the loop.
@@ -406,26 +566,130 @@ type parseLoopParameters struct {
 // method to init loop parameters
 func (params *parseLoopParameters) init() {
 	params.newLocation = true
-	params.feature.Attributes = make(map[string]string)
 	params.parseStep = "metadata"
 	params.genbankStarted = false
 	params.genbank.Meta.Other = make(map[string]string)
 }
 
+// saveQualifier adds the qualifier being parsed to the current feature.
+func (params *parseLoopParameters) saveQualifier() {
+	params.feature.Qualifiers = append(params.feature.Qualifiers, Qualifier{Key: params.attribute, Value: params.attributeValue, Flag: params.flagAttribute})
+	params.attribute = ""
+	params.attributeValue = ""
+	params.flagAttribute = false
+}
+
+// continueQualifier adds a continued line to the value of the qualifier being
+// parsed. Lines of text are wrapped between words or after a hyphen, while
+// sequences like /translation are wrapped anywhere.
+func (params *parseLoopParameters) continueQualifier(line string) {
+	if !unspacedQualifiers[params.attribute] && !strings.HasSuffix(params.attributeValue, "-") {
+		params.attributeValue += " "
+	}
+	params.attributeValue += line
+}
+
 // ParseMultiNth takes in a reader representing a multi gbk/gb/genbank file and parses the first n records into a slice of Genbank structs.
+// A negative count parses all records.
 func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
-	scanner := bufio.NewScanner(r)
-	var genbanks []Genbank
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	if count < 0 {
+		count = math.MaxInt
+	}
+	parser := NewParser(r, maxLineSize)
+	return parser.ParseN(count)
+}
+
+// Parser is a flexible parser that provides ample
+// control over reading genbank-formatted sequences.
+// It is initialized with NewParser.
+type Parser struct {
+	// reader keeps state of current reader.
+	reader     bufio.Reader
+	line       uint
+	parameters parseLoopParameters
+}
+
+// NewParser returns a Parser that uses r as the source
+// from which to parse genbank formatted sequences.
+func NewParser(r io.Reader, maxLineSize int) *Parser {
+	parser := &Parser{
+		reader: *bufio.NewReaderSize(r, maxLineSize),
+	}
+	parser.parameters.init()
+	return parser
+}
 
-	// Sequence setup
+// ParseAll parses all sequences in underlying reader only returning non-EOF errors.
+// It returns all valid genbank sequences up to error if encountered.
+func (parser *Parser) ParseAll() ([]Genbank, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// ParseN parses up to maxSequences genbank sequences from the Parser's underlying reader.
+// ParseN does not return EOF if encountered.
+// If an non-EOF error is encountered it returns it and all correctly parsed sequences up to then.
+func (parser *Parser) ParseN(maxSequences int) (genbanks []Genbank, err error) {
+	for counter := 0; counter < maxSequences; counter++ {
+		genbank, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return genbanks, err
+		}
+		genbanks = append(genbanks, genbank)
+	}
+	return genbanks, nil
+}
 
-	var parameters parseLoopParameters
-	parameters.init()
+// Reset discards all data in buffer and resets state.
+func (parser *Parser) Reset(r io.Reader) {
+	parser.reader.Reset(r)
+	parser.line = 0
+	parser.parameters = parseLoopParameters{}
+	parser.parameters.init()
+}
 
+// readLine reads the next line without its line ending. It returns the last
+// line of the reader together with io.EOF if it does not end with a newline.
+func (parser *Parser) readLine() (string, error) {
+	lineBytes, err := parser.reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", fmt.Errorf("line %d too large for buffer, use larger maxLineSize: %w", parser.line+1, err)
+	}
+	if err != nil && (!errors.Is(err, io.EOF) || len(lineBytes) == 0) {
+		return "", err
+	}
+	parser.line++
+	lineBytes = bytes.TrimSuffix(lineBytes, []byte("\n"))
+	lineBytes = bytes.TrimSuffix(lineBytes, []byte("\r"))
+	return string(lineBytes), err
+}
+
+// endOfInput converts the EOF of a reader ending in the middle of a record,
+// before its terminating //, into io.ErrUnexpectedEOF.
+func (parser *Parser) endOfInput(err error) error {
+	if errors.Is(err, io.EOF) && parser.parameters.genbankStarted {
+		return fmt.Errorf("record ends without // on line %d: %w", parser.line, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// ParseNext reads the next genbank record in the underlying reader, keeping
+// only that record in memory. It returns io.EOF once the reader is exhausted,
+// and io.ErrUnexpectedEOF if it ends in a record not terminated with //.
+func (parser *Parser) ParseNext() (Genbank, error) {
+	parameters := &parser.parameters
 	// Loop through each line of the file
-	for lineNum := 0; scanner.Scan(); lineNum++ {
-		// get line from scanner and split it
-		line := scanner.Text()
+	for {
+		line, readErr := parser.readLine()
+		if line == "" && readErr != nil {
+			return Genbank{}, parser.endOfInput(readErr)
+		}
+		lineNum := int(parser.line) - 1
+		// get line and split it
 		splitLine := strings.Split(strings.TrimSpace(line), " ")
 
 		prevline := parameters.currentLine
@@ -438,11 +702,14 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 			locusFlag := strings.Contains(line, "LOCUS")
 
 			if locusFlag {
-				parameters = parseLoopParameters{}
+				*parameters = parseLoopParameters{}
 				parameters.init()
 				parameters.genbank.Meta.Locus = parseLocus(line)
 				parameters.genbankStarted = true
 			}
+			if readErr != nil {
+				return Genbank{}, parser.endOfInput(readErr)
+			}
 			continue
 		}
 
@@ -450,7 +717,7 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 		case "metadata":
 			// Handle empty lines
 			if len(line) == 0 {
-				return genbanks, fmt.Errorf("Empty metadata line on line %d", lineNum)
+				return Genbank{}, fmt.Errorf("Empty metadata line on line %d", lineNum)
 			}
 
 			// If we are currently reading a line, we need to figure out if it is a new meta line.
@@ -464,6 +731,8 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 					parameters.genbank.Meta.Accession = parseMetadata(parameters.metadataData)
 				case "VERSION":
 					parameters.genbank.Meta.Version = parseMetadata(parameters.metadataData)
+				case "DBSOURCE":
+					parameters.genbank.Meta.DBSource = parseMetadata(parameters.metadataData)
 				case "KEYWORDS":
 					parameters.genbank.Meta.Keywords = parseMetadata(parameters.metadataData)
 				case "SOURCE":
@@ -471,7 +740,7 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				case "REFERENCE":
 					reference, err := parseReferencesFn(parameters.metadataData)
 					if err != nil {
-						return []Genbank{}, fmt.Errorf("Failed in parsing reference above line %d. Got error: %s", lineNum, err)
+						return Genbank{}, fmt.Errorf("Failed in parsing reference above line %d. Got error: %s", lineNum, err)
 					}
 					parameters.genbank.Meta.References = append(parameters.genbank.Meta.References, reference)
 
@@ -504,7 +773,7 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				for countIndex := 2; countIndex < len(fields)-1; countIndex += 2 { // starts at two because we don't want to include "BASE COUNT" in our fields
 					count, err := strconv.Atoi(fields[countIndex])
 					if err != nil {
-						return []Genbank{}, err
+						return Genbank{}, err
 					}
 
 					baseCount := BaseCount{
@@ -521,13 +790,10 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				parameters.parseStep = "sequence"
 
 				// save our completed attribute / qualifier string to the current feature
-				if parameters.attributeValue != "" {
-					parameters.feature.Attributes[parameters.attribute] = parameters.attributeValue
+				if parameters.attribute != "" {
+					parameters.saveQualifier()
 					parameters.features = append(parameters.features, parameters.feature)
-					parameters.attributeValue = ""
-					parameters.attribute = ""
 					parameters.feature = Feature{}
-					parameters.feature.Attributes = make(map[string]string)
 				} else {
 					parameters.features = append(parameters.features, parameters.feature)
 				}
@@ -536,12 +802,12 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				for _, feature := range parameters.features {
 					location, err := parseLocation(feature.Location.GbkLocationString)
 					if err != nil {
-						return []Genbank{}, err
+						return Genbank{}, err
 					}
 					feature.Location = location
 					err = parameters.genbank.AddFeature(&feature)
 					if err != nil {
-						return []Genbank{}, err
+						return Genbank{}, err
 					}
 				}
 				continue
@@ -556,13 +822,10 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 			// determine if current line is a new top level feature
 			if countLeadingSpaces(parameters.currentLine) < countLeadingSpaces(parameters.prevline) || parameters.prevline == "FEATURES" {
 				// save our completed attribute / qualifier string to the current feature
-				if parameters.attributeValue != "" {
-					parameters.feature.Attributes[parameters.attribute] = parameters.attributeValue
+				if parameters.attribute != "" {
+					parameters.saveQualifier()
 					parameters.features = append(parameters.features, parameters.feature)
-					parameters.attributeValue = ""
-					parameters.attribute = ""
 					parameters.feature = Feature{}
-					parameters.feature.Attributes = make(map[string]string)
 				}
 
 				// }
@@ -572,11 +835,10 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 				}
 
 				parameters.feature = Feature{}
-				parameters.feature.Attributes = make(map[string]string)
 
 				// An initial feature line looks like this: `source          1..2686` with a type separated by its location
 				if len(splitLine) < 2 {
-					return genbanks, fmt.Errorf("Feature line malformed on line %d. Got line: %s", lineNum, line)
+					return Genbank{}, fmt.Errorf("Feature line malformed on line %d. Got line: %s", lineNum, line)
 				}
 				parameters.feature.Type = strings.TrimSpace(splitLine[0])
 				parameters.feature.Location.GbkLocationString = strings.TrimSpace(splitLine[len(splitLine)-1])
@@ -587,48 +849,40 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 					parameters.feature.Location.GbkLocationString += strings.TrimSpace(line)
 					parameters.multiLineFeature = true // without this we can't tell if something is a multiline feature or multiline qualifier
 				} else { // it's a continued line of a qualifier
-					removeAttributeValueQuotes := strings.Replace(trimmedLine, "\"", "", -1)
-
-					parameters.attributeValue = parameters.attributeValue + removeAttributeValueQuotes
+					parameters.continueQualifier(strings.Replace(trimmedLine, "\"", "", -1))
 				}
 			} else if strings.Contains(parameters.currentLine, "/") { // current line is a new qualifier
 				trimmedCurrentLine := strings.TrimSpace(parameters.currentLine)
 				if trimmedCurrentLine[0] != '/' { // if we have an exception case, like (adenine(1518)-N(6)/adenine(1519)-N(6))-
-					parameters.attributeValue = parameters.attributeValue + trimmedCurrentLine
+					parameters.continueQualifier(trimmedCurrentLine)
 					continue
 				}
 				// save our completed attribute / qualifier string to the current feature
-				if parameters.attributeValue != "" || parameters.emptyAttribute {
-					parameters.feature.Attributes[parameters.attribute] = parameters.attributeValue
-					parameters.emptyAttribute = false
+				if parameters.attribute != "" {
+					parameters.saveQualifier()
 				}
-				parameters.attributeValue = ""
-				splitAttribute := strings.Split(line, "=")
-				trimmedSpaceAttribute := strings.TrimSpace(splitAttribute[0])
+				// values may contain "=" themselves, so only the first one separates the key from the value.
+				attribute, attributeValue, hasValue := strings.Cut(line, "=")
+				trimmedSpaceAttribute := strings.TrimSpace(attribute)
 				removedForwardSlashAttribute := strings.Replace(trimmedSpaceAttribute, "/", "", 1)
 
 				parameters.attribute = removedForwardSlashAttribute
 
-				var removeAttributeValueQuotes string
-				if len(splitAttribute) == 1 { // handle case of ` /pseudo `, which has no text
-					removeAttributeValueQuotes = ""
-					parameters.emptyAttribute = true
-				} else { // this is normally triggered
-					removeAttributeValueQuotes = strings.Replace(splitAttribute[1], "\"", "", -1)
-				}
-				parameters.attributeValue = removeAttributeValueQuotes
+				// handle case of ` /pseudo `, which has no text
+				parameters.flagAttribute = !hasValue
+				parameters.attributeValue = strings.Replace(attributeValue, "\"", "", -1)
 				parameters.multiLineFeature = false // without this we can't tell if something is a multiline feature or multiline qualifier
 			}
 
 		case "sequence":
 			if len(line) < 2 { // throw error if line is malformed
-				return genbanks, fmt.Errorf("Too short line found while parsing genbank sequence on line %d. Got line: %s", lineNum, line)
+				return Genbank{}, fmt.Errorf("Too short line found while parsing genbank sequence on line %d. Got line: %s", lineNum, line)
 			} else if line[0:2] == "//" { // end of sequence
 				parameters.genbank.Sequence = parameters.sequenceBuilder.String()
 
-				genbanks = append(genbanks, parameters.genbank)
 				parameters.genbankStarted = false
 				parameters.sequenceBuilder.Reset()
+				return parameters.genbank, nil
 			} else { // add line to total sequence
 				parameters.sequenceBuilder.WriteString(sequenceRegex.ReplaceAllString(line, ""))
 			}
@@ -636,8 +890,10 @@ func ParseMultiNth(r io.Reader, count int) ([]Genbank, error) {
 			log.Warnf("Unknown parse step: %s", parameters.parseStep)
 			parameters.genbankStarted = false
 		}
+		if readErr != nil {
+			return Genbank{}, parser.endOfInput(readErr)
+		}
 	}
-	return genbanks, nil
 }
 
 func countLeadingSpaces(line string) int {
@@ -777,9 +1033,15 @@ func parseLocus(locusString string) Locus {
 			locus.SequenceCoding = splitBaseSequenceLength[1]
 		}
 	}
+	if locus.SequenceCoding == "aa" {
+		locus.ResidueType = AminoAcidResidues
+	}
 
-	// molecule type
+	// molecule type, which protein records don't have.
 	for _, moleculeType := range genBankMoleculeTypes {
+		if locus.ResidueType == AminoAcidResidues {
+			break
+		}
 		moleculeRegex, _ := regexp.Compile(moleculeType)
 		match := string(moleculeRegex.Find([]byte(locusString)))
 		if match != "" {
@@ -813,6 +1075,10 @@ func parseLocus(locusString string) Locus {
 const subMetaIndex = 5
 const qualifierIndex = 21
 
+// qualifierLineWidth is the length of the longest qualifier line, which NCBI
+// wraps before column 80.
+const qualifierLineWidth = 79
+
 func getSourceOrganism(metadataData []string) (string, string, []string) {
 	source := strings.TrimSpace(metadataData[0])
 	var organism string
@@ -839,41 +1105,47 @@ func getSourceOrganism(metadataData []string) (string, string, []string) {
 	return source, organism, taxonomy
 }
 
+// ParseLocation parses a gbk location string, like join(1..10,complement(20..30)),
+// into a Location. It is the counterpart of BuildLocationString.
+//
+// The full INSDC feature table location grammar is supported: ranges (1..10),
+// single positions (5), sites between two bases (5^6), single bases within a
+// range (5.10), partial ends (<1..>10), complement, join, order and bond, and
+// references to other entries (J00194.1:100..202), which are recorded in
+// RemoteAccession.
+//
+// https://www.insdc.org/submitting-standards/feature-table/#3.4
+func ParseLocation(locationString string) (Location, error) {
+	return parseLocation(locationString)
+}
+
 func parseLocation(locationString string) (Location, error) {
 	var location Location
 	location.GbkLocationString = locationString
-	if !strings.ContainsAny(locationString, "(") { // Case checks for simple expression of x..x
-		if !strings.ContainsAny(locationString, ".") { //Case checks for simple expression x
-			position, err := strconv.Atoi(locationString)
-			if err != nil {
-				return Location{}, err
-			}
-			location = Location{Start: position, End: position}
-		} else {
-			// to remove FivePrimePartial and ThreePrimePartial indicators from start and end before converting to int.
-			startEndSplit := strings.Split(locationString, "..")
-			start, err := strconv.Atoi(partialRegex.ReplaceAllString(startEndSplit[0], ""))
-			if err != nil {
-				return Location{}, err
-			}
-			end, err := strconv.Atoi(partialRegex.ReplaceAllString(startEndSplit[1], ""))
-			if err != nil {
-				return Location{}, err
-			}
-			location = Location{Start: start - 1, End: end}
+	if !strings.ContainsAny(locationString, "(") { // Case checks for simple expressions like x..x
+		var err error
+		location, err = parseSimpleLocation(locationString)
+		if err != nil {
+			return Location{}, err
 		}
+		location.GbkLocationString = locationString
 	} else {
 		firstOuterParentheses := strings.Index(locationString, "(")
-		expression := locationString[firstOuterParentheses+1 : strings.LastIndex(locationString, ")")]
+		lastOuterParentheses := strings.LastIndex(locationString, ")")
+		if lastOuterParentheses < firstOuterParentheses {
+			return Location{}, fmt.Errorf("Unbalanced parentheses")
+		}
+		expression := locationString[firstOuterParentheses+1 : lastOuterParentheses]
 		switch command := locationString[0:firstOuterParentheses]; command {
-		case "join":
-			location.Join = true
+		case "join", "order", "bond":
+			location.Join = command == "join"
+			location.Order = command == "order"
+			location.Bond = command == "bond"
 			// This case checks for join(complement(x..x),complement(x..x)), or any more complicated derivatives
 			if strings.ContainsAny(expression, "(") {
-				firstInnerParentheses := strings.Index(expression, "(")
-				ParenthesesCount := 1
+				ParenthesesCount := 0
 				prevSubLocationStart := 0
-				for i := firstInnerParentheses + 1; i < len(expression); i++ { // "(" is at 0, so we start at 1
+				for i := 0; i < len(expression); i++ {
 					switch expression[i] {
 					case '(':
 						ParenthesesCount++
@@ -919,6 +1191,9 @@ func parseLocation(locationString string) (Location, error) {
 			subLocation.Complement = true
 			subLocation.GbkLocationString = locationString
 			location.SubLocations = append(location.SubLocations, subLocation)
+
+		default:
+			return Location{}, fmt.Errorf("Unknown location operator %s in %s", command, locationString)
 		}
 	}
 
@@ -931,13 +1206,74 @@ func parseLocation(locationString string) (Location, error) {
 	}
 
 	// if excess root node then trim node. Maybe should just be handled with second arg?
-	if location.Start == 0 && location.End == 0 && !location.Join && !location.Complement {
+	if location.Start == 0 && location.End == 0 && !location.Join && !location.Order && !location.Bond && !location.Complement && !location.Between {
+		if len(location.SubLocations) == 0 {
+			return Location{}, fmt.Errorf("Could not parse location %s", locationString)
+		}
 		location = location.SubLocations[0]
 	}
 
 	return location, nil
 }
 
+// parseSimpleLocation parses a location without operators: a range (1..10),
+// a single position (5), a site between two bases (5^6) or a single base
+// within a range (5.10), optionally prefixed by the accession of another
+// entry (J00194.1:1..10).
+func parseSimpleLocation(locationString string) (Location, error) {
+	var location Location
+	if accession, remoteLocation, found := strings.Cut(locationString, ":"); found {
+		location, err := parseSimpleLocation(remoteLocation)
+		if err != nil {
+			return Location{}, err
+		}
+		location.RemoteAccession = accession
+		return location, nil
+	}
+	// to remove FivePrimePartial and ThreePrimePartial indicators from start and end before converting to int.
+	positions := partialRegex.ReplaceAllString(locationString, "")
+	var separator string
+	switch {
+	case strings.Contains(positions, ".."):
+		separator = ".."
+	case strings.Contains(positions, "^"):
+		separator = "^"
+		location.Between = true
+	case strings.Contains(positions, "."):
+		separator = "."
+		location.WithinRange = true
+	default: //Case checks for simple expression x
+		position, err := strconv.Atoi(positions)
+		if err != nil {
+			return Location{}, err
+		}
+		location.Start = position - 1
+		location.End = position
+		return location, nil
+	}
+	startEndSplit := strings.Split(positions, separator)
+	if len(startEndSplit) != 2 {
+		return Location{}, fmt.Errorf("Could not parse location %s", locationString)
+	}
+	start, err := strconv.Atoi(startEndSplit[0])
+	if err != nil {
+		return Location{}, err
+	}
+	end, err := strconv.Atoi(startEndSplit[1])
+	if err != nil {
+		return Location{}, err
+	}
+	if location.Between {
+		// 123^124 is stored as Start 123, End 123, an empty slice between both bases.
+		location.Start = start
+		location.End = end - 1
+		return location, nil
+	}
+	location.Start = start - 1
+	location.End = end
+	return location, nil
+}
+
 // buildMetaString is a helper function to build the meta section of genbank files.
 func buildMetaString(name string, data string) string {
 	keyWhitespaceTrailLength := 12 - len(name) // I wish I was kidding.
@@ -967,20 +1303,39 @@ func BuildLocationString(location Location) string {
 	if location.Complement {
 		location.Complement = false
 		locationString = "complement(" + BuildLocationString(location) + ")"
-	} else if location.Join {
-		locationString = "join("
+	} else if location.Join || location.Order || location.Bond {
+		switch {
+		case location.Order:
+			locationString = "order("
+		case location.Bond:
+			locationString = "bond("
+		default:
+			locationString = "join("
+		}
 		for _, sublocation := range location.SubLocations {
 			locationString += BuildLocationString(sublocation) + ","
 		}
 		locationString = strings.TrimSuffix(locationString, ",") + ")"
 	} else {
-		locationString = strconv.Itoa(location.Start+1) + ".." + strconv.Itoa(location.End)
+		start, end := strconv.Itoa(location.Start+1), strconv.Itoa(location.End)
 		if location.FivePrimePartial {
-			locationString = "<" + locationString
+			start = "<" + start
 		}
-
 		if location.ThreePrimePartial {
-			locationString += ">"
+			end = ">" + end
+		}
+		switch {
+		case location.Between:
+			locationString = strconv.Itoa(location.Start) + "^" + strconv.Itoa(location.End+1)
+		case location.WithinRange:
+			locationString = start + "." + end
+		case location.End == location.Start+1 && !location.FivePrimePartial && !location.ThreePrimePartial:
+			locationString = end
+		default:
+			locationString = start + ".." + end
+		}
+		if location.RemoteAccession != "" {
+			locationString = location.RemoteAccession + ":" + locationString
 		}
 	}
 	return locationString
@@ -1000,15 +1355,41 @@ func BuildFeatureString(feature Feature) string {
 	featureHeader := generateWhiteSpace(subMetaIndex) + feature.Type + whiteSpaceTrail + location + "\n"
 	returnString := featureHeader
 
-	qualifierKeys := make([]string, 0, len(feature.Attributes))
-	for key := range feature.Attributes {
-		qualifierKeys = append(qualifierKeys, key)
+	for _, qualifier := range feature.Qualifiers {
+		qualifierString := "/" + qualifier.Key
+		switch {
+		case qualifier.Flag: // qualifiers without a value, like /pseudo
+		case unquotedQualifiers[qualifier.Key]:
+			qualifierString += "=" + qualifier.Value
+		default:
+			qualifierString += "=\"" + qualifier.Value + "\""
+		}
+		for _, line := range wrapQualifier(qualifierString, qualifierLineWidth-qualifierIndex) {
+			returnString += generateWhiteSpace(qualifierIndex) + line + "\n"
+		}
 	}
+	return returnString
+}
 
-	for _, qualifier := range qualifierKeys {
-		returnString += generateWhiteSpace(qualifierIndex) + "/" + qualifier + "=\"" + feature.Attributes[qualifier] + "\"\n"
+// wrapQualifier splits a qualifier into lines of up to width characters the
+// way NCBI does: between words where possible, otherwise after a hyphen, and
+// anywhere for values without either, like a /translation.
+func wrapQualifier(qualifier string, width int) []string {
+	var lines []string
+	for len(qualifier) > width {
+		if end := strings.LastIndex(qualifier[:width+1], " "); end > 0 {
+			lines = append(lines, qualifier[:end])
+			qualifier = qualifier[end+1:]
+			continue
+		}
+		end := strings.LastIndex(qualifier[:width], "-") + 1
+		if end <= 0 {
+			end = width
+		}
+		lines = append(lines, qualifier[:end])
+		qualifier = qualifier[end:]
 	}
-	return returnString
+	return append(lines, qualifier)
 }
 
 func generateWhiteSpace(length int) string {
@@ -1,6 +1,8 @@
 package genbank
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +14,9 @@ import (
 
 	"reflect"
 
+	"github.com/bebop/poly/io/annotation"
+	"github.com/bebop/poly/io/gff"
+	"github.com/bebop/poly/io/polyjson"
 	"github.com/bebop/poly/transform"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -110,7 +115,27 @@ func TestGbkLocationStringBuilder(t *testing.T) {
 	testInputGbk, _ := Read("../../data/sample.gbk")
 	testOutputGbk, _ := Read(tmpGbkFilePath)
 
-	if diff := cmp.Diff(testInputGbk, testOutputGbk, []cmp.Option{cmpopts.IgnoreFields(Feature{}, "ParentSequence")}...); diff != "" {
+	// sample.gbk has the legacy partial location 687..3158>, which Build
+	// writes in its INSDC form 687..>3158. Every other location is written
+	// just like in the file.
+	legacyFeatures := 0
+	for featureIndex, feature := range testInputGbk.Features {
+		if feature.Location.GbkLocationString != "687..3158>" {
+			continue
+		}
+		legacyFeatures++
+		if got := testOutputGbk.Features[featureIndex].Location.GbkLocationString; got != "687..>3158" {
+			t.Errorf("Build() wrote the legacy location 687..3158> as %q, want %q", got, "687..>3158")
+		}
+		testInputGbk.Features[featureIndex].Location.GbkLocationString = "687..>3158"
+	}
+	if legacyFeatures != 1 {
+		t.Errorf("sample.gbk has %d features with the location 687..3158>, want 1", legacyFeatures)
+	}
+	ignore := []cmp.Option{
+		cmpopts.IgnoreFields(Feature{}, "ParentSequence"),
+	}
+	if diff := cmp.Diff(testInputGbk, testOutputGbk, ignore...); diff != "" {
 		t.Errorf("Issue with partial location building. Parsing the output of Build() does not produce the same output as parsing the original file read with Read(). Got this diff:\n%s", diff)
 	}
 }
@@ -254,7 +279,7 @@ func TestGenbankNewlineParsingRegression(t *testing.T) {
 
 	for _, feature := range gbk.Features {
 		if feature.Location.Start == 410 && feature.Location.End == 1750 && feature.Type == "CDS" {
-			if feature.Attributes["product"] != "chromosomal replication initiator informational ATPase" {
+			if feature.Attributes()["product"] != "chromosomal replication initiator informational ATPase" {
 				t.Errorf("Newline parsing has failed.")
 			}
 			break
@@ -451,7 +476,15 @@ func TestParseMultiNth(t *testing.T) {
 		want    []Genbank
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "empty reader",
+			args: args{r: strings.NewReader(""), count: 1},
+		},
+		{
+			name:    "unterminated record",
+			args:    args{r: strings.NewReader("LOCUS       test    4 bp    DNA     linear   UNA 01-JAN-2000\nORIGIN\n        1 acgt\n"), count: 1},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -467,6 +500,79 @@ func TestParseMultiNth(t *testing.T) {
 	}
 }
 
+func TestParser(t *testing.T) {
+	file, err := os.Open("../../data/multiGbk_test.seq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	want, err := ParseMulti(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = file.Seek(0, io.SeekStart)
+	parser := NewParser(file, 2*32*1024)
+	for index := range want {
+		got, err := parser.ParseNext()
+		if err != nil {
+			t.Fatalf("ParseNext() failed on record %d: %s", index, err)
+		}
+		if diff := cmp.Diff(want[index], got, cmpopts.IgnoreFields(Feature{}, "ParentSequence")); diff != "" {
+			t.Errorf("ParseNext() record %d mismatch (-want +got):\n%s", index, diff)
+		}
+	}
+	if _, err = parser.ParseNext(); !errors.Is(err, io.EOF) {
+		t.Errorf("ParseNext() at end of file returned %v, want EOF", err)
+	}
+
+	_, _ = file.Seek(0, io.SeekStart)
+	parser.Reset(file)
+	genbanks, err := parser.ParseN(1)
+	if err != nil || len(genbanks) != 1 {
+		t.Fatalf("ParseN(1) returned %d records and error %v", len(genbanks), err)
+	}
+	if genbanks[0].Meta.Locus.Name != want[0].Meta.Locus.Name {
+		t.Errorf("ParseN(1) returned %s, want %s", genbanks[0].Meta.Locus.Name, want[0].Meta.Locus.Name)
+	}
+	genbanks, err = parser.ParseAll()
+	if err != nil || len(genbanks) != len(want)-1 {
+		t.Fatalf("ParseAll() returned %d records and error %v", len(genbanks), err)
+	}
+	if genbanks[0].Meta.Locus.Name != want[1].Meta.Locus.Name {
+		t.Errorf("ParseAll() returned %s, want %s", genbanks[0].Meta.Locus.Name, want[1].Meta.Locus.Name)
+	}
+}
+
+func TestParser_truncatedRecord(t *testing.T) {
+	file, err := os.ReadFile("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := bytes.TrimSuffix(bytes.TrimSpace(file), []byte("//"))
+	parser := NewParser(bytes.NewReader(truncated), 2*32*1024)
+	genbanks, err := parser.ParseAll()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ParseAll() of a record without // returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if len(genbanks) != 0 {
+		t.Errorf("ParseAll() of a record without // returned %d records, want 0", len(genbanks))
+	}
+}
+
+func TestParser_lineTooLarge(t *testing.T) {
+	file, err := os.Open("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	parser := NewParser(file, 16)
+	_, err = parser.ParseNext()
+	if !errors.Is(err, bufio.ErrBufferFull) {
+		t.Errorf("ParseNext() with a small buffer returned %v, want %v", err, bufio.ErrBufferFull)
+	}
+}
+
 func Test_parseMetadata(t *testing.T) {
 	type args struct {
 		metadataData []string
@@ -647,7 +753,73 @@ func Test_parseLocation(t *testing.T) {
 		want    Location
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "join with complement after a plain range",
+			args: args{locationString: "join(5..10,complement(100..120))"},
+			want: Location{Join: true, GbkLocationString: "join(5..10,complement(100..120))", SubLocations: []Location{
+				{Start: 4, End: 10, GbkLocationString: "join(5..10,complement(100..120))"},
+				{Start: 99, End: 120, Complement: true, GbkLocationString: "join(5..10,complement(100..120))"},
+			}},
+		},
+		{
+			name: "single position",
+			args: args{locationString: "467"},
+			want: Location{Start: 466, End: 467, GbkLocationString: "467"},
+		},
+		{
+			name: "site between two bases",
+			args: args{locationString: "123^124"},
+			want: Location{Start: 123, End: 123, Between: true, GbkLocationString: "123^124"},
+		},
+		{
+			name: "single base within a range",
+			args: args{locationString: "102.110"},
+			want: Location{Start: 101, End: 110, WithinRange: true, GbkLocationString: "102.110"},
+		},
+		{
+			name: "order",
+			args: args{locationString: "order(1..5,<10..>20)"},
+			want: Location{Order: true, FivePrimePartial: true, ThreePrimePartial: true, GbkLocationString: "order(1..5,<10..>20)", SubLocations: []Location{
+				{Start: 0, End: 5, GbkLocationString: "1..5"},
+				{Start: 9, End: 20, FivePrimePartial: true, ThreePrimePartial: true, GbkLocationString: "<10..>20"},
+			}},
+		},
+		{
+			name: "bond of single residues",
+			args: args{locationString: "bond(12,98)"},
+			want: Location{Bond: true, GbkLocationString: "bond(12,98)", SubLocations: []Location{
+				{Start: 11, End: 12, GbkLocationString: "12"},
+				{Start: 97, End: 98, GbkLocationString: "98"},
+			}},
+		},
+		{
+			name: "remote entry in a join",
+			args: args{locationString: "join(1..100,J00194.1:100..202)"},
+			want: Location{Join: true, GbkLocationString: "join(1..100,J00194.1:100..202)", SubLocations: []Location{
+				{Start: 0, End: 100, GbkLocationString: "1..100"},
+				{Start: 99, End: 202, RemoteAccession: "J00194.1", GbkLocationString: "J00194.1:100..202"},
+			}},
+		},
+		{
+			name: "legacy three prime partial",
+			args: args{locationString: "687..3158>"},
+			want: Location{Start: 686, End: 3158, ThreePrimePartial: true, GbkLocationString: "687..3158>"},
+		},
+		{
+			name:    "unbalanced parentheses",
+			args:    args{locationString: "join(1..2,"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown operator",
+			args:    args{locationString: "one-of(1..2,3..4)"},
+			wantErr: true,
+		},
+		{
+			name:    "too many separators",
+			args:    args{locationString: "1..2..3"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -663,6 +835,70 @@ func Test_parseLocation(t *testing.T) {
 	}
 }
 
+func TestLocationRoundTrip(t *testing.T) {
+	for _, locationString := range []string{
+		"467",
+		"340..565",
+		"<345..500",
+		"<1..888",
+		"1..>888",
+		"102.110",
+		"123^124",
+		"145^1",
+		"join(12..78,134..202)",
+		"complement(34..126)",
+		"complement(join(2691..4571,4918..5163))",
+		"join(complement(4918..5163),complement(2691..4571))",
+		"order(1..5,complement(10..20))",
+		"bond(12,98)",
+		"J00194.1:100..202",
+		"join(1..100,J00194.1:100..202)",
+	} {
+		location, err := ParseLocation(locationString)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %s", locationString, err)
+			continue
+		}
+		if got := BuildLocationString(location); got != locationString {
+			t.Errorf("BuildLocationString(ParseLocation(%s)) = %s", locationString, got)
+		}
+	}
+}
+
+func TestFeature_GetSequence_locationGrammar(t *testing.T) {
+	sequence := Genbank{Sequence: "atgcatgcat"}
+	tests := []struct {
+		location string
+		want     string
+		wantErr  error
+	}{
+		{location: "3", want: "g"},
+		{location: "3^4", want: ""},
+		{location: "10^1", want: ""},
+		{location: "order(1..2,complement(5..6))", want: "atat"},
+		{location: "bond(1,4)", want: "ac"},
+		{location: "2.4", wantErr: annotation.ErrUncertainLocation},
+		{location: "J00194.1:1..3", wantErr: annotation.ErrRemoteLocation},
+		{location: "join(1..3,J00194.1:1..3)", wantErr: annotation.ErrRemoteLocation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			location, err := ParseLocation(tt.location)
+			if err != nil {
+				t.Fatalf("Failed to parse location: %s", err)
+			}
+			feature := Feature{Location: location, ParentSequence: &sequence}
+			got, err := feature.GetSequence()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSequence() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetSequence() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_buildMetaString(t *testing.T) {
 	type args struct {
 		name string
@@ -693,7 +929,46 @@ func TestBuildLocationString(t *testing.T) {
 		args args
 		want string
 	}{
-		// TODO: Add test cases.
+		{
+			name: "range",
+			args: args{location: Location{Start: 4, End: 10}},
+			want: "5..10",
+		},
+		{
+			name: "partial range of a single base",
+			args: args{location: Location{Start: 4, End: 5, FivePrimePartial: true}},
+			want: "<5..5",
+		},
+		{
+			name: "complement of a single position",
+			args: args{location: Location{Start: 466, End: 467, Complement: true}},
+			want: "complement(467)",
+		},
+		{
+			name: "site between two bases",
+			args: args{location: Location{Start: 123, End: 123, Between: true}},
+			want: "123^124",
+		},
+		{
+			name: "single base within a range",
+			args: args{location: Location{Start: 101, End: 110, WithinRange: true}},
+			want: "102.110",
+		},
+		{
+			name: "order",
+			args: args{location: Location{Order: true, SubLocations: []Location{{Start: 0, End: 5}, {Start: 9, End: 20}}}},
+			want: "order(1..5,10..20)",
+		},
+		{
+			name: "bond",
+			args: args{location: Location{Bond: true, SubLocations: []Location{{Start: 11, End: 12}, {Start: 97, End: 98}}}},
+			want: "bond(12,98)",
+		},
+		{
+			name: "remote entry",
+			args: args{location: Location{Join: true, SubLocations: []Location{{Start: 0, End: 100}, {Start: 99, End: 202, RemoteAccession: "J00194.1"}}}},
+			want: "join(1..100,J00194.1:100..202)",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -704,6 +979,16 @@ func TestBuildLocationString(t *testing.T) {
 	}
 }
 
+func TestBuildLocationString_singleBaseRange(t *testing.T) {
+	location, err := ParseLocation("5..5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := BuildLocationString(location); got != "5" {
+		t.Errorf("BuildLocationString() of 5..5 = %q, want %q", got, "5")
+	}
+}
+
 func Test_generateWhiteSpace(t *testing.T) {
 	type args struct {
 		length int
@@ -749,6 +1034,182 @@ func TestBuildFeatureString(t *testing.T) {
 	assert.Equal(t, str, "     test type       gbk location\n")
 }
 
+func TestRepeatedQualifiers(t *testing.T) {
+	original, err := os.ReadFile("../../data/repeated_qualifiers.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence, err := Parse(bytes.NewReader(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cds := sequence.Features[2]
+	if diff := cmp.Diff([]string{"GI:16127995", "UniProtKB/Swiss-Prot:P0A9Q1"}, cds.QualifierValues("db_xref")); diff != "" {
+		t.Errorf("Unexpected db_xref values (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"first note", "second note, with p=0.05"}, cds.QualifierValues("note")); diff != "" {
+		t.Errorf("Unexpected note values (-want +got):\n%s", diff)
+	}
+	if got := cds.Qualifiers[len(cds.Qualifiers)-1]; got != (Qualifier{Key: "pseudo", Flag: true}) {
+		t.Errorf("The /pseudo qualifier at the end of the features was parsed as %+v", got)
+	}
+	if got := sequence.Features[1].Qualifiers[4]; got != (Qualifier{Key: "note"}) {
+		t.Errorf("The empty /note=\"\" qualifier was parsed as %+v", got)
+	}
+
+	// the qualifiers should be written out exactly as NCBI writes them.
+	built, _ := Build(sequence)
+	features := func(gbk []byte) string {
+		start := bytes.Index(gbk, []byte("FEATURES"))
+		end := bytes.Index(gbk, []byte("ORIGIN"))
+		return string(gbk[start:end])
+	}
+	if diff := cmp.Diff(features(original), features(built)); diff != "" {
+		t.Errorf("Build() changed the features (-original +built):\n%s", diff)
+	}
+}
+
+func TestBuild_ncbiFeatures(t *testing.T) {
+	// Build should write the features of NCBI files byte for byte, wrapping
+	// qualifiers the way NCBI does.
+	for _, path := range []string{"../../data/sample.gbk", "../../data/phix174.gb"} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			original, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sequence, err := Parse(bytes.NewReader(original))
+			if err != nil {
+				t.Fatal(err)
+			}
+			built, _ := Build(sequence)
+			features := func(gbk []byte) string {
+				start := bytes.Index(gbk, []byte("FEATURES"))
+				end := bytes.Index(gbk, []byte("BASE COUNT"))
+				if end < 0 {
+					end = bytes.Index(gbk, []byte("ORIGIN"))
+				}
+				return string(gbk[start:end])
+			}
+			if diff := cmp.Diff(features(original), features(built)); diff != "" {
+				t.Errorf("Build() changed the features (-original +built):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_wrapQualifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		qualifier string
+		want      []string
+	}{
+		{"short", `/gene="AXL2"`, []string{`/gene="AXL2"`}},
+		{"between words", `/note="plasma membrane glycoprotein"`, []string{`/note="plasma`, `membrane`, `glycoprotein"`}},
+		{"after a hyphen", `/product="ab-cdefghijklmnopq"`, []string{`/product="ab-`, `cdefghijklmnopq"`}},
+		{"anywhere", `/translation="MTQLQISLLLTATISLLHLVV"`, []string{`/translation="MTQL`, `QISLLLTATISLLHLVV"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, wrapQualifier(tt.qualifier, 18)); diff != "" {
+				t.Errorf("wrapQualifier() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFeature_qualifiers(t *testing.T) {
+	feature := Feature{Qualifiers: []Qualifier{
+		{Key: "gene", Value: "abcD"},
+		{Key: "db_xref", Value: "GeneID:1"},
+		{Key: "note", Value: "a note"},
+		{Key: "db_xref", Value: "GI:2"},
+	}}
+	if diff := cmp.Diff(map[string]string{"gene": "abcD", "db_xref": "GeneID:1", "note": "a note"}, feature.Attributes()); diff != "" {
+		t.Errorf("Attributes() mismatch (-want +got):\n%s", diff)
+	}
+
+	feature.AddQualifier("db_xref", "taxon:562")
+	if got := feature.QualifierValues("db_xref"); len(got) != 3 || got[2] != "taxon:562" {
+		t.Errorf("AddQualifier() did not append the qualifier, got %v", got)
+	}
+
+	feature.SetQualifier("db_xref", "GeneID:3")
+	feature.SetQualifier("product", "AbcD")
+	want := []Qualifier{
+		{Key: "gene", Value: "abcD"},
+		{Key: "db_xref", Value: "GeneID:3"},
+		{Key: "note", Value: "a note"},
+		{Key: "product", Value: "AbcD"},
+	}
+	if diff := cmp.Diff(want, feature.Qualifiers); diff != "" {
+		t.Errorf("SetQualifier() mismatch (-want +got):\n%s", diff)
+	}
+
+	feature.RemoveQualifier("note")
+	if _, ok := feature.Qualifier("note"); ok || len(feature.Qualifiers) != 3 {
+		t.Errorf("RemoveQualifier() did not remove the note, got %v", feature.Qualifiers)
+	}
+
+	fromMap := QualifiersFromMap(map[string]string{"product": "AbcD", "gene": "abcD"})
+	if diff := cmp.Diff([]Qualifier{{Key: "gene", Value: "abcD"}, {Key: "product", Value: "AbcD"}}, fromMap); diff != "" {
+		t.Errorf("QualifiersFromMap() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenPept(t *testing.T) {
+	protein, err := Read("../../data/NP_000509.gp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locus := protein.Meta.Locus
+	if locus.ResidueType != AminoAcidResidues || locus.SequenceLength != "147" || locus.SequenceCoding != "aa" || locus.MoleculeType != "" {
+		t.Errorf("Unexpected locus %+v", locus)
+	}
+	if protein.Meta.DBSource != "REFSEQ: accession NM_000518.5" {
+		t.Errorf("Unexpected DBSOURCE %q", protein.Meta.DBSource)
+	}
+	if _, ok := protein.Meta.Other["DBSOURCE"]; ok {
+		t.Errorf("DBSOURCE should not be stored in Other")
+	}
+
+	wantSequences := map[string]string{
+		"Protein": protein.Sequence,
+		"Region":  protein.Sequence[2:],
+		"Site":    "hh",
+	}
+	for _, feature := range protein.Features {
+		want, ok := wantSequences[feature.Type]
+		if !ok {
+			continue
+		}
+		got, err := feature.GetSequence()
+		if err != nil {
+			t.Errorf("Failed to get the sequence of the %s feature: %s", feature.Type, err)
+		}
+		if got != want {
+			t.Errorf("%s feature sequence = %q, want %q", feature.Type, got, want)
+		}
+	}
+
+	complemented := Feature{Type: "Site", Location: Location{Start: 1, End: 5, Complement: true}, ParentSequence: &protein}
+	if _, err := complemented.GetSequence(); err == nil {
+		t.Errorf("GetSequence() of a complemented protein feature should fail")
+	}
+
+	built, _ := Build(protein)
+	if !bytes.HasPrefix(built, []byte("LOCUS       NP_000509     147 aa")) || !bytes.Contains(built, []byte("\nDBSOURCE    REFSEQ: accession NM_000518.5\n")) {
+		t.Errorf("Build() did not write a GenPept header, got:\n%s", built[:bytes.Index(built, []byte("KEYWORDS"))])
+	}
+	rebuilt, err := Parse(bytes.NewReader(built))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(protein, rebuilt, cmpopts.IgnoreFields(Feature{}, "ParentSequence")); diff != "" {
+		t.Errorf("Parsing the output of Build() changed the record (-want +got):\n%s", diff)
+	}
+}
+
 func TestParse_error(t *testing.T) {
 	parseMultiErr := errors.New("parse error")
 	oldParseMultiNthFn := parseMultiNthFn
@@ -781,15 +1242,15 @@ func TestParseReferences_error(t *testing.T) {
 
 func TestIssue303Regression(t *testing.T) {
 	seq, _ := Read("../../data/puc19_303_regression.gbk")
-	expectedAttribute := "16S rRNA(adenine(1518)-N(6)/adenine(1519)-N(6))-dimethyltransferase"
+	expectedAttribute := "16S rRNA (adenine(1518)-N(6)/adenine(1519)-N(6))-dimethyltransferase"
 	for _, feature := range seq.Features {
-		if feature.Attributes["locus_tag"] == "JCVISYN3A_0004" && feature.Type == "CDS" {
-			if feature.Attributes["product"] != expectedAttribute {
-				t.Errorf("Failed to get proper expected attribute. Got: %s Expected: %s", feature.Attributes["product"], expectedAttribute)
+		if feature.Attributes()["locus_tag"] == "JCVISYN3A_0004" && feature.Type == "CDS" {
+			if feature.Attributes()["product"] != expectedAttribute {
+				t.Errorf("Failed to get proper expected attribute. Got: %s Expected: %s", feature.Attributes()["product"], expectedAttribute)
 			}
 		}
-		if feature.Attributes["locus_tag"] == "JCVISYN3A_0051" && feature.Type == "CDS" {
-			if _, ok := feature.Attributes["pseudo"]; !ok {
+		if feature.Attributes()["locus_tag"] == "JCVISYN3A_0051" && feature.Type == "CDS" {
+			if _, ok := feature.Qualifier("pseudo"); !ok {
 				t.Errorf("pseudo should be in attributes")
 			}
 		}
@@ -802,3 +1263,103 @@ func TestConsortiumRegression(t *testing.T) {
 		t.Errorf("Failed to read consrtm. Got err: %s", err)
 	}
 }
+
+func TestRecordConversion(t *testing.T) {
+	gbk, err := Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := gbk.Records()
+	if len(records) != 1 {
+		t.Fatalf("Records() returned %d records, want 1", len(records))
+	}
+	converted := FromRecord(records[0])
+	if converted.Sequence != gbk.Sequence || converted.Meta.Locus.Name != gbk.Meta.Locus.Name || converted.Meta.Definition != gbk.Meta.Definition {
+		t.Errorf("FromRecord() did not keep the sequence, name and definition")
+	}
+	if !converted.Meta.Locus.Circular {
+		t.Errorf("FromRecord() did not keep the topology")
+	}
+	ignore := []cmp.Option{
+		cmpopts.IgnoreFields(Feature{}, "ParentSequence"),
+		cmpopts.IgnoreFields(Location{}, "GbkLocationString"),
+		cmpopts.EquateEmpty(),
+	}
+	if diff := cmp.Diff(gbk.Meta, converted.Meta, ignore...); diff != "" {
+		t.Errorf("FromRecord() changed the meta (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(gbk.Features, converted.Features, ignore...); diff != "" {
+		t.Errorf("FromRecord() changed the features (-want +got):\n%s", diff)
+	}
+	for index, feature := range converted.Features {
+		if feature.Location.Start > feature.Location.End {
+			continue // origin spanning features have no sequence.
+		}
+		got, err := feature.GetSequence()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := gbk.Features[index].GetSequence()
+		if got != want {
+			t.Errorf("GetSequence() of converted feature %d = %s, want %s", index, got, want)
+		}
+	}
+}
+
+func TestRecordConversion_throughOtherFormats(t *testing.T) {
+	gbk, err := Read("../../data/sample.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A value with a comma and qualifiers that aren't sorted by key are kept
+	// by formats that store qualifiers in a map.
+	gbk.Features[1].AddQualifier("note", "first, with a comma")
+	gbk.Features[1].AddQualifier("db_xref", "GeneID:1")
+	gbk.Features[1].AddQualifier("note", "second")
+
+	ignore := []cmp.Option{
+		cmpopts.IgnoreFields(Feature{}, "ParentSequence"),
+		cmpopts.IgnoreFields(Location{}, "GbkLocationString"),
+		cmpopts.EquateEmpty(),
+	}
+	throughGff := FromRecord(gff.FromRecords(gbk.Records()...).Records()[0])
+	if diff := cmp.Diff(gbk, throughGff, ignore...); diff != "" {
+		t.Errorf("genbank -> gff -> genbank changed the genbank (-want +got):\n%s", diff)
+	}
+	throughPolyjson := FromRecord(polyjson.FromRecord(gbk.Records()[0]).Records()[0])
+	if diff := cmp.Diff(gbk, throughPolyjson, ignore...); diff != "" {
+		t.Errorf("genbank -> polyjson -> genbank changed the genbank (-want +got):\n%s", diff)
+	}
+
+	built, err := Build(gbk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtThroughGff, err := Build(throughGff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(built), string(builtThroughGff)); diff != "" {
+		t.Errorf("Build() of genbank -> gff -> genbank differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecordConversion_gffThroughGenbank(t *testing.T) {
+	// The source, score and phase of gff features are kept by genbank.
+	sequence, err := gff.Read("../gff/data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var converted []annotation.Record
+	for _, record := range sequence.Records() {
+		converted = append(converted, FromRecord(record).Records()...)
+	}
+	original, err := os.ReadFile("../gff/data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, _ := gff.Build(gff.FromRecords(converted...))
+	if diff := cmp.Diff(string(original), string(built)); diff != "" {
+		t.Errorf("gff -> genbank -> gff differs from the original (-want +got):\n%s", diff)
+	}
+}
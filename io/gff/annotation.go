@@ -0,0 +1,198 @@
+package gff
+
+import (
+	"github.com/bebop/poly/io/annotation"
+	"github.com/bebop/poly/io/fasta"
+)
+
+/******************************************************************************
+
+Conversion to and from annotation.Record begins here.
+
+******************************************************************************/
+
+// featureFields holds the fields of a gff feature that other formats have no
+// place for. It is kept in the Extras of an annotation.Feature, with the
+// fields that FromRecords fills in by itself left empty.
+type featureFields struct {
+	Source string
+	Score  string
+	Strand string
+	Phase  string
+}
+
+// Records returns an annotation.Record for every seqid of the Gff, in the
+// order of the ##FASTA section, which makes Gff an
+// annotation.AnnotatedSequence. A Gff without a ##FASTA section, like one
+// that was built by hand, returns a single Record named after Meta.Name.
+//
+// Features on the - strand get a complement location. The source, score,
+// strand and phase of a feature are kept in its Extras unless they are empty
+// or, for the strand, follow from its location.
+func (sequence Gff) Records() []annotation.Record {
+	var records []annotation.Record
+	recordIndexes := make(map[string]int)
+	if len(sequence.Sequences) == 0 {
+		records = append(records, annotation.Record{
+			Name:                 sequence.Meta.Name,
+			Sequence:             sequence.Sequence,
+			SequenceHash:         sequence.Meta.SequenceHash,
+			SequenceHashFunction: sequence.Meta.SequenceHashFunction,
+		})
+	}
+	for _, fastaRecord := range sequence.Sequences {
+		seqid := recordSeqid(fastaRecord)
+		description := ""
+		if len(fastaRecord.Name) > len(seqid) {
+			description = fastaRecord.Name[len(seqid)+1:]
+		}
+		recordIndexes[seqid] = len(records)
+		records = append(records, annotation.Record{Name: seqid, Description: description, Sequence: fastaRecord.Sequence})
+	}
+	if len(records) > 0 && records[0].Name == sequence.Meta.Name {
+		records[0].SequenceHash = sequence.Meta.SequenceHash
+		records[0].SequenceHashFunction = sequence.Meta.SequenceHashFunction
+	}
+	for _, region := range sequence.Meta.SequenceRegions {
+		if recordIndex, ok := recordIndexes[region.Seqid]; ok {
+			records[recordIndex].Extras = annotation.CopyExtras(region.Extras)
+		}
+	}
+
+	for _, feature := range sequence.Features {
+		recordIndex, ok := recordIndexes[feature.Name]
+		if !ok {
+			if len(sequence.Sequences) == 0 {
+				recordIndex = 0
+			} else {
+				// features on a seqid without a ##FASTA record get a Record of their own.
+				recordIndex = len(records)
+				recordIndexes[feature.Name] = recordIndex
+				records = append(records, annotation.Record{Name: feature.Name})
+			}
+		}
+		qualifiers, extras := annotation.MapQualifiers(feature.Attributes, feature.Extras, true)
+		location := toAnnotationLocation(feature.Location)
+		location.Complement = location.Complement || feature.Strand == "-"
+		fields := featureFields{Source: feature.Source, Score: feature.Score, Strand: feature.Strand, Phase: feature.Phase}
+		if fields.Score == "." {
+			fields.Score = ""
+		}
+		if fields.Phase == "." {
+			fields.Phase = ""
+		}
+		if fields.Strand == "+" && !location.Complement || fields.Strand == "-" && location.Complement {
+			fields.Strand = ""
+		}
+		if fields != (featureFields{}) {
+			extras = append(extras, fields)
+		}
+		records[recordIndex].Features = append(records[recordIndex].Features, annotation.Feature{
+			Type:       feature.Type,
+			Qualifiers: qualifiers,
+			Location:   location,
+			Extras:     extras,
+		})
+	}
+	return records
+}
+
+// FromRecords converts one or more annotation.Records, for example those of a
+// genbank or polyjson file, to a single Gff. Every Record becomes a seqid with
+// its own ##sequence-region pragma and ##FASTA record. Features without a
+// strand, score or phase, like those of a genbank file, get "." or a strand
+// derived from their location. Fields that gff has no place for are kept in
+// the Extras of the features and sequence regions.
+func FromRecords(records ...annotation.Record) Gff {
+	var sequence Gff
+	sequence.Meta.Version = "3"
+	for recordIndex, record := range records {
+		sequence.Meta.SequenceRegions = append(sequence.Meta.SequenceRegions, SequenceRegion{Seqid: record.Name, Start: 1, End: len(record.Sequence), Extras: annotation.CopyExtras(record.Extras)})
+		name := record.Name
+		if record.Description != "" {
+			name += " " + record.Description
+		}
+		sequence.Sequences = append(sequence.Sequences, fasta.Fasta{Name: name, Sequence: record.Sequence})
+		if recordIndex == 0 {
+			sequence.Sequence = record.Sequence
+			sequence.Meta.Name = record.Name
+			sequence.Meta.RegionStart = 1
+			sequence.Meta.RegionEnd = len(record.Sequence)
+			sequence.Meta.Size = sequence.Meta.RegionEnd - sequence.Meta.RegionStart
+			sequence.Meta.SequenceHash = record.SequenceHash
+			sequence.Meta.SequenceHashFunction = record.SequenceHashFunction
+		}
+	}
+	if len(sequence.Sequences) > 0 {
+		sequence.Meta.Description = ">" + sequence.Sequences[len(sequence.Sequences)-1].Name
+	}
+	for _, record := range records {
+		for _, recordFeature := range record.Features {
+			fields, extras, _ := annotation.TakeExtra[featureFields](recordFeature.Extras)
+			attributes, extras := annotation.QualifierMap(recordFeature.Qualifiers, extras, true)
+			feature := Feature{
+				Name:       record.Name,
+				Source:     fields.Source,
+				Type:       recordFeature.Type,
+				Score:      orMissing(fields.Score),
+				Strand:     fields.Strand,
+				Phase:      orMissing(fields.Phase),
+				Attributes: attributes,
+				Location:   fromAnnotationLocation(recordFeature.Location),
+				Extras:     extras,
+			}
+			if feature.Strand == "" {
+				feature.Strand = "+"
+				if feature.Location.Complement {
+					feature.Strand = "-"
+				}
+			}
+			// The strand of a gff feature takes the place of a complement location.
+			if feature.Strand == "-" {
+				feature.Location.Complement = false
+			}
+			_ = sequence.AddFeature(&feature)
+		}
+	}
+	return sequence
+}
+
+// orMissing returns value, or "." if value is empty.
+func orMissing(value string) string {
+	if value == "" {
+		return "."
+	}
+	return value
+}
+
+// toAnnotationLocation converts a Location to an annotation.Location.
+func toAnnotationLocation(location Location) annotation.Location {
+	converted := annotation.Location{
+		Start:             location.Start,
+		End:               location.End,
+		Complement:        location.Complement,
+		Join:              location.Join,
+		FivePrimePartial:  location.FivePrimePartial,
+		ThreePrimePartial: location.ThreePrimePartial,
+	}
+	for _, subLocation := range location.SubLocations {
+		converted.SubLocations = append(converted.SubLocations, toAnnotationLocation(subLocation))
+	}
+	return converted
+}
+
+// fromAnnotationLocation converts an annotation.Location to a Location.
+func fromAnnotationLocation(location annotation.Location) Location {
+	converted := Location{
+		Start:             location.Start,
+		End:               location.End,
+		Complement:        location.Complement,
+		Join:              location.Join,
+		FivePrimePartial:  location.FivePrimePartial,
+		ThreePrimePartial: location.ThreePrimePartial,
+	}
+	for _, subLocation := range location.SubLocations {
+		converted.SubLocations = append(converted.SubLocations, fromAnnotationLocation(subLocation))
+	}
+	return converted
+}
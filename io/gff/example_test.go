@@ -65,6 +65,15 @@ func ExampleWrite() {
 	// Output: U00096.3
 }
 
+func ExampleGff_SplicedSequence() {
+	sequence, _ := gff.Read("data/multi.gff3")
+
+	// the CDS of gene2 on chr2 is split over two exons on the minus strand.
+	codingSequence, _ := sequence.SplicedSequence("mRNA2a", "CDS")
+	fmt.Println(codingSequence)
+	// Output: ATGTCTGGCAAACCGGAAGGTCTGATCTGGTAG
+}
+
 func ExampleGff_AddFeature() {
 	// Sequence for greenflourescent protein (GFP) that we're using as test data for this example.
 	gfpSequence := "ATGGCTAGCAAAGGAGAAGAACTTTTCACTGGAGTTGTCCCAATTCTTGTTGAATTAGATGGTGATGTTAATGGGCACAAATTTTCTGTCAGTGGAGAGGGTGAAGGTGATGCTACATACGGAAAGCTTACCCTTAAATTTATTTGCACTACTGGAAAACTACCTGTTCCATGGCCAACACTTGTCACTACTTTCTCTTATGGTGTTCAATGCTTTTCCCGTTATCCGGATCATATGAAACGGCATGACTTTTTCAAGAGTGCCATGCCCGAAGGTTATGTACAGGAACGCACTATATCTTTCAAAGATGACGGGAACTACAAGACGCGTGCTGAAGTCAAGTTTGAAGGTGATACCCTTGTTAATCGTATCGAGTTAAAAGGTATTGATTTTAAAGAAGATGGAAACATTCTCGGACACAAACTCGAGTACAACTATAACTCACACAATGTATACATCACGGCAGACAAACAAAAGAATGGAATCAAAGCTAACTTCAAAATTCGCCACAACATTGAAGATGGATCCGTTCAACTAGCAGACCATTATCAACAAAATACTCCAATTGGCGATGGCCCTGTCCTTTTACCAGACAACCATTACCTGTCGACACAATCTGCCCTTTCGAAAGATCCCAACGAAAAGCGTGACCACATGGTCCTTCTTGAGTTTGTAACTGCTGCTGGGATTACACATGGCATGGATGAGCTCTACAAATAA"
@@ -13,7 +13,7 @@ package gff
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -22,7 +22,8 @@ import (
 
 	"lukechampine.com/blake3"
 
-	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/io/annotation"
+	"github.com/bebop/poly/io/fasta"
 )
 
 var (
@@ -32,36 +33,61 @@ var (
 )
 
 // Gff is a struct that represents a gff file.
+//
+// A gff file may describe many sequences (seqids), each with its own
+// ##sequence-region pragma and its own record in the ##FASTA section.
+// Sequences and Meta.SequenceRegions hold all of them and are what Build
+// writes. Sequence, Meta.Name, Meta.RegionStart and Meta.RegionEnd describe a
+// single sequence, and are only used by Build if Sequences or
+// Meta.SequenceRegions are empty. Otherwise they have to be empty or match
+// the first seqid, like Parse sets them.
 type Gff struct {
-	Meta     Meta
-	Features []Feature // will need a GetFeatures interface to standardize
-	Sequence string
+	Meta      Meta
+	Features  []Feature // will need a GetFeatures interface to standardize
+	Sequence  string
+	Sequences []fasta.Fasta
 }
 
 // Meta holds meta information about a gff file.
 type Meta struct {
-	Name                 string   `json:"name"`
-	Description          string   `json:"description"`
-	Version              string   `json:"gff_version"`
-	RegionStart          int      `json:"region_start"`
-	RegionEnd            int      `json:"region_end"`
-	Size                 int      `json:"size"`
-	SequenceHash         string   `json:"sequence_hash"`
-	SequenceHashFunction string   `json:"hash_function"`
-	CheckSum             [32]byte `json:"checkSum"` // blake3 checksum of the parsed file itself. Useful for if you want to check if incoming genbank/gff files are different.
+	Name                 string           `json:"name"`
+	Description          string           `json:"description"`
+	Version              string           `json:"gff_version"`
+	RegionStart          int              `json:"region_start"`
+	RegionEnd            int              `json:"region_end"`
+	Size                 int              `json:"size"`
+	SequenceHash         string           `json:"sequence_hash"`
+	SequenceHashFunction string           `json:"hash_function"`
+	CheckSum             [32]byte         `json:"checkSum"` // blake3 checksum of the parsed file itself. Useful for if you want to check if incoming genbank/gff files are different.
+	SequenceRegions      []SequenceRegion `json:"sequence_regions"`
+}
+
+// SequenceRegion holds the contents of a ##sequence-region pragma. Start and
+// End are 1-based and inclusive, just like in the file.
+type SequenceRegion struct {
+	Seqid string `json:"seqid"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Extras holds metadata of other formats that gff has no place for, like
+	// the references of a genbank file, kept when converting from an
+	// annotation.Record.
+	Extras []any `json:"-"`
 }
 
 // Feature is a struct that represents a feature in a gff file.
 type Feature struct {
-	Name           string            `json:"name"`
-	Source         string            `json:"source"`
-	Type           string            `json:"type"`
-	Score          string            `json:"score"`
-	Strand         string            `json:"strand"`
-	Phase          string            `json:"phase"`
-	Attributes     map[string]string `json:"attributes"`
-	Location       Location          `json:"location"`
-	ParentSequence *Gff              `json:"-"`
+	Name       string            `json:"name"`
+	Source     string            `json:"source"`
+	Type       string            `json:"type"`
+	Score      string            `json:"score"`
+	Strand     string            `json:"strand"`
+	Phase      string            `json:"phase"`
+	Attributes map[string]string `json:"attributes"`
+	Location   Location          `json:"location"`
+	// Extras holds fields of other formats that gff has no place for, kept
+	// when converting from an annotation.Record.
+	Extras         []any `json:"-"`
+	ParentSequence *Gff  `json:"-"`
 }
 
 // Location is a struct that represents a location in a gff file.
@@ -85,32 +111,34 @@ func (sequence *Gff) AddFeature(feature *Feature) error {
 
 // GetSequence takes a feature and returns a sequence string for that feature.
 func (feature Feature) GetSequence() (string, error) {
-	return getFeatureSequence(feature, feature.Location)
+	sequence, err := feature.ParentSequence.SeqidSequence(feature.Name)
+	if err != nil {
+		return "", err
+	}
+	return annotation.LocationSequence(sequence, toAnnotationLocation(feature.Location))
 }
 
-// getFeatureSequence takes a feature and location object and returns a sequence string.
-func getFeatureSequence(feature Feature, location Location) (string, error) {
-	var sequenceBuffer bytes.Buffer
-	var sequenceString string
-	parentSequence := feature.ParentSequence.Sequence
-
-	if len(location.SubLocations) == 0 {
-		sequenceBuffer.WriteString(parentSequence[location.Start:location.End])
-	} else {
-		for _, subLocation := range location.SubLocations {
-			sequence, _ := getFeatureSequence(feature, subLocation)
-			sequenceBuffer.WriteString(sequence)
-		}
+// SeqidSequence returns the sequence of the ##FASTA record of a seqid. A Gff
+// without any ##FASTA records, like one that was built by hand, describes a
+// single sequence, for which it returns sequence.Sequence. It returns an
+// error for seqids without a ##FASTA record in other Gffs.
+func (sequence *Gff) SeqidSequence(seqid string) (string, error) {
+	if len(sequence.Sequences) == 0 {
+		return sequence.Sequence, nil
 	}
-
-	// reverse complements resulting string if needed.
-	if location.Complement {
-		sequenceString = transform.ReverseComplement(sequenceBuffer.String())
-	} else {
-		sequenceString = sequenceBuffer.String()
+	for _, record := range sequence.Sequences {
+		if recordSeqid(record) == seqid {
+			return record.Sequence, nil
+		}
 	}
+	return "", fmt.Errorf("no sequence for seqid %q", seqid)
+}
 
-	return sequenceString, nil
+// recordSeqid returns the seqid of a fasta record, which is the name up to
+// the first whitespace.
+func recordSeqid(record fasta.Fasta) string {
+	seqid, _, _ := strings.Cut(record.Name, " ")
+	return seqid
 }
 
 // Parse Takes in a string representing a gffv3 file and parses it into an Sequence object.
@@ -122,79 +150,62 @@ func Parse(file io.Reader) (Gff, error) {
 
 	gffString := string(fileBytes)
 	gff := Gff{}
+	meta := Meta{}
 
 	// Add the CheckSum to sequence (blake3)
-	gff.Meta.CheckSum = blake3.Sum256(fileBytes)
+	meta.CheckSum = blake3.Sum256(fileBytes)
 
 	lines := strings.Split(gffString, "\n")
-	regionStringArray, endOfMetaInfo, err := extractInfoFromField(lines, "##sequence-region")
-	metaString := lines[0:endOfMetaInfo]
-	versionString := metaString[0]
-	if err != nil {
-		return Gff{}, err
-	}
-	// get name for general meta
-	meta := Meta{}
-	meta.Name = regionStringArray[1] // Formally region name, but changed to name here for generality/interoperability.
-
-	// get meta info only specific to GFF files
-	meta.Version = strings.Split(versionString, " ")[1]
-	meta.RegionStart, err = atoiFn(regionStringArray[2])
-	if err != nil {
-		return Gff{}, err
-	}
-	meta.RegionEnd, err = atoiFn(regionStringArray[3])
-	if err != nil {
-		return Gff{}, err
-	}
-	meta.Size = meta.RegionEnd - meta.RegionStart
-
 	var sequenceBuffer bytes.Buffer
 	fastaFlag := false
-	for _, line := range lines {
-		if line == "##FASTA" {
+	for lineIndex, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		lineNumber := lineIndex + 1
+		switch {
+		case line == "##FASTA":
 			fastaFlag = true
-		} else if len(line) == 0 {
+		case len(line) == 0:
 			continue
-		} else if line[0:2] == "##" || line[0:2] == "#!" {
-			continue
-		} else if fastaFlag && line[0:1] != ">" {
-			// sequence.Sequence = sequence.Sequence + line
+		case line[0] == '>':
+			// the ##FASTA pragma is optional if the fasta section starts with a record.
+			if fastaFlag && len(gff.Sequences) > 0 {
+				gff.Sequences[len(gff.Sequences)-1].Sequence = sequenceBuffer.String()
+			}
+			fastaFlag = true
+			sequenceBuffer.Reset()
+			gff.Sequences = append(gff.Sequences, fasta.Fasta{Name: line[1:]})
+		case fastaFlag:
+			if len(gff.Sequences) == 0 {
+				return Gff{}, fmt.Errorf("Error on line %d: sequence found before the first fasta name", lineNumber)
+			}
 			sequenceBuffer.WriteString(line)
-		} else if fastaFlag && line[0:1] == ">" {
-			gff.Meta.Description = line
-		} else {
-			record := Feature{}
-			fields := strings.Split(line, "\t")
-			record.Name = fields[0]
-			record.Source = fields[1]
-			record.Type = fields[2]
-
-			// Indexing starts at 1 for gff so we need to shift down for Sequence 0 index.
-			record.Location.Start, err = atoiFn(fields[3])
+		case strings.HasPrefix(line, "##gff-version"):
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return Gff{}, fmt.Errorf("Error on line %d: missing gff version", lineNumber)
+			}
+			meta.Version = fields[1]
+		case strings.HasPrefix(line, "##sequence-region"):
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				return Gff{}, fmt.Errorf("Error on line %d: expected ##sequence-region seqid start end", lineNumber)
+			}
+			region := SequenceRegion{Seqid: fields[1]}
+			region.Start, err = atoiFn(fields[2])
 			if err != nil {
 				return Gff{}, err
 			}
-
-			record.Location.Start--
-			record.Location.End, err = atoiFn(fields[4])
+			region.End, err = atoiFn(fields[3])
 			if err != nil {
 				return Gff{}, err
 			}
-
-			record.Score = fields[5]
-			record.Strand = fields[6]
-			record.Phase = fields[7]
-			record.Attributes = make(map[string]string)
-			attributes := fields[8]
-			// var eqIndex int
-			attributeSlice := strings.Split(attributes, ";")
-
-			for _, attribute := range attributeSlice {
-				attributeSplit := strings.Split(attribute, "=")
-				key := attributeSplit[0]
-				value := attributeSplit[1]
-				record.Attributes[key] = value
+			meta.SequenceRegions = append(meta.SequenceRegions, region)
+		case line[0] == '#':
+			continue
+		default:
+			record, err := parseFeature(line)
+			if err != nil {
+				return Gff{}, fmt.Errorf("Error on line %d: %w", lineNumber, err)
 			}
 			err = gff.AddFeature(&record)
 			if err != nil {
@@ -202,36 +213,80 @@ func Parse(file io.Reader) (Gff, error) {
 			}
 		}
 	}
-	gff.Sequence = sequenceBuffer.String()
+	if len(gff.Sequences) > 0 {
+		gff.Sequences[len(gff.Sequences)-1].Sequence = sequenceBuffer.String()
+		gff.Sequence = gff.Sequences[0].Sequence
+		// Description holds the last header line of the ##FASTA section, like
+		// it always has.
+		meta.Description = ">" + gff.Sequences[len(gff.Sequences)-1].Name
+	}
+
+	// get name for general meta
+	if len(meta.SequenceRegions) > 0 {
+		region := meta.SequenceRegions[0]
+		meta.Name = region.Seqid // Formally region name, but changed to name here for generality/interoperability.
+		meta.RegionStart = region.Start
+		meta.RegionEnd = region.End
+		meta.Size = meta.RegionEnd - meta.RegionStart
+	} else if len(gff.Sequences) > 0 {
+		meta.Name = recordSeqid(gff.Sequences[0])
+	}
 	gff.Meta = meta
 
-	return gff, err
+	return gff, nil
 }
 
-// regionString takes in the lines array,fieldName that is needed in gff file, and
-// returns the region containing fieldName if found
-// throws error if not found
-func extractInfoFromField(lines []string, fieldName string) ([]string, int, error) {
-	index := 0
-	endOfMetaInfo := 0
-	for lineIndex, line := range lines {
-		if strings.Contains(line, "#") {
-			if strings.Contains(line, fieldName) {
-				index = lineIndex
-			}
+// parseFeature parses a single tab separated feature line.
+func parseFeature(line string) (Feature, error) {
+	record := Feature{}
+	fields := strings.Split(line, "\t")
+	if len(fields) != 9 {
+		return Feature{}, fmt.Errorf("expected 9 tab separated fields, got %d", len(fields))
+	}
+	record.Name = fields[0]
+	record.Source = fields[1]
+	record.Type = fields[2]
+
+	// Indexing starts at 1 for gff so we need to shift down for Sequence 0 index.
+	var err error
+	record.Location.Start, err = atoiFn(fields[3])
+	if err != nil {
+		return Feature{}, err
+	}
+
+	record.Location.Start--
+	record.Location.End, err = atoiFn(fields[4])
+	if err != nil {
+		return Feature{}, err
+	}
+
+	record.Score = fields[5]
+	record.Strand = fields[6]
+	record.Phase = fields[7]
+	record.Attributes = make(map[string]string)
+	attributes := fields[8]
+	attributeSlice := strings.Split(attributes, ";")
+
+	for _, attribute := range attributeSlice {
+		if attribute == "" || attribute == "." {
 			continue
 		}
-		endOfMetaInfo = lineIndex
-		break
-	}
-	if index == 0 && fieldName != "gff-version" {
-		return nil, 0, errors.New("the given file does not have any meta information")
+		key, value, found := strings.Cut(attribute, "=")
+		if !found {
+			return Feature{}, fmt.Errorf("attribute '%s' is not a key=value pair", attribute)
+		}
+		record.Attributes[key] = value
 	}
-	return strings.Split(lines[index], " "), endOfMetaInfo, nil
+	return record, nil
 }
 
 // Build takes an Annotated sequence and returns a byte array representing a gff to be written out.
+// It returns an error if the single sequence fields of the Gff don't match
+// its Sequences or Meta.SequenceRegions.
 func Build(sequence Gff) ([]byte, error) {
+	if err := sequence.checkSingleSequence(); err != nil {
+		return nil, err
+	}
 	var gffBuffer bytes.Buffer
 
 	versionString := "##gff-version 3 \n"
@@ -253,8 +308,14 @@ func Build(sequence Gff) ([]byte, error) {
 		start = strconv.Itoa(sequence.Meta.RegionStart)
 	}
 
-	regionString := "##sequence-region " + name + " " + start + " " + end + "\n"
-	gffBuffer.WriteString(regionString)
+	if len(sequence.Meta.SequenceRegions) == 0 {
+		regionString := "##sequence-region " + name + " " + start + " " + end + "\n"
+		gffBuffer.WriteString(regionString)
+	}
+	for _, region := range sequence.Meta.SequenceRegions {
+		regionString := "##sequence-region " + region.Seqid + " " + strconv.Itoa(region.Start) + " " + strconv.Itoa(region.End) + "\n"
+		gffBuffer.WriteString(regionString)
+	}
 
 	for _, feature := range sequence.Features {
 		var featureString string
@@ -299,21 +360,54 @@ func Build(sequence Gff) ([]byte, error) {
 
 	gffBuffer.WriteString("###\n")
 	gffBuffer.WriteString("##FASTA\n")
-	gffBuffer.WriteString(">" + sequence.Meta.Name + "\n")
-
-	for letterIndex, letter := range sequence.Sequence {
-		letterIndex++
-		if letterIndex%70 == 0 && letterIndex != 0 && letterIndex != sequence.Meta.RegionEnd {
-			gffBuffer.WriteRune(letter)
-			gffBuffer.WriteString("\n")
-		} else {
-			gffBuffer.WriteRune(letter)
+
+	records := sequence.Sequences
+	if len(records) == 0 {
+		records = []fasta.Fasta{{Name: sequence.Meta.Name, Sequence: sequence.Sequence}}
+	}
+	for _, record := range records {
+		gffBuffer.WriteString(">" + record.Name + "\n")
+		for letterIndex, letter := range record.Sequence {
+			letterIndex++
+			if letterIndex%70 == 0 && letterIndex != len(record.Sequence) {
+				gffBuffer.WriteRune(letter)
+				gffBuffer.WriteString("\n")
+			} else {
+				gffBuffer.WriteRune(letter)
+			}
 		}
+		gffBuffer.WriteString("\n")
 	}
-	gffBuffer.WriteString("\n")
 	return gffBuffer.Bytes(), nil
 }
 
+// checkSingleSequence returns an error if the fields describing a single
+// sequence are set but don't match the first seqid of Sequences or
+// Meta.SequenceRegions, which Build writes instead.
+func (sequence Gff) checkSingleSequence() error {
+	if len(sequence.Sequences) > 0 && sequence.Sequence != "" && sequence.Sequence != sequence.Sequences[0].Sequence {
+		return fmt.Errorf("Sequence does not match the first of Sequences, %s", sequence.Sequences[0].Name)
+	}
+	seqid := ""
+	switch {
+	case len(sequence.Meta.SequenceRegions) > 0:
+		region := sequence.Meta.SequenceRegions[0]
+		seqid = region.Seqid
+		if sequence.Meta.RegionStart != 0 && sequence.Meta.RegionStart != region.Start {
+			return fmt.Errorf("Meta.RegionStart %d does not match the start %d of sequence region %s", sequence.Meta.RegionStart, region.Start, region.Seqid)
+		}
+		if sequence.Meta.RegionEnd != 0 && sequence.Meta.RegionEnd != region.End {
+			return fmt.Errorf("Meta.RegionEnd %d does not match the end %d of sequence region %s", sequence.Meta.RegionEnd, region.End, region.Seqid)
+		}
+	case len(sequence.Sequences) > 0:
+		seqid = recordSeqid(sequence.Sequences[0])
+	}
+	if seqid != "" && sequence.Meta.Name != "" && sequence.Meta.Name != seqid {
+		return fmt.Errorf("Meta.Name %s does not match the first seqid %s", sequence.Meta.Name, seqid)
+	}
+	return nil
+}
+
 // Read takes in a filepath for a .gffv3 file and parses it into an Annotated poly.Sequence struct.
 func Read(path string) (Gff, error) {
 	file, err := openFn(path)
@@ -327,6 +421,9 @@ func Read(path string) (Gff, error) {
 
 // Write takes an poly.Sequence struct and a path string and writes out a gff to that path.
 func Write(sequence Gff, path string) error {
-	gff, _ := Build(sequence)
+	gff, err := Build(sequence)
+	if err != nil {
+		return err
+	}
 	return os.WriteFile(path, gff, 0644)
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bebop/poly/transform"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pmezard/go-difflib/difflib"
@@ -127,6 +128,209 @@ func TestRead_error(t *testing.T) {
 	}
 }
 
+func TestMultiSequence(t *testing.T) {
+	sequence, err := Read("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRegions := []SequenceRegion{{Seqid: "chr1", Start: 1, End: 100}, {Seqid: "chr2", Start: 1, End: 80}}
+	if diff := cmp.Diff(wantRegions, sequence.Meta.SequenceRegions); diff != "" {
+		t.Errorf("SequenceRegions mismatch (-want +got):\n%s", diff)
+	}
+	if sequence.Meta.Name != "chr1" || sequence.Meta.RegionEnd != 100 {
+		t.Errorf("Meta should describe the first region, got %s %d", sequence.Meta.Name, sequence.Meta.RegionEnd)
+	}
+	if len(sequence.Sequences) != 2 || len(sequence.Sequences[0].Sequence) != 100 || len(sequence.Sequences[1].Sequence) != 80 {
+		t.Fatalf("Expected sequences of length 100 and 80")
+	}
+	if sequence.Sequence != sequence.Sequences[0].Sequence {
+		t.Errorf("Sequence should hold the first fasta record")
+	}
+	if sequence.Meta.Description != ">chr2 second example contig" {
+		t.Errorf("Description should hold the last fasta header line, got %s", sequence.Meta.Description)
+	}
+	if chr2, err := sequence.SeqidSequence("chr2"); err != nil || chr2 != sequence.Sequences[1].Sequence {
+		t.Errorf("SeqidSequence(chr2) returned the wrong sequence, error %v", err)
+	}
+	if _, err := sequence.SeqidSequence("chr3"); err == nil {
+		t.Errorf("SeqidSequence(chr3) should fail for a seqid without a ##FASTA record")
+	}
+
+	// features resolve to the sequence of their own seqid.
+	for _, feature := range sequence.Features {
+		got, err := feature.GetSequence()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqidSequence, _ := sequence.SeqidSequence(feature.Name)
+		want := seqidSequence[feature.Location.Start:feature.Location.End]
+		if got != want {
+			t.Errorf("GetSequence of %s %s returned %s, want %s", feature.Type, feature.Attributes["ID"], got, want)
+		}
+	}
+
+	original, err := os.ReadFile("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := Build(sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(original), string(built)); diff != "" {
+		t.Errorf("Build does not reproduce the original file (-want +got):\n%s", diff)
+	}
+}
+
+func TestParse_lineErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing columns", "##gff-version 3\nchr1\texample\tgene\t1\t10\n"},
+		{"bad attribute", "##gff-version 3\nchr1\texample\tgene\t1\t10\t.\t+\t.\tID\n"},
+		{"bad region", "##gff-version 3\n##sequence-region chr1 1\n"},
+		{"sequence without name", "##gff-version 3\n##FASTA\nACGT\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+func TestFeatureTree(t *testing.T) {
+	sequence, err := Read("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := sequence.FeatureTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []string
+	for _, root := range tree.Roots {
+		roots = append(roots, root.Feature.Attributes["ID"])
+	}
+	if diff := cmp.Diff([]string{"gene1", "gene2"}, roots); diff != "" {
+		t.Errorf("Roots mismatch (-want +got):\n%s", diff)
+	}
+	if len(tree.Lookup("cds1")) != 2 {
+		t.Errorf("Expected cds1 to span 2 lines")
+	}
+	exon3 := tree.Lookup("exon3")[0]
+	if len(exon3.Parents) != 2 {
+		t.Errorf("Expected exon3 to have 2 parents, got %d", len(exon3.Parents))
+	}
+	var exons []string
+	for _, exon := range tree.Lookup("gene2")[0].Descendants("exon") {
+		exons = append(exons, exon.Feature.Attributes["ID"])
+	}
+	if diff := cmp.Diff([]string{"exon3", "exon4", "exon5"}, exons); diff != "" {
+		t.Errorf("Descendants mismatch (-want +got):\n%s", diff)
+	}
+
+	sequence.Features[1].Attributes["Parent"] = "missing"
+	if _, err = sequence.FeatureTree(); err == nil {
+		t.Errorf("Expected error for a missing parent")
+	}
+}
+
+func TestSplicedSequence_missingFasta(t *testing.T) {
+	// chr3 has no ##FASTA record, so its features have no sequence rather
+	// than that of chr1.
+	gffString := "##gff-version 3\n" +
+		"chr1\texample\tgene\t1\t5\t.\t+\t.\tID=g1\n" +
+		"chr3\texample\tgene\t1\t5\t.\t+\t.\tID=g3\n" +
+		"##FASTA\n>chr1\nAAAAA\n"
+	sequence, err := Parse(strings.NewReader(gffString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sequence.SplicedSequence("g1", "gene"); err != nil || got != "AAAAA" {
+		t.Errorf("SplicedSequence(g1) = %q, %v, want AAAAA", got, err)
+	}
+	_, err = sequence.SplicedSequence("g3", "gene")
+	if err == nil || !strings.Contains(err.Error(), `no sequence for seqid "chr3"`) {
+		t.Errorf("SplicedSequence(g3) error = %v, want no sequence for seqid chr3", err)
+	}
+	if _, err = sequence.Features[1].GetSequence(); err == nil {
+		t.Errorf("GetSequence() of a chr3 feature should fail")
+	}
+}
+
+func TestBuild_conflictingSequences(t *testing.T) {
+	sequence, err := Read("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		modify func(*Gff)
+	}{
+		{"sequence", func(sequence *Gff) { sequence.Sequence = "ACGT" }},
+		{"name", func(sequence *Gff) { sequence.Meta.Name = "chr2" }},
+		{"region start", func(sequence *Gff) { sequence.Meta.RegionStart = 5 }},
+		{"region end", func(sequence *Gff) { sequence.Meta.RegionEnd = 50 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicting := sequence
+			conflicting.Meta.SequenceRegions = append([]SequenceRegion(nil), sequence.Meta.SequenceRegions...)
+			tt.modify(&conflicting)
+			if _, err := Build(conflicting); err == nil {
+				t.Errorf("Build() should fail for a %s that doesn't match the first seqid", tt.name)
+			}
+		})
+	}
+
+	// the single sequence fields may be left empty.
+	sequence.Sequence = ""
+	sequence.Meta.Name = ""
+	sequence.Meta.RegionStart, sequence.Meta.RegionEnd = 0, 0
+	if _, err = Build(sequence); err != nil {
+		t.Errorf("Build() error = %v", err)
+	}
+}
+
+func TestSplicedSequence(t *testing.T) {
+	sequence, err := Read("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chr1, _ := sequence.SeqidSequence("chr1")
+	chr2, _ := sequence.SeqidSequence("chr2")
+	tests := []struct {
+		id          string
+		featureType string
+		want        string
+		wantErr     bool
+	}{
+		{"gene1", "CDS", "ATGGCTAAACGTGGCCCGTTTGAAGATCTGTAA", false},
+		{"mRNA1", "CDS", "ATGGCTAAACGTGGCCCGTTTGAAGATCTGTAA", false},
+		{"cds1", "CDS", "ATGGCTAAACGTGGCCCGTTTGAAGATCTGTAA", false},
+		{"gene1", "exon", chr1[10:30] + chr1[50:70], false},
+		{"mRNA2a", "CDS", "ATGTCTGGCAAACCGGAAGGTCTGATCTGGTAG", false},
+		{"mRNA2b", "exon", transform.ReverseComplement(chr2[4:25] + chr2[60:78]), false},
+		{"gene2", "CDS", "", true},
+		{"gene1", "tRNA", "", true},
+		{"missing", "CDS", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id+"/"+tt.featureType, func(t *testing.T) {
+			got, err := sequence.SplicedSequence(tt.id, tt.featureType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplicedSequence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SplicedSequence() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func BenchmarkReadGff(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = Read("../../data/ecoli-mg1655-short.gff")
@@ -144,3 +348,35 @@ func BenchmarkReadGff10000(b *testing.B) { BenchmarkReadGff(b) }
 Gff related tests and benchmarks end here.
 
 ******************************************************************************/
+
+func TestRecordConversion(t *testing.T) {
+	sequence, err := Read("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := sequence.Records()
+	if len(records) != 2 || records[1].Name != "chr2" || records[1].Description != "second example contig" {
+		t.Fatalf("Records() should return a record for every seqid")
+	}
+	if len(records[0].Features) != 6 || len(records[1].Features) != 10 {
+		t.Errorf("Records() returned %d and %d features, want 6 and 10", len(records[0].Features), len(records[1].Features))
+	}
+
+	converted := FromRecords(records...)
+	ignore := []cmp.Option{
+		cmpopts.IgnoreFields(Feature{}, "ParentSequence"),
+		cmpopts.IgnoreFields(Meta{}, "CheckSum"),
+		cmpopts.EquateEmpty(),
+	}
+	if diff := cmp.Diff(sequence, converted, ignore...); diff != "" {
+		t.Errorf("FromRecords() does not reproduce the original (-want +got):\n%s", diff)
+	}
+	original, err := os.ReadFile("data/multi.gff3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, _ := Build(converted)
+	if diff := cmp.Diff(string(original), string(built)); diff != "" {
+		t.Errorf("Build() of the converted gff differs from the original (-want +got):\n%s", diff)
+	}
+}
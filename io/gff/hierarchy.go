@@ -0,0 +1,193 @@
+package gff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Feature hierarchy begins here.
+
+gff3 links features with the ID and Parent attributes. A gene is the parent of
+one or more mRNAs, which are in turn the parents of their exons and CDSs:
+
+	chr1	.	gene	1000	9000	.	+	.	ID=gene1
+	chr1	.	mRNA	1050	9000	.	+	.	ID=mRNA1;Parent=gene1
+	chr1	.	exon	1050	1500	.	+	.	ID=exon1;Parent=mRNA1
+	chr1	.	CDS	1201	1500	.	+	0	ID=cds1;Parent=mRNA1
+	chr1	.	CDS	3000	3902	.	+	0	ID=cds1;Parent=mRNA1
+
+A feature may have several parents (Parent=mRNA1,mRNA2), and a feature that
+is split over several lines, like the CDS above, repeats the same ID on every
+line. FeatureTree follows these links so that the parts of a gene can be
+collected without having to search through all features of a file.
+
+https://github.com/The-Sequence-Ontology/Specifications/blob/master/gff3.md
+
+******************************************************************************/
+
+// FeatureNode is a single feature line in a FeatureTree.
+type FeatureNode struct {
+	Feature  Feature
+	Parents  []*FeatureNode
+	Children []*FeatureNode
+}
+
+// FeatureTree is the ID/Parent hierarchy of the features of a Gff. It is
+// initialized with Gff.FeatureTree.
+type FeatureTree struct {
+	Roots    []*FeatureNode // features without a Parent attribute.
+	sequence *Gff
+	ids      map[string][]*FeatureNode
+}
+
+// FeatureTree builds the ID/Parent hierarchy of the features of a Gff. Lines
+// that share an ID are treated as parts of the same feature, so children are
+// linked to the first line of their parent. It returns an error if a Parent
+// refers to an ID that does not exist.
+func (sequence *Gff) FeatureTree() (FeatureTree, error) {
+	tree := FeatureTree{sequence: sequence, ids: make(map[string][]*FeatureNode)}
+	nodes := make([]*FeatureNode, len(sequence.Features))
+	for featureIndex, feature := range sequence.Features {
+		node := &FeatureNode{Feature: feature}
+		nodes[featureIndex] = node
+		if id, ok := feature.Attributes["ID"]; ok {
+			tree.ids[id] = append(tree.ids[id], node)
+		}
+	}
+	for _, node := range nodes {
+		parentAttribute, ok := node.Feature.Attributes["Parent"]
+		if !ok {
+			tree.Roots = append(tree.Roots, node)
+			continue
+		}
+		for _, parentID := range strings.Split(parentAttribute, ",") {
+			parents := tree.ids[parentID]
+			if len(parents) == 0 {
+				return FeatureTree{}, fmt.Errorf("Parent '%s' of %s feature at %s:%d-%d not found", parentID, node.Feature.Type, node.Feature.Name, node.Feature.Location.Start+1, node.Feature.Location.End)
+			}
+			node.Parents = append(node.Parents, parents[0])
+			parents[0].Children = append(parents[0].Children, node)
+		}
+	}
+	return tree, nil
+}
+
+// Lookup returns all feature lines with the given ID.
+func (tree FeatureTree) Lookup(id string) []*FeatureNode {
+	return tree.ids[id]
+}
+
+// Descendants returns all descendants of a node with the given feature type,
+// like "exon" or "CDS", in the order they appear in the file. An empty
+// feature type returns all descendants.
+func (node *FeatureNode) Descendants(featureType string) []*FeatureNode {
+	var descendants []*FeatureNode
+	visited := make(map[*FeatureNode]bool)
+	var walk func(*FeatureNode)
+	walk = func(current *FeatureNode) {
+		for _, child := range current.Children {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			if featureType == "" || child.Feature.Type == featureType {
+				descendants = append(descendants, child)
+			}
+			walk(child)
+		}
+	}
+	walk(node)
+	sort.SliceStable(descendants, func(i, j int) bool {
+		return descendants[i].Feature.Location.Start < descendants[j].Feature.Location.Start
+	})
+	return descendants
+}
+
+// SplicedSequence returns the spliced sequence of all features of the given
+// type below the feature with the given ID. For example,
+// SplicedSequence("gene1", "CDS") returns the coding sequence of gene1, and
+// SplicedSequence("mRNA1", "exon") returns the mature transcript of mRNA1.
+// Minus strand features are reverse complemented. The phase of the first CDS
+// is not applied, so the sequence starts at the first base of the first CDS.
+//
+// If the ID itself belongs to features of the given type, like a CDS that is
+// split over several lines, those features are joined. A gene with several
+// transcripts is ambiguous, so an error is returned and the ID of one of the
+// transcripts has to be used instead.
+func (tree FeatureTree) SplicedSequence(id string, featureType string) (string, error) {
+	nodes := tree.Lookup(id)
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("feature with ID '%s' not found", id)
+	}
+	parts, err := splicedParts(nodes, featureType)
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("feature '%s' has no %s features", id, featureType)
+	}
+
+	seqid := parts[0].Feature.Name
+	strand := parts[0].Feature.Strand
+	location := Location{Join: len(parts) > 1, Complement: strand == "-"}
+	for _, part := range parts {
+		if part.Feature.Name != seqid || part.Feature.Strand != strand {
+			return "", fmt.Errorf("%s features of '%s' are not on the same sequence and strand", featureType, id)
+		}
+		partLocation := part.Feature.Location
+		partLocation.Complement = false
+		location.SubLocations = append(location.SubLocations, partLocation)
+	}
+	feature := Feature{Name: seqid, Location: location, ParentSequence: tree.sequence}
+	return feature.GetSequence()
+}
+
+// splicedParts returns the features of a type that belong to the feature
+// made up of nodes, sorted by start position.
+func splicedParts(nodes []*FeatureNode, featureType string) ([]*FeatureNode, error) {
+	if nodes[0].Feature.Type == featureType {
+		parts := append([]*FeatureNode(nil), nodes...)
+		sort.SliceStable(parts, func(i, j int) bool {
+			return parts[i].Feature.Location.Start < parts[j].Feature.Location.Start
+		})
+		return parts, nil
+	}
+
+	var parts []*FeatureNode
+	var transcripts []string
+	for _, child := range nodes[0].Children {
+		if child.Feature.Type == featureType {
+			parts = append(parts, child)
+			continue
+		}
+		if len(child.Descendants(featureType)) > 0 {
+			transcripts = append(transcripts, child.Feature.Attributes["ID"])
+		}
+	}
+	switch {
+	case len(transcripts) > 1:
+		return nil, fmt.Errorf("feature '%s' has %d transcripts with %s features (%s), use the ID of one of them", nodes[0].Feature.Attributes["ID"], len(transcripts), featureType, strings.Join(transcripts, ", "))
+	case len(transcripts) == 1:
+		return nodes[0].Descendants(featureType), nil
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		return parts[i].Feature.Location.Start < parts[j].Feature.Location.Start
+	})
+	return parts, nil
+}
+
+// SplicedSequence builds the FeatureTree of a Gff and returns the spliced
+// sequence of all features of the given type below the feature with the given
+// ID. See FeatureTree.SplicedSequence for details. Use FeatureTree directly
+// when getting the sequences of many features.
+func (sequence *Gff) SplicedSequence(id string, featureType string) (string, error) {
+	tree, err := sequence.FeatureTree()
+	if err != nil {
+		return "", err
+	}
+	return tree.SplicedSequence(id, featureType)
+}
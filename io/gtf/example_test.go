@@ -0,0 +1,30 @@
+package gtf_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/gtf"
+)
+
+// ExampleRead shows basic usage for Read.
+func ExampleRead() {
+	records, _ := gtf.Read("data/example.gtf")
+	for _, record := range records {
+		if record.Type == "exon" {
+			fmt.Println(record.TranscriptID, record.Start, record.End)
+		}
+	}
+	//Output:
+	//ENST00000456328 11869 12227
+	//ENST00000456328 12613 12721
+	//ENST00000456328 13221 14409
+}
+
+// ExampleRecord_Feature shows how to convert a GTF record into a gff feature.
+func ExampleRecord_Feature() {
+	records, _ := gtf.Read("data/example.gtf")
+	feature := records[2].Feature()
+	fmt.Println(feature.Type, feature.Location.Start, feature.Location.End, feature.Attributes["transcript_id"])
+	//Output:
+	//exon 11868 12227 ENST00000456328
+}
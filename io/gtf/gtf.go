@@ -0,0 +1,419 @@
+/*
+Package gtf contains GTF parsers and writers.
+
+GTF (Gene Transfer Format) is a close relative of gff. It has the same first
+8 tab separated columns, but its attributes are written as `key "value";`
+pairs and every line must carry a gene_id and, for everything below the gene
+level, a transcript_id:
+
+	```
+	1	havana	exon	11869	12227	.	+	.	gene_id "ENSG00000223972"; transcript_id "ENST00000456328"; exon_number "1";
+	```
+
+	1. seqname: The name of the sequence
+	2. source: The program or database that generated the feature
+	3. feature: The feature type, like gene, transcript, exon or CDS
+	4. start: Start of the feature (indexed at 1)
+	5. end: End of the feature (included in the feature)
+	6. score: A score, or .
+	7. strand: +, - or .
+	8. frame: 0, 1 or 2 for coding features, otherwise .
+	9. attributes: Semicolon separated key "value" pairs
+
+The specification can be found here: https://mblab.wustl.edu/GTF22.html
+
+This package provides a parser and writer for GTF files, and converters between
+GTF records and gff features.
+*/
+package gtf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/gff"
+)
+
+// Attribute is a single key "value" pair of a GTF attribute column. Keys may
+// appear more than once, like Ensembl's tag attribute.
+type Attribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Record is a single line of a GTF file. Coordinates are stored as they are in
+// the file: 1-based, with End included in the feature.
+type Record struct {
+	SequenceName string      `json:"sequence_name"`
+	Source       string      `json:"source"`
+	Type         string      `json:"type"`
+	Start        int         `json:"start"`
+	End          int         `json:"end"`
+	Score        string      `json:"score"`
+	Strand       string      `json:"strand"`
+	Frame        string      `json:"frame"`
+	GeneID       string      `json:"gene_id"`
+	TranscriptID string      `json:"transcript_id"` // empty for gene level records
+	Attributes   []Attribute `json:"attributes"`    // all attributes except gene_id and transcript_id
+}
+
+// Get returns the first value of an attribute.
+func (record Record) Get(key string) (string, bool) {
+	for _, attribute := range record.Attributes {
+		if attribute.Key == key {
+			return attribute.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parse parses a given GTF file into an array of Records.
+func Parse(r io.Reader) ([]Record, error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	parser := NewParser(r, maxLineSize)
+	return parser.ParseAll()
+}
+
+// Parser is a GTF parser.
+type Parser struct {
+	reader bufio.Reader
+	line   uint
+}
+
+// NewParser creates a parser from an io.Reader for GTF data.
+func NewParser(r io.Reader, maxLineSize int) *Parser {
+	return &Parser{
+		reader: *bufio.NewReaderSize(r, maxLineSize),
+	}
+}
+
+// ParseAll parses all records in underlying reader only returning non-EOF errors.
+// It returns all valid records up to error if encountered.
+func (parser *Parser) ParseAll() ([]Record, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// ParseN parses up to maxRecords records from the Parser's underlying reader.
+// ParseN does not return EOF if encountered.
+// If an non-EOF error is encountered it returns it and all correctly parsed records up to then.
+func (parser *Parser) ParseN(maxRecords int) (records []Record, err error) {
+	for counter := 0; counter < maxRecords; counter++ {
+		record, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ParseNext parses the next record of a GTF file. Comments (#) and empty
+// lines are skipped.
+// ParseNext returns an EOF if encountered.
+func (parser *Parser) ParseNext() (Record, error) {
+	for {
+		if _, err := parser.reader.Peek(1); err != nil {
+			// Early return on error. Probably will be EOF.
+			return Record{}, err
+		}
+		lineBytes, err := parser.reader.ReadSlice('\n')
+		parser.line++
+		if err != nil && !errors.Is(err, io.EOF) {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				return Record{}, fmt.Errorf("line %d too large for buffer, use larger maxLineSize: %w", parser.line, err)
+			}
+			return Record{}, err
+		}
+		line := strings.TrimRight(string(lineBytes), "\r\n")
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		record, err := parseRecord(line)
+		if err != nil {
+			return Record{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+		}
+		return record, nil
+	}
+}
+
+// parseRecord parses a single GTF line.
+func parseRecord(line string) (Record, error) {
+	values := strings.Split(line, "\t")
+	if len(values) != 9 {
+		return Record{}, fmt.Errorf("Got %d values, expected 9.", len(values))
+	}
+	record := Record{
+		SequenceName: values[0],
+		Source:       values[1],
+		Type:         values[2],
+		Score:        values[5],
+		Strand:       values[6],
+		Frame:        values[7],
+	}
+	var err error
+	if record.Start, err = strconv.Atoi(values[3]); err != nil {
+		return Record{}, fmt.Errorf("Failed to convert start '%s': %w", values[3], err)
+	}
+	if record.End, err = strconv.Atoi(values[4]); err != nil {
+		return Record{}, fmt.Errorf("Failed to convert end '%s': %w", values[4], err)
+	}
+	if record.Start < 1 || record.End < record.Start {
+		return Record{}, fmt.Errorf("invalid interval %d-%d", record.Start, record.End)
+	}
+	if record.Strand != "+" && record.Strand != "-" && record.Strand != "." {
+		return Record{}, fmt.Errorf("strand must be +, - or . Got: %s", record.Strand)
+	}
+	if record.Frame != "0" && record.Frame != "1" && record.Frame != "2" && record.Frame != "." {
+		return Record{}, fmt.Errorf("frame must be 0, 1, 2 or . Got: %s", record.Frame)
+	}
+	attributes, err := parseAttributes(values[8])
+	if err != nil {
+		return Record{}, err
+	}
+	for _, attribute := range attributes {
+		switch attribute.Key {
+		case "gene_id":
+			record.GeneID = attribute.Value
+		case "transcript_id":
+			record.TranscriptID = attribute.Value
+		default:
+			record.Attributes = append(record.Attributes, attribute)
+		}
+	}
+	if record.GeneID == "" {
+		return Record{}, errors.New("missing gene_id attribute")
+	}
+	return record, nil
+}
+
+// parseAttributes parses the attribute column, like `gene_id "g1"; exon_number 1;`.
+func parseAttributes(column string) ([]Attribute, error) {
+	var attributes []Attribute
+	for {
+		column = strings.TrimLeft(column, " ")
+		if column == "" {
+			return attributes, nil
+		}
+		key, rest, found := strings.Cut(column, " ")
+		if !found {
+			return nil, fmt.Errorf("attribute '%s' has no value", column)
+		}
+		rest = strings.TrimLeft(rest, " ")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// Quoted values may contain semicolons.
+			end := strings.IndexByte(rest[1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quote in attribute %s", key)
+			}
+			value = rest[1 : end+1]
+			rest = strings.TrimLeft(rest[end+2:], " ")
+		} else {
+			value, rest, _ = strings.Cut(rest, ";")
+			value = strings.TrimRight(value, " ")
+			rest = ";" + rest
+		}
+		// The last attribute doesn't always end with a semicolon.
+		if rest != "" && !strings.HasPrefix(rest, ";") {
+			return nil, fmt.Errorf("expected ; after attribute %s", key)
+		}
+		attributes = append(attributes, Attribute{Key: key, Value: value})
+		column = strings.TrimPrefix(rest, ";")
+	}
+}
+
+// Reset discards all data in buffer and resets state.
+func (parser *Parser) Reset(r io.Reader) {
+	parser.reader.Reset(r)
+	parser.line = 0
+}
+
+/******************************************************************************
+
+Start of gff conversion functions
+
+******************************************************************************/
+
+// Feature converts a GTF record into a gff feature. The sequence name becomes
+// the feature's Name, as in gff files. gene_id and transcript_id are kept as
+// attributes of the same name, and the values of repeated attributes are
+// joined with commas.
+func (record Record) Feature() gff.Feature {
+	feature := gff.Feature{
+		Name:       record.SequenceName,
+		Source:     record.Source,
+		Type:       record.Type,
+		Score:      record.Score,
+		Strand:     record.Strand,
+		Phase:      record.Frame,
+		Attributes: map[string]string{"gene_id": record.GeneID},
+		Location: gff.Location{
+			// gff locations are 0-based, GTF records are 1-based.
+			Start:      record.Start - 1,
+			End:        record.End,
+			Complement: record.Strand == "-",
+		},
+	}
+	if record.TranscriptID != "" {
+		feature.Attributes["transcript_id"] = record.TranscriptID
+	}
+	for _, attribute := range record.Attributes {
+		if value, ok := feature.Attributes[attribute.Key]; ok {
+			feature.Attributes[attribute.Key] = value + "," + attribute.Value
+			continue
+		}
+		feature.Attributes[attribute.Key] = attribute.Value
+	}
+	return feature
+}
+
+// FromFeature converts a gff feature into GTF records. The feature must have
+// a gene_id attribute. A feature with sub-locations, like a joined CDS, can't
+// be expressed in a single GTF line, so every sub-location becomes a record of
+// its own. The other attributes are written in alphabetical order.
+func FromFeature(feature gff.Feature) ([]Record, error) {
+	geneID := feature.Attributes["gene_id"]
+	if geneID == "" {
+		return nil, fmt.Errorf("feature %s %d-%d has no gene_id attribute", feature.Type, feature.Location.Start, feature.Location.End)
+	}
+	template := Record{
+		SequenceName: feature.Name,
+		Source:       orMissing(feature.Source),
+		Type:         feature.Type,
+		Score:        orMissing(feature.Score),
+		Strand:       orMissing(feature.Strand),
+		Frame:        orMissing(feature.Phase),
+		GeneID:       geneID,
+		TranscriptID: feature.Attributes["transcript_id"],
+	}
+	if template.Strand == "." && feature.Location.Complement {
+		template.Strand = "-"
+	}
+	keys := make([]string, 0, len(feature.Attributes))
+	for key := range feature.Attributes {
+		if key != "gene_id" && key != "transcript_id" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		template.Attributes = append(template.Attributes, Attribute{Key: key, Value: feature.Attributes[key]})
+	}
+
+	var records []Record
+	for _, location := range leafLocations(feature.Location) {
+		record := template
+		record.Start, record.End = location.Start+1, location.End
+		if record.End < record.Start {
+			return nil, fmt.Errorf("invalid feature location %d-%d", location.Start, location.End)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// leafLocations returns the locations without sub-locations that make up a location.
+func leafLocations(location gff.Location) []gff.Location {
+	if len(location.SubLocations) == 0 {
+		return []gff.Location{location}
+	}
+	var leaves []gff.Location
+	for _, subLocation := range location.SubLocations {
+		leaves = append(leaves, leafLocations(subLocation)...)
+	}
+	return leaves
+}
+
+func orMissing(value string) string {
+	if value == "" {
+		return "."
+	}
+	return value
+}
+
+/******************************************************************************
+
+Start of  Read functions
+
+******************************************************************************/
+
+// Read reads a GTF file into an array of Records.
+func Read(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+Start of  Write functions
+
+******************************************************************************/
+
+// WriteRecords writes a GTF record array to an io.Writer. gene_id and
+// transcript_id are written first, and all attribute values are quoted.
+func WriteRecords(records []Record, w io.Writer) error {
+	for _, record := range records {
+		if record.GeneID == "" {
+			return fmt.Errorf("record %s %s:%d-%d has no gene_id", record.Type, record.SequenceName, record.Start, record.End)
+		}
+		attributes := []Attribute{{Key: "gene_id", Value: record.GeneID}}
+		if record.TranscriptID != "" {
+			attributes = append(attributes, Attribute{Key: "transcript_id", Value: record.TranscriptID})
+		}
+		attributes = append(attributes, record.Attributes...)
+		attributeStrings := make([]string, len(attributes))
+		for index, attribute := range attributes {
+			if strings.Contains(attribute.Value, `"`) {
+				return fmt.Errorf("attribute %s of record %s:%d-%d contains a quote", attribute.Key, record.SequenceName, record.Start, record.End)
+			}
+			attributeStrings[index] = attribute.Key + ` "` + attribute.Value + `";`
+		}
+		_, err := w.Write([]byte(strings.Join([]string{
+			record.SequenceName,
+			orMissing(record.Source),
+			record.Type,
+			strconv.Itoa(record.Start),
+			strconv.Itoa(record.End),
+			orMissing(record.Score),
+			orMissing(record.Strand),
+			orMissing(record.Frame),
+			strings.Join(attributeStrings, " "),
+		}, "\t") + "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build builds a GTF file from an array of Records.
+func Build(records []Record) ([]byte, error) {
+	var gtfBuffer bytes.Buffer
+	err := WriteRecords(records, &gtfBuffer)
+	return gtfBuffer.Bytes(), err
+}
+
+// Write writes a GTF record array to a file.
+func Write(records []Record, path string) error {
+	gtfBytes, err := Build(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, gtfBytes, 0644)
+}
@@ -0,0 +1,173 @@
+package gtf
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/gff"
+)
+
+func TestParse(t *testing.T) {
+	records, err := Read("data/example.gtf")
+	if err != nil {
+		t.Fatalf("Failed to read example.gtf. Got error: %s", err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("Expected 6 records. Got: %d", len(records))
+	}
+	gene := records[0]
+	if gene.Type != "gene" || gene.GeneID != "ENSG00000223972" || gene.TranscriptID != "" || gene.Start != 11869 || gene.End != 14409 {
+		t.Errorf("Wrong fields parsed for gene: %+v", gene)
+	}
+	if name, _ := gene.Get("gene_name"); name != "DDX11L1" {
+		t.Errorf("Expected gene_name DDX11L1. Got: %s", name)
+	}
+	expectedAttributes := []Attribute{
+		{Key: "gene_name", Value: "DDX11L1"},
+		{Key: "transcript_name", Value: "DDX11L1-202"},
+		{Key: "tag", Value: "basic"},
+		{Key: "tag", Value: "Ensembl_canonical"},
+	}
+	if !reflect.DeepEqual(records[1].Attributes, expectedAttributes) {
+		t.Errorf("Expected attributes %v. Got: %v", expectedAttributes, records[1].Attributes)
+	}
+
+	// Unquoted values and quoted semicolons
+	records, err = Parse(strings.NewReader("1\tsrc\texon\t1\t10\t.\t+\t.\tgene_id \"a;b\"; exon_number 1\n"))
+	if err != nil || records[0].GeneID != "a;b" || !reflect.DeepEqual(records[0].Attributes, []Attribute{{Key: "exon_number", Value: "1"}}) {
+		t.Errorf("Failed to parse unquoted and semicolon values. Got: %+v, %v", records, err)
+	}
+}
+
+func TestParseExceptions(t *testing.T) {
+	badLines := []string{
+		"1\tsrc\texon\t1\t10\t.\t+\t.\n",                                    // too few columns
+		"1\tsrc\texon\tone\t10\t.\t+\t.\tgene_id \"g\";\n",                  // bad start
+		"1\tsrc\texon\t1\tten\t.\t+\t.\tgene_id \"g\";\n",                   // bad end
+		"1\tsrc\texon\t10\t1\t.\t+\t.\tgene_id \"g\";\n",                    // end before start
+		"1\tsrc\texon\t1\t10\t.\tup\t.\tgene_id \"g\";\n",                   // bad strand
+		"1\tsrc\texon\t1\t10\t.\t+\t3\tgene_id \"g\";\n",                    // bad frame
+		"1\tsrc\texon\t1\t10\t.\t+\t.\ttranscript_id \"t\";\n",              // no gene_id
+		"1\tsrc\texon\t1\t10\t.\t+\t.\tgene_id \"g;\n",                      // unterminated quote
+		"1\tsrc\texon\t1\t10\t.\t+\t.\tgene_id\n",                           // attribute without value
+		"1\tsrc\texon\t1\t10\t.\t+\t.\tgene_id \"g\" transcript_id \"t\"\n", // missing semicolon
+	}
+	for _, badLine := range badLines {
+		if _, err := Parse(strings.NewReader(badLine)); err == nil {
+			t.Errorf("Test should have failed parsing %q", badLine)
+		}
+	}
+	file, _ := os.Open("data/example.gtf")
+	defer file.Close()
+	parser := NewParser(file, 64)
+	if _, err := parser.ParseAll(); err == nil {
+		t.Errorf("Should have encountered a maxLine error")
+	}
+	parser.Reset(strings.NewReader("1\tsrc\texon\t1\t10\t.\t+\t.\tgene_id \"g\";\n"))
+	if records, err := parser.ParseAll(); err != nil || len(records) != 1 {
+		t.Errorf("Failed to parse after Reset. Got error: %s", err)
+	}
+}
+
+func TestFeature(t *testing.T) {
+	records, _ := Read("data/example.gtf")
+	feature := records[5].Feature()
+	expected := gff.Feature{
+		Name:   "1",
+		Source: "ensembl",
+		Type:   "CDS",
+		Score:  "0.5",
+		Strand: "-",
+		Phase:  "0",
+		Attributes: map[string]string{
+			"gene_id":       "ENSG00000186092",
+			"transcript_id": "ENST00000641515",
+			"exon_number":   "2",
+			"protein_id":    "ENSP00000493376",
+		},
+		Location: gff.Location{Start: 65564, End: 65573, Complement: true},
+	}
+	if !reflect.DeepEqual(feature, expected) {
+		t.Errorf("Expected feature %+v. Got: %+v", expected, feature)
+	}
+	if tags := records[1].Feature().Attributes["tag"]; tags != "basic,Ensembl_canonical" {
+		t.Errorf("Expected repeated tags to be joined. Got: %s", tags)
+	}
+
+	converted, err := FromFeature(feature)
+	if err != nil {
+		t.Fatalf("Failed to convert feature back. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(converted, records[5:]) {
+		t.Errorf("Record changed after converting to a feature and back. Expected %+v. Got: %+v", records[5:], converted)
+	}
+}
+
+func TestFromFeature(t *testing.T) {
+	feature := gff.Feature{
+		Name:       "chr1",
+		Type:       "CDS",
+		Attributes: map[string]string{"gene_id": "g1", "transcript_id": "t1"},
+		Location: gff.Location{
+			Start:        0,
+			End:          30,
+			Complement:   true,
+			Join:         true,
+			SubLocations: []gff.Location{{Start: 0, End: 10}, {Start: 20, End: 30}},
+		},
+	}
+	records, err := FromFeature(feature)
+	if err != nil {
+		t.Fatalf("Failed to convert feature. Got error: %s", err)
+	}
+	expected := []Record{
+		{SequenceName: "chr1", Source: ".", Type: "CDS", Start: 1, End: 10, Score: ".", Strand: "-", Frame: ".", GeneID: "g1", TranscriptID: "t1"},
+		{SequenceName: "chr1", Source: ".", Type: "CDS", Start: 21, End: 30, Score: ".", Strand: "-", Frame: ".", GeneID: "g1", TranscriptID: "t1"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected records %+v. Got: %+v", expected, records)
+	}
+
+	delete(feature.Attributes, "gene_id")
+	if _, err = FromFeature(feature); err == nil {
+		t.Errorf("Test should have failed converting a feature without gene_id")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	gtfBytes, _ := os.ReadFile("data/example.gtf")
+	records, _ := Read("data/example.gtf")
+	builtBytes, err := Build(records)
+	if err != nil {
+		t.Fatalf("Failed to build gtf. Got error: %s", err)
+	}
+	// Comments are not kept.
+	lines := strings.Split(string(gtfBytes), "\n")[2:]
+	if string(builtBytes) != strings.Join(lines, "\n") {
+		t.Errorf("Built gtf differs from example.gtf. Got:\n%s", builtBytes)
+	}
+
+	if _, err = Build([]Record{{SequenceName: "1", Start: 1, End: 2}}); err == nil {
+		t.Errorf("Test should have failed building a record without gene_id")
+	}
+	if _, err = Build([]Record{{SequenceName: "1", Start: 1, End: 2, GeneID: "g", Attributes: []Attribute{{Key: "note", Value: `"`}}}}); err == nil {
+		t.Errorf("Test should have failed building an attribute with a quote")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	records, _ := Read("data/example.gtf")
+	path := t.TempDir() + "/example.gtf"
+	if err := Write(records, path); err != nil {
+		t.Fatalf("Failed to write gtf. Got error: %s", err)
+	}
+	writtenRecords, err := Read(path)
+	if err != nil || !reflect.DeepEqual(records, writtenRecords) {
+		t.Errorf("Records changed after writing and reading. Got error: %v", err)
+	}
+	if _, err = Read("data/doesntexist.gtf"); err == nil {
+		t.Errorf("Should have failed to read non-existent file")
+	}
+}
@@ -1,9 +1,8 @@
 /*
 Package io provides utilities for reading and writing sequence data.
-*/
-package io
 
-/*
-This package is supposed to be empty and only exists to provide a doc string.
-Otherwise its namespace would collide with Go's native IO package.
+Every supported file format has its own subpackage. This package detects the
+format of a file from its contents, so that files can be read without knowing
+their format or compression in advance.
 */
+package io
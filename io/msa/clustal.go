@@ -0,0 +1,133 @@
+package msa
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// clustalHeaders are the first words of the header line of Clustal files.
+// MUSCLE and PROBCONS write Clustal files with their own name.
+var clustalHeaders = []string{"CLUSTAL", "MUSCLE", "PROBCONS"}
+
+// clustalBlockWidth is the number of columns per block written by BuildClustal,
+// the same as Clustal Omega.
+const clustalBlockWidth = 60
+
+// ParseClustal parses a Clustal (.aln) file into an MSA.
+//
+// Sequence lines hold the name of a sequence, a part of its alignment and
+// optionally a residue count. Conservation lines start with whitespace and
+// are skipped, since they can be computed with ConservationSymbols.
+func ParseClustal(r io.Reader) (MSA, error) {
+	scanner := bufio.NewScanner(r)
+	var alignment MSA
+	sequenceIndex := make(map[string]int)
+	foundHeader := false
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !foundHeader {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if !isClustalHeader(line) {
+				return MSA{}, fmt.Errorf("expected a Clustal header on line %d, got: %s", lineNumber, line)
+			}
+			foundHeader = true
+			continue
+		}
+		// Blank lines separate blocks, conservation lines start with whitespace.
+		if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return MSA{}, fmt.Errorf("expected a sequence name and sequence on line %d, got: %s", lineNumber, line)
+		}
+		if len(fields) == 3 {
+			if _, err := strconv.Atoi(fields[2]); err != nil {
+				return MSA{}, fmt.Errorf("expected a residue count on line %d, got: %s", lineNumber, fields[2])
+			}
+		}
+		index, ok := sequenceIndex[fields[0]]
+		if !ok {
+			index = len(alignment.Sequences)
+			sequenceIndex[fields[0]] = index
+			alignment.Sequences = append(alignment.Sequences, Sequence{Name: fields[0]})
+		}
+		alignment.Sequences[index].Sequence += fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return MSA{}, err
+	}
+	if !foundHeader {
+		return MSA{}, ErrEmptyAlignment
+	}
+	if err := alignment.Validate(); err != nil {
+		return MSA{}, err
+	}
+	return alignment, nil
+}
+
+// isClustalHeader checks if a line is the header line of a Clustal file.
+func isClustalHeader(line string) bool {
+	for _, header := range clustalHeaders {
+		if strings.HasPrefix(line, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildClustal writes an MSA in Clustal format, in blocks of 60 columns with
+// a conservation line below each block. Annotations are not written.
+func BuildClustal(alignment MSA) ([]byte, error) {
+	if err := alignment.Validate(); err != nil {
+		return nil, err
+	}
+	nameWidth := 0
+	for _, sequence := range alignment.Sequences {
+		if strings.ContainsAny(sequence.Name, " \t") {
+			return nil, fmt.Errorf("sequence name %q contains whitespace, which Clustal doesn't allow", sequence.Name)
+		}
+		nameWidth = max(nameWidth, len(sequence.Name))
+	}
+	// Clustal separates names and sequences with at least a few spaces.
+	nameWidth += 6
+
+	conservation := alignment.ConservationSymbols()
+	var clustal bytes.Buffer
+	clustal.WriteString("CLUSTAL W multiple sequence alignment\n\n")
+	for start := 0; start < alignment.Length(); start += clustalBlockWidth {
+		end := min(start+clustalBlockWidth, alignment.Length())
+		clustal.WriteString("\n")
+		for _, sequence := range alignment.Sequences {
+			fmt.Fprintf(&clustal, "%-*s%s\n", nameWidth, sequence.Name, sequence.Sequence[start:end])
+		}
+		fmt.Fprintf(&clustal, "%s%s\n", strings.Repeat(" ", nameWidth), conservation[start:end])
+	}
+	return clustal.Bytes(), nil
+}
+
+// ReadClustal reads a Clustal file from path.
+func ReadClustal(path string) (MSA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return MSA{}, err
+	}
+	defer file.Close()
+	return ParseClustal(file)
+}
+
+// WriteClustal writes an MSA in Clustal format to path.
+func WriteClustal(alignment MSA, path string) error {
+	clustalBytes, err := BuildClustal(alignment)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, clustalBytes, 0644)
+}
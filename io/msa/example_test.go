@@ -0,0 +1,52 @@
+package msa_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bebop/poly/io/msa"
+)
+
+// This example converts a Stockholm alignment into Clustal format.
+func Example_basic() {
+	alignments, _ := msa.ReadStockholm("data/example.sto")
+	alignment, _ := alignments[0].Slice(0, 20)
+	clustal, _ := msa.BuildClustal(alignment)
+	for _, line := range strings.Split(string(clustal), "\n") {
+		if strings.TrimSpace(line) != "" {
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+	// Output:
+	// CLUSTAL W multiple sequence alignment
+	// HBA_HUMAN      MV.LSPADKTNVKAAWGKVG
+	// HBA_MOUSE      MV.LSGEDKSNIKAAWGKIG
+	// HBB_HUMAN      MVHLTPEEKSAVTALWGKV.
+	// HBB_MOUSE      MVHLTDAEKAAVSCLWGKV.
+	//                ** *:  :*: :.. ***:
+}
+
+func ExampleMSA_Consensus() {
+	alignment, _ := msa.ReadClustal("data/example.aln")
+	sliced, _ := alignment.Slice(0, 10)
+	fmt.Println(sliced.Consensus())
+	fmt.Println(sliced.Conservation())
+	// Output:
+	// MVHLSPADKS
+	// [1 1 0.5 1 0.5 0.5 0.5 0.5 1 0.5]
+}
+
+func ExampleMSA_Slice() {
+	alignments, _ := msa.ReadStockholm("data/example.sto")
+	sliced, _ := alignments[0].Slice(40, 50)
+	for _, sequence := range sliced.Sequences {
+		fmt.Println(sequence.Name, sequence.Sequence)
+	}
+	fmt.Println(sliced.ColumnAnnotations["SS_cons"])
+	// Output:
+	// HBA_HUMAN TKTYFPHF.D
+	// HBA_MOUSE TKTYFPHF.D
+	// HBB_HUMAN TQRFFESFGD
+	// HBB_MOUSE TQRYFDSFGD
+	// HHHHHCCCCC
+}
@@ -0,0 +1,65 @@
+package msa
+
+import (
+	"io"
+	"os"
+
+	"github.com/bebop/poly/io/fasta"
+)
+
+// ParseFasta parses a gapped FASTA file, in which every sequence is an aligned
+// row of the same length, into an MSA.
+func ParseFasta(r io.Reader) (MSA, error) {
+	fastas, err := fasta.Parse(r)
+	if err != nil {
+		return MSA{}, err
+	}
+	var alignment MSA
+	for _, record := range fastas {
+		alignment.Sequences = append(alignment.Sequences, Sequence{Name: record.Name, Sequence: record.Sequence})
+	}
+	if err := alignment.Validate(); err != nil {
+		return MSA{}, err
+	}
+	return alignment, nil
+}
+
+// BuildFasta writes an MSA as gapped FASTA. Annotations are not written.
+func BuildFasta(alignment MSA) ([]byte, error) {
+	if err := alignment.Validate(); err != nil {
+		return nil, err
+	}
+	fastas := make([]fasta.Fasta, len(alignment.Sequences))
+	for index, sequence := range alignment.Sequences {
+		fastas[index] = fasta.Fasta{Name: sequence.Name, Sequence: sequence.Sequence}
+	}
+	fastaBytes, err := fasta.Build(fastas)
+	if err != nil {
+		return nil, err
+	}
+	// fasta.Build doesn't end the last sequence with a newline, which
+	// fasta.Parse needs to read it.
+	if len(fastaBytes) > 0 && fastaBytes[len(fastaBytes)-1] != '\n' {
+		fastaBytes = append(fastaBytes, '\n')
+	}
+	return fastaBytes, nil
+}
+
+// ReadFasta reads a gapped FASTA file from path.
+func ReadFasta(path string) (MSA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return MSA{}, err
+	}
+	defer file.Close()
+	return ParseFasta(file)
+}
+
+// WriteFasta writes an MSA as gapped FASTA to path.
+func WriteFasta(alignment MSA, path string) error {
+	fastaBytes, err := BuildFasta(alignment)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, fastaBytes, 0644)
+}
@@ -0,0 +1,294 @@
+/*
+Package msa provides a multiple sequence alignment type with parsers and
+writers for the Clustal, Stockholm and gapped FASTA formats.
+
+A multiple sequence alignment (MSA) lines up three or more sequences so that
+homologous residues end up in the same column. Alignment programs like
+Clustal Omega, MUSCLE or MAFFT write their results in one of a few formats:
+
+  - Clustal (.aln) is the format of the Clustal programs. Sequences are
+    written in interleaved blocks, with a conservation line under each block.
+  - Stockholm (.sto) is the format of Pfam and Rfam. Besides the sequences it
+    holds annotations of the file (#=GF), of sequences (#=GS), of the residues
+    of a sequence (#=GR) and of columns (#=GC), like a consensus secondary
+    structure.
+  - Gapped FASTA is plain FASTA where every sequence has the same length.
+
+All three are parsed into an MSA, so alignments can be converted from one
+format to the other. Annotations only Stockholm can hold are dropped when an
+MSA is written in another format.
+
+Gaps are kept as they are in the file, usually - or . (Stockholm uses . for
+gaps in insert columns). Both count as gaps when computing the consensus and
+conservation of an alignment.
+*/
+package msa
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Annotation is a free text annotation of a file or sequence, like a #=GF or
+// #=GS line of a Stockholm file.
+type Annotation struct {
+	Feature string `json:"feature"`
+	Value   string `json:"value"`
+}
+
+// Sequence is a single aligned sequence of an MSA.
+type Sequence struct {
+	Name     string `json:"name"`
+	Sequence string `json:"sequence"`
+	// Annotations of the sequence, from #=GS lines.
+	Annotations []Annotation `json:"annotations"`
+	// ResidueAnnotations maps features to a per-column annotation of the
+	// sequence, from #=GR lines, like its secondary structure.
+	ResidueAnnotations map[string]string `json:"residue_annotations"`
+}
+
+// MSA is a multiple sequence alignment. All sequences and column annotations
+// have the same length.
+type MSA struct {
+	Sequences []Sequence `json:"sequences"`
+	// Annotations of the whole alignment, from #=GF lines.
+	Annotations []Annotation `json:"annotations"`
+	// ColumnAnnotations maps features to a per-column annotation of the
+	// alignment, from #=GC lines, like the consensus secondary structure.
+	ColumnAnnotations map[string]string `json:"column_annotations"`
+}
+
+// ErrEmptyAlignment is returned when a file does not contain any sequences.
+var ErrEmptyAlignment = errors.New("alignment does not contain any sequences")
+
+// isGap checks if a character of an aligned sequence is a gap.
+func isGap(character byte) bool {
+	return character == '-' || character == '.'
+}
+
+// Length returns the number of columns of the alignment.
+func (alignment MSA) Length() int {
+	if len(alignment.Sequences) == 0 {
+		return 0
+	}
+	return len(alignment.Sequences[0].Sequence)
+}
+
+// Validate checks that all sequences and per-column annotations of the
+// alignment have the same length and that sequence names are unique.
+func (alignment MSA) Validate() error {
+	if len(alignment.Sequences) == 0 {
+		return ErrEmptyAlignment
+	}
+	length := alignment.Length()
+	names := make(map[string]bool, len(alignment.Sequences))
+	for _, sequence := range alignment.Sequences {
+		if names[sequence.Name] {
+			return fmt.Errorf("sequence %q is in the alignment more than once", sequence.Name)
+		}
+		names[sequence.Name] = true
+		if len(sequence.Sequence) != length {
+			return fmt.Errorf("sequence %q has %d columns, expected %d", sequence.Name, len(sequence.Sequence), length)
+		}
+		for feature, annotation := range sequence.ResidueAnnotations {
+			if len(annotation) != length {
+				return fmt.Errorf("%s annotation of sequence %q has %d columns, expected %d", feature, sequence.Name, len(annotation), length)
+			}
+		}
+	}
+	for feature, annotation := range alignment.ColumnAnnotations {
+		if len(annotation) != length {
+			return fmt.Errorf("%s column annotation has %d columns, expected %d", feature, len(annotation), length)
+		}
+	}
+	return nil
+}
+
+// Slice returns the columns from start up to, but not including, end of the
+// alignment, together with the matching part of the per-column annotations.
+// Columns are counted from 0.
+func (alignment MSA) Slice(start, end int) (MSA, error) {
+	if start < 0 || end > alignment.Length() || start > end {
+		return MSA{}, fmt.Errorf("columns %d to %d are out of the alignment of %d columns", start, end, alignment.Length())
+	}
+	sliced := MSA{
+		Sequences:         make([]Sequence, len(alignment.Sequences)),
+		Annotations:       append([]Annotation(nil), alignment.Annotations...),
+		ColumnAnnotations: sliceAnnotations(alignment.ColumnAnnotations, start, end),
+	}
+	for index, sequence := range alignment.Sequences {
+		sliced.Sequences[index] = Sequence{
+			Name:               sequence.Name,
+			Sequence:           sequence.Sequence[start:end],
+			Annotations:        append([]Annotation(nil), sequence.Annotations...),
+			ResidueAnnotations: sliceAnnotations(sequence.ResidueAnnotations, start, end),
+		}
+	}
+	return sliced, nil
+}
+
+// sliceAnnotations slices every per-column annotation of a map.
+func sliceAnnotations(annotations map[string]string, start, end int) map[string]string {
+	if annotations == nil {
+		return nil
+	}
+	sliced := make(map[string]string, len(annotations))
+	for feature, annotation := range annotations {
+		sliced[feature] = annotation[start:end]
+	}
+	return sliced
+}
+
+// Column returns the residues of every sequence at a column, counted from 0.
+func (alignment MSA) Column(index int) string {
+	column := make([]byte, len(alignment.Sequences))
+	for sequenceIndex, sequence := range alignment.Sequences {
+		column[sequenceIndex] = sequence.Sequence[index]
+	}
+	return string(column)
+}
+
+// residueCounts counts the residues of a column, ignoring case and gaps.
+func residueCounts(column string) map[byte]int {
+	counts := make(map[byte]int)
+	for index := 0; index < len(column); index++ {
+		if !isGap(column[index]) {
+			counts[upper(column[index])]++
+		}
+	}
+	return counts
+}
+
+// upper uppercases an ASCII letter.
+func upper(character byte) byte {
+	if 'a' <= character && character <= 'z' {
+		return character - 'a' + 'A'
+	}
+	return character
+}
+
+// mostCommonResidue returns the most common residue of a column and how often
+// it is found. Ties are broken by taking the alphabetically first residue.
+func mostCommonResidue(column string) (byte, int) {
+	var best byte
+	var bestCount int
+	for residue, count := range residueCounts(column) {
+		if count > bestCount || (count == bestCount && residue < best) {
+			best, bestCount = residue, count
+		}
+	}
+	return best, bestCount
+}
+
+// Consensus returns the most common residue of every column, in upper case.
+// Gaps are not counted, and columns that only hold gaps get a -. Ties are
+// broken by taking the alphabetically first residue.
+func (alignment MSA) Consensus() string {
+	consensus := make([]byte, alignment.Length())
+	for index := range consensus {
+		residue, count := mostCommonResidue(alignment.Column(index))
+		if count == 0 {
+			residue = '-'
+		}
+		consensus[index] = residue
+	}
+	return string(consensus)
+}
+
+// Conservation returns, for every column, the fraction of sequences that have
+// the consensus residue of that column. Gaps count as sequences without the
+// consensus residue, so a column is only fully conserved if none of the
+// sequences has a gap.
+func (alignment MSA) Conservation() []float64 {
+	conservation := make([]float64, alignment.Length())
+	for index := range conservation {
+		_, count := mostCommonResidue(alignment.Column(index))
+		conservation[index] = float64(count) / float64(len(alignment.Sequences))
+	}
+	return conservation
+}
+
+// Groups of amino acids used by Clustal to mark columns that aren't
+// identical, but only have residues with similar properties.
+var (
+	strongGroups = []string{"STA", "NEQK", "NHQK", "NDEQ", "QHRK", "MILV", "MILF", "HY", "FYW"}
+	weakGroups   = []string{"CSA", "ATV", "SAG", "STNK", "STPA", "SGND", "SNDEQK", "NDEQHK", "NEQHRK", "FVLIM", "HFY"}
+)
+
+// ConservationSymbols returns the conservation line Clustal writes below each
+// block of an alignment. Columns are marked with
+//
+//   - * if all sequences have the same residue,
+//   - : if all residues are in one of the strongly similar groups of Clustal,
+//   - . if all residues are in one of the weakly similar groups of Clustal,
+//   - a space otherwise, and for all columns with a gap.
+//
+// Similarity is only marked for protein alignments. Alignments that only hold
+// A, C, G, T, U and N are treated as nucleotide alignments.
+func (alignment MSA) ConservationSymbols() string {
+	nucleotides := alignment.isNucleotide()
+	symbols := make([]byte, alignment.Length())
+	for index := range symbols {
+		column := alignment.Column(index)
+		counts := residueCounts(column)
+		hasGap := strings.IndexFunc(column, func(character rune) bool { return isGap(byte(character)) }) != -1
+		switch {
+		case hasGap:
+			symbols[index] = ' '
+		case len(counts) == 1:
+			symbols[index] = '*'
+		case nucleotides:
+			symbols[index] = ' '
+		case inGroup(counts, strongGroups):
+			symbols[index] = ':'
+		case inGroup(counts, weakGroups):
+			symbols[index] = '.'
+		default:
+			symbols[index] = ' '
+		}
+	}
+	return string(symbols)
+}
+
+// inGroup checks if all residues of a column are in one of the groups.
+func inGroup(counts map[byte]int, groups []string) bool {
+	for _, group := range groups {
+		contained := true
+		for residue := range counts {
+			if strings.IndexByte(group, residue) == -1 {
+				contained = false
+				break
+			}
+		}
+		if contained {
+			return true
+		}
+	}
+	return false
+}
+
+// isNucleotide checks if all residues of the alignment are nucleotides.
+func (alignment MSA) isNucleotide() bool {
+	for _, sequence := range alignment.Sequences {
+		for index := 0; index < len(sequence.Sequence); index++ {
+			character := sequence.Sequence[index]
+			if !isGap(character) && strings.IndexByte("ACGTUN", upper(character)) == -1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a map of annotations in sorted order, so
+// that writers give the same output every time.
+func sortedKeys(annotations map[string]string) []string {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,253 @@
+package msa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatsAgree(t *testing.T) {
+	clustal, err := ReadClustal("data/example.aln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fasta, err := ReadFasta("data/example.fasta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stockholm, err := ReadStockholm("data/example.sto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stockholm) != 1 {
+		t.Fatalf("got %d Stockholm alignments, want 1", len(stockholm))
+	}
+	for _, alignment := range []MSA{clustal, fasta, stockholm[0]} {
+		if len(alignment.Sequences) != 4 || alignment.Length() != 76 {
+			t.Fatalf("got %d sequences of %d columns, want 4 of 76", len(alignment.Sequences), alignment.Length())
+		}
+		for index, sequence := range alignment.Sequences {
+			// Stockholm uses . for gaps.
+			if strings.ReplaceAll(sequence.Sequence, ".", "-") != clustal.Sequences[index].Sequence {
+				t.Errorf("sequence %s differs from the Clustal alignment", sequence.Name)
+			}
+		}
+	}
+	if clustal.Sequences[2].Name != "HBB_HUMAN" || fasta.Sequences[2].Name != "sp|P68871|HBB_HUMAN" {
+		t.Errorf("unexpected names %s and %s", clustal.Sequences[2].Name, fasta.Sequences[2].Name)
+	}
+}
+
+func TestParseStockholm_annotations(t *testing.T) {
+	alignments, err := ReadStockholm("data/example.sto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alignment := alignments[0]
+	assert.Equal(t, []Annotation{
+		{Feature: "ID", Value: "Globin"},
+		{Feature: "AC", Value: "PF00042.25"},
+		{Feature: "DE", Value: "Globin"},
+		{Feature: "CC", Value: "Globins are heme proteins, which bind and transport oxygen."},
+	}, alignment.Annotations)
+	assert.Equal(t, []Annotation{{Feature: "AC", Value: "P68871"}, {Feature: "DE", Value: "Hemoglobin subunit beta"}}, alignment.Sequences[2].Annotations)
+	secondaryStructure := alignment.Sequences[2].ResidueAnnotations["SS"]
+	if len(secondaryStructure) != 76 || !strings.HasPrefix(secondaryStructure, "CCHHH") {
+		t.Errorf("unexpected #=GR SS annotation %q", secondaryStructure)
+	}
+	if alignment.ColumnAnnotations["SS_cons"] != secondaryStructure {
+		t.Errorf("unexpected #=GC SS_cons annotation %q", alignment.ColumnAnnotations["SS_cons"])
+	}
+	if alignment.Sequences[0].ResidueAnnotations != nil {
+		t.Errorf("sequences without #=GR lines should not have residue annotations")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	stockholm, err := ReadStockholm("data/example.sto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stockholm keeps everything, including the annotations.
+	stockholmBytes, err := BuildStockholm(append(stockholm, stockholm[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reparsed, err := ParseStockholm(strings.NewReader(string(stockholmBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []MSA{stockholm[0], stockholm[0]}, reparsed)
+
+	clustal, err := ReadClustal("data/example.aln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clustalBytes, err := BuildClustal(clustal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reparsedClustal, err := ParseClustal(strings.NewReader(string(clustalBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, clustal, reparsedClustal)
+	// The conservation lines are the ones Clustal wrote.
+	file, _ := os.ReadFile("data/example.aln")
+	for _, line := range strings.Split(string(clustalBytes), "\n") {
+		if strings.HasPrefix(line, " ") && !strings.Contains(string(file), strings.TrimSpace(line)) {
+			t.Errorf("conservation line %q is not in the Clustal file", line)
+		}
+	}
+
+	fasta, err := ReadFasta("data/example.fasta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "example.fasta")
+	if err := WriteFasta(fasta, path); err != nil {
+		t.Fatal(err)
+	}
+	rewritten, err := ReadFasta(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fasta, rewritten)
+}
+
+func TestMSA_Slice(t *testing.T) {
+	alignments, err := ReadStockholm("data/example.sto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alignment := alignments[0]
+	sliced, err := alignment.Slice(2, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sliced.Length() != 4 || sliced.Sequences[0].Sequence != ".LSP" || sliced.Sequences[2].Sequence != "HLTP" {
+		t.Errorf("unexpected sliced sequences %+v", sliced.Sequences)
+	}
+	if sliced.ColumnAnnotations["SS_cons"] != "HHHH" || sliced.Sequences[2].ResidueAnnotations["SS"] != "HHHH" {
+		t.Errorf("per-column annotations should be sliced too")
+	}
+	if err := sliced.Validate(); err != nil {
+		t.Error(err)
+	}
+	// Slicing doesn't change the original alignment.
+	sliced.Sequences[2].Annotations[0].Value = "changed"
+	if alignment.Sequences[2].Annotations[0].Value != "P68871" {
+		t.Errorf("Slice() should copy annotations")
+	}
+
+	for _, columns := range [][2]int{{-1, 3}, {3, 2}, {0, 77}} {
+		if _, err := alignment.Slice(columns[0], columns[1]); err == nil {
+			t.Errorf("Slice(%d, %d) should fail", columns[0], columns[1])
+		}
+	}
+}
+
+func TestMSA_Consensus(t *testing.T) {
+	alignment := MSA{Sequences: []Sequence{
+		{Name: "a", Sequence: "ACGT-A"},
+		{Name: "b", Sequence: "acGA-C"},
+		{Name: "c", Sequence: "ATG--G"},
+		{Name: "d", Sequence: "ATGC-T"},
+	}}
+	if consensus := alignment.Consensus(); consensus != "ACGA-A" {
+		t.Errorf("Consensus() = %s, want ACGA-A", consensus)
+	}
+	assert.Equal(t, []float64{1, 0.5, 1, 0.25, 0, 0.25}, alignment.Conservation())
+	if symbols := alignment.ConservationSymbols(); symbols != "* *   " {
+		t.Errorf("ConservationSymbols() = %q, want %q", symbols, "* *   ")
+	}
+
+	protein := MSA{Sequences: []Sequence{
+		{Name: "a", Sequence: "WSCAK"},
+		{Name: "b", Sequence: "WTSVK"},
+		{Name: "c", Sequence: "WAAA-"},
+	}}
+	if symbols := protein.ConservationSymbols(); symbols != "*:.. " {
+		t.Errorf("ConservationSymbols() = %q, want %q", symbols, "*:.. ")
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func() error
+	}{
+		{name: "clustal without header", parse: func() error {
+			_, err := ParseClustal(strings.NewReader("a ACGT\nb ACGT\n"))
+			return err
+		}},
+		{name: "clustal of different lengths", parse: func() error {
+			_, err := ParseClustal(strings.NewReader("CLUSTAL W\n\na ACGT\nb ACG\n"))
+			return err
+		}},
+		{name: "clustal with bad count", parse: func() error {
+			_, err := ParseClustal(strings.NewReader("CLUSTAL W\n\na ACGT four\nb ACGT 4\n"))
+			return err
+		}},
+		{name: "empty clustal", parse: func() error {
+			_, err := ParseClustal(strings.NewReader(""))
+			return err
+		}},
+		{name: "fasta of different lengths", parse: func() error {
+			_, err := ParseFasta(strings.NewReader(">a\nACGT\n>b\nAC\n"))
+			return err
+		}},
+		{name: "stockholm without header", parse: func() error {
+			_, err := ParseStockholm(strings.NewReader("a ACGT\n//\n"))
+			return err
+		}},
+		{name: "stockholm without end", parse: func() error {
+			_, err := ParseStockholm(strings.NewReader("# STOCKHOLM 1.0\na ACGT\n"))
+			return err
+		}},
+		{name: "stockholm with bad #=GC", parse: func() error {
+			_, err := ParseStockholm(strings.NewReader("# STOCKHOLM 1.0\na ACGT\n#=GC SS_cons\n//\n"))
+			return err
+		}},
+		{name: "stockholm with short #=GR", parse: func() error {
+			_, err := ParseStockholm(strings.NewReader("# STOCKHOLM 1.0\na ACGT\n#=GR a SS HH\n//\n"))
+			return err
+		}},
+		{name: "stockholm with #=GS of a missing sequence", parse: func() error {
+			_, err := ParseStockholm(strings.NewReader("# STOCKHOLM 1.0\n#=GS b AC P1\na ACGT\n//\n"))
+			return err
+		}},
+		{name: "empty stockholm", parse: func() error {
+			_, err := ParseStockholm(strings.NewReader("\n"))
+			return err
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.parse(); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestBuild_errors(t *testing.T) {
+	if _, err := BuildClustal(MSA{}); !errors.Is(err, ErrEmptyAlignment) {
+		t.Errorf("BuildClustal() error = %v, want %v", err, ErrEmptyAlignment)
+	}
+	withSpace := MSA{Sequences: []Sequence{{Name: "a b", Sequence: "AC"}}}
+	if _, err := BuildClustal(withSpace); err == nil {
+		t.Errorf("BuildClustal() should fail for names with whitespace")
+	}
+	if _, err := BuildStockholm([]MSA{withSpace}); err == nil {
+		t.Errorf("BuildStockholm() should fail for names with whitespace")
+	}
+	duplicate := MSA{Sequences: []Sequence{{Name: "a", Sequence: "AC"}, {Name: "a", Sequence: "AG"}}}
+	if _, err := BuildFasta(duplicate); err == nil {
+		t.Errorf("BuildFasta() should fail for duplicate names")
+	}
+}
@@ -0,0 +1,212 @@
+package msa
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stockholmHeader is the first line of every alignment of a Stockholm file.
+const stockholmHeader = "# STOCKHOLM 1.0"
+
+// stockholmParser holds the state of an alignment while it is parsed.
+type stockholmParser struct {
+	alignment     MSA
+	sequenceIndex map[string]int
+}
+
+// sequence returns the sequence with the given name, adding it if it hasn't
+// been seen yet. #=GS lines may come before the sequence itself.
+func (parser *stockholmParser) sequence(name string) *Sequence {
+	index, ok := parser.sequenceIndex[name]
+	if !ok {
+		index = len(parser.alignment.Sequences)
+		parser.sequenceIndex[name] = index
+		parser.alignment.Sequences = append(parser.alignment.Sequences, Sequence{Name: name})
+	}
+	return &parser.alignment.Sequences[index]
+}
+
+// splitFields splits a line into n whitespace separated fields, the last of
+// which holds the rest of the line.
+func splitFields(line string, n int) ([]string, bool) {
+	fields := make([]string, 0, n)
+	rest := strings.TrimSpace(line)
+	for len(fields) < n-1 {
+		end := strings.IndexAny(rest, " \t")
+		if end == -1 {
+			return nil, false
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return append(fields, rest), rest != ""
+}
+
+// ParseStockholm parses a Stockholm file, which may contain several
+// alignments, like the Pfam seed alignments, into MSAs.
+//
+// Sequences and per-column annotations may be split over several blocks.
+// Comments that aren't annotations are skipped.
+func ParseStockholm(r io.Reader) ([]MSA, error) {
+	scanner := bufio.NewScanner(r)
+	// Alignments that aren't interleaved have a line per sequence, which can
+	// get long.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var alignments []MSA
+	var parser *stockholmParser
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if parser == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, stockholmHeader) {
+				return alignments, fmt.Errorf("expected %q on line %d, got: %s", stockholmHeader, lineNumber, line)
+			}
+			parser = &stockholmParser{sequenceIndex: make(map[string]int)}
+			continue
+		}
+		if strings.TrimSpace(line) == "//" {
+			if err := parser.alignment.Validate(); err != nil {
+				return alignments, fmt.Errorf("Error in alignment ending on line %d: %w", lineNumber, err)
+			}
+			alignments = append(alignments, parser.alignment)
+			parser = nil
+			continue
+		}
+		if err := parser.parseLine(line); err != nil {
+			return alignments, fmt.Errorf("Error on line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return alignments, err
+	}
+	if parser != nil {
+		return alignments, fmt.Errorf("alignment is missing its closing //")
+	}
+	if len(alignments) == 0 {
+		return nil, ErrEmptyAlignment
+	}
+	return alignments, nil
+}
+
+// parseLine parses a single line within an alignment of a Stockholm file.
+func (parser *stockholmParser) parseLine(line string) error {
+	switch {
+	case strings.TrimSpace(line) == "":
+		return nil
+	case strings.HasPrefix(line, "#=GF"):
+		fields, ok := splitFields(line, 3)
+		if !ok {
+			return fmt.Errorf("expected #=GF <feature> <text>, got: %s", line)
+		}
+		parser.alignment.Annotations = append(parser.alignment.Annotations, Annotation{Feature: fields[1], Value: fields[2]})
+	case strings.HasPrefix(line, "#=GS"):
+		fields, ok := splitFields(line, 4)
+		if !ok {
+			return fmt.Errorf("expected #=GS <sequence> <feature> <text>, got: %s", line)
+		}
+		sequence := parser.sequence(fields[1])
+		sequence.Annotations = append(sequence.Annotations, Annotation{Feature: fields[2], Value: fields[3]})
+	case strings.HasPrefix(line, "#=GR"):
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return fmt.Errorf("expected #=GR <sequence> <feature> <annotation>, got: %s", line)
+		}
+		sequence := parser.sequence(fields[1])
+		if sequence.ResidueAnnotations == nil {
+			sequence.ResidueAnnotations = make(map[string]string)
+		}
+		sequence.ResidueAnnotations[fields[2]] += fields[3]
+	case strings.HasPrefix(line, "#=GC"):
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return fmt.Errorf("expected #=GC <feature> <annotation>, got: %s", line)
+		}
+		if parser.alignment.ColumnAnnotations == nil {
+			parser.alignment.ColumnAnnotations = make(map[string]string)
+		}
+		parser.alignment.ColumnAnnotations[fields[1]] += fields[2]
+	case strings.HasPrefix(line, "#"):
+		// Plain comments are skipped.
+	default:
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("expected <sequence> <aligned sequence>, got: %s", line)
+		}
+		parser.sequence(fields[0]).Sequence += fields[1]
+	}
+	return nil
+}
+
+// BuildStockholm writes MSAs in Stockholm format. Every alignment is written
+// in a single block, with the #=GR annotations of a sequence right below it.
+// Per-column annotations are written in alphabetical order of their features.
+func BuildStockholm(alignments []MSA) ([]byte, error) {
+	var stockholm bytes.Buffer
+	for _, alignment := range alignments {
+		if err := alignment.Validate(); err != nil {
+			return nil, err
+		}
+		// Sequences and annotations start in the same column.
+		nameWidth := 0
+		for _, sequence := range alignment.Sequences {
+			if strings.ContainsAny(sequence.Name, " \t") {
+				return nil, fmt.Errorf("sequence name %q contains whitespace, which Stockholm doesn't allow", sequence.Name)
+			}
+			nameWidth = max(nameWidth, len(sequence.Name))
+			for feature := range sequence.ResidueAnnotations {
+				nameWidth = max(nameWidth, len("#=GR  ")+len(sequence.Name)+len(feature))
+			}
+		}
+		for feature := range alignment.ColumnAnnotations {
+			nameWidth = max(nameWidth, len("#=GC ")+len(feature))
+		}
+		nameWidth++
+
+		stockholm.WriteString(stockholmHeader + "\n")
+		for _, annotation := range alignment.Annotations {
+			fmt.Fprintf(&stockholm, "#=GF %s %s\n", annotation.Feature, annotation.Value)
+		}
+		for _, sequence := range alignment.Sequences {
+			for _, annotation := range sequence.Annotations {
+				fmt.Fprintf(&stockholm, "#=GS %s %s %s\n", sequence.Name, annotation.Feature, annotation.Value)
+			}
+		}
+		stockholm.WriteString("\n")
+		for _, sequence := range alignment.Sequences {
+			fmt.Fprintf(&stockholm, "%-*s%s\n", nameWidth, sequence.Name, sequence.Sequence)
+			for _, feature := range sortedKeys(sequence.ResidueAnnotations) {
+				fmt.Fprintf(&stockholm, "%-*s%s\n", nameWidth, "#=GR "+sequence.Name+" "+feature, sequence.ResidueAnnotations[feature])
+			}
+		}
+		for _, feature := range sortedKeys(alignment.ColumnAnnotations) {
+			fmt.Fprintf(&stockholm, "%-*s%s\n", nameWidth, "#=GC "+feature, alignment.ColumnAnnotations[feature])
+		}
+		stockholm.WriteString("//\n")
+	}
+	return stockholm.Bytes(), nil
+}
+
+// ReadStockholm reads a Stockholm file from path.
+func ReadStockholm(path string) ([]MSA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseStockholm(file)
+}
+
+// WriteStockholm writes MSAs in Stockholm format to path.
+func WriteStockholm(alignments []MSA, path string) error {
+	stockholmBytes, err := BuildStockholm(alignments)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, stockholmBytes, 0644)
+}
@@ -0,0 +1,335 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/bebop/poly/io/bgzf"
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/fastq"
+	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/io/gff"
+	"github.com/bebop/poly/io/polyjson"
+	"github.com/bebop/poly/io/slow5"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Format detection begins here.
+
+Files don't always come with a useful extension. Luckily every format we
+support can be recognized by its first few bytes:
+
+	FASTA:    >name
+	FASTQ:    @name
+	GenBank:  LOCUS, possibly after the header of a GenBank release file
+	GFF:      ##gff-version
+	polyjson: {
+	slow5:    #slow5_version
+
+Compressed files are recognized by the gzip magic bytes 1f 8b. BGZF files are
+gzip files whose header has a BC extra field, so they are detected as well and
+are decompressed block by block.
+
+Open and NewParser detect the format and return a Parser that yields the
+records of the file one at a time, while Read returns all of them at once.
+
+******************************************************************************/
+
+// Format is a file format that can be detected by DetectFormat.
+type Format int
+
+// Formats that can be detected.
+const (
+	Unknown Format = iota
+	Fasta
+	Fastq
+	Genbank
+	Gff
+	PolyJSON
+	Slow5
+)
+
+// String returns the name of a Format.
+func (format Format) String() string {
+	switch format {
+	case Fasta:
+		return "fasta"
+	case Fastq:
+		return "fastq"
+	case Genbank:
+		return "genbank"
+	case Gff:
+		return "gff"
+	case PolyJSON:
+		return "polyjson"
+	case Slow5:
+		return "slow5"
+	}
+	return "unknown"
+}
+
+// Compression is the compression of a file.
+type Compression int
+
+// Compressions that can be detected.
+const (
+	Uncompressed Compression = iota
+	Gzip
+	Bgzf
+)
+
+// String returns the name of a Compression.
+func (compression Compression) String() string {
+	switch compression {
+	case Gzip:
+		return "gzip"
+	case Bgzf:
+		return "bgzf"
+	}
+	return "uncompressed"
+}
+
+// ErrUnknownFormat is returned when the format of a file can't be detected.
+var ErrUnknownFormat = errors.New("unknown file format")
+
+// sniffSize is the number of bytes that are looked at to detect a format.
+const sniffSize = 1024
+
+var (
+	openFn       = os.Open
+	gzipReaderFn = gzip.NewReader
+)
+
+// DetectCompression detects whether the data in r is gzip or BGZF compressed
+// and returns a reader of the decompressed data. The returned reader also
+// returns the bytes that were read for detection.
+func DetectCompression(r io.Reader) (Compression, io.Reader, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(18)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Uncompressed, nil, err
+	}
+	if len(header) < 2 || header[0] != 0x1f || header[1] != 0x8b {
+		return Uncompressed, reader, nil
+	}
+
+	// BGZF blocks are gzip members with FEXTRA set and a "BC" subfield.
+	if len(header) == 18 && header[3]&0x04 != 0 && header[12] == 'B' && header[13] == 'C' {
+		bgzfReader, err := bgzf.NewReader(reader)
+		if err != nil {
+			return Bgzf, nil, err
+		}
+		return Bgzf, bgzfReader, nil
+	}
+	gzipReader, err := gzipReaderFn(reader)
+	if err != nil {
+		return Gzip, nil, err
+	}
+	return Gzip, gzipReader, nil
+}
+
+// DetectFormat detects the format of the uncompressed data in r from its
+// first bytes and returns a reader that also returns the bytes that were read
+// for detection. Leading blank lines are ignored.
+func DetectFormat(r io.Reader) (Format, io.Reader, error) {
+	reader := bufio.NewReader(r)
+	start, err := reader.Peek(sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return Unknown, nil, err
+	}
+	start = bytes.TrimPrefix(start, []byte("\xef\xbb\xbf")) // UTF-8 byte order mark
+	start = bytes.TrimLeft(start, " \t\r\n")
+
+	switch {
+	case bytes.HasPrefix(start, []byte(">")):
+		return Fasta, reader, nil
+	case bytes.HasPrefix(start, []byte("@")):
+		return Fastq, reader, nil
+	case bytes.HasPrefix(start, []byte("LOCUS")):
+		return Genbank, reader, nil
+	case bytes.HasPrefix(start, []byte("##gff-version")):
+		return Gff, reader, nil
+	case bytes.HasPrefix(start, []byte("{")):
+		return PolyJSON, reader, nil
+	case bytes.HasPrefix(start, []byte("#slow5_version")):
+		return Slow5, reader, nil
+	case bytes.Contains(start, []byte("\nLOCUS ")):
+		// GenBank flat file releases start with a header before the first LOCUS.
+		return Genbank, reader, nil
+	}
+	return Unknown, reader, ErrUnknownFormat
+}
+
+// Parser reads the records of a file of any detected format one at a time.
+// It is initialized with NewParser or Open.
+type Parser struct {
+	Format      Format
+	Compression Compression
+	// Slow5Headers holds the headers of a slow5 file, which are read by NewParser.
+	Slow5Headers []slow5.Header
+	next         func() (any, error)
+	closer       io.Closer
+}
+
+// NewParser detects the compression and format of r and returns a Parser
+// for it. maxLineSize is passed on to the parsers of line based formats.
+func NewParser(r io.Reader, maxLineSize int) (*Parser, error) {
+	compression, decompressed, err := DetectCompression(r)
+	if err != nil {
+		return nil, err
+	}
+	format, reader, err := DetectFormat(decompressed)
+	if err != nil {
+		return nil, err
+	}
+	parser := &Parser{Format: format, Compression: compression}
+
+	switch format {
+	case Fasta:
+		fastaParser := fasta.NewParser(reader, maxLineSize)
+		parser.next = func() (any, error) {
+			record, _, err := fastaParser.ParseNext()
+			return record, err
+		}
+	case Fastq:
+		fastqParser := fastq.NewParser(reader, maxLineSize)
+		parser.next = func() (any, error) {
+			record, _, err := fastqParser.ParseNext()
+			return record, err
+		}
+	case Genbank:
+		genbankParser := genbank.NewParser(reader, maxLineSize)
+		parser.next = func() (any, error) {
+			return genbankParser.ParseNext()
+		}
+	case Slow5:
+		slow5Parser, headers, err := slow5.NewParser(reader, maxLineSize)
+		if err != nil {
+			return nil, err
+		}
+		parser.Slow5Headers = headers
+		parser.next = func() (any, error) {
+			return slow5Parser.ParseNext()
+		}
+	case Gff:
+		parser.next = parseOnce(func() (any, error) { return gff.Parse(reader) })
+	case PolyJSON:
+		parser.next = parseOnce(func() (any, error) { return polyjson.Parse(reader) })
+	}
+	return parser, nil
+}
+
+// parseOnce turns the parser of a format that holds a single record per file
+// into a function that returns the record once, followed by io.EOF.
+func parseOnce(parse func() (any, error)) func() (any, error) {
+	done := false
+	return func() (any, error) {
+		if done {
+			return nil, io.EOF
+		}
+		done = true
+		return parse()
+	}
+}
+
+// Open opens a file of any supported format, compressed or not, and returns a
+// Parser for it. The Parser must be closed with Close.
+func Open(path string) (*Parser, error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	file, err := openFn(path)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := NewParser(file, maxLineSize)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Failed to open %s: %w", path, err)
+	}
+	parser.closer = file
+	return parser, nil
+}
+
+// Next returns the next record. Depending on the Format of the Parser it is
+// a fasta.Fasta, fastq.Fastq, genbank.Genbank, gff.Gff, polyjson.Poly or
+// slow5.Read. gff and polyjson files hold a single record. Next returns
+// io.EOF once all records have been read.
+func (parser *Parser) Next() (any, error) {
+	return parser.next()
+}
+
+// Close closes the file opened by Open. It does nothing for a Parser made
+// with NewParser.
+func (parser *Parser) Close() error {
+	if parser.closer == nil {
+		return nil
+	}
+	return parser.closer.Close()
+}
+
+// Records holds all records of a file. Only the field of the detected Format
+// is filled.
+type Records struct {
+	Format       Format
+	Compression  Compression
+	Fasta        []fasta.Fasta
+	Fastq        []fastq.Fastq
+	Genbank      []genbank.Genbank
+	Gff          []gff.Gff
+	PolyJSON     []polyjson.Poly
+	Slow5Headers []slow5.Header
+	Slow5        []slow5.Read
+}
+
+// ParseN reads up to maxRecords records into Records. It does not return
+// io.EOF if it is encountered.
+func (parser *Parser) ParseN(maxRecords int) (Records, error) {
+	records := Records{Format: parser.Format, Compression: parser.Compression, Slow5Headers: parser.Slow5Headers}
+	for counter := 0; counter < maxRecords; counter++ {
+		record, err := parser.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return records, err
+		}
+		switch record := record.(type) {
+		case fasta.Fasta:
+			records.Fasta = append(records.Fasta, record)
+		case fastq.Fastq:
+			records.Fastq = append(records.Fastq, record)
+		case genbank.Genbank:
+			records.Genbank = append(records.Genbank, record)
+		case gff.Gff:
+			records.Gff = append(records.Gff, record)
+		case polyjson.Poly:
+			records.PolyJSON = append(records.PolyJSON, record)
+		case slow5.Read:
+			records.Slow5 = append(records.Slow5, record)
+		}
+	}
+	return records, nil
+}
+
+// ParseAll reads all remaining records into Records.
+func (parser *Parser) ParseAll() (Records, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// Read reads all records of a file of any supported format, compressed or not.
+func Read(path string) (Records, error) {
+	parser, err := Open(path)
+	if err != nil {
+		return Records{}, err
+	}
+	defer parser.Close()
+	return parser.ParseAll()
+}
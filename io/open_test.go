@@ -0,0 +1,144 @@
+package io
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/bgzf"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		path            string
+		wantFormat      Format
+		wantCompression Compression
+		wantRecords     int
+	}{
+		{"fasta/data/base.fasta", Fasta, Uncompressed, 2},
+		{"fasta/data/uniprot_1mb_test.fasta.gz", Fasta, Gzip, 0},
+		{"fastq/data/nanosavseq.fastq", Fastq, Uncompressed, 4},
+		{"fastq/data/nanosavseq.fastq.gz", Fastq, Gzip, 4},
+		{"../data/multiGbk_test.seq", Genbank, Uncompressed, 2},
+		{"../data/flatGbk_test.seq.gz", Genbank, Gzip, 2},
+		{"../data/ecoli-mg1655-short.gff", Gff, Uncompressed, 1},
+		{"../data/cat.json", PolyJSON, Uncompressed, 1},
+		{"slow5/data/example.slow5", Slow5, Uncompressed, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			records, err := Read(tt.path)
+			if err != nil {
+				t.Fatalf("Read() failed: %s", err)
+			}
+			if records.Format != tt.wantFormat || records.Compression != tt.wantCompression {
+				t.Errorf("Read() detected %s %s, want %s %s", records.Compression, records.Format, tt.wantCompression, tt.wantFormat)
+			}
+			count := len(records.Fasta) + len(records.Fastq) + len(records.Genbank) + len(records.Gff) + len(records.PolyJSON) + len(records.Slow5)
+			if tt.wantRecords > 0 && count != tt.wantRecords {
+				t.Errorf("Read() returned %d records, want %d", count, tt.wantRecords)
+			}
+			if count == 0 {
+				t.Errorf("Read() returned no records")
+			}
+		})
+	}
+}
+
+func TestNewParser_bgzf(t *testing.T) {
+	original, err := os.ReadFile("fastq/data/nanosavseq.fastq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	writer := bgzf.NewWriter(&compressed)
+	if _, err = writer.Write(original); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := NewParser(&compressed, 2*32*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser.Format != Fastq || parser.Compression != Bgzf {
+		t.Errorf("NewParser() detected %s %s, want bgzf fastq", parser.Compression, parser.Format)
+	}
+	records, err := parser.ParseAll()
+	if err != nil || len(records.Fastq) != 4 {
+		t.Errorf("ParseAll() returned %d records and error %v", len(records.Fastq), err)
+	}
+	if _, err = parser.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() at end of file returned %v, want EOF", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Format
+	}{
+		{"\n\n>seq\nACGT\n", Fasta},
+		{"\xef\xbb\xbfLOCUS       test", Genbank},
+		{"##gff-version 3\n", Gff},
+		{"  {\"meta\": {}}", PolyJSON},
+		{"#slow5_version\t0.2.0\n", Slow5},
+		{"ACGT\n", Unknown},
+		{"", Unknown},
+	}
+	for _, tt := range tests {
+		format, reader, err := DetectFormat(strings.NewReader(tt.input))
+		if format != tt.want {
+			t.Errorf("DetectFormat(%q) = %s, want %s", tt.input, format, tt.want)
+		}
+		if tt.want == Unknown {
+			if !errors.Is(err, ErrUnknownFormat) {
+				t.Errorf("DetectFormat(%q) returned %v, want ErrUnknownFormat", tt.input, err)
+			}
+			continue
+		}
+		// the bytes read for detection must not be lost.
+		data, _ := io.ReadAll(reader)
+		if string(data) != tt.input {
+			t.Errorf("DetectFormat(%q) reader returned %q", tt.input, data)
+		}
+	}
+}
+
+func TestOpen_errors(t *testing.T) {
+	if _, err := Open("does/not/exist.fasta"); err == nil {
+		t.Errorf("Open() of a missing file should fail")
+	}
+
+	tmpDataDir, err := os.MkdirTemp("", "data-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDataDir)
+	path := filepath.Join(tmpDataDir, "unknown.txt")
+	if err = os.WriteFile(path, []byte("hello world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Open(path); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Open() of an unknown format returned %v, want ErrUnknownFormat", err)
+	}
+
+	gzipErr := errors.New("gzip error")
+	oldGzipReaderFn := gzipReaderFn
+	gzipReaderFn = func(r io.Reader) (*gzip.Reader, error) {
+		return nil, gzipErr
+	}
+	defer func() {
+		gzipReaderFn = oldGzipReaderFn
+	}()
+	if _, err = Open("fastq/data/nanosavseq.fastq.gz"); !errors.Is(err, gzipErr) {
+		t.Errorf("Open() returned %v, want %v", err, gzipErr)
+	}
+}
@@ -0,0 +1,344 @@
+package pileup
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/vcf"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Pileup analysis begins here.
+
+A Pileup only stores the raw read results of a position. To confirm a
+sequencing run, like nanopore sequencing of a whole plasmid, those results
+have to be turned into the alleles found at every position:
+
+	. and ,      a read matching the reference base, on the forward and
+	             reverse strand
+	ACGTN/acgtn  a read with a different base
+	*            a read with a deletion of this position, announced by a -
+	             token at an earlier position
+	+3ACG        an insertion of ACG right after the base of the read
+	-2AC         a deletion of the 2 reference bases AC after the base of the
+	             read
+	^]. and .$   the start of a read, with its mapping quality, and the end of
+	             a read
+
+Every read covering a position, including those with a *, has a quality value
+in Quality. Alleles are counted both as reads and as the sum of the
+probability that those reads are right, 1 - 10^(-Q/10), so that low quality
+reads count less. Indels are weighted by the quality of the base they follow.
+
+From the alleles, Consensus calls a consensus sequence, using IUPAC codes for
+positions with more than one base, and CallVariants reports SNVs and indels
+with their allele frequency and depth.
+
+******************************************************************************/
+
+// Allele counts the reads supporting a single allele at a position.
+type Allele struct {
+	// Count is the number of reads with the allele.
+	Count int `json:"count"`
+	// Weight is the sum of the probability that each of those reads is right,
+	// computed from their quality.
+	Weight float64 `json:"weight"`
+}
+
+// AlleleCounts holds the alleles found at a single position of a pileup.
+type AlleleCounts struct {
+	Sequence      string `json:"sequence"`
+	Position      uint   `json:"position"`
+	ReferenceBase string `json:"reference_base"` // in upper case
+	// Depth is the number of reads covering the position, including reads
+	// with a deletion of the position.
+	Depth int `json:"depth"`
+	// Weight is the summed weight of all reads covering the position.
+	Weight float64 `json:"weight"`
+	// Bases maps A, C, G, T and N to the reads with that base, and * to
+	// the reads with a deletion of the position.
+	Bases map[string]Allele `json:"bases"`
+	// Insertions maps inserted sequences, in upper case, to the reads with
+	// that insertion right after the position.
+	Insertions map[string]Allele `json:"insertions"`
+	// Deletions maps deleted reference sequences, in upper case, to the reads
+	// with that deletion right after the position.
+	Deletions map[string]Allele `json:"deletions"`
+}
+
+// Frequency returns the fraction of the weight of all reads covering the
+// position that an allele has.
+func (counts AlleleCounts) Frequency(allele Allele) float64 {
+	if counts.Weight == 0 {
+		return 0
+	}
+	return allele.Weight / counts.Weight
+}
+
+// qualityWeight returns the probability that a base with a phred+33 encoded
+// quality is right.
+func qualityWeight(quality byte) float64 {
+	phred := max(int(quality)-33, 0)
+	return 1 - math.Pow(10, -float64(phred)/10)
+}
+
+// add adds a read with weight to the allele stored in alleles under key.
+func add[K comparable](alleles map[K]Allele, key K, weight float64) {
+	allele := alleles[key]
+	allele.Count++
+	allele.Weight += weight
+	alleles[key] = allele
+}
+
+// CountAlleles decodes the read results of a pileup into the alleles found
+// at its position.
+func CountAlleles(pileup Pileup) (AlleleCounts, error) {
+	counts := AlleleCounts{
+		Sequence:   pileup.Sequence,
+		Position:   pileup.Position,
+		Bases:      make(map[string]Allele),
+		Insertions: make(map[string]Allele),
+		Deletions:  make(map[string]Allele),
+	}
+	if len(pileup.ReferenceBase) != 1 {
+		return AlleleCounts{}, fmt.Errorf("expected a single reference base at position %d, got %q", pileup.Position, pileup.ReferenceBase)
+	}
+	counts.ReferenceBase = strings.ToUpper(pileup.ReferenceBase)
+
+	// lastWeight is the weight of the base of the last read, which is also
+	// used for an indel following it.
+	lastWeight := -1.0
+	for _, result := range pileup.ReadResults {
+		result = strings.TrimSuffix(result, "$")
+		if result == "" {
+			return AlleleCounts{}, fmt.Errorf("empty read result at position %d", pileup.Position)
+		}
+		switch result[0] {
+		case '+', '-':
+			if lastWeight < 0 {
+				return AlleleCounts{}, fmt.Errorf("indel %s at position %d doesn't follow a read", result, pileup.Position)
+			}
+			sequence := strings.ToUpper(strings.TrimLeft(result[1:], "0123456789"))
+			if result[0] == '+' {
+				add(counts.Insertions, sequence, lastWeight)
+			} else {
+				add(counts.Deletions, sequence, lastWeight)
+			}
+			continue
+		case '^':
+			// ^ is followed by the mapping quality of the read.
+			if len(result) != 3 {
+				return AlleleCounts{}, fmt.Errorf("invalid read start %q at position %d", result, pileup.Position)
+			}
+			result = result[2:]
+		}
+		if counts.Depth >= len(pileup.Quality) {
+			return AlleleCounts{}, fmt.Errorf("position %d has more reads than its %d quality values", pileup.Position, len(pileup.Quality))
+		}
+		lastWeight = qualityWeight(pileup.Quality[counts.Depth])
+		base := strings.ToUpper(result[:1])
+		if base == "." || base == "," {
+			base = counts.ReferenceBase
+		}
+		add(counts.Bases, base, lastWeight)
+		counts.Depth++
+		counts.Weight += lastWeight
+	}
+	if counts.Depth != len(pileup.Quality) {
+		return AlleleCounts{}, fmt.Errorf("position %d has %d reads but %d quality values", pileup.Position, counts.Depth, len(pileup.Quality))
+	}
+	return counts, nil
+}
+
+// iupacCodes holds the IUPAC code of every set of bases, indexed by a bit
+// mask of A = 1, C = 2, G = 4 and T = 8.
+const iupacCodes = "-ACMGRSVTWYHKDBN"
+
+// baseBits maps bases to their bit in the index of iupacCodes.
+var baseBits = map[string]int{"A": 1, "C": 2, "G": 4, "T": 8}
+
+// Consensus calls a consensus sequence for every reference sequence of the
+// pileups, which have to be sorted by position.
+//
+// Positions with fewer than minDepth reads, or that aren't in the pileups,
+// get an N. Otherwise every base with at least minFrequency of the reads is
+// part of the consensus, and positions with more than one of those bases get
+// their IUPAC code. Deleted positions are left out, and insertions added,
+// when the majority of the reads has them.
+func Consensus(pileups []Pileup, minDepth int, minFrequency float64) ([]fasta.Fasta, error) {
+	var consensus []fasta.Fasta
+	var sequence strings.Builder
+	var lastPosition uint
+	finish := func() {
+		if len(consensus) > 0 {
+			consensus[len(consensus)-1].Sequence = sequence.String()
+		}
+		sequence.Reset()
+	}
+	for _, pileup := range pileups {
+		if len(consensus) == 0 || consensus[len(consensus)-1].Name != pileup.Sequence {
+			finish()
+			consensus = append(consensus, fasta.Fasta{Name: pileup.Sequence})
+			lastPosition = 0
+		}
+		if pileup.Position <= lastPosition {
+			return nil, fmt.Errorf("pileups of %s aren't sorted: position %d follows position %d", pileup.Sequence, pileup.Position, lastPosition)
+		}
+		counts, err := CountAlleles(pileup)
+		if err != nil {
+			return nil, err
+		}
+		// Positions without reads aren't in the pileups.
+		sequence.WriteString(strings.Repeat("N", int(pileup.Position-lastPosition-1)))
+		lastPosition = pileup.Position
+
+		if counts.Depth < minDepth {
+			sequence.WriteByte('N')
+			continue
+		}
+		if counts.Frequency(counts.Bases["*"]) <= 0.5 {
+			sequence.WriteByte(counts.consensusBase(minFrequency))
+		}
+		if insertion, allele := mostCommon(counts.Insertions); counts.Frequency(allele) > 0.5 {
+			sequence.WriteString(insertion)
+		}
+	}
+	finish()
+	return consensus, nil
+}
+
+// consensusBase returns the IUPAC code of the bases with at least
+// minFrequency of the reads, or N if there are none.
+func (counts AlleleCounts) consensusBase(minFrequency float64) byte {
+	mask := 0
+	for base, bit := range baseBits {
+		allele, ok := counts.Bases[base]
+		if ok && counts.Frequency(allele) >= minFrequency {
+			mask |= bit
+		}
+	}
+	if mask == 0 {
+		return 'N'
+	}
+	return iupacCodes[mask]
+}
+
+// mostCommon returns the allele with the highest weight. Ties are broken by
+// taking the alphabetically first allele.
+func mostCommon(alleles map[string]Allele) (string, Allele) {
+	var best string
+	var bestAllele Allele
+	for sequence, allele := range alleles {
+		if allele.Weight > bestAllele.Weight || (allele.Weight == bestAllele.Weight && sequence < best) {
+			best, bestAllele = sequence, allele
+		}
+	}
+	return best, bestAllele
+}
+
+// VariantType is the type of a Variant.
+type VariantType string
+
+// The types of variants reported by CallVariants.
+const (
+	SNV       VariantType = "SNV"
+	Insertion VariantType = "insertion"
+	Deletion  VariantType = "deletion"
+)
+
+// Variant is a difference between the reads and the reference sequence.
+// Reference and Alternate are written like in VCF files: indels include the
+// reference base before them, at Position.
+type Variant struct {
+	Sequence  string      `json:"sequence"`
+	Position  uint        `json:"position"`
+	Type      VariantType `json:"type"`
+	Reference string      `json:"reference"`
+	Alternate string      `json:"alternate"`
+	// Depth is the number of reads covering the position.
+	Depth int `json:"depth"`
+	// Count is the number of reads with the variant.
+	Count int `json:"count"`
+	// AlleleFrequency is the quality weighted fraction of the reads with the
+	// variant.
+	AlleleFrequency float64 `json:"allele_frequency"`
+}
+
+// CallVariants reports the SNVs and indels found in at least minFrequency of
+// the quality weighted reads, at positions with at least minDepth reads.
+// Variants are sorted by position, with SNVs before insertions and
+// deletions at the same position.
+func CallVariants(pileups []Pileup, minDepth int, minFrequency float64) ([]Variant, error) {
+	var variants []Variant
+	for _, pileup := range pileups {
+		counts, err := CountAlleles(pileup)
+		if err != nil {
+			return nil, err
+		}
+		if counts.Depth < minDepth {
+			continue
+		}
+		reference := counts.ReferenceBase
+		newVariant := func(variantType VariantType, referenceAllele, alternate string, allele Allele) Variant {
+			return Variant{
+				Sequence:        counts.Sequence,
+				Position:        counts.Position,
+				Type:            variantType,
+				Reference:       referenceAllele,
+				Alternate:       alternate,
+				Depth:           counts.Depth,
+				Count:           allele.Count,
+				AlleleFrequency: counts.Frequency(allele),
+			}
+		}
+		for _, base := range []string{"A", "C", "G", "T"} {
+			allele, ok := counts.Bases[base]
+			if ok && base != reference && counts.Frequency(allele) >= minFrequency {
+				variants = append(variants, newVariant(SNV, reference, base, allele))
+			}
+		}
+		for _, insertion := range sortedKeys(counts.Insertions) {
+			if allele := counts.Insertions[insertion]; counts.Frequency(allele) >= minFrequency {
+				variants = append(variants, newVariant(Insertion, reference, reference+insertion, allele))
+			}
+		}
+		for _, deletion := range sortedKeys(counts.Deletions) {
+			if allele := counts.Deletions[deletion]; counts.Frequency(allele) >= minFrequency {
+				variants = append(variants, newVariant(Deletion, reference+deletion, reference, allele))
+			}
+		}
+	}
+	return variants, nil
+}
+
+// sortedKeys returns the keys of a map of alleles in sorted order.
+func sortedKeys(alleles map[string]Allele) []string {
+	keys := make([]string, 0, len(alleles))
+	for key := range alleles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Record converts a Variant into a VCF record, with its depth and allele
+// frequency in the DP and AF INFO fields.
+func (variant Variant) Record() vcf.Record {
+	return vcf.Record{
+		Chromosome: variant.Sequence,
+		Position:   int(variant.Position),
+		Reference:  variant.Reference,
+		Alternates: []string{variant.Alternate},
+		Filters:    []string{"PASS"},
+		Info: []vcf.InfoField{
+			{Key: "DP", Values: []string{strconv.Itoa(variant.Depth)}},
+			{Key: "AF", Values: []string{strconv.FormatFloat(variant.AlleleFrequency, 'f', 4, 64)}},
+		},
+	}
+}
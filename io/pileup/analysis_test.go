@@ -0,0 +1,151 @@
+package pileup
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/fasta"
+	"github.com/bebop/poly/io/vcf"
+	"github.com/stretchr/testify/assert"
+)
+
+const analysisPileup = "p\t1\ta\t5\t^].,.,C\tIIIII\n" + // a C in 1 of 5 reads
+	"p\t2\tc\t5\t.+2GG.+2gg.+2GG..\tIIIII\n" + // an insertion of GG in 3 of 5 reads
+	"p\t3\tg\t5\t.-1T.-1t.-1TA.\tIIIII\n" + // a deletion of T in 3 of 5 reads, and an A in 1
+	"p\t4\tt\t5\t***..\tIIIII\n" + // the deleted T
+	"p\t6\ta\t4\tGgA,$\tIIII\n" + // a G in half of the reads, after a position without reads
+	"p\t7\tc\t1\t.\tI\n" + // too few reads
+	"p\t8\ta\t2\t.C\tI!\n" // a C with a quality of 0
+
+func parseAnalysisPileup(t *testing.T) []Pileup {
+	t.Helper()
+	pileups, err := Parse(strings.NewReader(analysisPileup))
+	if err != nil {
+		t.Fatalf("Failed to parse pileup: %s", err)
+	}
+	return pileups
+}
+
+func TestCountAlleles(t *testing.T) {
+	pileups := parseAnalysisPileup(t)
+
+	counts, err := CountAlleles(pileups[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "A", counts.ReferenceBase)
+	assert.Equal(t, 5, counts.Depth)
+	assert.Equal(t, 4, counts.Bases["A"].Count)
+	assert.Equal(t, 1, counts.Bases["C"].Count)
+	assert.InDelta(t, 0.2, counts.Frequency(counts.Bases["C"]), 1e-9)
+
+	counts, err = CountAlleles(pileups[1])
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Allele{"GG": {Count: 3, Weight: 3 * qualityWeight('I')}}, counts.Insertions)
+
+	counts, err = CountAlleles(pileups[2])
+	assert.NoError(t, err)
+	assert.Equal(t, 3, counts.Deletions["T"].Count)
+	assert.Equal(t, 1, counts.Bases["A"].Count)
+
+	counts, err = CountAlleles(pileups[3])
+	assert.NoError(t, err)
+	assert.Equal(t, 3, counts.Bases["*"].Count)
+
+	// Reads are weighted by their quality.
+	counts, err = CountAlleles(pileups[6])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counts.Bases["C"].Count)
+	assert.Equal(t, 0.0, counts.Frequency(counts.Bases["C"]))
+	assert.InDelta(t, 0.9999, counts.Weight, 1e-9)
+
+	assert.Equal(t, 0.0, AlleleCounts{}.Frequency(Allele{Count: 1}))
+}
+
+func TestCountAlleles_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		pileup Pileup
+		err    string
+	}{
+		{"too few quality values", Pileup{Position: 1, ReferenceBase: "A", ReadResults: []string{".", "."}, Quality: "I"}, "position 1 has more reads than its 1 quality values"},
+		{"too many quality values", Pileup{Position: 1, ReferenceBase: "A", ReadResults: []string{"."}, Quality: "II"}, "position 1 has 1 reads but 2 quality values"},
+		{"indel without read", Pileup{Position: 1, ReferenceBase: "A", ReadResults: []string{"+1A", "."}, Quality: "I"}, "indel +1A at position 1 doesn't follow a read"},
+		{"invalid read start", Pileup{Position: 1, ReferenceBase: "A", ReadResults: []string{"^."}, Quality: "I"}, `invalid read start "^." at position 1`},
+		{"invalid reference base", Pileup{Position: 1, ReferenceBase: "AC"}, `expected a single reference base at position 1, got "AC"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := CountAlleles(test.pileup)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	consensus, err := Consensus(parseAnalysisPileup(t), 2, 0.3)
+	assert.NoError(t, err)
+	assert.Equal(t, []fasta.Fasta{{Name: "p", Sequence: "ACGGGNRNA"}}, consensus)
+
+	// Every reference sequence gets its own consensus.
+	pileups := []Pileup{
+		{Sequence: "first", Position: 2, ReferenceBase: "A", ReadResults: []string{"."}, Quality: "I"},
+		{Sequence: "second", Position: 1, ReferenceBase: "C", ReadResults: []string{",", "T"}, Quality: "II"},
+	}
+	consensus, err = Consensus(pileups, 1, 0.3)
+	assert.NoError(t, err)
+	assert.Equal(t, []fasta.Fasta{{Name: "first", Sequence: "NA"}, {Name: "second", Sequence: "Y"}}, consensus)
+
+	file, err := os.Open("data/test.pileup")
+	if err != nil {
+		t.Fatalf("Failed to open test.pileup: %s", err)
+	}
+	defer file.Close()
+	testPileups, err := Parse(file)
+	assert.NoError(t, err)
+	consensus, err = Consensus(testPileups, 10, 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, []fasta.Fasta{{Name: "pOpen_v3", Sequence: "CACCTGCACCAGTCAGTAAA"}}, consensus)
+
+	_, err = Consensus([]Pileup{pileups[0], pileups[0]}, 1, 0.3)
+	assert.EqualError(t, err, "pileups of first aren't sorted: position 2 follows position 2")
+
+	_, err = Consensus([]Pileup{{Position: 1, ReferenceBase: "A", ReadResults: []string{"."}}}, 1, 0.3)
+	assert.Error(t, err)
+}
+
+func TestCallVariants(t *testing.T) {
+	variants, err := CallVariants(parseAnalysisPileup(t), 2, 0.15)
+	assert.NoError(t, err)
+	expected := []Variant{
+		{Sequence: "p", Position: 1, Type: SNV, Reference: "A", Alternate: "C", Depth: 5, Count: 1, AlleleFrequency: 0.2},
+		{Sequence: "p", Position: 2, Type: Insertion, Reference: "C", Alternate: "CGG", Depth: 5, Count: 3, AlleleFrequency: 0.6},
+		{Sequence: "p", Position: 3, Type: SNV, Reference: "G", Alternate: "A", Depth: 5, Count: 1, AlleleFrequency: 0.2},
+		{Sequence: "p", Position: 3, Type: Deletion, Reference: "GT", Alternate: "G", Depth: 5, Count: 3, AlleleFrequency: 0.6},
+		{Sequence: "p", Position: 6, Type: SNV, Reference: "A", Alternate: "G", Depth: 4, Count: 2, AlleleFrequency: 0.5},
+	}
+	assert.Len(t, variants, len(expected))
+	for index, variant := range variants {
+		assert.InDelta(t, expected[index].AlleleFrequency, variant.AlleleFrequency, 1e-9)
+		variant.AlleleFrequency = expected[index].AlleleFrequency
+		assert.Equal(t, expected[index], variant)
+	}
+
+	variants, err = CallVariants(parseAnalysisPileup(t), 5, 0.5)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 2)
+
+	_, err = CallVariants([]Pileup{{Position: 1, ReferenceBase: "A", ReadResults: []string{"."}}}, 1, 0.3)
+	assert.Error(t, err)
+}
+
+func TestVariant_Record(t *testing.T) {
+	variant := Variant{Sequence: "p", Position: 2, Type: Insertion, Reference: "C", Alternate: "CGG", Depth: 5, Count: 3, AlleleFrequency: 0.6}
+	assert.Equal(t, vcf.Record{
+		Chromosome: "p",
+		Position:   2,
+		Reference:  "C",
+		Alternates: []string{"CGG"},
+		Filters:    []string{"PASS"},
+		Info:       []vcf.InfoField{{Key: "DP", Values: []string{"5"}}, {Key: "AF", Values: []string{"0.6000"}}},
+	}, variant.Record())
+}
@@ -29,7 +29,9 @@ wikipedia (https://en.wikipedia.org/wiki/Pileup_format) is shown below:
 	5. Read Results: The resultant alignments
 	6. Quality: Phred quality scores associated with each base
 
-This package provides a parser and writer for working with pileup files.
+This package provides a parser and writer for working with pileup files, as
+well as CountAlleles, Consensus and CallVariants to turn the read results of
+pileups into allele counts, a consensus sequence and a list of variants.
 */
 package pileup
 
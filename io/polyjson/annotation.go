@@ -0,0 +1,103 @@
+package polyjson
+
+import (
+	"github.com/bebop/poly/io/annotation"
+)
+
+/******************************************************************************
+
+Conversion to and from annotation.Record begins here.
+
+******************************************************************************/
+
+// Records returns the Poly as a single annotation.Record, which makes Poly an
+// annotation.AnnotatedSequence. The Meta is kept in its Extras if it has
+// metadata that a Record has no field for, like the URL and creator.
+func (sequence Poly) Records() []annotation.Record {
+	record := annotation.Record{
+		Name:         sequence.Meta.Name,
+		Description:  sequence.Meta.Description,
+		Sequence:     sequence.Sequence,
+		SequenceHash: sequence.Meta.Hash,
+		Extras:       annotation.CopyExtras(sequence.Meta.Extras),
+	}
+	meta := sequence.Meta
+	meta.Extras = nil
+	if meta.URL != "" || meta.CreatedBy != "" || meta.CreatedWith != "" || !meta.CreatedOn.IsZero() || meta.Schema != "" {
+		record.Extras = append(record.Extras, meta)
+	}
+	for _, feature := range sequence.Features {
+		qualifiers, extras := annotation.MapQualifiers(feature.Tags, feature.Extras, false)
+		record.Features = append(record.Features, annotation.Feature{
+			Type:         feature.Type,
+			Name:         feature.Name,
+			Description:  feature.Description,
+			Qualifiers:   qualifiers,
+			Location:     toAnnotationLocation(feature.Location),
+			SequenceHash: feature.Hash,
+			Sequence:     feature.Sequence,
+			Extras:       extras,
+		})
+	}
+	return []annotation.Record{record}
+}
+
+// FromRecord converts an annotation.Record, for example one of a genbank or
+// gff file, to a Poly. Qualifiers become tags, and fields that polyjson has
+// no place for are kept in Extras. Records that were converted from a Poly
+// get their original Meta back.
+func FromRecord(record annotation.Record) Poly {
+	meta, extras, _ := annotation.TakeExtra[Meta](record.Extras)
+	sequence := Poly{Meta: meta, Sequence: record.Sequence}
+	sequence.Meta.Extras = extras
+	sequence.Meta.Name = record.Name
+	sequence.Meta.Description = record.Description
+	sequence.Meta.Hash = record.SequenceHash
+	for _, recordFeature := range record.Features {
+		tags, extras := annotation.QualifierMap(recordFeature.Qualifiers, recordFeature.Extras, false)
+		feature := Feature{
+			Name:        recordFeature.Name,
+			Hash:        recordFeature.SequenceHash,
+			Type:        recordFeature.Type,
+			Description: recordFeature.Description,
+			Location:    fromAnnotationLocation(recordFeature.Location),
+			Tags:        tags,
+			Sequence:    recordFeature.Sequence,
+			Extras:      extras,
+		}
+		_ = sequence.AddFeature(&feature)
+	}
+	return sequence
+}
+
+// toAnnotationLocation converts a Location to an annotation.Location.
+func toAnnotationLocation(location Location) annotation.Location {
+	converted := annotation.Location{
+		Start:             location.Start,
+		End:               location.End,
+		Complement:        location.Complement,
+		Join:              location.Join,
+		FivePrimePartial:  location.FivePrimePartial,
+		ThreePrimePartial: location.ThreePrimePartial,
+	}
+	for _, subLocation := range location.SubLocations {
+		converted.SubLocations = append(converted.SubLocations, toAnnotationLocation(subLocation))
+	}
+	return converted
+}
+
+// fromAnnotationLocation converts an annotation.Location to a Location.
+func fromAnnotationLocation(location annotation.Location) Location {
+	converted := Location{
+		Start:             location.Start,
+		End:               location.End,
+		Complement:        location.Complement,
+		Join:              location.Join,
+		FivePrimePartial:  location.FivePrimePartial,
+		ThreePrimePartial: location.ThreePrimePartial,
+	}
+	for _, subLocation := range location.SubLocations {
+		converted.SubLocations = append(converted.SubLocations, fromAnnotationLocation(subLocation))
+	}
+	return converted
+}
@@ -13,7 +13,7 @@ import (
 	"os"
 	"time"
 
-	"github.com/bebop/poly/transform"
+	"github.com/bebop/poly/io/annotation"
 )
 
 /******************************************************************************
@@ -45,18 +45,26 @@ type Meta struct {
 	CreatedWith string    `json:"created_with"`
 	CreatedOn   time.Time `json:"created_on"`
 	Schema      string    `json:"schema"`
+	// Extras holds metadata of other formats that polyjson has no place for,
+	// like the references of a genbank file, kept when converting from an
+	// annotation.Record.
+	Extras []any `json:"-"`
 }
 
 // Feature contains all the feature data for a poly feature struct.
 type Feature struct {
-	Name           string            `json:"name"`
-	Hash           string            `json:"hash"`
-	Type           string            `json:"type"`
-	Description    string            `json:"description"`
-	Location       Location          `json:"location"`
-	Tags           map[string]string `json:"tags"`
-	Sequence       string            `json:"sequence"`
-	ParentSequence *Poly             `json:"-"`
+	Name        string            `json:"name"`
+	Hash        string            `json:"hash"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Location    Location          `json:"location"`
+	Tags        map[string]string `json:"tags"`
+	Sequence    string            `json:"sequence"`
+	// Extras holds fields of other formats that polyjson has no place for,
+	// like the score of a gff feature, kept when converting from an
+	// annotation.Record.
+	Extras         []any `json:"-"`
+	ParentSequence *Poly `json:"-"`
 }
 
 // Location contains all the location data for a poly feature's location.
@@ -79,35 +87,7 @@ func (sequence *Poly) AddFeature(feature *Feature) error {
 
 // GetSequence takes a feature and returns a sequence string for that feature.
 func (feature Feature) GetSequence() (string, error) {
-	return getFeatureSequence(feature, feature.Location)
-}
-
-// getFeatureSequence takes a feature and location object and returns a sequence string.
-func getFeatureSequence(feature Feature, location Location) (string, error) {
-	var sequenceBuffer bytes.Buffer
-	var sequenceString string
-	parentSequence := feature.ParentSequence.Sequence
-
-	if len(location.SubLocations) == 0 {
-		sequenceBuffer.WriteString(parentSequence[location.Start:location.End])
-	} else {
-		for _, subLocation := range location.SubLocations {
-			sequence, err := getFeatureSequence(feature, subLocation)
-			if err != nil { // todo: test error
-				return "", err
-			}
-			sequenceBuffer.WriteString(sequence)
-		}
-	}
-
-	// reverse complements resulting string if needed.
-	if location.Complement {
-		sequenceString = transform.ReverseComplement(sequenceBuffer.String())
-	} else {
-		sequenceString = sequenceBuffer.String()
-	}
-
-	return sequenceString, nil
+	return annotation.LocationSequence(feature.ParentSequence.Sequence, toAnnotationLocation(feature.Location))
 }
 
 // Parse parses a Poly JSON file and adds appropriate pointers to struct.
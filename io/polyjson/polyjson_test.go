@@ -104,3 +104,26 @@ func TestWrite_error(t *testing.T) {
 	err := Write(Poly{}, "/tmp/file")
 	assert.EqualError(t, err, marshalIndentErr.Error())
 }
+
+func TestRecordConversion(t *testing.T) {
+	sequence, err := Read("../../data/cat.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := sequence.Records()
+	if len(records) != 1 {
+		t.Fatalf("Records() returned %d records, want 1", len(records))
+	}
+	converted := FromRecord(records[0])
+	assert.Equal(t, sequence.Sequence, converted.Sequence)
+	assert.Equal(t, sequence.Meta.Name, converted.Meta.Name)
+	assert.Equal(t, sequence.Meta.Description, converted.Meta.Description)
+	assert.Equal(t, sequence.Meta.Hash, converted.Meta.Hash)
+	assert.Equal(t, len(sequence.Features), len(converted.Features))
+	for index, feature := range converted.Features {
+		want := sequence.Features[index]
+		want.ParentSequence = nil
+		feature.ParentSequence = nil
+		assert.Equal(t, want, feature)
+	}
+}
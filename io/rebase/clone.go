@@ -0,0 +1,220 @@
+package rebase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/clone"
+	"github.com/bebop/poly/transform"
+)
+
+// ErrUnknownCleavage is returned by CloneEnzyme for enzymes whose cleavage
+// site is not known, like methylases or enzymes listed as GGATCC without a ^.
+var ErrUnknownCleavage = errors.New("cleavage site is unknown")
+
+// ErrMultipleCleavages is returned by CloneEnzyme for enzymes that cut on both
+// sides of their recognition sequence, like BcgI (10/12)CGANNNNNNTGC(12/10),
+// which clone does not support.
+var ErrMultipleCleavages = errors.New("enzymes that cut on both sides of their recognition sequence are not supported")
+
+// ErrUnknownMethylation is returned by CloneMethylase for enzymes whose
+// methylated bases are not known.
+var ErrUnknownMethylation = errors.New("methylation site is unknown")
+
+// methylationRegexp matches a single entry of a REBASE methylation site, like
+// 2(6), -5(6) or ?(5), or a pair of them written as X,X2(Y,Y2).
+var methylationRegexp = regexp.MustCompile(`^(-?\d+|\?)(?:,(-?\d+|\?))?\((\d)(?:,(\d))?\)`)
+
+// cleavageRegexp matches the cleavage notation of recognition sequences.
+var cleavageRegexp = regexp.MustCompile(`\^|\(-?\d+/-?\d+\)`)
+
+// CloneEnzyme converts an Enzyme into a clone.Enzyme that can cut sequences.
+//
+// Both notations REBASE uses for cleavage sites are supported. G^AATTC marks
+// the cut on the given strand, and the other strand is cut at the symmetric
+// position. GGTCTC(1/5) gives the cuts on the given and the other strand as
+// the number of bases after the recognition sequence, which may be negative
+// for cuts within or before it. Ambiguity codes like N or R in the
+// recognition sequence match any of the bases they stand for, on both
+// strands.
+//
+// clone describes cuts with the position of the leftmost cut and the length
+// of the overhang, so 3' overhangs are represented by the same bases as 5'
+// overhangs would be.
+//
+// REBASE dumps do not say which methylases block an enzyme, so BlockedBy is
+// left empty. Set it before using clone.CutWithEnzymeMethylated.
+func (enzyme Enzyme) CloneEnzyme() (clone.Enzyme, error) {
+	site, topCut, bottomCut, err := parseRecognitionSequence(enzyme.RecognitionSequence)
+	if err != nil {
+		return clone.Enzyme{}, fmt.Errorf("Failed to convert %s with recognition sequence %s: %w", enzyme.Name, enzyme.RecognitionSequence, err)
+	}
+	regexpFor, err := clone.SiteRegexp(site)
+	if err != nil {
+		return clone.Enzyme{}, fmt.Errorf("Failed to convert %s with recognition sequence %s: %w", enzyme.Name, enzyme.RecognitionSequence, err)
+	}
+	regexpRev, err := clone.SiteRegexp(transform.ReverseComplement(site))
+	if err != nil {
+		return clone.Enzyme{}, fmt.Errorf("Failed to convert %s with recognition sequence %s: %w", enzyme.Name, enzyme.RecognitionSequence, err)
+	}
+	overhangLength := topCut - bottomCut
+	if overhangLength < 0 {
+		overhangLength = -overhangLength
+	}
+	return clone.Enzyme{
+		Name:            enzyme.Name,
+		RegexpFor:       regexpFor,
+		RegexpRev:       regexpRev,
+		Skip:            min(topCut, bottomCut) - len(site),
+		OverheadLength:  overhangLength,
+		RecognitionSite: site,
+	}, nil
+}
+
+// parseRecognitionSequence splits a REBASE recognition sequence into the
+// recognition site and the positions of the cuts on the given (top) and
+// the other (bottom) strand, counted from the start of the site.
+func parseRecognitionSequence(recognitionSequence string) (string, int, int, error) {
+	sequence := strings.ToUpper(strings.TrimSpace(recognitionSequence))
+	var site string
+	var topCut, bottomCut int
+	switch {
+	case strings.HasPrefix(sequence, "("):
+		return "", 0, 0, ErrMultipleCleavages
+	case strings.Contains(sequence, "^"):
+		if strings.Count(sequence, "^") > 1 {
+			return "", 0, 0, ErrMultipleCleavages
+		}
+		topCut = strings.Index(sequence, "^")
+		site = strings.Replace(sequence, "^", "", 1)
+		bottomCut = len(site) - topCut
+	case strings.HasSuffix(sequence, ")"):
+		cutsStart := strings.Index(sequence, "(")
+		if cutsStart == -1 {
+			return "", 0, 0, fmt.Errorf("unbalanced parentheses")
+		}
+		site = sequence[:cutsStart]
+		top, bottom, found := strings.Cut(sequence[cutsStart+1:len(sequence)-1], "/")
+		if !found {
+			return "", 0, 0, fmt.Errorf("cleavage sites should be given as (top/bottom)")
+		}
+		topOffset, err := strconv.Atoi(top)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		bottomOffset, err := strconv.Atoi(bottom)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		topCut = len(site) + topOffset
+		bottomCut = len(site) + bottomOffset
+	default:
+		return "", 0, 0, ErrUnknownCleavage
+	}
+
+	if site == "" {
+		return "", 0, 0, ErrUnknownCleavage
+	}
+	return site, topCut, bottomCut, nil
+}
+
+// CloneEnzymes converts all enzymes of a REBASE dump that clone can use,
+// sorted by name. Enzymes with an unknown cleavage site, like methylases, and
+// enzymes that cut on both sides of their recognition sequence are skipped.
+// Set commercialOnly to only convert enzymes that can be bought.
+func CloneEnzymes(enzymeMap map[string]Enzyme, commercialOnly bool) []clone.Enzyme {
+	var enzymes []clone.Enzyme
+	for _, enzyme := range enzymeMap {
+		if commercialOnly && len(enzyme.CommercialAvailability) == 0 {
+			continue
+		}
+		cloneEnzyme, err := enzyme.CloneEnzyme()
+		if err != nil {
+			continue
+		}
+		enzymes = append(enzymes, cloneEnzyme)
+	}
+	sort.Slice(enzymes, func(i, j int) bool { return enzymes[i].Name < enzymes[j].Name })
+	return enzymes
+}
+
+// NewEnzymeManager builds a clone.EnzymeManager from a REBASE dump, so that
+// its enzymes can be used by name in clone.EnzymeManager.CutWithEnzymeByName.
+// See CloneEnzymes for the enzymes that are included.
+func NewEnzymeManager(enzymeMap map[string]Enzyme, commercialOnly bool) clone.EnzymeManager {
+	return clone.NewEnzymeManager(CloneEnzymes(enzymeMap, commercialOnly))
+}
+
+// ParseMethylationSite parses the methylation site notation of REBASE, like
+// 2(6) or 1(5),-2(5), into the methylated bases. Positions given as ? are
+// returned as Position 0. An empty site gives no methylations.
+func ParseMethylationSite(methylationSite string) ([]clone.Methylation, error) {
+	remaining := strings.ReplaceAll(methylationSite, " ", "")
+	var methylations []clone.Methylation
+	for remaining != "" {
+		match := methylationRegexp.FindStringSubmatch(remaining)
+		if match == nil {
+			return nil, fmt.Errorf("%q is not a valid methylation site", methylationSite)
+		}
+		remaining = strings.TrimPrefix(remaining[len(match[0]):], ",")
+
+		positions := []string{match[1]}
+		types := []string{match[3]}
+		switch {
+		case match[2] != "" && match[4] != "":
+			positions = append(positions, match[2])
+			types = append(types, match[4])
+		case match[2] != "":
+			positions = append(positions, match[2])
+			types = append(types, match[3])
+		case match[4] != "":
+			return nil, fmt.Errorf("%q gives more methylation types than positions", methylationSite)
+		}
+
+		for index, position := range positions {
+			var methylation clone.Methylation
+			if position != "?" {
+				methylation.Position, _ = strconv.Atoi(position)
+			}
+			methylationType, _ := strconv.Atoi(types[index])
+			methylation.Type = clone.MethylationType(methylationType)
+			switch methylation.Type {
+			case clone.N4Methylcytosine, clone.C5Methylcytosine, clone.N6Methyladenine:
+			default:
+				return nil, fmt.Errorf("%q has unknown methylation type %d", methylationSite, methylationType)
+			}
+			methylations = append(methylations, methylation)
+		}
+	}
+	return methylations, nil
+}
+
+// CloneMethylase converts an Enzyme into a clone.Methylase, so that the
+// methylation of its site can be added to a clone.MethylationProfile. Any
+// cleavage notation in the recognition sequence is ignored. Enzymes without
+// known methylated bases give ErrUnknownMethylation. The methylase keeps its
+// REBASE name, like M.Aca72Dam, which is the name that the BlockedBy of a
+// clone.Enzyme has to list.
+func (enzyme Enzyme) CloneMethylase() (clone.Methylase, error) {
+	site := cleavageRegexp.ReplaceAllString(strings.ToUpper(strings.TrimSpace(enzyme.RecognitionSequence)), "")
+	if _, err := clone.SiteRegexp(site); err != nil || site == "" {
+		return clone.Methylase{}, fmt.Errorf("Failed to convert %s with recognition sequence %s: %w", enzyme.Name, enzyme.RecognitionSequence, ErrUnknownMethylation)
+	}
+	methylations, err := ParseMethylationSite(enzyme.MethylationSite)
+	if err != nil {
+		return clone.Methylase{}, fmt.Errorf("Failed to convert %s: %w", enzyme.Name, err)
+	}
+	if len(methylations) == 0 {
+		return clone.Methylase{}, fmt.Errorf("Failed to convert %s: %w", enzyme.Name, ErrUnknownMethylation)
+	}
+	for _, methylation := range methylations {
+		if methylation.Position == 0 || methylation.Position > len(site) || -methylation.Position > len(site) {
+			return clone.Methylase{}, fmt.Errorf("Failed to convert %s with methylation site %s: %w", enzyme.Name, enzyme.MethylationSite, ErrUnknownMethylation)
+		}
+	}
+	return clone.Methylase{Name: enzyme.Name, Site: site, Methylations: methylations}, nil
+}
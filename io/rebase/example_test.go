@@ -3,6 +3,7 @@ package rebase_test
 import (
 	"fmt"
 
+	"github.com/bebop/poly/clone"
 	"github.com/bebop/poly/io/rebase"
 )
 
@@ -26,3 +27,31 @@ func ExampleExport() {
 	fmt.Println(string(enzymeJSON)[:100])
 	// Output: {"AaaI":{"name":"AaaI","isoschizomers":["XmaIII","BseX3I","BsoDI","BstZI","EagI","EclXI","Eco52I","S
 }
+
+func ExampleNewEnzymeManager() {
+	enzymeMap, _ := rebase.Read("data/rebase_test.txt")
+	enzymeManager := rebase.NewEnzymeManager(enzymeMap, true)
+
+	// AatII cuts GACGT^C, leaving an ACGT overhang.
+	fragments, _ := enzymeManager.CutWithEnzymeByName(clone.Part{Sequence: "AAAAAGACGTCAAAAA"}, false, "AatII")
+	for _, fragment := range fragments {
+		fmt.Printf("%s %q %q\n", fragment.Sequence, fragment.ForwardOverhang, fragment.ReverseOverhang)
+	}
+	// Output:
+	// CAAAAA "ACGT" ""
+	// AAAAAG "" "ACGT"
+}
+
+func ExampleEnzyme_CloneEnzyme() {
+	enzymeMap, _ := rebase.Read("data/rebase_test.txt")
+	aarI, _ := enzymeMap["AarI"].CloneEnzyme()
+	fmt.Println(aarI.RecognitionSite, aarI.Skip, aarI.OverheadLength)
+	// Output: CACCTGC 4 4
+}
+
+func ExampleEnzyme_CloneMethylase() {
+	enzymeMap, _ := rebase.Read("data/rebase_test.txt")
+	dam, _ := enzymeMap["M.Aca72Dam"].CloneMethylase()
+	fmt.Println(dam.Site, dam.Methylations)
+	// Output: GATC [{2 6}]
+}
@@ -15,6 +15,11 @@ http://rebase.neb.com/rebase/rebase.f31.html
 The actual data dump itself is linked here and updated once a month:
 http://rebase.neb.com/rebase/link_withrefm
 
+Enzymes can be converted with CloneEnzyme for use in the clone package, and
+NewEnzymeManager makes all enzymes of a dump available by name. Methylases
+can be converted with CloneMethylase to simulate cutting methylated DNA with
+clone.CutWithEnzymeMethylated.
+
 The header of this file gives a wonderful explanation of its structure. Here is the
 header with the commercial suppliers format and an example enzyme.
 
@@ -147,6 +152,8 @@ import (
 	"io"
 	"os"
 	"strings"
+
+	"github.com/bebop/poly/clone"
 )
 
 var (
@@ -165,6 +172,9 @@ type Enzyme struct {
 	Source                 string   `json:"source"`
 	CommercialAvailability []string `json:"commercialAvailability"`
 	References             string   `json:"references"`
+	// Methylations is MethylationSite parsed with ParseMethylationSite. It is
+	// empty for methylation sites that ParseMethylationSite can't parse.
+	Methylations []clone.Methylation `json:"methylations"`
 }
 
 // Parse parses the Rebase database into a map of enzymes
@@ -241,6 +251,11 @@ func Parse(file io.Reader) (map[string]Enzyme, error) {
 			enzyme.RecognitionSequence = line[3:]
 		case strings.Contains(line, "<4>"):
 			enzyme.MethylationSite = line[3:]
+			// sites that can't be parsed leave Methylations empty rather than failing the whole database.
+			methylations, err := ParseMethylationSite(enzyme.MethylationSite)
+			if err == nil {
+				enzyme.Methylations = methylations
+			}
 		case strings.Contains(line, "<5>"):
 			enzyme.MicroOrganism = line[3:]
 		case strings.Contains(line, "<6>"):
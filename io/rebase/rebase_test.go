@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bebop/poly/clone"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +66,199 @@ func TestExport_error(t *testing.T) {
 	_, err := Export(map[string]Enzyme{})
 	assert.EqualError(t, err, exportErr.Error())
 }
+
+func TestEnzyme_CloneEnzyme(t *testing.T) {
+	tests := []struct {
+		recognitionSequence string
+		site                string
+		skip                int
+		overhangLength      int
+		wantErr             error
+	}{
+		{recognitionSequence: "G^AATTC", site: "GAATTC", skip: -5, overhangLength: 4},
+		{recognitionSequence: "CTGCA^G", site: "CTGCAG", skip: -5, overhangLength: 4},
+		{recognitionSequence: "GAT^ATC", site: "GATATC", skip: -3, overhangLength: 0},
+		{recognitionSequence: "GACNNNN^NNGTC", site: "GACNNNNNNGTC", skip: -7, overhangLength: 2},
+		{recognitionSequence: "CACCTGC(4/8)", site: "CACCTGC", skip: 4, overhangLength: 4},
+		{recognitionSequence: "CCGC(-3/-1)", site: "CCGC", skip: -3, overhangLength: 2},
+		{recognitionSequence: "CCTCAGC(-5/-2)", site: "CCTCAGC", skip: -5, overhangLength: 3},
+		{recognitionSequence: "(10/12)CGANNNNNNTGC(12/10)", wantErr: ErrMultipleCleavages},
+		{recognitionSequence: "GGATCC", wantErr: ErrUnknownCleavage},
+		{recognitionSequence: "?", wantErr: ErrUnknownCleavage},
+		{recognitionSequence: "", wantErr: ErrUnknownCleavage},
+	}
+	for _, test := range tests {
+		t.Run(test.recognitionSequence, func(t *testing.T) {
+			enzyme, err := Enzyme{Name: "test", RecognitionSequence: test.recognitionSequence}.CloneEnzyme()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("CloneEnzyme() error = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if enzyme.RecognitionSite != test.site || enzyme.Skip != test.skip || enzyme.OverheadLength != test.overhangLength {
+				t.Errorf("CloneEnzyme() = site %s, skip %d, overhang %d, want site %s, skip %d, overhang %d", enzyme.RecognitionSite, enzyme.Skip, enzyme.OverheadLength, test.site, test.skip, test.overhangLength)
+			}
+		})
+	}
+
+	for _, recognitionSequence := range []string{"GA^ZTTC", "GAATTC(1/", "GAATTC(a/5)"} {
+		if _, err := (Enzyme{RecognitionSequence: recognitionSequence}).CloneEnzyme(); err == nil {
+			t.Errorf("CloneEnzyme() of %s should fail", recognitionSequence)
+		}
+	}
+}
+
+func TestEnzyme_CloneEnzyme_baseEnzymes(t *testing.T) {
+	recognitionSequences := map[string]string{"BsaI": "GGTCTC(1/5)", "BbsI": "GAAGAC(2/6)", "BtgZI": "GCGATG(10/14)"}
+	for _, want := range clone.GetBaseRestrictionEnzymes() {
+		got, err := Enzyme{Name: want.Name, RecognitionSequence: recognitionSequences[want.Name]}.CloneEnzyme()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.RegexpFor.String() != want.RegexpFor.String() || got.RegexpRev.String() != want.RegexpRev.String() || got.Skip != want.Skip || got.OverheadLength != want.OverheadLength || got.RecognitionSite != want.RecognitionSite {
+			t.Errorf("CloneEnzyme() = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestEnzyme_CloneEnzyme_ambiguity(t *testing.T) {
+	accI, err := Enzyme{Name: "AccI", RecognitionSequence: "GT^MKAC"}.CloneEnzyme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sequence, want := range map[string]bool{"GTAGAC": true, "GTCTAC": true, "GTATAC": true, "GTAAAC": false} {
+		if got := accI.RegexpFor.MatchString(sequence); got != want {
+			t.Errorf("AccI match of %s = %t, want %t", sequence, got, want)
+		}
+	}
+	aarI, _ := Enzyme{Name: "AarI", RecognitionSequence: "CACCTGC(4/8)"}.CloneEnzyme()
+	if aarI.RegexpRev.String() != "GCAGGTG" {
+		t.Errorf("AarI reverse regexp = %s, want GCAGGTG", aarI.RegexpRev)
+	}
+
+	// AarI cuts the same overhang whether its site is on the top or bottom strand.
+	insert := "GGGGAAAACCCCTTTTGGGGAAAA"
+	forward := clone.Part{Sequence: "TTCACCTGCATGC" + "ACGT" + insert + "TCGA" + "GCATGCAGGTGTT"}
+	fragments := clone.CutWithEnzyme(forward, true, aarI)
+	if len(fragments) != 1 || fragments[0].Sequence != insert || fragments[0].ForwardOverhang != "ACGT" || fragments[0].ReverseOverhang != "TCGA" {
+		t.Errorf("Unexpected AarI fragments %+v", fragments)
+	}
+}
+
+func TestNewEnzymeManager(t *testing.T) {
+	enzymeMap, err := Read("data/rebase_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := NewEnzymeManager(enzymeMap, false)
+	commercial := NewEnzymeManager(enzymeMap, true)
+	for _, test := range []struct {
+		name                   string
+		inAll, inCommercialSet bool
+	}{
+		{name: "AarI", inAll: true, inCommercialSet: true},
+		{name: "AatII", inAll: true, inCommercialSet: true},
+		{name: "AaaI", inAll: true, inCommercialSet: false},
+		{name: "AacLI", inAll: false, inCommercialSet: false}, // GGATCC, cleavage unknown
+		{name: "M.Aap5906II", inAll: false, inCommercialSet: false},
+	} {
+		if _, err := all.GetEnzymeByName(test.name); (err == nil) != test.inAll {
+			t.Errorf("%s in all enzymes = %t, want %t", test.name, err == nil, test.inAll)
+		}
+		if _, err := commercial.GetEnzymeByName(test.name); (err == nil) != test.inCommercialSet {
+			t.Errorf("%s in commercial enzymes = %t, want %t", test.name, err == nil, test.inCommercialSet)
+		}
+	}
+}
+
+func TestCloneEnzymes_sorted(t *testing.T) {
+	enzymeMap, err := Read("data/rebase_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enzymes := CloneEnzymes(enzymeMap, false)
+	if len(enzymes) == 0 {
+		t.Fatal("CloneEnzymes() returned no enzymes")
+	}
+	for index := 1; index < len(enzymes); index++ {
+		if enzymes[index-1].Name >= enzymes[index].Name {
+			t.Errorf("CloneEnzymes() returned %s before %s, want them sorted by name", enzymes[index-1].Name, enzymes[index].Name)
+		}
+	}
+}
+
+func TestParseMethylationSite(t *testing.T) {
+	tests := []struct {
+		methylationSite string
+		want            []clone.Methylation
+		wantErr         bool
+	}{
+		{methylationSite: ""},
+		{methylationSite: "2(6)", want: []clone.Methylation{{Position: 2, Type: clone.N6Methyladenine}}},
+		{methylationSite: "1(5),-2(5)", want: []clone.Methylation{{Position: 1, Type: clone.C5Methylcytosine}, {Position: -2, Type: clone.C5Methylcytosine}}},
+		{methylationSite: "5,-5(6,4)", want: []clone.Methylation{{Position: 5, Type: clone.N6Methyladenine}, {Position: -5, Type: clone.N4Methylcytosine}}},
+		{methylationSite: "?(5)", want: []clone.Methylation{{Position: 0, Type: clone.C5Methylcytosine}}},
+		{methylationSite: "2(7)", wantErr: true},
+		{methylationSite: "2(6,5)", wantErr: true},
+		{methylationSite: "2(6)x", wantErr: true},
+		{methylationSite: "6", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.methylationSite, func(t *testing.T) {
+			got, err := ParseMethylationSite(test.methylationSite)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseMethylationSite() error = %v, wantErr %t", err, test.wantErr)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestParse_methylations(t *testing.T) {
+	enzymeMap, err := Read("data/rebase_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []clone.Methylation{{Position: 5, Type: clone.N6Methyladenine}, {Position: -5, Type: clone.N6Methyladenine}}, enzymeMap["AccVIII"].Methylations)
+	assert.Empty(t, enzymeMap["AaaI"].Methylations)
+
+	// an unparseable methylation site doesn't fail the rest of the database.
+	enzymeMap, err = Parse(strings.NewReader("<1>FakeI\n<2>\n<3>GATC\n<4>2(9)\n<5>\n<6>\n<7>\n<8>\n\n<1>FakeII\n<2>\n<3>GATC\n<4>2(6)\n<5>\n<6>\n<7>\n<8>\n\n<1>FakeIII\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	assert.Equal(t, "2(9)", enzymeMap["FakeI"].MethylationSite)
+	assert.Empty(t, enzymeMap["FakeI"].Methylations)
+	assert.Equal(t, []clone.Methylation{{Position: 2, Type: clone.N6Methyladenine}}, enzymeMap["FakeII"].Methylations)
+}
+
+func TestEnzyme_CloneMethylase(t *testing.T) {
+	enzymeMap, err := Read("data/rebase_test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dam, err := enzymeMap["M.Aca72Dam"].CloneMethylase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dam.Site != clone.Dam.Site {
+		t.Errorf("CloneMethylase() site = %s, want %s", dam.Site, clone.Dam.Site)
+	}
+	assert.Equal(t, clone.Dam.Methylations, dam.Methylations)
+
+	// Cleavage notation is removed from the site.
+	aciI, err := enzymeMap["AciI"].CloneMethylase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aciI.Site != "CCGC" {
+		t.Errorf("CloneMethylase() site = %s, want CCGC", aciI.Site)
+	}
+
+	for _, name := range []string{"M.AceCDnmt3", "AaaI"} {
+		if _, err := enzymeMap[name].CloneMethylase(); !errors.Is(err, ErrUnknownMethylation) {
+			t.Errorf("CloneMethylase() of %s error = %v, want %v", name, err, ErrUnknownMethylation)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package sam
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+BAM parsing begins here.
+
+BAM files are BGZF compressed. BGZF files are just a series of gzip members,
+which go's gzip.Reader reads one after the other by default, so we don't need
+anything special to decompress them.
+
+The decompressed data starts with the header:
+
+	magic          char[4]    "BAM\1"
+	l_text         int32
+	text           char[l_text]   (the SAM header text)
+	n_ref          int32
+	references     (repeated n_ref times)
+		l_name     int32
+		name       char[l_name]   (NUL terminated)
+		l_ref      int32
+
+Followed by the alignments, each of which is:
+
+	block_size     int32      (size of the rest of the alignment)
+	refID          int32      (-1 if unmapped)
+	pos            int32      (0-based, -1 if unmapped)
+	l_read_name    uint8
+	mapq           uint8
+	bin            uint16
+	n_cigar_op     uint16
+	flag           uint16
+	l_seq          uint32
+	next_refID     int32
+	next_pos       int32
+	tlen           int32
+	read_name      char[l_read_name]         (NUL terminated)
+	cigar          uint32[n_cigar_op]        (length<<4 | operation)
+	seq            uint8[(l_seq+1)/2]        (4 bits per base)
+	qual           char[l_seq]               (0xFF if missing)
+	tags           (until the end of the block)
+
+Everything is little endian.
+
+******************************************************************************/
+
+var bamMagicNumber = []byte{'B', 'A', 'M', 1}
+
+// bamSequenceCodes maps the 4 bit BAM base encoding to its letter.
+const bamSequenceCodes = "=ACMGRSVTWYHKDBN"
+
+// bamFixedAlignmentSize is the size of the fixed fields of an alignment after block_size.
+const bamFixedAlignmentSize = 32
+
+// NewBAMParser decompresses and parses the header of a BAM file and returns a
+// Parser for its alignments. The header is parsed from the SAM header text,
+// but the References always come from the BAM reference list.
+func NewBAMParser(r io.Reader) (*Parser, Header, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, Header{}, fmt.Errorf("Failed to decompress BAM file: %w", err)
+	}
+	parser := &Parser{
+		reader: *bufio.NewReader(gzipReader),
+		bam:    true,
+	}
+	magicNumber := make([]byte, len(bamMagicNumber))
+	if _, err = io.ReadFull(&parser.reader, magicNumber); err != nil {
+		return parser, Header{}, err
+	}
+	if !bytes.Equal(magicNumber, bamMagicNumber) {
+		return parser, Header{}, fmt.Errorf("Not a BAM file. Expected magic number %q, got %q", bamMagicNumber, magicNumber)
+	}
+	var textLength int32
+	if err = binary.Read(&parser.reader, binary.LittleEndian, &textLength); err != nil {
+		return parser, Header{}, err
+	}
+	text := make([]byte, textLength)
+	if _, err = io.ReadFull(&parser.reader, text); err != nil {
+		return parser, Header{}, err
+	}
+	// The header text may be NUL padded.
+	textParser, header, err := NewParser(bytes.NewReader(bytes.TrimRight(text, "\x00")), len(text)+1)
+	if err != nil {
+		return parser, Header{}, err
+	}
+	if _, err = textParser.reader.Peek(1); !errors.Is(err, io.EOF) {
+		return parser, Header{}, errors.New("BAM header text contains lines that do not start with @")
+	}
+
+	var referenceCount int32
+	if err = binary.Read(&parser.reader, binary.LittleEndian, &referenceCount); err != nil {
+		return parser, Header{}, err
+	}
+	header.References = nil
+	for referenceIndex := int32(0); referenceIndex < referenceCount; referenceIndex++ {
+		var nameLength int32
+		if err = binary.Read(&parser.reader, binary.LittleEndian, &nameLength); err != nil {
+			return parser, Header{}, err
+		}
+		name := make([]byte, nameLength)
+		if _, err = io.ReadFull(&parser.reader, name); err != nil {
+			return parser, Header{}, err
+		}
+		var length int32
+		if err = binary.Read(&parser.reader, binary.LittleEndian, &length); err != nil {
+			return parser, Header{}, err
+		}
+		header.References = append(header.References, Reference{Name: strings.TrimRight(string(name), "\x00"), Length: int(length)})
+	}
+	parser.references = header.References
+	return parser, header, nil
+}
+
+// parseNextBAM reads and decodes the next BAM alignment.
+func (parser *Parser) parseNextBAM() (Alignment, error) {
+	if _, err := parser.reader.Peek(1); err != nil {
+		// Early return on error. Probably will be EOF.
+		return Alignment{}, err
+	}
+	var blockSize int32
+	if err := binary.Read(&parser.reader, binary.LittleEndian, &blockSize); err != nil {
+		return Alignment{}, fmt.Errorf("Failed to read size of alignment %d: %w", parser.line+1, err)
+	}
+	if blockSize < bamFixedAlignmentSize {
+		return Alignment{}, fmt.Errorf("Alignment %d too small. Got block size %d", parser.line+1, blockSize)
+	}
+	block := make([]byte, blockSize)
+	if _, err := io.ReadFull(&parser.reader, block); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return Alignment{}, fmt.Errorf("Failed to read alignment %d: %w", parser.line+1, err)
+	}
+	parser.line++
+	alignment, err := parser.parseBAMAlignment(block)
+	if err != nil {
+		return Alignment{}, fmt.Errorf("Error in alignment %d: %w", parser.line, err)
+	}
+	return alignment, nil
+}
+
+// parseBAMAlignment decodes a single BAM alignment block (without block_size).
+func (parser *Parser) parseBAMAlignment(block []byte) (Alignment, error) {
+	var alignment Alignment
+	var err error
+	littleEndian := binary.LittleEndian
+	if alignment.ReferenceName, err = parser.referenceName(int32(littleEndian.Uint32(block[0:]))); err != nil {
+		return Alignment{}, err
+	}
+	alignment.Position = int(int32(littleEndian.Uint32(block[4:]))) + 1
+	readNameLength := int(block[8])
+	alignment.MappingQuality = block[9]
+	// block[10:12] is the bin, which can be calculated from the position and cigar.
+	cigarLength := int(littleEndian.Uint16(block[12:]))
+	alignment.Flag = Flag(littleEndian.Uint16(block[14:]))
+	sequenceLength := int(littleEndian.Uint32(block[16:]))
+	if alignment.MateReferenceName, err = parser.referenceName(int32(littleEndian.Uint32(block[20:]))); err != nil {
+		return Alignment{}, err
+	}
+	alignment.MatePosition = int(int32(littleEndian.Uint32(block[24:]))) + 1
+	alignment.TemplateLength = int(int32(littleEndian.Uint32(block[28:])))
+
+	variableLength := readNameLength + 4*cigarLength + (sequenceLength+1)/2 + sequenceLength
+	data := block[bamFixedAlignmentSize:]
+	if len(data) < variableLength {
+		return Alignment{}, fmt.Errorf("block size %d too small for its read name, cigar and sequence", len(block))
+	}
+	alignment.QueryName = strings.TrimRight(string(data[:readNameLength]), "\x00")
+	if alignment.QueryName == "*" {
+		alignment.QueryName = ""
+	}
+	data = data[readNameLength:]
+
+	for cigarIndex := 0; cigarIndex < cigarLength; cigarIndex++ {
+		operation := littleEndian.Uint32(data[4*cigarIndex:])
+		if operation&0xf >= uint32(len(cigarOperations)) {
+			return Alignment{}, fmt.Errorf("unknown CIGAR operation code %d", operation&0xf)
+		}
+		alignment.Cigar = append(alignment.Cigar, CigarOperation{Length: int(operation >> 4), Operation: cigarOperations[operation&0xf]})
+	}
+	data = data[4*cigarLength:]
+
+	sequence := make([]byte, sequenceLength)
+	for baseIndex := range sequence {
+		code := data[baseIndex/2]
+		if baseIndex%2 == 0 {
+			code >>= 4
+		}
+		sequence[baseIndex] = bamSequenceCodes[code&0xf]
+	}
+	alignment.Sequence = string(sequence)
+	data = data[(sequenceLength+1)/2:]
+
+	quality := data[:sequenceLength]
+	if sequenceLength > 0 && quality[0] != 0xff {
+		qualityBytes := make([]byte, sequenceLength)
+		for qualityIndex, score := range quality {
+			qualityBytes[qualityIndex] = score + 33
+		}
+		alignment.Quality = string(qualityBytes)
+	}
+	data = data[sequenceLength:]
+
+	for len(data) > 0 {
+		var tag Tag
+		tag, data, err = parseBAMTag(data)
+		if err != nil {
+			return Alignment{}, err
+		}
+		alignment.Tags = append(alignment.Tags, tag)
+	}
+	return replaceLongCigar(alignment)
+}
+
+// referenceName looks up the name of a BAM reference id. -1 means no reference.
+func (parser *Parser) referenceName(referenceID int32) (string, error) {
+	if referenceID == -1 {
+		return "", nil
+	}
+	if referenceID < 0 || int(referenceID) >= len(parser.references) {
+		return "", fmt.Errorf("reference id %d out of range, file has %d references", referenceID, len(parser.references))
+	}
+	return parser.references[referenceID].Name, nil
+}
+
+// replaceLongCigar handles alignments with more than 65535 CIGAR operations.
+// BAM can't store them in the cigar field, so it stores a placeholder CIGAR
+// of kSmN (k the sequence length, m the reference length) and puts the real
+// CIGAR in a CG tag.
+func replaceLongCigar(alignment Alignment) (Alignment, error) {
+	if len(alignment.Cigar) != 2 || alignment.Cigar[0].Operation != 'S' || alignment.Cigar[0].Length != len(alignment.Sequence) || alignment.Cigar[1].Operation != 'N' {
+		return alignment, nil
+	}
+	for tagIndex, tag := range alignment.Tags {
+		if tag.Name != "CG" {
+			continue
+		}
+		operations, ok := tag.Value.([]uint32)
+		if !ok {
+			return Alignment{}, errors.New("CG tag must be an array of uint32")
+		}
+		alignment.Cigar = make(Cigar, len(operations))
+		for operationIndex, operation := range operations {
+			if operation&0xf >= uint32(len(cigarOperations)) {
+				return Alignment{}, fmt.Errorf("unknown CIGAR operation code %d", operation&0xf)
+			}
+			alignment.Cigar[operationIndex] = CigarOperation{Length: int(operation >> 4), Operation: cigarOperations[operation&0xf]}
+		}
+		alignment.Tags = append(alignment.Tags[:tagIndex], alignment.Tags[tagIndex+1:]...)
+		break
+	}
+	return alignment, nil
+}
+
+// parseBAMTag decodes the first tag of data and returns the rest of data.
+func parseBAMTag(data []byte) (Tag, []byte, error) {
+	if len(data) < 4 {
+		return Tag{}, nil, errors.New("truncated tag")
+	}
+	tag := Tag{Name: string(data[:2]), Type: data[2]}
+	data = data[3:]
+	littleEndian := binary.LittleEndian
+	truncated := fmt.Errorf("truncated tag %s", tag.Name)
+	switch tag.Type {
+	case 'A':
+		tag.Value = data[0]
+		return tag, data[1:], nil
+	case 'c', 'C', 's', 'S', 'i', 'I':
+		bitSize, signed, _ := arrayIntegerType(tag.Type)
+		if len(data) < bitSize/8 {
+			return Tag{}, nil, truncated
+		}
+		tag.Value = int(readBAMInteger(data, bitSize, signed))
+		tag.Type = 'i'
+		return tag, data[bitSize/8:], nil
+	case 'f':
+		if len(data) < 4 {
+			return Tag{}, nil, truncated
+		}
+		tag.Value = math.Float32frombits(littleEndian.Uint32(data))
+		return tag, data[4:], nil
+	case 'Z', 'H':
+		end := bytes.IndexByte(data, 0)
+		if end == -1 {
+			return Tag{}, nil, fmt.Errorf("tag %s is not NUL terminated", tag.Name)
+		}
+		tag.Value = string(data[:end])
+		return tag, data[end+1:], nil
+	case 'B':
+		if len(data) < 5 {
+			return Tag{}, nil, truncated
+		}
+		subType := data[0]
+		count := int(littleEndian.Uint32(data[1:]))
+		data = data[5:]
+		if subType == 'f' {
+			if len(data)/4 < count {
+				return Tag{}, nil, truncated
+			}
+			array := make([]float32, count)
+			for index := range array {
+				array[index] = math.Float32frombits(littleEndian.Uint32(data[4*index:]))
+			}
+			tag.Value = array
+			return tag, data[4*count:], nil
+		}
+		bitSize, signed, err := arrayIntegerType(subType)
+		if err != nil {
+			return Tag{}, nil, fmt.Errorf("tag %s: %w", tag.Name, err)
+		}
+		if len(data)/(bitSize/8) < count {
+			return Tag{}, nil, truncated
+		}
+		integers := make([]int64, count)
+		for index := range integers {
+			integers[index] = readBAMInteger(data[index*bitSize/8:], bitSize, signed)
+		}
+		tag.Value = convertIntegerArray(subType, integers)
+		return tag, data[count*bitSize/8:], nil
+	}
+	return Tag{}, nil, fmt.Errorf("unknown type '%c' in tag %s", tag.Type, tag.Name)
+}
+
+// readBAMInteger reads a little endian integer of the given size.
+func readBAMInteger(data []byte, bitSize int, signed bool) int64 {
+	switch {
+	case bitSize == 8 && signed:
+		return int64(int8(data[0]))
+	case bitSize == 8:
+		return int64(data[0])
+	case bitSize == 16 && signed:
+		return int64(int16(binary.LittleEndian.Uint16(data)))
+	case bitSize == 16:
+		return int64(binary.LittleEndian.Uint16(data))
+	case signed:
+		return int64(int32(binary.LittleEndian.Uint32(data)))
+	default:
+		return int64(binary.LittleEndian.Uint32(data))
+	}
+}
+
+// ReadBAM reads a BAM file into a Header and a list of Alignments.
+func ReadBAM(path string) (Header, []Alignment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer file.Close()
+	parser, header, err := NewBAMParser(file)
+	if err != nil {
+		return header, nil, err
+	}
+	alignments, err := parser.ParseAll()
+	return header, alignments, err
+}
@@ -0,0 +1,133 @@
+package sam
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadBAM(t *testing.T) {
+	samHeader, samAlignments, err := Read("data/example.sam")
+	if err != nil {
+		t.Fatalf("Failed to read example.sam. Got error: %s", err)
+	}
+	bamHeader, bamAlignments, err := ReadBAM("data/example.bam")
+	if err != nil {
+		t.Fatalf("Failed to read example.bam. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(samHeader, bamHeader) {
+		t.Errorf("BAM header differs from SAM header. Got: %+v", bamHeader)
+	}
+	if !reflect.DeepEqual(samAlignments, bamAlignments) {
+		t.Errorf("BAM alignments differ from SAM alignments. Got: %+v", bamAlignments)
+	}
+	if _, _, err = ReadBAM("data/doesntexist.bam"); err == nil {
+		t.Errorf("Should have failed to read non-existent file")
+	}
+	if _, _, err = ReadBAM("data/example.sam"); err == nil {
+		t.Errorf("Should have failed to read a sam file as bam")
+	}
+}
+
+// buildBAM builds an uncompressed BAM body with one reference and the given alignment blocks.
+func buildBAM(blocks ...[]byte) []byte {
+	littleEndian := binary.LittleEndian
+	bam := append([]byte{}, bamMagicNumber...)
+	bam = littleEndian.AppendUint32(bam, 0) // no header text
+	bam = littleEndian.AppendUint32(bam, 1)
+	bam = littleEndian.AppendUint32(bam, 5)
+	bam = append(bam, "ref1\x00"...)
+	bam = littleEndian.AppendUint32(bam, 100)
+	for _, block := range blocks {
+		bam = littleEndian.AppendUint32(bam, uint32(len(block)))
+		bam = append(bam, block...)
+	}
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	_, _ = gzipWriter.Write(bam)
+	_ = gzipWriter.Close()
+	return compressed.Bytes()
+}
+
+// buildBAMAlignment builds an alignment block with a read name, cigar and sequence.
+func buildBAMAlignment(referenceID int32, cigar []uint32, sequenceLength int, tags []byte) []byte {
+	littleEndian := binary.LittleEndian
+	block := littleEndian.AppendUint32(nil, uint32(referenceID))
+	block = littleEndian.AppendUint32(block, 0)
+	block = append(block, 2, 60)                // l_read_name, mapq
+	block = littleEndian.AppendUint16(block, 0) // bin
+	block = littleEndian.AppendUint16(block, uint16(len(cigar)))
+	block = littleEndian.AppendUint16(block, 0) // flag
+	block = littleEndian.AppendUint32(block, uint32(sequenceLength))
+	block = littleEndian.AppendUint32(block, 0xffffffff) // next_refID
+	block = littleEndian.AppendUint32(block, 0xffffffff) // next_pos
+	block = littleEndian.AppendUint32(block, 0)          // tlen
+	block = append(block, "r\x00"...)
+	for _, operation := range cigar {
+		block = littleEndian.AppendUint32(block, operation)
+	}
+	block = append(block, make([]byte, (sequenceLength+1)/2)...)
+	block = append(block, bytes.Repeat([]byte{0xff}, sequenceLength)...)
+	return append(block, tags...)
+}
+
+func TestBAMLongCigar(t *testing.T) {
+	// 4S10N placeholder cigar, with the real cigar 2M2I in the CG tag.
+	cgTag := []byte{'C', 'G', 'B', 'I', 2, 0, 0, 0}
+	cgTag = binary.LittleEndian.AppendUint32(cgTag, 2<<4|0)
+	cgTag = binary.LittleEndian.AppendUint32(cgTag, 2<<4|1)
+	block := buildBAMAlignment(0, []uint32{4<<4 | 4, 10<<4 | 3}, 4, cgTag)
+	parser, _, err := NewBAMParser(bytes.NewReader(buildBAM(block)))
+	if err != nil {
+		t.Fatalf("Failed to parse bam header. Got error: %s", err)
+	}
+	alignment, err := parser.ParseNext()
+	if err != nil {
+		t.Fatalf("Failed to parse bam alignment. Got error: %s", err)
+	}
+	if alignment.Cigar.String() != "2M2I" || len(alignment.Tags) != 0 {
+		t.Errorf("Expected cigar 2M2I from CG tag. Got: %s with tags %v", alignment.Cigar, alignment.Tags)
+	}
+	if alignment.Sequence != "====" || alignment.Quality != "" {
+		t.Errorf("Unexpected sequence %q or quality %q", alignment.Sequence, alignment.Quality)
+	}
+}
+
+func TestBAMExceptions(t *testing.T) {
+	badBlocks := [][]byte{
+		buildBAMAlignment(5, nil, 0, nil),                                    // reference out of range
+		buildBAMAlignment(0, []uint32{1<<4 | 9}, 1, nil),                     // unknown cigar operation
+		buildBAMAlignment(0, nil, 0, []byte{'X', 'X', 'q', 1}),               // unknown tag type
+		buildBAMAlignment(0, nil, 0, []byte{'X', 'X', 'Z', 'a'}),             // unterminated string tag
+		buildBAMAlignment(0, nil, 0, []byte{'X', 'X', 'B', 'i', 9, 0, 0, 0}), // truncated array tag
+		buildBAMAlignment(0, nil, 0, nil)[:20],                               // block too small
+	}
+	for blockIndex, block := range badBlocks {
+		parser, _, err := NewBAMParser(bytes.NewReader(buildBAM(block)))
+		if err != nil {
+			t.Fatalf("Failed to parse bam header. Got error: %s", err)
+		}
+		if _, err = parser.ParseAll(); err == nil {
+			t.Errorf("Test should have failed parsing bad block %d", blockIndex)
+		}
+	}
+
+	bamBytes, _ := os.ReadFile("data/example.bam")
+	var truncated bytes.Buffer
+	gzipReader, _ := gzip.NewReader(bytes.NewReader(bamBytes))
+	_, _ = truncated.ReadFrom(gzipReader)
+	var recompressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&recompressed)
+	_, _ = gzipWriter.Write(truncated.Bytes()[:truncated.Len()-10])
+	_ = gzipWriter.Close()
+	parser, _, err := NewBAMParser(&recompressed)
+	if err != nil {
+		t.Fatalf("Failed to parse bam header. Got error: %s", err)
+	}
+	if _, err = parser.ParseAll(); err == nil {
+		t.Errorf("Test should have failed parsing a truncated bam file")
+	}
+}
@@ -0,0 +1,74 @@
+package sam_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bebop/poly/io/sam"
+)
+
+// ExampleRead shows basic usage for Read.
+func ExampleRead() {
+	header, alignments, _ := sam.Read("data/example.sam")
+	fmt.Println(header.References[0].Name)
+	fmt.Println(alignments[0].QueryName, alignments[0].Position, alignments[0].Cigar)
+	//Output:
+	//ref1
+	//read1 7 8M2I4M1D3M
+}
+
+// ExampleReadBAM shows basic usage for ReadBAM.
+func ExampleReadBAM() {
+	_, alignments, _ := sam.ReadBAM("data/example.bam")
+	fmt.Println(alignments[0].QueryName, alignments[0].Position, alignments[0].Cigar)
+	//Output:
+	//read1 7 8M2I4M1D3M
+}
+
+func ExampleParser() {
+	file, _ := os.Open("data/example.bam")
+	defer file.Close()
+	parser, _, _ := sam.NewBAMParser(file)
+	for {
+		alignment, err := parser.ParseNext()
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		if alignment.Flag.Has(sam.FlagUnmapped) {
+			continue
+		}
+		fmt.Println(alignment.QueryName, alignment.ReferenceName, alignment.Position)
+	}
+	//Output:
+	//read1 ref1 7
+	//read2 ref1 37
+	//read3 ref1 50
+	//read5 ref2 10
+	//EOF
+}
+
+// ExampleBuild shows how to build a SAM file from scratch.
+func ExampleBuild() {
+	header := sam.Header{
+		Lines:      []sam.HeaderLine{{Type: "HD", Tags: []sam.HeaderTag{{Key: "VN", Value: "1.6"}}}},
+		References: []sam.Reference{{Name: "ref1", Length: 100}},
+	}
+	cigar, _ := sam.ParseCigar("4M")
+	alignment := sam.Alignment{
+		QueryName:      "read1",
+		ReferenceName:  "ref1",
+		Position:       1,
+		MappingQuality: 60,
+		Cigar:          cigar,
+		Sequence:       "ACGT",
+		Tags:           []sam.Tag{{Name: "NM", Type: 'i', Value: 0}},
+	}
+	samBytes, _ := sam.Build(header, []sam.Alignment{alignment})
+	fmt.Print(strings.ReplaceAll(string(samBytes), "\t", " "))
+	//Output:
+	//@HD VN:1.6
+	//@SQ SN:ref1 LN:100
+	//read1 0 ref1 1 60 4M * 0 0 ACGT * NM:i:0
+}
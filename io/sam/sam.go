@@ -0,0 +1,746 @@
+/*
+Package sam contains SAM and BAM parsers and a SAM writer.
+
+SAM (Sequence Alignment/Map) is the text format that pretty much every read
+aligner outputs. It stores how sequencing reads align against one or more
+reference sequences. BAM is the binary, BGZF compressed version of SAM. Both
+contain the same information, so they are parsed into the same structs.
+
+A SAM file starts with a header, where every line starts with an @, followed by
+one tab separated line per alignment with 11 mandatory fields and optional
+tags:
+
+	```
+	@HD	VN:1.6	SO:coordinate
+	@SQ	SN:ref1	LN:100
+	read1	99	ref1	7	60	8M2I4M1D3M	=	37	39	TTAGATAAAGGATACTG	*	NM:i:3
+	```
+
+	1.  QNAME: Query template name
+	2.  FLAG: Bitwise flag
+	3.  RNAME: Reference sequence name
+	4.  POS: 1-based leftmost mapping position
+	5.  MAPQ: Mapping quality
+	6.  CIGAR: CIGAR string
+	7.  RNEXT: Reference name of the mate/next read
+	8.  PNEXT: Position of the mate/next read
+	9.  TLEN: Observed template length
+	10. SEQ: Segment sequence
+	11. QUAL: Phred quality scores + 33
+
+The specification can be found here: https://samtools.github.io/hts-specs/SAMv1.pdf
+
+This package provides parsers for SAM and BAM files and a writer for SAM files.
+*/
+package sam
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Flag is the bitwise flag of an alignment.
+type Flag uint16
+
+// Flag bits as defined in the SAM specification.
+const (
+	FlagPaired        Flag = 0x1   // template having multiple segments in sequencing
+	FlagProperPair    Flag = 0x2   // each segment properly aligned according to the aligner
+	FlagUnmapped      Flag = 0x4   // segment unmapped
+	FlagMateUnmapped  Flag = 0x8   // next segment in the template unmapped
+	FlagReverse       Flag = 0x10  // SEQ being reverse complemented
+	FlagMateReverse   Flag = 0x20  // SEQ of the next segment in the template being reverse complemented
+	FlagRead1         Flag = 0x40  // the first segment in the template
+	FlagRead2         Flag = 0x80  // the last segment in the template
+	FlagSecondary     Flag = 0x100 // secondary alignment
+	FlagQCFail        Flag = 0x200 // not passing filters, such as platform/vendor quality controls
+	FlagDuplicate     Flag = 0x400 // PCR or optical duplicate
+	FlagSupplementary Flag = 0x800 // supplementary alignment
+)
+
+// Has returns true if all bits of other are set in flag.
+func (flag Flag) Has(other Flag) bool {
+	return flag&other == other
+}
+
+// CigarOperation is a single operation of a CIGAR string, like 8M.
+type CigarOperation struct {
+	Length    int  `json:"length"`
+	Operation byte `json:"operation"` // one of MIDNSHP=X
+}
+
+// Cigar describes how a read aligns to the reference.
+type Cigar []CigarOperation
+
+// cigarOperations are the valid CIGAR operations, in the order of their BAM codes.
+const cigarOperations = "MIDNSHP=X"
+
+// String returns the CIGAR string, or * if the CIGAR is empty.
+func (cigar Cigar) String() string {
+	if len(cigar) == 0 {
+		return "*"
+	}
+	var cigarString strings.Builder
+	for _, operation := range cigar {
+		cigarString.WriteString(strconv.Itoa(operation.Length))
+		cigarString.WriteByte(operation.Operation)
+	}
+	return cigarString.String()
+}
+
+// ReferenceLength returns the amount of reference bases the CIGAR covers.
+func (cigar Cigar) ReferenceLength() int {
+	var length int
+	for _, operation := range cigar {
+		switch operation.Operation {
+		case 'M', 'D', 'N', '=', 'X':
+			length += operation.Length
+		}
+	}
+	return length
+}
+
+// QueryLength returns the amount of read bases the CIGAR covers, which should
+// be equal to the length of the alignment's Sequence.
+func (cigar Cigar) QueryLength() int {
+	var length int
+	for _, operation := range cigar {
+		switch operation.Operation {
+		case 'M', 'I', 'S', '=', 'X':
+			length += operation.Length
+		}
+	}
+	return length
+}
+
+// Tag is an optional field of an alignment, like NM:i:3.
+//
+// Type is the SAM type of the tag, and Value holds the corresponding go type:
+//   - A: byte
+//   - i: int (BAM integers of any size are converted to int)
+//   - f: float32
+//   - Z: string
+//   - H: string (hex encoded byte array)
+//   - B: []int8, []uint8, []int16, []uint16, []int32, []uint32 or []float32
+type Tag struct {
+	Name  string `json:"name"`
+	Type  byte   `json:"type"`
+	Value any    `json:"value"`
+}
+
+// Alignment is a single alignment of a SAM or BAM file.
+type Alignment struct {
+	QueryName         string `json:"query_name"`
+	Flag              Flag   `json:"flag"`
+	ReferenceName     string `json:"reference_name"` // empty if unmapped
+	Position          int    `json:"position"`       // 1-based, 0 if unmapped
+	MappingQuality    uint8  `json:"mapping_quality"`
+	Cigar             Cigar  `json:"cigar"`
+	MateReferenceName string `json:"mate_reference_name"` // "=" is resolved to ReferenceName
+	MatePosition      int    `json:"mate_position"`       // 1-based, 0 if unavailable
+	TemplateLength    int    `json:"template_length"`
+	Sequence          string `json:"sequence"` // empty if not stored
+	Quality           string `json:"quality"`  // phred+33 encoded, empty if not stored
+	Tags              []Tag  `json:"tags"`
+}
+
+// Tag returns the tag with the given name.
+func (alignment Alignment) Tag(name string) (Tag, bool) {
+	for _, tag := range alignment.Tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// HeaderTag is a single KEY:VALUE field of a header line.
+type HeaderTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// HeaderLine is a single header line, like @HD or @SQ. Comment lines (@CO)
+// store their text in Comment, all other lines store their fields in Tags.
+type HeaderLine struct {
+	Type    string      `json:"type"` // HD, SQ, RG, PG or CO
+	Tags    []HeaderTag `json:"tags"`
+	Comment string      `json:"comment"`
+}
+
+// Get returns the value of a tag of the header line.
+func (line HeaderLine) Get(key string) (string, bool) {
+	for _, tag := range line.Tags {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Reference is a reference sequence that reads are aligned to.
+type Reference struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+}
+
+// Header is the header of a SAM or BAM file.
+type Header struct {
+	Lines      []HeaderLine `json:"lines"`
+	References []Reference  `json:"references"`
+}
+
+// Parse parses a SAM file into a Header and a list of Alignments.
+func Parse(r io.Reader) (Header, []Alignment, error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	parser, header, err := NewParser(r, maxLineSize)
+	if err != nil {
+		return header, nil, err
+	}
+	alignments, err := parser.ParseAll()
+	return header, alignments, err
+}
+
+// Parser is a flexible parser that provides ample
+// control over reading SAM and BAM alignments.
+// It is initialized with NewParser or NewBAMParser.
+type Parser struct {
+	// reader keeps state of current reader.
+	reader     bufio.Reader
+	line       uint
+	bam        bool
+	references []Reference
+}
+
+// NewParser parses the header of a SAM file and returns a Parser for its alignments.
+func NewParser(r io.Reader, maxLineSize int) (*Parser, Header, error) {
+	parser := &Parser{
+		reader: *bufio.NewReaderSize(r, maxLineSize),
+	}
+	var header Header
+	for {
+		peek, err := parser.reader.Peek(1)
+		if err != nil || peek[0] != '@' {
+			break // the alignments start here. ParseNext will handle any errors.
+		}
+		lineBytes, err := parser.reader.ReadSlice('\n')
+		parser.line++
+		if err != nil && !errors.Is(err, io.EOF) {
+			return parser, Header{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+		}
+		headerLine, err := parseHeaderLine(strings.TrimRight(string(lineBytes), "\r\n"))
+		if err != nil {
+			return parser, Header{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+		}
+		header.Lines = append(header.Lines, headerLine)
+		if headerLine.Type == "SQ" {
+			reference, err := parseReference(headerLine)
+			if err != nil {
+				return parser, Header{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+			}
+			header.References = append(header.References, reference)
+		}
+	}
+	parser.references = header.References
+	return parser, header, nil
+}
+
+// ParseAll parses all alignments in underlying reader only returning non-EOF errors.
+// It returns all valid alignments up to error if encountered.
+func (parser *Parser) ParseAll() ([]Alignment, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// ParseN parses up to maxAlignments alignments from the Parser's underlying reader.
+// ParseN does not return EOF if encountered.
+// If an non-EOF error is encountered it returns it and all correctly parsed alignments up to then.
+func (parser *Parser) ParseN(maxAlignments int) (alignments []Alignment, err error) {
+	for counter := 0; counter < maxAlignments; counter++ {
+		alignment, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return alignments, err
+		}
+		alignments = append(alignments, alignment)
+	}
+	return alignments, nil
+}
+
+// ParseNext parses the next alignment from the Parser's underlying reader.
+// ParseNext returns an EOF if encountered.
+func (parser *Parser) ParseNext() (Alignment, error) {
+	if parser.bam {
+		return parser.parseNextBAM()
+	}
+	for {
+		if _, err := parser.reader.Peek(1); err != nil {
+			// Early return on error. Probably will be EOF.
+			return Alignment{}, err
+		}
+		lineBytes, err := parser.reader.ReadSlice('\n')
+		parser.line++
+		if err != nil && !errors.Is(err, io.EOF) {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				return Alignment{}, fmt.Errorf("line %d too large for buffer, use larger maxLineSize: %w", parser.line, err)
+			}
+			return Alignment{}, err
+		}
+		line := strings.TrimRight(string(lineBytes), "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		alignment, err := parseAlignment(line)
+		if err != nil {
+			return Alignment{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+		}
+		return alignment, nil
+	}
+}
+
+// parseHeaderLine parses a single SAM header line.
+func parseHeaderLine(line string) (HeaderLine, error) {
+	if len(line) < 3 || line[0] != '@' {
+		return HeaderLine{}, fmt.Errorf("malformed header line: %s", line)
+	}
+	headerLine := HeaderLine{Type: line[1:3]}
+	if headerLine.Type == "CO" {
+		headerLine.Comment = strings.TrimPrefix(line[3:], "\t")
+		return headerLine, nil
+	}
+	for _, field := range strings.Split(line, "\t")[1:] {
+		key, value, found := strings.Cut(field, ":")
+		if !found {
+			return HeaderLine{}, fmt.Errorf("header field '%s' is not in KEY:VALUE format", field)
+		}
+		headerLine.Tags = append(headerLine.Tags, HeaderTag{Key: key, Value: value})
+	}
+	return headerLine, nil
+}
+
+// parseReference gets the reference name and length from an @SQ line.
+func parseReference(headerLine HeaderLine) (Reference, error) {
+	name, ok := headerLine.Get("SN")
+	if !ok {
+		return Reference{}, errors.New("@SQ line without SN field")
+	}
+	lengthString, ok := headerLine.Get("LN")
+	if !ok {
+		return Reference{}, errors.New("@SQ line without LN field")
+	}
+	length, err := strconv.Atoi(lengthString)
+	if err != nil {
+		return Reference{}, err
+	}
+	return Reference{Name: name, Length: length}, nil
+}
+
+// parseAlignment parses a single SAM alignment line.
+func parseAlignment(line string) (Alignment, error) {
+	values := strings.Split(line, "\t")
+	if len(values) < 11 {
+		return Alignment{}, fmt.Errorf("Got %d values, expected at least 11.", len(values))
+	}
+	var alignment Alignment
+	var err error
+	if values[0] != "*" {
+		alignment.QueryName = values[0]
+	}
+	flag, err := strconv.ParseUint(values[1], 10, 16)
+	if err != nil {
+		return Alignment{}, fmt.Errorf("Failed to convert FLAG '%s': %w", values[1], err)
+	}
+	alignment.Flag = Flag(flag)
+	if values[2] != "*" {
+		alignment.ReferenceName = values[2]
+	}
+	if alignment.Position, err = strconv.Atoi(values[3]); err != nil {
+		return Alignment{}, fmt.Errorf("Failed to convert POS '%s': %w", values[3], err)
+	}
+	mappingQuality, err := strconv.ParseUint(values[4], 10, 8)
+	if err != nil {
+		return Alignment{}, fmt.Errorf("Failed to convert MAPQ '%s': %w", values[4], err)
+	}
+	alignment.MappingQuality = uint8(mappingQuality)
+	if alignment.Cigar, err = ParseCigar(values[5]); err != nil {
+		return Alignment{}, err
+	}
+	switch values[6] {
+	case "*":
+	case "=":
+		alignment.MateReferenceName = alignment.ReferenceName
+	default:
+		alignment.MateReferenceName = values[6]
+	}
+	if alignment.MatePosition, err = strconv.Atoi(values[7]); err != nil {
+		return Alignment{}, fmt.Errorf("Failed to convert PNEXT '%s': %w", values[7], err)
+	}
+	if alignment.TemplateLength, err = strconv.Atoi(values[8]); err != nil {
+		return Alignment{}, fmt.Errorf("Failed to convert TLEN '%s': %w", values[8], err)
+	}
+	if values[9] != "*" {
+		alignment.Sequence = values[9]
+	}
+	if values[10] != "*" {
+		alignment.Quality = values[10]
+	}
+	if alignment.Quality != "" && len(alignment.Quality) != len(alignment.Sequence) {
+		return Alignment{}, fmt.Errorf("QUAL length %d does not match SEQ length %d", len(alignment.Quality), len(alignment.Sequence))
+	}
+	for _, tagString := range values[11:] {
+		tag, err := parseTag(tagString)
+		if err != nil {
+			return Alignment{}, err
+		}
+		alignment.Tags = append(alignment.Tags, tag)
+	}
+	return alignment, nil
+}
+
+// ParseCigar parses a CIGAR string, like 8M2I4M1D3M. * returns an empty Cigar.
+func ParseCigar(cigarString string) (Cigar, error) {
+	if cigarString == "*" {
+		return nil, nil
+	}
+	var cigar Cigar
+	var length int
+	var lengthDigits int
+	for index := 0; index < len(cigarString); index++ {
+		character := cigarString[index]
+		if character >= '0' && character <= '9' {
+			length = length*10 + int(character-'0')
+			lengthDigits++
+			continue
+		}
+		if strings.IndexByte(cigarOperations, character) == -1 {
+			return nil, fmt.Errorf("unknown CIGAR operation '%c' in %s", character, cigarString)
+		}
+		if lengthDigits == 0 {
+			return nil, fmt.Errorf("CIGAR operation '%c' without length in %s", character, cigarString)
+		}
+		cigar = append(cigar, CigarOperation{Length: length, Operation: character})
+		length = 0
+		lengthDigits = 0
+	}
+	if lengthDigits != 0 {
+		return nil, fmt.Errorf("CIGAR %s ends without operation", cigarString)
+	}
+	return cigar, nil
+}
+
+// parseTag parses a SAM optional field, like NM:i:3.
+func parseTag(tagString string) (Tag, error) {
+	if len(tagString) < 5 || tagString[2] != ':' || tagString[4] != ':' {
+		return Tag{}, fmt.Errorf("tag '%s' is not in TAG:TYPE:VALUE format", tagString)
+	}
+	tag := Tag{Name: tagString[:2], Type: tagString[3]}
+	value := tagString[5:]
+	switch tag.Type {
+	case 'A':
+		if len(value) != 1 {
+			return Tag{}, fmt.Errorf("tag '%s' of type A must have a single character", tagString)
+		}
+		tag.Value = value[0]
+	case 'i':
+		integer, err := strconv.Atoi(value)
+		if err != nil {
+			return Tag{}, fmt.Errorf("Failed to convert tag '%s': %w", tagString, err)
+		}
+		tag.Value = integer
+	case 'f':
+		float, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return Tag{}, fmt.Errorf("Failed to convert tag '%s': %w", tagString, err)
+		}
+		tag.Value = float32(float)
+	case 'Z', 'H':
+		tag.Value = value
+	case 'B':
+		array, err := parseTagArray(value)
+		if err != nil {
+			return Tag{}, fmt.Errorf("Failed to convert tag '%s': %w", tagString, err)
+		}
+		tag.Value = array
+	default:
+		return Tag{}, fmt.Errorf("unknown type '%c' in tag '%s'", tag.Type, tagString)
+	}
+	return tag, nil
+}
+
+// parseTagArray parses the value of a B tag, like s,-1,200,3.
+func parseTagArray(value string) (any, error) {
+	values := strings.Split(value, ",")
+	if len(values[0]) != 1 {
+		return nil, fmt.Errorf("unknown array type '%s'", values[0])
+	}
+	subType := values[0][0]
+	values = values[1:]
+	if subType == 'f' {
+		array := make([]float32, len(values))
+		for index, value := range values {
+			float, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				return nil, err
+			}
+			array[index] = float32(float)
+		}
+		return array, nil
+	}
+	bitSize, signed, err := arrayIntegerType(subType)
+	if err != nil {
+		return nil, err
+	}
+	integers := make([]int64, len(values))
+	for index, value := range values {
+		if signed {
+			integers[index], err = strconv.ParseInt(value, 10, bitSize)
+		} else {
+			var unsigned uint64
+			unsigned, err = strconv.ParseUint(value, 10, bitSize)
+			integers[index] = int64(unsigned)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return convertIntegerArray(subType, integers), nil
+}
+
+// arrayIntegerType returns the size and signedness of an integer B array subtype.
+func arrayIntegerType(subType byte) (bitSize int, signed bool, err error) {
+	switch subType {
+	case 'c':
+		return 8, true, nil
+	case 'C':
+		return 8, false, nil
+	case 's':
+		return 16, true, nil
+	case 'S':
+		return 16, false, nil
+	case 'i':
+		return 32, true, nil
+	case 'I':
+		return 32, false, nil
+	}
+	return 0, false, fmt.Errorf("unknown array type '%c'", subType)
+}
+
+// convertIntegerArray converts integers into the go slice type of a B array subtype.
+func convertIntegerArray(subType byte, integers []int64) any {
+	switch subType {
+	case 'c':
+		return convertIntegers[int8](integers)
+	case 'C':
+		return convertIntegers[uint8](integers)
+	case 's':
+		return convertIntegers[int16](integers)
+	case 'S':
+		return convertIntegers[uint16](integers)
+	case 'i':
+		return convertIntegers[int32](integers)
+	default:
+		return convertIntegers[uint32](integers)
+	}
+}
+
+func convertIntegers[T int8 | uint8 | int16 | uint16 | int32 | uint32](integers []int64) []T {
+	converted := make([]T, len(integers))
+	for index, integer := range integers {
+		converted[index] = T(integer)
+	}
+	return converted
+}
+
+/******************************************************************************
+
+Start of  Read functions
+
+******************************************************************************/
+
+// Read reads a SAM file into a Header and a list of Alignments.
+func Read(path string) (Header, []Alignment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+Start of  Write functions
+
+******************************************************************************/
+
+// Writer writes alignments in the SAM format.
+// It is initialized with NewWriter.
+type Writer struct {
+	writer io.Writer
+}
+
+// NewWriter writes the header to w and returns a Writer for the alignments.
+// If the header has no @SQ lines, they are generated from its References.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	var headerBuffer bytes.Buffer
+	hasReferenceLines := false
+	for _, line := range header.Lines {
+		if line.Type == "SQ" {
+			hasReferenceLines = true
+		}
+	}
+	for lineIndex, line := range header.Lines {
+		headerBuffer.WriteString("@" + line.Type)
+		if line.Type == "CO" {
+			headerBuffer.WriteString("\t" + line.Comment)
+		}
+		for _, tag := range line.Tags {
+			headerBuffer.WriteString("\t" + tag.Key + ":" + tag.Value)
+		}
+		headerBuffer.WriteString("\n")
+		// @SQ lines go right after @HD, which must be the first line.
+		if !hasReferenceLines && lineIndex == 0 {
+			writeReferenceLines(&headerBuffer, header.References)
+			hasReferenceLines = true
+		}
+	}
+	if !hasReferenceLines {
+		writeReferenceLines(&headerBuffer, header.References)
+	}
+	_, err := w.Write(headerBuffer.Bytes())
+	return &Writer{writer: w}, err
+}
+
+func writeReferenceLines(headerBuffer *bytes.Buffer, references []Reference) {
+	for _, reference := range references {
+		headerBuffer.WriteString("@SQ\tSN:" + reference.Name + "\tLN:" + strconv.Itoa(reference.Length) + "\n")
+	}
+}
+
+// Write writes a single alignment.
+func (writer *Writer) Write(alignment Alignment) error {
+	line, err := buildAlignment(alignment)
+	if err != nil {
+		return err
+	}
+	_, err = writer.writer.Write(line)
+	return err
+}
+
+// buildAlignment builds a single SAM alignment line.
+func buildAlignment(alignment Alignment) ([]byte, error) {
+	orStar := func(value string) string {
+		if value == "" {
+			return "*"
+		}
+		return value
+	}
+	mateReferenceName := orStar(alignment.MateReferenceName)
+	if alignment.MateReferenceName != "" && alignment.MateReferenceName == alignment.ReferenceName {
+		mateReferenceName = "="
+	}
+	fields := []string{
+		orStar(alignment.QueryName),
+		strconv.FormatUint(uint64(alignment.Flag), 10),
+		orStar(alignment.ReferenceName),
+		strconv.Itoa(alignment.Position),
+		strconv.FormatUint(uint64(alignment.MappingQuality), 10),
+		alignment.Cigar.String(),
+		mateReferenceName,
+		strconv.Itoa(alignment.MatePosition),
+		strconv.Itoa(alignment.TemplateLength),
+		orStar(alignment.Sequence),
+		orStar(alignment.Quality),
+	}
+	for _, tag := range alignment.Tags {
+		tagString, err := buildTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to write tag of %s: %w", alignment.QueryName, err)
+		}
+		fields = append(fields, tagString)
+	}
+	return []byte(strings.Join(fields, "\t") + "\n"), nil
+}
+
+// buildTag builds a SAM optional field, like NM:i:3.
+func buildTag(tag Tag) (string, error) {
+	prefix := tag.Name + ":" + string(tag.Type) + ":"
+	switch value := tag.Value.(type) {
+	case byte:
+		if tag.Type == 'A' {
+			return prefix + string(value), nil
+		}
+	case int:
+		if tag.Type == 'i' {
+			return prefix + strconv.Itoa(value), nil
+		}
+	case float32:
+		if tag.Type == 'f' {
+			return prefix + strconv.FormatFloat(float64(value), 'g', -1, 32), nil
+		}
+	case string:
+		if tag.Type == 'Z' || tag.Type == 'H' {
+			return prefix + value, nil
+		}
+	case []int8:
+		return prefix + buildTagArray('c', value), nil
+	case []uint8:
+		return prefix + buildTagArray('C', value), nil
+	case []int16:
+		return prefix + buildTagArray('s', value), nil
+	case []uint16:
+		return prefix + buildTagArray('S', value), nil
+	case []int32:
+		return prefix + buildTagArray('i', value), nil
+	case []uint32:
+		return prefix + buildTagArray('I', value), nil
+	case []float32:
+		arrayString := "f"
+		for _, float := range value {
+			arrayString += "," + strconv.FormatFloat(float64(float), 'g', -1, 32)
+		}
+		return prefix + arrayString, nil
+	}
+	return "", fmt.Errorf("tag %s of type %c has unsupported value %v", tag.Name, tag.Type, tag.Value)
+}
+
+func buildTagArray[T int8 | uint8 | int16 | uint16 | int32 | uint32](subType byte, array []T) string {
+	arrayString := string(subType)
+	for _, value := range array {
+		arrayString += "," + strconv.FormatInt(int64(value), 10)
+	}
+	return arrayString
+}
+
+// Build builds a SAM file from a header and a list of alignments.
+func Build(header Header, alignments []Alignment) ([]byte, error) {
+	var samBuffer bytes.Buffer
+	writer, err := NewWriter(&samBuffer, header)
+	if err != nil {
+		return nil, err
+	}
+	for _, alignment := range alignments {
+		if err = writer.Write(alignment); err != nil {
+			return nil, err
+		}
+	}
+	return samBuffer.Bytes(), nil
+}
+
+// Write writes a header and a list of alignments to a SAM file.
+func Write(header Header, alignments []Alignment, path string) error {
+	samBytes, err := Build(header, alignments)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, samBytes, 0644)
+}
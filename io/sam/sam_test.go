@@ -0,0 +1,164 @@
+package sam
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	header, alignments, err := Read("data/example.sam")
+	if err != nil {
+		t.Fatalf("Failed to read example.sam. Got error: %s", err)
+	}
+	if len(header.Lines) != 6 {
+		t.Errorf("Expected 6 header lines. Got: %d", len(header.Lines))
+	}
+	expectedReferences := []Reference{{Name: "ref1", Length: 100}, {Name: "ref2", Length: 50}}
+	if !reflect.DeepEqual(header.References, expectedReferences) {
+		t.Errorf("Expected references %v. Got: %v", expectedReferences, header.References)
+	}
+	if sample, _ := header.Lines[3].Get("SM"); sample != "sample1" {
+		t.Errorf("Expected read group sample sample1. Got: %s", sample)
+	}
+	if header.Lines[5].Comment != "example alignments for poly" {
+		t.Errorf("Expected comment 'example alignments for poly'. Got: %s", header.Lines[5].Comment)
+	}
+	if len(alignments) != 5 {
+		t.Fatalf("Expected 5 alignments. Got: %d", len(alignments))
+	}
+
+	first := alignments[0]
+	if !first.Flag.Has(FlagPaired|FlagProperPair|FlagMateReverse|FlagRead1) || first.Flag.Has(FlagReverse) {
+		t.Errorf("Wrong flags parsed for read1: %d", first.Flag)
+	}
+	if first.Position != 7 || first.MateReferenceName != "ref1" || first.MatePosition != 37 || first.TemplateLength != 39 {
+		t.Errorf("Wrong positions parsed for read1: %+v", first)
+	}
+	if first.Cigar.String() != "8M2I4M1D3M" || first.Cigar.ReferenceLength() != 16 || first.Cigar.QueryLength() != len(first.Sequence) {
+		t.Errorf("Wrong cigar parsed for read1: %s", first.Cigar)
+	}
+	if first.Quality != "" {
+		t.Errorf("Expected empty quality for read1. Got: %s", first.Quality)
+	}
+	if tag, ok := first.Tag("NM"); !ok || tag.Value != 3 {
+		t.Errorf("Expected NM tag of 3 for read1. Got: %v", tag)
+	}
+
+	third := alignments[2]
+	expectedTags := []Tag{
+		{Name: "NM", Type: 'i', Value: 0},
+		{Name: "XS", Type: 'i', Value: -5},
+		{Name: "ZF", Type: 'f', Value: float32(3.5)},
+		{Name: "ZB", Type: 'B', Value: []int16{-1, 200, 3}},
+		{Name: "ZC", Type: 'B', Value: []uint8{1, 2, 255}},
+		{Name: "ZA", Type: 'A', Value: byte('x')},
+		{Name: "ZH", Type: 'H', Value: "1AE301"},
+	}
+	if !reflect.DeepEqual(third.Tags, expectedTags) {
+		t.Errorf("Expected tags %v. Got: %v", expectedTags, third.Tags)
+	}
+
+	unmapped := alignments[3]
+	if !unmapped.Flag.Has(FlagUnmapped) || unmapped.ReferenceName != "" || unmapped.Position != 0 || unmapped.Cigar != nil {
+		t.Errorf("Wrong fields parsed for unmapped read: %+v", unmapped)
+	}
+}
+
+func TestParseCigar(t *testing.T) {
+	cigar, err := ParseCigar("3H10M2=1X4N")
+	if err != nil {
+		t.Errorf("Failed to parse cigar. Got error: %s", err)
+	}
+	expected := Cigar{{3, 'H'}, {10, 'M'}, {2, '='}, {1, 'X'}, {4, 'N'}}
+	if !reflect.DeepEqual(cigar, expected) {
+		t.Errorf("Expected cigar %v. Got: %v", expected, cigar)
+	}
+	for _, badCigar := range []string{"10Q", "M", "10M5"} {
+		if _, err = ParseCigar(badCigar); err == nil {
+			t.Errorf("Test should have failed parsing cigar %s", badCigar)
+		}
+	}
+}
+
+func TestParseExceptions(t *testing.T) {
+	header := "@HD\tVN:1.6\n@SQ\tSN:ref1\tLN:100\n"
+	badLines := []string{
+		"read1\t0\tref1\t1\t60\t4M\t*\t0\t0\tACGT\n",                // too few fields
+		"read1\tflag\tref1\t1\t60\t4M\t*\t0\t0\tACGT\t*\n",          // bad flag
+		"read1\t0\tref1\t1\t600\t4M\t*\t0\t0\tACGT\t*\n",            // mapq too large
+		"read1\t0\tref1\t1\t60\t4M\t*\t0\t0\tACGT\tII\n",            // qual length mismatch
+		"read1\t0\tref1\t1\t60\t4M\t*\t0\t0\tACGT\t*\tNM:q:1\n",     // unknown tag type
+		"read1\t0\tref1\t1\t60\t4M\t*\t0\t0\tACGT\t*\tZB:B:c,300\n", // array value out of range
+	}
+	for _, badLine := range badLines {
+		_, _, err := Parse(strings.NewReader(header + badLine))
+		if err == nil {
+			t.Errorf("Test should have failed parsing %q", badLine)
+		}
+	}
+	_, _, err := Parse(strings.NewReader("@SQ\tSN:ref1\n"))
+	if err == nil {
+		t.Errorf("Test should have failed parsing @SQ line without LN")
+	}
+
+	file, _ := os.Open("data/example.sam")
+	defer file.Close()
+	parser, _, _ := NewParser(file, 128)
+	if _, err = parser.ParseAll(); err == nil {
+		t.Errorf("Should have encountered a maxLine error")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	samBytes, err := os.ReadFile("data/example.sam")
+	if err != nil {
+		t.Fatalf("Failed to read example.sam. Got error: %s", err)
+	}
+	header, alignments, err := Parse(strings.NewReader(string(samBytes)))
+	if err != nil {
+		t.Fatalf("Failed to parse example.sam. Got error: %s", err)
+	}
+	builtBytes, err := Build(header, alignments)
+	if err != nil {
+		t.Fatalf("Failed to build sam. Got error: %s", err)
+	}
+	if string(builtBytes) != string(samBytes) {
+		t.Errorf("Built sam differs from example.sam. Got:\n%s", builtBytes)
+	}
+
+	// Without @SQ lines, they are generated from the References.
+	header = Header{
+		Lines:      []HeaderLine{{Type: "HD", Tags: []HeaderTag{{Key: "VN", Value: "1.6"}}}},
+		References: []Reference{{Name: "ref1", Length: 100}},
+	}
+	builtBytes, _ = Build(header, nil)
+	expected := "@HD\tVN:1.6\n@SQ\tSN:ref1\tLN:100\n"
+	if string(builtBytes) != expected {
+		t.Errorf("Expected header %q. Got: %q", expected, builtBytes)
+	}
+
+	_, err = Build(header, []Alignment{{Tags: []Tag{{Name: "NM", Type: 'i', Value: "three"}}}})
+	if err == nil {
+		t.Errorf("Test should have failed building a tag with the wrong value type")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	header, alignments, _ := Read("data/example.sam")
+	path := t.TempDir() + "/example.sam"
+	if err := Write(header, alignments, path); err != nil {
+		t.Fatalf("Failed to write sam. Got error: %s", err)
+	}
+	writtenHeader, writtenAlignments, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read written sam. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(header, writtenHeader) || !reflect.DeepEqual(alignments, writtenAlignments) {
+		t.Errorf("Alignments changed after writing and reading")
+	}
+	if _, _, err = Read("data/doesntexist.sam"); err == nil {
+		t.Errorf("Should have failed to read non-existent file")
+	}
+}
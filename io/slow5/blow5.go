@@ -0,0 +1,543 @@
+package slow5
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+blow5 parser and writer begin here. Specification below:
+https://hasindu2008.github.io/slow5specs/slow5-v1.0.0.pdf
+
+blow5 is the binary version of slow5. A blow5 file starts with a small fixed
+size binary header (magic number, version, compression methods and the number
+of read groups) padded to 64 bytes. That is followed by the size of the text
+header and the text header itself, which is exactly the same as the slow5
+header minus the #slow5_version and #num_read_groups lines.
+
+After the header come the records. Each record is prefixed with its size as a
+uint64, and the record itself may be compressed with zlib. Inside of a record,
+the primary fields are stored in little endian in the order given by the
+specification, followed by the auxiliary fields in the order of the text
+header. Arrays (like char* for channel_number) are prefixed with their length
+as a uint64.
+
+The raw signal can additionally be compressed with svb-zd, which is zig-zag
+delta encoding followed by StreamVByte encoding. This works really well for
+nanopore signals, since the difference between two samples is usually tiny.
+
+The file ends with the "5WOLB" end of file marker.
+
+Since the text header is the same as in slow5, we simply reuse the slow5
+parser for it, and the Header and Read structs are shared between both
+formats.
+
+******************************************************************************/
+
+// RecordCompression is the method used to compress entire blow5 records.
+type RecordCompression uint8
+
+// SignalCompression is the method used to compress the raw signal of blow5 records.
+type SignalCompression uint8
+
+// Record compression methods defined by the slow5 specification. Only
+// RecordCompressionNone and RecordCompressionZlib are supported.
+const (
+	RecordCompressionNone RecordCompression = 0
+	RecordCompressionZlib RecordCompression = 1
+	RecordCompressionZstd RecordCompression = 2
+)
+
+// Signal compression methods defined by the slow5 specification. Only
+// SignalCompressionNone and SignalCompressionSvbZd are supported.
+const (
+	SignalCompressionNone  SignalCompression = 0
+	SignalCompressionSvbZd SignalCompression = 1
+)
+
+var (
+	blow5MagicNumber = []byte{'B', 'L', 'O', 'W', '5', 1}
+	blow5EndOfFile   = []byte{'5', 'W', 'O', 'L', 'B'}
+)
+
+// blow5HeaderSizeOffset is the offset where the size of the text header is stored.
+const blow5HeaderSizeOffset = 64
+
+// the primary fields every read has, in the order they are stored in a blow5 record.
+const blow5PrimaryFields = 8
+
+// primitiveTypeSizes holds the size in bytes of every primitive type allowed
+// in slow5 auxiliary fields. enums are stored as a uint8.
+var primitiveTypeSizes = map[string]int{
+	"int8_t":   1,
+	"uint8_t":  1,
+	"char":     1,
+	"int16_t":  2,
+	"uint16_t": 2,
+	"int32_t":  4,
+	"uint32_t": 4,
+	"float":    4,
+	"int64_t":  8,
+	"uint64_t": 8,
+	"double":   8,
+}
+
+// auxiliaryFieldSizes holds the size in bytes of the known fixed size auxiliary fields.
+var auxiliaryFieldSizes = map[string]int{
+	"start_time":    8,
+	"read_number":   4,
+	"start_mux":     1,
+	"median_before": 8,
+	"end_reason":    1,
+}
+
+// Blow5Parser is a parser for the binary blow5 format.
+// It is initialized with NewBlow5Parser.
+type Blow5Parser struct {
+	// reader keeps state of current reader.
+	reader            bufio.Reader
+	record            uint
+	recordCompression RecordCompression
+	signalCompression SignalCompression
+	headerMap         map[int]string
+	typeMap           map[int]string
+	endReasonMap      map[int]string
+	// offset is the position of the next record in the file, used for indexing.
+	offset uint64
+	// source and index are used for random access with Fetch.
+	source io.Reader
+	index  Index
+}
+
+// NewBlow5Parser parses the header of a blow5 file and returns a parser for
+// its reads. The headers are the same as the headers returned by NewParser.
+func NewBlow5Parser(r io.Reader) (*Blow5Parser, []Header, error) {
+	parser := &Blow5Parser{
+		reader: *bufio.NewReader(r),
+		source: r,
+	}
+	fixedHeader := make([]byte, blow5HeaderSizeOffset+4)
+	if _, err := io.ReadFull(&parser.reader, fixedHeader); err != nil {
+		return parser, []Header{}, err
+	}
+	if !bytes.Equal(fixedHeader[:len(blow5MagicNumber)], blow5MagicNumber) {
+		return parser, []Header{}, fmt.Errorf("Not a blow5 file. Expected magic number %q, got %q", blow5MagicNumber, fixedHeader[:len(blow5MagicNumber)])
+	}
+	slow5Version := fmt.Sprintf("%d.%d.%d", fixedHeader[6], fixedHeader[7], fixedHeader[8])
+	parser.recordCompression = RecordCompression(fixedHeader[9])
+	numReadGroups := binary.LittleEndian.Uint32(fixedHeader[10:14])
+	parser.signalCompression = SignalCompression(fixedHeader[14])
+	if err := checkCompression(parser.recordCompression, parser.signalCompression); err != nil {
+		return parser, []Header{}, err
+	}
+
+	headerSize := binary.LittleEndian.Uint32(fixedHeader[blow5HeaderSizeOffset:])
+	headerText := make([]byte, headerSize)
+	if _, err := io.ReadFull(&parser.reader, headerText); err != nil {
+		return parser, []Header{}, err
+	}
+
+	// The text header is a slow5 header without the first two lines, so we
+	// add them back in and let the slow5 parser do the work.
+	var slow5Header bytes.Buffer
+	fmt.Fprintf(&slow5Header, "#slow5_version\t%s\n#num_read_groups\t%d\n", slow5Version, numReadGroups)
+	slow5Header.Write(headerText)
+	textParser, headers, err := NewParser(&slow5Header, slow5Header.Len()+1)
+	if err != nil {
+		return parser, []Header{}, err
+	}
+	parser.offset = uint64(len(fixedHeader)) + uint64(headerSize)
+	parser.headerMap = textParser.headerMap
+	parser.typeMap = textParser.typeMap
+	parser.endReasonMap = textParser.endReasonMap
+	return parser, headers, nil
+}
+
+// ParseNext parses the next read from a blow5 parser.
+// ParseNext returns an EOF once the end of file marker is reached.
+func (parser *Blow5Parser) ParseNext() (Read, error) {
+	record, err := parser.nextRecord()
+	if err != nil {
+		return Read{}, err
+	}
+	return parser.parseRecord(record)
+}
+
+// nextRecord reads the next raw record without decoding it.
+func (parser *Blow5Parser) nextRecord() ([]byte, error) {
+	peek, err := parser.reader.Peek(len(blow5EndOfFile))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(peek, blow5EndOfFile) {
+		return nil, io.EOF
+	}
+	var recordSize uint64
+	if err = binary.Read(&parser.reader, binary.LittleEndian, &recordSize); err != nil {
+		return nil, err
+	}
+	record := make([]byte, recordSize)
+	if _, err = io.ReadFull(&parser.reader, record); err != nil {
+		return nil, fmt.Errorf("Failed to read record %d. Got error: %w", parser.record, err)
+	}
+	parser.record++
+	parser.offset += 8 + recordSize
+	return record, nil
+}
+
+// decompressRecord undoes the record compression of a blow5 record.
+func (parser *Blow5Parser) decompressRecord(record []byte) ([]byte, error) {
+	if parser.recordCompression != RecordCompressionZlib {
+		return record, nil
+	}
+	zlibReader, err := zlib.NewReader(bytes.NewReader(record))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decompress record %d. Got error: %w", parser.record, err)
+	}
+	record, err = io.ReadAll(zlibReader)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decompress record %d. Got error: %w", parser.record, err)
+	}
+	return record, nil
+}
+
+// parseRecord decodes a single (possibly compressed) blow5 record.
+func (parser *Blow5Parser) parseRecord(record []byte) (Read, error) {
+	record, err := parser.decompressRecord(record)
+	if err != nil {
+		return Read{}, err
+	}
+
+	reader := bytes.NewReader(record)
+	var newRead Read
+	var readIDLength uint16
+	var lenRawSignal uint64
+	if err := binary.Read(reader, binary.LittleEndian, &readIDLength); err != nil {
+		return Read{}, fmt.Errorf("Failed to read read_id of record %d. Got error: %w", parser.record, err)
+	}
+	readID := make([]byte, readIDLength)
+	if _, err := io.ReadFull(reader, readID); err != nil {
+		return Read{}, fmt.Errorf("Failed to read read_id of record %d. Got error: %w", parser.record, err)
+	}
+	newRead.ReadID = string(readID)
+	for _, field := range []any{&newRead.ReadGroupID, &newRead.Digitisation, &newRead.Offset, &newRead.Range, &newRead.SamplingRate, &lenRawSignal} {
+		if err := binary.Read(reader, binary.LittleEndian, field); err != nil {
+			return Read{}, fmt.Errorf("Failed to read primary fields of record %d. Got error: %w", parser.record, err)
+		}
+	}
+
+	// When the signal is compressed, len_raw_signal is the size of the
+	// compressed signal in int16_t units rather than the amount of samples.
+	if 2*lenRawSignal > uint64(reader.Len()) {
+		return Read{}, fmt.Errorf("Failed to read raw_signal of record %d. Got error: %w", parser.record, io.ErrUnexpectedEOF)
+	}
+	rawSignal := make([]byte, 2*lenRawSignal)
+	if _, err := io.ReadFull(reader, rawSignal); err != nil {
+		return Read{}, fmt.Errorf("Failed to read raw_signal of record %d. Got error: %w", parser.record, err)
+	}
+	switch parser.signalCompression {
+	case SignalCompressionSvbZd:
+		signal, err := decompressSvbZd(rawSignal)
+		if err != nil {
+			return Read{}, fmt.Errorf("Failed to decompress raw_signal of record %d. Got error: %w", parser.record, err)
+		}
+		newRead.RawSignal = signal
+	default:
+		newRead.RawSignal = make([]int16, lenRawSignal)
+		for signalIndex := range newRead.RawSignal {
+			newRead.RawSignal[signalIndex] = int16(binary.LittleEndian.Uint16(rawSignal[2*signalIndex:]))
+		}
+	}
+	newRead.LenRawSignal = uint64(len(newRead.RawSignal))
+
+	// Auxiliary fields are stored in the order of the header.
+	for fieldIndex := blow5PrimaryFields; fieldIndex < len(parser.headerMap); fieldIndex++ {
+		fieldName := parser.headerMap[fieldIndex]
+		value, err := readAuxiliaryValue(reader, parser.typeMap[fieldIndex])
+		if err != nil {
+			return Read{}, fmt.Errorf("Failed to read %s of record %d. Got error: %w", fieldName, parser.record, err)
+		}
+		if size, ok := auxiliaryFieldSizes[fieldName]; ok && len(value) != size {
+			newRead.Error = fmt.Errorf("Failed to convert %s in record %d. Expected a %d byte value, got %d bytes", fieldName, parser.record, size, len(value))
+			continue
+		}
+		switch fieldName {
+		case "start_time":
+			newRead.StartTime = binary.LittleEndian.Uint64(value)
+		case "read_number":
+			newRead.ReadNumber = int32(binary.LittleEndian.Uint32(value))
+		case "start_mux":
+			newRead.StartMux = value[0]
+		case "median_before":
+			newRead.MedianBefore = math.Float64frombits(binary.LittleEndian.Uint64(value))
+		case "end_reason":
+			endReason, ok := parser.endReasonMap[int(value[0])]
+			if !ok {
+				newRead.Error = fmt.Errorf("End reason out of range. Got '%d' in record %d. Cannot find valid enum reason", value[0], parser.record)
+			}
+			newRead.EndReason = endReason
+		case "channel_number":
+			newRead.ChannelNumber = string(value)
+		default:
+			newRead.Error = fmt.Errorf("Unknown field to parser '%s' found in record %d. Please report to github.com/bebop/poly", fieldName, parser.record)
+		}
+	}
+	return newRead, nil
+}
+
+// readAuxiliaryValue reads the raw bytes of an auxiliary value of the given
+// slow5 type. For arrays, only the array contents are returned.
+func readAuxiliaryValue(reader *bytes.Reader, valueType string) ([]byte, error) {
+	var valueSize uint64
+	switch {
+	case strings.HasSuffix(valueType, "*"):
+		var arrayLength uint64
+		if err := binary.Read(reader, binary.LittleEndian, &arrayLength); err != nil {
+			return nil, err
+		}
+		elementSize, ok := primitiveTypeSizes[strings.TrimSuffix(valueType, "*")]
+		if !ok {
+			return nil, fmt.Errorf("unknown array type '%s'", valueType)
+		}
+		valueSize = arrayLength * uint64(elementSize)
+		if valueSize > uint64(reader.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+	case strings.HasPrefix(valueType, "enum"):
+		valueSize = 1
+	default:
+		primitiveSize, ok := primitiveTypeSizes[valueType]
+		if !ok {
+			return nil, fmt.Errorf("unknown type '%s'", valueType)
+		}
+		valueSize = uint64(primitiveSize)
+	}
+	value := make([]byte, valueSize)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// checkCompression returns an error for compression methods that poly cannot handle.
+func checkCompression(recordCompression RecordCompression, signalCompression SignalCompression) error {
+	switch recordCompression {
+	case RecordCompressionNone, RecordCompressionZlib:
+	case RecordCompressionZstd:
+		return errors.New("zstd record compression is not supported. Please convert the file to zlib or no compression with slow5tools")
+	default:
+		return fmt.Errorf("Unknown record compression method %d", recordCompression)
+	}
+	switch signalCompression {
+	case SignalCompressionNone, SignalCompressionSvbZd:
+	default:
+		return fmt.Errorf("Unknown signal compression method %d", signalCompression)
+	}
+	return nil
+}
+
+/******************************************************************************
+
+Start of blow5 Write functions
+
+******************************************************************************/
+
+// WriteBlow5 writes a list of headers and a channel of reads to an output in
+// the blow5 format. Records are compressed with recordCompression and raw
+// signals with signalCompression.
+func WriteBlow5(headers []Header, reads <-chan Read, output io.Writer, recordCompression RecordCompression, signalCompression SignalCompression) error {
+	if err := checkCompression(recordCompression, signalCompression); err != nil {
+		return err
+	}
+	version, err := parseSlow5Version(headers[0].Slow5Version)
+	if err != nil {
+		return err
+	}
+	// Signal compression was only introduced in slow5 v0.2.0
+	if signalCompression != SignalCompressionNone && (version[0] == 0 && version[1] < 2) {
+		return fmt.Errorf("Signal compression requires slow5 version 0.2.0 or higher. Got: %s", headers[0].Slow5Version)
+	}
+
+	var headerBody bytes.Buffer
+	if err = writeHeaderBody(headers, &headerBody); err != nil {
+		return err
+	}
+	fixedHeader := make([]byte, blow5HeaderSizeOffset+4)
+	copy(fixedHeader, blow5MagicNumber)
+	copy(fixedHeader[6:9], version[:])
+	fixedHeader[9] = byte(recordCompression)
+	binary.LittleEndian.PutUint32(fixedHeader[10:14], uint32(len(headers)))
+	fixedHeader[14] = byte(signalCompression)
+	binary.LittleEndian.PutUint32(fixedHeader[blow5HeaderSizeOffset:], uint32(headerBody.Len()))
+	if _, err = output.Write(fixedHeader); err != nil {
+		return err
+	}
+	if _, err = output.Write(headerBody.Bytes()); err != nil {
+		return err
+	}
+
+	endReasonHeaderMap := headers[0].EndReasonHeaderMap
+	var compressedRecord bytes.Buffer
+	for read := range reads {
+		record := buildRecord(read, endReasonHeaderMap, signalCompression)
+		if recordCompression == RecordCompressionZlib {
+			compressedRecord.Reset()
+			zlibWriter := zlib.NewWriter(&compressedRecord)
+			if _, err = zlibWriter.Write(record); err != nil {
+				return err
+			}
+			if err = zlibWriter.Close(); err != nil {
+				return err
+			}
+			record = compressedRecord.Bytes()
+		}
+		if err = binary.Write(output, binary.LittleEndian, uint64(len(record))); err != nil {
+			return err
+		}
+		if _, err = output.Write(record); err != nil {
+			return err
+		}
+	}
+	_, err = output.Write(blow5EndOfFile)
+	return err
+}
+
+// buildRecord builds an uncompressed blow5 record. The auxiliary fields are
+// the same as the ones written by writeHeaderBody.
+func buildRecord(read Read, endReasonHeaderMap map[string]int, signalCompression SignalCompression) []byte {
+	var rawSignal []byte
+	switch signalCompression {
+	case SignalCompressionSvbZd:
+		rawSignal = compressSvbZd(read.RawSignal)
+		// len_raw_signal is given in int16_t units, so pad to an even size.
+		if len(rawSignal)%2 != 0 {
+			rawSignal = append(rawSignal, 0)
+		}
+	default:
+		rawSignal = make([]byte, 0, 2*len(read.RawSignal))
+		for _, signal := range read.RawSignal {
+			rawSignal = binary.LittleEndian.AppendUint16(rawSignal, uint16(signal))
+		}
+	}
+
+	record := make([]byte, 0, len(read.ReadID)+len(rawSignal)+len(read.ChannelNumber)+80)
+	record = binary.LittleEndian.AppendUint16(record, uint16(len(read.ReadID)))
+	record = append(record, read.ReadID...)
+	record = binary.LittleEndian.AppendUint32(record, read.ReadGroupID)
+	record = binary.LittleEndian.AppendUint64(record, math.Float64bits(read.Digitisation))
+	record = binary.LittleEndian.AppendUint64(record, math.Float64bits(read.Offset))
+	record = binary.LittleEndian.AppendUint64(record, math.Float64bits(read.Range))
+	record = binary.LittleEndian.AppendUint64(record, math.Float64bits(read.SamplingRate))
+	record = binary.LittleEndian.AppendUint64(record, uint64(len(rawSignal)/2))
+	record = append(record, rawSignal...)
+
+	// Auxiliary fields
+	record = binary.LittleEndian.AppendUint64(record, read.StartTime)
+	record = binary.LittleEndian.AppendUint32(record, uint32(read.ReadNumber))
+	record = append(record, read.StartMux)
+	record = binary.LittleEndian.AppendUint64(record, math.Float64bits(read.MedianBefore))
+	record = append(record, uint8(endReasonHeaderMap[read.EndReason]))
+	record = binary.LittleEndian.AppendUint64(record, uint64(len(read.ChannelNumber)))
+	record = append(record, read.ChannelNumber...)
+	return record
+}
+
+// parseSlow5Version converts a version string like "0.2.0" into the three bytes stored in blow5 headers.
+func parseSlow5Version(slow5Version string) ([3]uint8, error) {
+	var version [3]uint8
+	versionSplit := strings.Split(slow5Version, ".")
+	if len(versionSplit) != 3 {
+		return version, fmt.Errorf("Expected slow5 version in major.minor.patch format. Got: %s", slow5Version)
+	}
+	for versionIndex, versionString := range versionSplit {
+		versionNumber, err := strconv.ParseUint(versionString, 10, 8)
+		if err != nil {
+			return version, fmt.Errorf("Failed to convert slow5 version '%s'. Got error: %w", slow5Version, err)
+		}
+		version[versionIndex] = uint8(versionNumber)
+	}
+	return version, nil
+}
+
+/******************************************************************************
+
+svb-zd signal compression
+
+svb-zd first zig-zag delta encodes the signal into uint32s, and then encodes
+those with StreamVByte. StreamVByte stores a 2 bit code for every integer
+(number of bytes - 1) in a block of control bytes, followed by the integers
+themselves using only the bytes they need. The amount of integers is stored
+as a uint32 in front of the control bytes.
+
+******************************************************************************/
+
+// compressSvbZd compresses a raw signal with svb-zd.
+func compressSvbZd(signal []int16) []byte {
+	controlLength := (len(signal) + 3) / 4
+	compressed := make([]byte, 4+controlLength, 4+controlLength+2*len(signal))
+	binary.LittleEndian.PutUint32(compressed, uint32(len(signal)))
+	var previous int32
+	for signalIndex, signal := range signal {
+		delta := int32(signal) - previous
+		previous = int32(signal)
+		zigzag := uint32((delta << 1) ^ (delta >> 31))
+		var code byte
+		switch {
+		case zigzag < 1<<8:
+			code = 0
+		case zigzag < 1<<16:
+			code = 1
+		case zigzag < 1<<24:
+			code = 2
+		default:
+			code = 3
+		}
+		compressed[4+signalIndex/4] |= code << (2 * (signalIndex % 4))
+		for byteIndex := byte(0); byteIndex <= code; byteIndex++ {
+			compressed = append(compressed, byte(zigzag>>(8*byteIndex)))
+		}
+	}
+	return compressed
+}
+
+// decompressSvbZd decompresses a raw signal compressed with svb-zd.
+func decompressSvbZd(compressed []byte) ([]int16, error) {
+	if len(compressed) < 4 {
+		return nil, errors.New("svb-zd signal too short to contain its length")
+	}
+	signalLength := int(binary.LittleEndian.Uint32(compressed))
+	controlLength := (signalLength + 3) / 4
+	// every value takes at least a byte, which protects us from absurd lengths.
+	if len(compressed) < 4+controlLength+signalLength {
+		return nil, fmt.Errorf("svb-zd signal of %d bytes too short for %d values", len(compressed), signalLength)
+	}
+	control := compressed[4 : 4+controlLength]
+	data := compressed[4+controlLength:]
+	signal := make([]int16, signalLength)
+	var previous int32
+	var position int
+	for signalIndex := range signal {
+		valueLength := int(control[signalIndex/4]>>(2*(signalIndex%4))&3) + 1
+		if position+valueLength > len(data) {
+			return nil, fmt.Errorf("svb-zd signal truncated at value %d", signalIndex)
+		}
+		var zigzag uint32
+		for byteIndex := 0; byteIndex < valueLength; byteIndex++ {
+			zigzag |= uint32(data[position+byteIndex]) << (8 * byteIndex)
+		}
+		position += valueLength
+		previous += int32(zigzag>>1) ^ -int32(zigzag&1)
+		signal[signalIndex] = int16(previous)
+	}
+	return signal, nil
+}
@@ -0,0 +1,218 @@
+package slow5
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readExampleSlow5(t *testing.T) ([]Header, []Read) {
+	file, err := os.Open("data/example.slow5")
+	if err != nil {
+		t.Fatalf("Failed to open example.slow5: %s", err)
+	}
+	defer file.Close()
+	parser, headers, err := NewParser(file, maxLineSize)
+	if err != nil {
+		t.Fatalf("Failed to parse headers of file: %s", err)
+	}
+	var reads []Read
+	for {
+		read, err := parser.ParseNext()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Got unknown error: %s", err)
+			}
+			break
+		}
+		reads = append(reads, read)
+	}
+	return headers, reads
+}
+
+func sendReads(reads []Read) <-chan Read {
+	readChan := make(chan Read)
+	go func() {
+		for _, read := range reads {
+			readChan <- read
+		}
+		close(readChan)
+	}()
+	return readChan
+}
+
+func TestBlow5RoundTrip(t *testing.T) {
+	headers, reads := readExampleSlow5(t)
+	compressions := []struct {
+		record RecordCompression
+		signal SignalCompression
+	}{
+		{RecordCompressionNone, SignalCompressionNone},
+		{RecordCompressionZlib, SignalCompressionNone},
+		{RecordCompressionNone, SignalCompressionSvbZd},
+		{RecordCompressionZlib, SignalCompressionSvbZd},
+	}
+	for _, compression := range compressions {
+		var blow5 bytes.Buffer
+		err := WriteBlow5(headers, sendReads(reads), &blow5, compression.record, compression.signal)
+		if err != nil {
+			t.Errorf("Failed to write blow5 with compression %d/%d. Got error: %s", compression.record, compression.signal, err)
+			continue
+		}
+		parser, parsedHeaders, err := NewBlow5Parser(&blow5)
+		if err != nil {
+			t.Errorf("Failed to parse blow5 headers with compression %d/%d. Got error: %s", compression.record, compression.signal, err)
+			continue
+		}
+		if !reflect.DeepEqual(headers, parsedHeaders) {
+			t.Errorf("Headers changed after blow5 round trip with compression %d/%d", compression.record, compression.signal)
+		}
+		var parsedReads []Read
+		for {
+			read, err := parser.ParseNext()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("Got unknown error: %s", err)
+				}
+				break
+			}
+			parsedReads = append(parsedReads, read)
+		}
+		if !reflect.DeepEqual(reads, parsedReads) {
+			t.Errorf("Reads changed after blow5 round trip with compression %d/%d", compression.record, compression.signal)
+		}
+	}
+}
+
+func TestNewBlow5ParserErrors(t *testing.T) {
+	file, err := os.Open("data/example.slow5")
+	if err != nil {
+		t.Fatalf("Failed to open example.slow5: %s", err)
+	}
+	defer file.Close()
+	_, _, err = NewBlow5Parser(file)
+	if err == nil {
+		t.Errorf("Test should have failed on a slow5 file without the blow5 magic number")
+	}
+
+	headers, reads := readExampleSlow5(t)
+	var blow5 bytes.Buffer
+	err = WriteBlow5(headers, sendReads(reads), &blow5, RecordCompressionNone, SignalCompressionNone)
+	if err != nil {
+		t.Fatalf("Failed to write blow5. Got error: %s", err)
+	}
+	zstd := blow5.Bytes()
+	zstd[9] = byte(RecordCompressionZstd)
+	_, _, err = NewBlow5Parser(bytes.NewReader(zstd))
+	if err == nil {
+		t.Errorf("Test should have failed on zstd record compression")
+	}
+
+	err = WriteBlow5(headers, sendReads(reads), io.Discard, RecordCompressionZstd, SignalCompressionNone)
+	if err == nil {
+		t.Errorf("Test should have failed writing zstd record compression")
+	}
+}
+
+func TestBlow5Truncated(t *testing.T) {
+	headers, reads := readExampleSlow5(t)
+	var blow5 bytes.Buffer
+	err := WriteBlow5(headers, sendReads(reads), &blow5, RecordCompressionNone, SignalCompressionSvbZd)
+	if err != nil {
+		t.Fatalf("Failed to write blow5. Got error: %s", err)
+	}
+	// Cut off the end of file marker and part of the last record
+	truncated := blow5.Bytes()[:blow5.Len()-100]
+	parser, _, err := NewBlow5Parser(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatalf("Failed to parse blow5 headers. Got error: %s", err)
+	}
+	for {
+		_, err = parser.ParseNext()
+		if err != nil {
+			break
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		t.Errorf("Truncated blow5 file should not end with a plain EOF")
+	}
+}
+
+func TestSvbZd(t *testing.T) {
+	// deltas are 0, 1, -2, 301 which zig-zag to 0, 2, 3, 602.
+	signal := []int16{0, 1, -1, 300}
+	expected := []byte{4, 0, 0, 0, 0x40, 0x00, 0x02, 0x03, 0x5a, 0x02}
+	compressed := compressSvbZd(signal)
+	if !bytes.Equal(compressed, expected) {
+		t.Errorf("Expected svb-zd compression %v. Got: %v", expected, compressed)
+	}
+	decompressed, err := decompressSvbZd(compressed)
+	if err != nil {
+		t.Errorf("Failed to decompress svb-zd signal. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(signal, decompressed) {
+		t.Errorf("Expected svb-zd decompression %v. Got: %v", signal, decompressed)
+	}
+
+	extremes := []int16{-32768, 32767, -32768, 0, 12}
+	decompressed, _ = decompressSvbZd(compressSvbZd(extremes))
+	if !reflect.DeepEqual(extremes, decompressed) {
+		t.Errorf("Expected svb-zd decompression %v. Got: %v", extremes, decompressed)
+	}
+
+	_, err = decompressSvbZd(expected[:8])
+	if err == nil {
+		t.Errorf("Test should have failed on truncated svb-zd signal")
+	}
+}
+
+// slow5toolsFixture opens a file written by slow5tools from example.slow5,
+// skipping the test if it hasn't been generated. The fixtures are made with:
+//
+//	slow5tools view data/example.slow5 -c zlib -s none -o data/slow5tools/example_zlib.blow5
+//	slow5tools view data/example.slow5 -c zlib -s svb-zd -o data/slow5tools/example_zlib_svb-zd.blow5
+//	slow5tools index data/slow5tools/example_zlib_svb-zd.blow5
+func slow5toolsFixture(t *testing.T, name string) *os.File {
+	file, err := os.Open(filepath.Join("data", "slow5tools", name))
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("%s has not been generated with slow5tools", name)
+	}
+	if err != nil {
+		t.Fatalf("Failed to open %s: %s", name, err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestBlow5Slow5tools(t *testing.T) {
+	headers, reads := readExampleSlow5(t)
+	for _, name := range []string{"example_zlib.blow5", "example_zlib_svb-zd.blow5"} {
+		t.Run(name, func(t *testing.T) {
+			parser, parsedHeaders, err := NewBlow5Parser(slow5toolsFixture(t, name))
+			if err != nil {
+				t.Fatalf("Failed to parse blow5 headers. Got error: %s", err)
+			}
+			if !reflect.DeepEqual(headers, parsedHeaders) {
+				t.Errorf("Headers of %s differ from example.slow5", name)
+			}
+			var parsedReads []Read
+			for {
+				read, err := parser.ParseNext()
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						t.Fatalf("Got unknown error: %s", err)
+					}
+					break
+				}
+				parsedReads = append(parsedReads, read)
+			}
+			if !reflect.DeepEqual(reads, parsedReads) {
+				t.Errorf("Reads of %s differ from example.slow5", name)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package slow5_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
@@ -30,3 +31,51 @@ func ExampleNewParser() {
 	fmt.Println(outputReads[0].RawSignal[0:10])
 	// Output: [430 472 463 467 454 465 463 450 450 449]
 }
+
+func ExampleWriteBlow5() {
+	file, _ := os.Open("data/example.slow5")
+	defer file.Close()
+	const maxLineSize = 2 * 32 * 1024
+	parser, headers, _ := slow5.NewParser(file, maxLineSize)
+
+	// Convert the slow5 file into a zlib and svb-zd compressed blow5 file.
+	reads := make(chan slow5.Read)
+	go func() {
+		for {
+			read, err := parser.ParseNext()
+			if err != nil {
+				// Break at EOF
+				break
+			}
+			reads <- read
+		}
+		close(reads)
+	}()
+	var blow5 bytes.Buffer
+	_ = slow5.WriteBlow5(headers, reads, &blow5, slow5.RecordCompressionZlib, slow5.SignalCompressionSvbZd)
+
+	// Read the blow5 file back in.
+	blow5Parser, _, _ := slow5.NewBlow5Parser(&blow5)
+	read, _ := blow5Parser.ParseNext()
+
+	fmt.Println(read.RawSignal[0:10])
+	// Output: [430 472 463 467 454 465 463 450 450 449]
+}
+
+func ExampleParser_Fetch() {
+	// Build an index of example.slow5. slow5tools writes these as
+	// example.slow5.idx, which can be loaded with slow5.ParseIndex.
+	indexFile, _ := os.Open("data/example.slow5")
+	index, _ := slow5.BuildIndex(indexFile)
+	indexFile.Close()
+
+	file, _ := os.Open("data/example.slow5")
+	defer file.Close()
+	const maxLineSize = 2 * 32 * 1024
+	parser, _, _ := slow5.NewParser(file, maxLineSize)
+	parser.SetIndex(index)
+
+	read, _ := parser.Fetch("0026631e-33a3-49ab-aa22-3ab157d71f8b")
+	fmt.Println(read.RawSignal[0:10])
+	// Output: [430 472 463 467 454 465 463 450 450 449]
+}
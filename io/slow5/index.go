@@ -0,0 +1,296 @@
+package slow5
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+slow5 index begins here.
+
+slow5 files are huge, and most of the time we only want a handful of reads
+out of them. slow5tools solves this with an index file (<file>.idx), which
+stores the byte offset and size of every record by ReadID.
+
+The index file is binary and looks like this:
+
+	magic number   char[9]    "SLOW5IDX\1"
+	version        uint8[3]
+	padding        up to byte 64
+	entries        (repeated)
+		read_id_len  uint16
+		read_id      char[read_id_len]
+		offset       uint64
+		size         uint64
+	end of file    char[8]    "XDI5WOLS"
+
+For slow5 files, the offset points to the start of the read line and the size
+includes the trailing newline. For blow5 files, the offset points to the
+record size that prefixes every record, and the size includes those 8 bytes.
+
+******************************************************************************/
+
+var (
+	indexMagicNumber = []byte{'S', 'L', 'O', 'W', '5', 'I', 'D', 'X', 1}
+	indexEndOfFile   = []byte{'X', 'D', 'I', '5', 'W', 'O', 'L', 'S'}
+	// indexVersion is the index version written by WriteIndex.
+	indexVersion = [3]uint8{1, 0, 0}
+)
+
+// indexHeaderSize is the size of the padded index header.
+const indexHeaderSize = 64
+
+// IndexEntry holds the location of a single read in a slow5 or blow5 file.
+type IndexEntry struct {
+	ReadID string
+	Offset uint64
+	Size   uint64
+}
+
+// Index maps ReadIDs to the location of their records in a slow5 or blow5 file.
+type Index struct {
+	Version [3]uint8
+	Entries []IndexEntry
+	readIDs map[string]int
+}
+
+// add adds an entry to the index. ReadIDs must be unique.
+func (index *Index) add(entry IndexEntry) error {
+	if index.readIDs == nil {
+		index.readIDs = make(map[string]int)
+	}
+	if _, ok := index.readIDs[entry.ReadID]; ok {
+		return fmt.Errorf("Duplicate read_id '%s' found while indexing", entry.ReadID)
+	}
+	index.readIDs[entry.ReadID] = len(index.Entries)
+	index.Entries = append(index.Entries, entry)
+	return nil
+}
+
+// Lookup returns the index entry of a ReadID.
+func (index Index) Lookup(readID string) (IndexEntry, bool) {
+	entryIndex, ok := index.readIDs[readID]
+	if !ok {
+		return IndexEntry{}, false
+	}
+	return index.Entries[entryIndex], true
+}
+
+// BuildIndex builds an index from a slow5 or blow5 file. The format is
+// detected from the blow5 magic number.
+func BuildIndex(r io.Reader) (Index, error) {
+	reader := bufio.NewReader(r)
+	magicNumber, _ := reader.Peek(len(blow5MagicNumber))
+	if bytes.Equal(magicNumber, blow5MagicNumber) {
+		return buildBlow5Index(reader)
+	}
+	return buildSlow5Index(reader)
+}
+
+// buildSlow5Index indexes a slow5 file line by line. Read lines may be much
+// larger than the reader's buffer, so lines are read in chunks.
+func buildSlow5Index(reader *bufio.Reader) (Index, error) {
+	index := Index{Version: indexVersion}
+	var offset uint64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadSlice('\n')
+		if len(line) == 0 {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return Index{}, err
+		}
+		size := uint64(len(line))
+		isHeader := line[0] == '#' || line[0] == '@'
+		var readID string
+		if !isHeader {
+			tabIndex := bytes.IndexByte(line, '\t')
+			if tabIndex == -1 {
+				return Index{}, fmt.Errorf("Could not find read_id on line %d", lineNumber)
+			}
+			readID = string(line[:tabIndex])
+		}
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = reader.ReadSlice('\n')
+			size += uint64(len(line))
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Index{}, err
+		}
+		if !isHeader {
+			if err := index.add(IndexEntry{ReadID: readID, Offset: offset, Size: size}); err != nil {
+				return Index{}, err
+			}
+		}
+		offset += size
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	return index, nil
+}
+
+// buildBlow5Index indexes a blow5 file, only decoding the read_id of every record.
+func buildBlow5Index(reader *bufio.Reader) (Index, error) {
+	parser, _, err := NewBlow5Parser(reader)
+	if err != nil {
+		return Index{}, err
+	}
+	index := Index{Version: indexVersion}
+	for {
+		offset := parser.offset
+		record, err := parser.nextRecord()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return Index{}, err
+		}
+		record, err = parser.decompressRecord(record)
+		if err != nil {
+			return Index{}, err
+		}
+		if len(record) < 2 || len(record) < 2+int(binary.LittleEndian.Uint16(record)) {
+			return Index{}, fmt.Errorf("Record %d too short to contain a read_id", parser.record)
+		}
+		readID := string(record[2 : 2+binary.LittleEndian.Uint16(record)])
+		if err = index.add(IndexEntry{ReadID: readID, Offset: offset, Size: parser.offset - offset}); err != nil {
+			return Index{}, err
+		}
+	}
+	return index, nil
+}
+
+// ParseIndex parses a slow5tools compatible index file.
+func ParseIndex(r io.Reader) (Index, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, indexHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return Index{}, err
+	}
+	if !bytes.Equal(header[:len(indexMagicNumber)], indexMagicNumber) {
+		return Index{}, fmt.Errorf("Not a slow5 index file. Expected magic number %q, got %q", indexMagicNumber, header[:len(indexMagicNumber)])
+	}
+	index := Index{}
+	copy(index.Version[:], header[len(indexMagicNumber):])
+	for {
+		peek, err := reader.Peek(len(indexEndOfFile))
+		if err != nil {
+			return Index{}, fmt.Errorf("Index file ended without end of file marker. Got error: %w", err)
+		}
+		if bytes.Equal(peek, indexEndOfFile) {
+			break
+		}
+		var readIDLength uint16
+		if err = binary.Read(reader, binary.LittleEndian, &readIDLength); err != nil {
+			return Index{}, err
+		}
+		readID := make([]byte, readIDLength)
+		if _, err = io.ReadFull(reader, readID); err != nil {
+			return Index{}, err
+		}
+		entry := IndexEntry{ReadID: string(readID)}
+		if err = binary.Read(reader, binary.LittleEndian, &entry.Offset); err != nil {
+			return Index{}, err
+		}
+		if err = binary.Read(reader, binary.LittleEndian, &entry.Size); err != nil {
+			return Index{}, err
+		}
+		if err = index.add(entry); err != nil {
+			return Index{}, err
+		}
+	}
+	return index, nil
+}
+
+// WriteIndex writes an index in the slow5tools index format.
+func WriteIndex(index Index, output io.Writer) error {
+	writer := bufio.NewWriter(output)
+	header := make([]byte, indexHeaderSize)
+	copy(header, indexMagicNumber)
+	copy(header[len(indexMagicNumber):], indexVersion[:])
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, entry := range index.Entries {
+		entryBytes := binary.LittleEndian.AppendUint16(make([]byte, 0, len(entry.ReadID)+18), uint16(len(entry.ReadID)))
+		entryBytes = append(entryBytes, entry.ReadID...)
+		entryBytes = binary.LittleEndian.AppendUint64(entryBytes, entry.Offset)
+		entryBytes = binary.LittleEndian.AppendUint64(entryBytes, entry.Size)
+		if _, err := writer.Write(entryBytes); err != nil {
+			return err
+		}
+	}
+	if _, err := writer.Write(indexEndOfFile); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+/******************************************************************************
+
+Start of Fetch functions
+
+Fetch needs to jump around in the file, so the io.Reader given to NewParser or
+NewBlow5Parser must also be an io.ReaderAt (like an *os.File). Fetching does
+not change the state of ParseNext.
+
+******************************************************************************/
+
+// SetIndex sets the index used by Fetch.
+func (parser *Parser) SetIndex(index Index) {
+	parser.index = index
+}
+
+// Fetch seeks directly to the read with the given ReadID and parses it.
+// An index must be set with SetIndex first.
+func (parser *Parser) Fetch(readID string) (Read, error) {
+	record, err := fetchRecord(parser.source, parser.index, readID)
+	if err != nil {
+		return Read{}, err
+	}
+	return parser.parseRead(string(record)), nil
+}
+
+// SetIndex sets the index used by Fetch.
+func (parser *Blow5Parser) SetIndex(index Index) {
+	parser.index = index
+}
+
+// Fetch seeks directly to the read with the given ReadID and parses it.
+// An index must be set with SetIndex first.
+func (parser *Blow5Parser) Fetch(readID string) (Read, error) {
+	record, err := fetchRecord(parser.source, parser.index, readID)
+	if err != nil {
+		return Read{}, err
+	}
+	// skip the record size
+	if len(record) < 8 {
+		return Read{}, fmt.Errorf("Index entry for read_id '%s' too small to be a blow5 record", readID)
+	}
+	return parser.parseRecord(record[8:])
+}
+
+// fetchRecord reads the raw bytes of the record of a ReadID.
+func fetchRecord(source io.Reader, index Index, readID string) ([]byte, error) {
+	readerAt, ok := source.(io.ReaderAt)
+	if !ok {
+		return nil, errors.New("Fetch requires the parser's reader to be an io.ReaderAt")
+	}
+	entry, ok := index.Lookup(readID)
+	if !ok {
+		return nil, fmt.Errorf("read_id '%s' not found in index", readID)
+	}
+	record := make([]byte, entry.Size)
+	// ReadAt may return an EOF together with a full record at the end of the file.
+	if n, err := readerAt.ReadAt(record, int64(entry.Offset)); n != len(record) {
+		return nil, fmt.Errorf("Failed to fetch read_id '%s'. Got error: %w", readID, err)
+	}
+	return record, nil
+}
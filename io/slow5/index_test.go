@@ -0,0 +1,188 @@
+package slow5
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndexSlow5(t *testing.T) {
+	_, reads := readExampleSlow5(t)
+	file, err := os.Open("data/example.slow5")
+	if err != nil {
+		t.Fatalf("Failed to open example.slow5: %s", err)
+	}
+	defer file.Close()
+	index, err := BuildIndex(file)
+	if err != nil {
+		t.Fatalf("Failed to build index. Got error: %s", err)
+	}
+	if len(index.Entries) != len(reads) {
+		t.Errorf("Expected %d index entries. Got: %d", len(reads), len(index.Entries))
+	}
+
+	// The index must survive being written and parsed again.
+	var indexFile bytes.Buffer
+	if err = WriteIndex(index, &indexFile); err != nil {
+		t.Fatalf("Failed to write index. Got error: %s", err)
+	}
+	parsedIndex, err := ParseIndex(&indexFile)
+	if err != nil {
+		t.Fatalf("Failed to parse index. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(index, parsedIndex) {
+		t.Errorf("Index changed after writing and parsing")
+	}
+
+	_, err = file.Seek(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to seek: %s", err)
+	}
+	parser, _, err := NewParser(file, maxLineSize)
+	if err != nil {
+		t.Fatalf("Failed to parse headers of file: %s", err)
+	}
+	parser.SetIndex(parsedIndex)
+	// Fetch in reverse order to make sure we really jump around.
+	for readIndex := len(reads) - 1; readIndex >= 0; readIndex-- {
+		read, err := parser.Fetch(reads[readIndex].ReadID)
+		if err != nil {
+			t.Errorf("Failed to fetch %s. Got error: %s", reads[readIndex].ReadID, err)
+		}
+		if !reflect.DeepEqual(read, reads[readIndex]) {
+			t.Errorf("Fetched read %s differs from parsed read", reads[readIndex].ReadID)
+		}
+	}
+	// Fetching should not disturb sequential parsing
+	read, err := parser.ParseNext()
+	if err != nil || read.ReadID != reads[0].ReadID {
+		t.Errorf("Expected ParseNext to return %s after fetching. Got: %s, %v", reads[0].ReadID, read.ReadID, err)
+	}
+
+	_, err = parser.Fetch("not-a-read")
+	if err == nil {
+		t.Errorf("Test should have failed fetching a read_id that is not in the index")
+	}
+}
+
+func TestIndexBlow5(t *testing.T) {
+	headers, reads := readExampleSlow5(t)
+	path := filepath.Join(t.TempDir(), "example.blow5")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create blow5 file: %s", err)
+	}
+	defer file.Close()
+	err = WriteBlow5(headers, sendReads(reads), file, RecordCompressionZlib, SignalCompressionSvbZd)
+	if err != nil {
+		t.Fatalf("Failed to write blow5 file. Got error: %s", err)
+	}
+
+	_, err = file.Seek(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to seek: %s", err)
+	}
+	index, err := BuildIndex(file)
+	if err != nil {
+		t.Fatalf("Failed to build index. Got error: %s", err)
+	}
+	if len(index.Entries) != len(reads) {
+		t.Errorf("Expected %d index entries. Got: %d", len(reads), len(index.Entries))
+	}
+
+	_, err = file.Seek(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to seek: %s", err)
+	}
+	parser, _, err := NewBlow5Parser(file)
+	if err != nil {
+		t.Fatalf("Failed to parse blow5 headers. Got error: %s", err)
+	}
+	parser.SetIndex(index)
+	for readIndex := len(reads) - 1; readIndex >= 0; readIndex-- {
+		read, err := parser.Fetch(reads[readIndex].ReadID)
+		if err != nil {
+			t.Errorf("Failed to fetch %s. Got error: %s", reads[readIndex].ReadID, err)
+		}
+		if !reflect.DeepEqual(read, reads[readIndex]) {
+			t.Errorf("Fetched read %s differs from parsed read", reads[readIndex].ReadID)
+		}
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	headers, reads := readExampleSlow5(t)
+	var blow5 bytes.Buffer
+	err := WriteBlow5(headers, sendReads(reads), &blow5, RecordCompressionNone, SignalCompressionNone)
+	if err != nil {
+		t.Fatalf("Failed to write blow5 file. Got error: %s", err)
+	}
+	index, err := BuildIndex(bytes.NewReader(blow5.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to build index. Got error: %s", err)
+	}
+	// A bytes.Buffer is not an io.ReaderAt, so Fetch cannot work.
+	parser, _, err := NewBlow5Parser(&blow5)
+	if err != nil {
+		t.Fatalf("Failed to parse blow5 headers. Got error: %s", err)
+	}
+	parser.SetIndex(index)
+	_, err = parser.Fetch(reads[0].ReadID)
+	if err == nil {
+		t.Errorf("Test should have failed fetching from a reader that is not an io.ReaderAt")
+	}
+
+	_, err = ParseIndex(bytes.NewReader(make([]byte, 100)))
+	if err == nil {
+		t.Errorf("Test should have failed parsing an index without magic number")
+	}
+
+	var indexFile bytes.Buffer
+	if err = WriteIndex(index, &indexFile); err != nil {
+		t.Fatalf("Failed to write index. Got error: %s", err)
+	}
+	_, err = ParseIndex(bytes.NewReader(indexFile.Bytes()[:indexFile.Len()-3]))
+	if err == nil {
+		t.Errorf("Test should have failed parsing an index without end of file marker")
+	}
+}
+
+func TestFetchSlow5toolsIndex(t *testing.T) {
+	_, reads := readExampleSlow5(t)
+	index, err := ParseIndex(slow5toolsFixture(t, "example_zlib_svb-zd.blow5.idx"))
+	if err != nil {
+		t.Fatalf("Failed to parse the slow5tools index. Got error: %s", err)
+	}
+	if len(index.Entries) != len(reads) {
+		t.Errorf("Expected %d index entries. Got: %d", len(reads), len(index.Entries))
+	}
+	file := slow5toolsFixture(t, "example_zlib_svb-zd.blow5")
+	// the index slow5tools wrote should match the one we build ourselves.
+	builtIndex, err := BuildIndex(file)
+	if err != nil {
+		t.Fatalf("Failed to build index. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(index.Entries, builtIndex.Entries) {
+		t.Errorf("Index entries built from the blow5 file differ from those of slow5tools")
+	}
+
+	if _, err = file.Seek(0, 0); err != nil {
+		t.Fatalf("Failed to seek: %s", err)
+	}
+	parser, _, err := NewBlow5Parser(file)
+	if err != nil {
+		t.Fatalf("Failed to parse blow5 headers. Got error: %s", err)
+	}
+	parser.SetIndex(index)
+	for readIndex := len(reads) - 1; readIndex >= 0; readIndex-- {
+		read, err := parser.Fetch(reads[readIndex].ReadID)
+		if err != nil {
+			t.Errorf("Failed to fetch %s. Got error: %s", reads[readIndex].ReadID, err)
+		}
+		if !reflect.DeepEqual(read, reads[readIndex]) {
+			t.Errorf("Fetched read %s differs from parsed read", reads[readIndex].ReadID)
+		}
+	}
+}
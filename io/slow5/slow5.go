@@ -1,8 +1,9 @@
 /*
 Package slow5 contains slow5 parsers and writers.
 
-Right now, only parsing slow5 files is supported. Support for writing and blow5
-coming soon.
+Both the tsv based slow5 format and its binary counterpart, blow5, can be
+parsed and written. blow5 records can be stored uncompressed or zlib compressed,
+and raw signals can additionally be compressed with svb-zd.
 
 slow5 is a file format alternative to fast5, which is the file format outputted
 by Oxford Nanopore sequencing devices. fast5 uses hdf5, which is a complex file
@@ -102,7 +103,11 @@ type Parser struct {
 	reader       bufio.Reader
 	line         uint
 	headerMap    map[int]string
+	typeMap      map[int]string
 	endReasonMap map[int]string
+	// source and index are used for random access with Fetch.
+	source io.Reader
+	index  Index
 }
 
 // NewParser parsers a slow5 file.
@@ -110,11 +115,13 @@ func NewParser(r io.Reader, maxLineSize int) (*Parser, []Header, error) {
 	parser := &Parser{
 		reader: *bufio.NewReaderSize(r, maxLineSize),
 		line:   0,
+		source: r,
 	}
 	var headers []Header
 	var slow5Version string
 	var numReadGroups uint32
 	headerMap := make(map[int]string)
+	typeMap := make(map[int]string)
 	endReasonMap := make(map[int]string)
 	endReasonHeaderMap := make(map[string]int)
 
@@ -151,7 +158,9 @@ func NewParser(r io.Reader, maxLineSize int) (*Parser, []Header, error) {
 		// Terminate if we hit the beginning of the raw read headers
 		// Get endReasonEnums. This is simply a string between enum{} that is used for the reasons that a read could have ended.
 		if values[0] == "#char*" {
-			for _, typeInfo := range values {
+			for typeIndex, typeInfo := range values {
+				// The types are needed to decode the binary records of blow5 files.
+				typeMap[typeIndex] = strings.TrimPrefix(typeInfo, "#")
 				if strings.Contains(typeInfo, "enum") {
 					endReasonEnumsMinusPrefix := strings.TrimPrefix(typeInfo, "enum{")
 					endReasonEnumsMinusSuffix := strings.TrimSuffix(endReasonEnumsMinusPrefix, "}")
@@ -192,6 +201,7 @@ func NewParser(r io.Reader, maxLineSize int) (*Parser, []Header, error) {
 		continue
 	}
 	parser.headerMap = headerMap
+	parser.typeMap = typeMap
 	parser.endReasonMap = endReasonMap
 	return parser, headers, nil
 }
@@ -203,8 +213,12 @@ func (parser *Parser) ParseNext() (Read, error) {
 		return Read{}, err
 	}
 	parser.line++
-	line := strings.TrimSpace(string(lineBytes))
+	return parser.parseRead(string(lineBytes)), nil
+}
 
+// parseRead parses a single read line of a slow5 file.
+func (parser *Parser) parseRead(line string) Read {
+	line = strings.TrimSpace(line)
 	values := strings.Split(line, "\t")
 	// Reads have started.
 	// Once we have the read headers, start to parse the actual reads
@@ -303,7 +317,7 @@ func (parser *Parser) ParseNext() (Read, error) {
 			newRead.Error = fmt.Errorf("Unknown field to parser '%s' found on line %d. Please report to github.com/bebop/poly", fieldValue, parser.line)
 		}
 	}
-	return newRead, nil
+	return newRead
 }
 
 /******************************************************************************
@@ -340,6 +354,43 @@ func Write(headers []Header, reads <-chan Read, output io.Writer) error {
 	if err != nil {
 		return err
 	}
+	// Then write the attributes and read headers, which are shared with blow5.
+	err = writeHeaderBody(headers, output)
+	if err != nil {
+		return err
+	}
+
+	// Iterate over reads. This is reading from a channel, and will end
+	// when the channel is closed.
+	for read := range reads {
+		// converts []int16 to string
+		var rawSignalStringBuilder strings.Builder
+		for signalIndex, signal := range read.RawSignal {
+			_, err = fmt.Fprint(&rawSignalStringBuilder, signal)
+			if err != nil {
+				return err
+			}
+			if signalIndex != len(read.RawSignal)-1 { // Don't add a comma to last number
+				_, err = fmt.Fprint(&rawSignalStringBuilder, ",")
+				if err != nil {
+					return err
+				}
+			}
+		}
+		// Look at above output.Write("#read_id ... for the values here.
+		_, err = fmt.Fprintf(output, "%s\t%d\t%g\t%g\t%g\t%g\t%d\t%s\t%d\t%d\t%d\t%g\t%d\t%s\n", read.ReadID, read.ReadGroupID, read.Digitisation, read.Offset, read.Range, read.SamplingRate, read.LenRawSignal, rawSignalStringBuilder.String(), read.StartTime, read.ReadNumber, read.StartMux, read.MedianBefore, endReasonHeaderMap[read.EndReason], read.ChannelNumber)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeaderBody writes the header attributes, the read header types and the
+// read header names of a slow5 file. These lines are identical between slow5
+// and blow5 files. blow5 simply stores them after its binary header.
+func writeHeaderBody(headers []Header, output io.Writer) error {
+	endReasonHeaderMap := headers[0].EndReasonHeaderMap
 	// Next, we need a map of what attribute values are available
 	possibleAttributeKeys := make(map[string]bool)
 	for _, header := range headers {
@@ -378,7 +429,7 @@ func Write(headers []Header, reads <-chan Read, output io.Writer) error {
 
 	// Write the header attribute strings to the output
 	for _, headerAttributeString := range headerAttributeStrings {
-		_, err = fmt.Fprintf(output, "%s\n", headerAttributeString)
+		_, err := fmt.Fprintf(output, "%s\n", headerAttributeString)
 		if err != nil {
 			return err
 		}
@@ -404,7 +455,7 @@ func Write(headers []Header, reads <-chan Read, output io.Writer) error {
 
 	// Write the read headers
 	// These are according to the slow5 specifications
-	_, err = fmt.Fprintf(output, "#char*	uint32_t	double	double	double	double	uint64_t	int16_t*	uint64_t	int32_t	uint8_t	double	enum{%s}	char*\n", endReasonString)
+	_, err := fmt.Fprintf(output, "#char*	uint32_t	double	double	double	double	uint64_t	int16_t*	uint64_t	int32_t	uint8_t	double	enum{%s}	char*\n", endReasonString)
 	if err != nil {
 		return err
 	}
@@ -412,29 +463,5 @@ func Write(headers []Header, reads <-chan Read, output io.Writer) error {
 	if err != nil {
 		return err
 	}
-
-	// Iterate over reads. This is reading from a channel, and will end
-	// when the channel is closed.
-	for read := range reads {
-		// converts []int16 to string
-		var rawSignalStringBuilder strings.Builder
-		for signalIndex, signal := range read.RawSignal {
-			_, err = fmt.Fprint(&rawSignalStringBuilder, signal)
-			if err != nil {
-				return err
-			}
-			if signalIndex != len(read.RawSignal)-1 { // Don't add a comma to last number
-				_, err = fmt.Fprint(&rawSignalStringBuilder, ",")
-				if err != nil {
-					return err
-				}
-			}
-		}
-		// Look at above output.Write("#read_id ... for the values here.
-		_, err = fmt.Fprintf(output, "%s\t%d\t%g\t%g\t%g\t%g\t%d\t%s\t%d\t%d\t%d\t%g\t%d\t%s\n", read.ReadID, read.ReadGroupID, read.Digitisation, read.Offset, read.Range, read.SamplingRate, read.LenRawSignal, rawSignalStringBuilder.String(), read.StartTime, read.ReadNumber, read.StartMux, read.MedianBefore, endReasonHeaderMap[read.EndReason], read.ChannelNumber)
-		if err != nil {
-			return err
-		}
-	}
 	return nil
 }
@@ -0,0 +1,23 @@
+package snapgene_test
+
+import (
+	"fmt"
+
+	"github.com/bebop/poly/io/snapgene"
+)
+
+func Example_basic() {
+	plasmid, _ := snapgene.Read("data/puc19.dna")
+	fmt.Println(plasmid.Meta.Locus.Name, plasmid.Meta.Locus.Circular, len(plasmid.Sequence))
+
+	for _, feature := range plasmid.Features {
+		if feature.Type == "CDS" {
+			sequence, _ := feature.GetSequence()
+			fmt.Println(feature.Attributes()["label"], len(sequence))
+		}
+	}
+	// Output:
+	// puc19 true 2686
+	// lacZ-alpha 324
+	// AmpR 861
+}
@@ -0,0 +1,432 @@
+/*
+Package snapgene provides a parser for SnapGene .dna files.
+
+SnapGene is a popular commercial tool for plasmid design, and a lot of plasmid
+libraries are stored in its native .dna format. Unlike GenBank, .dna files are
+binary. They consist of a series of packets, each of which starts with a one
+byte packet type followed by the length of the packet as a big endian uint32:
+
+	| type (1 byte) | length (4 bytes) | data (length bytes) |
+
+The first packet is always a cookie packet, which contains the "SnapGene"
+magic string. The packets we care about are:
+
+	0x00: the DNA sequence, prefixed with a byte of topology and strandedness flags
+	0x05: the primers, stored as XML
+	0x06: the notes (description, organism, references, ...), stored as XML
+	0x0A: the features, stored as XML
+
+All other packets (history, alignments, display settings, ...) are skipped.
+
+SnapGene files are parsed into genbank.Genbank structs, so that they can be
+used with the rest of poly. Feature names are stored in the "label"
+attribute, just like SnapGene does when it exports GenBank files, the colour
+of a feature is stored in the "color" attribute and its qualifiers are stored
+as attributes. Primer binding sites become primer_bind features.
+
+There is no official specification of the format, but SnapGene does provide a
+(rather sparse) description of it on request. The Biopython and
+snapgene_reader implementations were used as a reference.
+*/
+package snapgene
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bebop/poly/io/genbank"
+)
+
+// Packet types of the SnapGene file format.
+const (
+	packetDNA      byte = 0x00
+	packetPrimers  byte = 0x05
+	packetNotes    byte = 0x06
+	packetCookie   byte = 0x09
+	packetFeatures byte = 0x0A
+)
+
+// Flags of the DNA packet.
+const (
+	flagCircular       byte = 0x01
+	flagDoubleStranded byte = 0x02
+)
+
+// cookieMagic is the magic string at the start of the cookie packet.
+const cookieMagic = "SnapGene"
+
+// sequenceTypeDNA is the sequence type of DNA files in the cookie packet.
+const sequenceTypeDNA = 1
+
+// Directionality values of features.
+const (
+	directionalityForward       = "1"
+	directionalityReverse       = "2"
+	directionalityBidirectional = "3"
+)
+
+// htmlTagRegex matches HTML tags, which SnapGene uses to format notes.
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+var (
+	errNotSnapGene = errors.New("not a SnapGene file: missing SnapGene cookie")
+	errNoSequence  = errors.New("SnapGene file does not contain a DNA sequence")
+)
+
+// features is the XML structure of the features packet.
+type features struct {
+	Features []feature `xml:"Feature"`
+}
+
+type feature struct {
+	Name           string      `xml:"name,attr"`
+	Type           string      `xml:"type,attr"`
+	Directionality string      `xml:"directionality,attr"`
+	Segments       []segment   `xml:"Segment"`
+	Qualifiers     []qualifier `xml:"Q"`
+}
+
+type segment struct {
+	Range string `xml:"range,attr"`
+	Color string `xml:"color,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type qualifier struct {
+	Name   string           `xml:"name,attr"`
+	Values []qualifierValue `xml:"V"`
+}
+
+type qualifierValue struct {
+	Text   string `xml:"text,attr"`
+	Int    string `xml:"int,attr"`
+	Predef string `xml:"predef,attr"`
+}
+
+// primers is the XML structure of the primers packet.
+type primers struct {
+	Primers []primer `xml:"Primer"`
+}
+
+type primer struct {
+	Name         string        `xml:"name,attr"`
+	Sequence     string        `xml:"sequence,attr"`
+	Description  string        `xml:"description,attr"`
+	BindingSites []bindingSite `xml:"BindingSite"`
+}
+
+type bindingSite struct {
+	Location    string `xml:"location,attr"`
+	BoundStrand string `xml:"boundStrand,attr"`
+}
+
+// notes is the XML structure of the notes packet.
+type notes struct {
+	Type            string      `xml:"Type"`
+	Description     string      `xml:"Description"`
+	AccessionNumber string      `xml:"AccessionNumber"`
+	CustomMapLabel  string      `xml:"CustomMapLabel"`
+	Organism        string      `xml:"Organism"`
+	LastModified    string      `xml:"LastModified"`
+	Comments        string      `xml:"Comments"`
+	References      []reference `xml:"References>Reference"`
+}
+
+type reference struct {
+	Title    string `xml:"title,attr"`
+	Authors  string `xml:"authors,attr"`
+	Journal  string `xml:"journal,attr"`
+	PubMedID string `xml:"pubMedID,attr"`
+}
+
+// Parse takes in a reader representing a SnapGene .dna file and parses it into a Genbank struct.
+func Parse(r io.Reader) (genbank.Genbank, error) {
+	reader := bufio.NewReader(r)
+	var record genbank.Genbank
+	var featureXML, primerXML []byte
+	var hasCookie, hasSequence bool
+	for {
+		packetType, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return genbank.Genbank{}, err
+		}
+		var packetLength uint32
+		if err = binary.Read(reader, binary.BigEndian, &packetLength); err != nil {
+			return genbank.Genbank{}, fmt.Errorf("Failed to read length of packet 0x%02x: %w", packetType, err)
+		}
+		if !hasCookie && packetType != packetCookie {
+			return genbank.Genbank{}, errNotSnapGene
+		}
+		packet := make([]byte, packetLength)
+		if _, err = io.ReadFull(reader, packet); err != nil {
+			return genbank.Genbank{}, fmt.Errorf("Failed to read packet 0x%02x of %d bytes: %w", packetType, packetLength, err)
+		}
+
+		switch packetType {
+		case packetCookie:
+			if len(packet) < len(cookieMagic)+2 || string(packet[:len(cookieMagic)]) != cookieMagic {
+				return genbank.Genbank{}, errNotSnapGene
+			}
+			if sequenceType := binary.BigEndian.Uint16(packet[len(cookieMagic):]); sequenceType != sequenceTypeDNA {
+				return genbank.Genbank{}, fmt.Errorf("unsupported SnapGene sequence type %d, only DNA files are supported", sequenceType)
+			}
+			hasCookie = true
+		case packetDNA:
+			if len(packet) == 0 {
+				return genbank.Genbank{}, fmt.Errorf("empty DNA packet")
+			}
+			flags := packet[0]
+			record.Sequence = string(packet[1:])
+			record.Meta.Locus.Circular = flags&flagCircular != 0
+			record.Meta.Locus.MoleculeType = "DNA"
+			if flags&flagDoubleStranded != 0 {
+				record.Meta.Locus.MoleculeType = "ds-DNA"
+			}
+			hasSequence = true
+		case packetNotes:
+			if err = parseNotes(packet, &record); err != nil {
+				return genbank.Genbank{}, err
+			}
+		// features and primers are parsed after all packets are read, since
+		// they need to know the length and topology of the sequence.
+		case packetFeatures:
+			featureXML = packet
+		case packetPrimers:
+			primerXML = packet
+		}
+	}
+	if !hasCookie {
+		return genbank.Genbank{}, errNotSnapGene
+	}
+	if !hasSequence {
+		return genbank.Genbank{}, errNoSequence
+	}
+	record.Meta.Locus.SequenceLength = strconv.Itoa(len(record.Sequence))
+	record.Meta.Locus.SequenceCoding = "bp"
+	for index := range record.Meta.References {
+		record.Meta.References[index].Range = fmt.Sprintf("(bases 1 to %d)", len(record.Sequence))
+	}
+
+	if featureXML != nil {
+		if err := parseFeatures(featureXML, &record); err != nil {
+			return genbank.Genbank{}, err
+		}
+	}
+	if primerXML != nil {
+		if err := parsePrimers(primerXML, &record); err != nil {
+			return genbank.Genbank{}, err
+		}
+	}
+	return record, nil
+}
+
+// parseNotes fills the meta data of a record from the notes packet.
+func parseNotes(packet []byte, record *genbank.Genbank) error {
+	var fileNotes notes
+	if err := xml.Unmarshal(packet, &fileNotes); err != nil {
+		return fmt.Errorf("Failed to parse notes: %w", err)
+	}
+	meta := &record.Meta
+	meta.Locus.Name = fileNotes.CustomMapLabel
+	if fileNotes.Type == "Synthetic" {
+		meta.Locus.GenbankDivision = "SYN"
+	}
+	if fileNotes.LastModified != "" {
+		// SnapGene uses dates like 2019.10.22, GenBank uses dates like 22-OCT-2019.
+		if date, err := time.Parse("2006.1.2", fileNotes.LastModified); err == nil {
+			meta.Locus.ModificationDate = strings.ToUpper(date.Format("02-Jan-2006"))
+		}
+	}
+	meta.Definition = stripHTML(fileNotes.Description)
+	meta.Accession = fileNotes.AccessionNumber
+	meta.Organism = fileNotes.Organism
+	meta.Source = fileNotes.Organism
+	if fileNotes.Comments != "" {
+		meta.Other = map[string]string{"COMMENT": stripHTML(fileNotes.Comments)}
+	}
+	for _, fileReference := range fileNotes.References {
+		meta.References = append(meta.References, genbank.Reference{
+			Authors: fileReference.Authors,
+			Title:   fileReference.Title,
+			Journal: fileReference.Journal,
+			PubMed:  fileReference.PubMedID,
+		})
+	}
+	return nil
+}
+
+// parseFeatures adds the features of the features packet to a record.
+func parseFeatures(packet []byte, record *genbank.Genbank) error {
+	var fileFeatures features
+	if err := xml.Unmarshal(packet, &fileFeatures); err != nil {
+		return fmt.Errorf("Failed to parse features: %w", err)
+	}
+	for _, fileFeature := range fileFeatures.Features {
+		var segmentLocations []genbank.Location
+		var color string
+		for _, featureSegment := range fileFeature.Segments {
+			if featureSegment.Type == "gap" {
+				continue
+			}
+			segmentLocation, err := parseRange(featureSegment.Range, record)
+			if err != nil {
+				return fmt.Errorf("Failed to parse segment of feature %s: %w", fileFeature.Name, err)
+			}
+			segmentLocations = append(segmentLocations, segmentLocation)
+			if color == "" {
+				color = featureSegment.Color
+			}
+		}
+		if len(segmentLocations) == 0 {
+			return fmt.Errorf("feature %s has no segments", fileFeature.Name)
+		}
+		location := joinLocations(segmentLocations)
+		if fileFeature.Directionality == directionalityReverse {
+			location.Complement = true
+		}
+
+		newFeature := genbank.Feature{Type: fileFeature.Type, Location: location}
+		for _, featureQualifier := range fileFeature.Qualifiers {
+			var values []string
+			for _, value := range featureQualifier.Values {
+				switch {
+				case value.Int != "":
+					values = append(values, value.Int)
+				case value.Predef != "":
+					values = append(values, value.Predef)
+				default:
+					values = append(values, stripHTML(value.Text))
+				}
+			}
+			newFeature.AddQualifier(featureQualifier.Name, strings.Join(values, ","))
+		}
+		if fileFeature.Name != "" {
+			newFeature.SetQualifier("label", fileFeature.Name)
+		}
+		if color != "" {
+			newFeature.SetQualifier("color", color)
+		}
+		if fileFeature.Directionality == directionalityBidirectional {
+			newFeature.SetQualifier("direction", "BOTH")
+		}
+		if err := record.AddFeature(&newFeature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePrimers adds a primer_bind feature for every binding site of the primers packet to a record.
+func parsePrimers(packet []byte, record *genbank.Genbank) error {
+	var filePrimers primers
+	if err := xml.Unmarshal(packet, &filePrimers); err != nil {
+		return fmt.Errorf("Failed to parse primers: %w", err)
+	}
+	for _, filePrimer := range filePrimers.Primers {
+		for _, site := range filePrimer.BindingSites {
+			location, err := parseRange(site.Location, record)
+			if err != nil {
+				return fmt.Errorf("Failed to parse binding site of primer %s: %w", filePrimer.Name, err)
+			}
+			// a primer bound to the bottom strand points in the reverse direction.
+			if site.BoundStrand == "1" {
+				location.Complement = true
+			}
+			newFeature := genbank.Feature{Type: "primer_bind", Location: location, Qualifiers: []genbank.Qualifier{{Key: "label", Value: filePrimer.Name}}}
+			if filePrimer.Description != "" {
+				newFeature.AddQualifier("note", stripHTML(filePrimer.Description))
+			}
+			if err = record.AddFeature(&newFeature); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseRange parses a SnapGene range, like 10-20, into a Location. Ranges
+// of circular sequences may span the origin, like 2315-217, in which case
+// they are converted into a join.
+func parseRange(rangeString string, record *genbank.Genbank) (genbank.Location, error) {
+	startString, endString, found := strings.Cut(rangeString, "-")
+	if !found {
+		return genbank.Location{}, fmt.Errorf("malformed range %q", rangeString)
+	}
+	start, err := strconv.Atoi(startString)
+	if err != nil {
+		return genbank.Location{}, fmt.Errorf("malformed range %q: %w", rangeString, err)
+	}
+	end, err := strconv.Atoi(endString)
+	if err != nil {
+		return genbank.Location{}, fmt.Errorf("malformed range %q: %w", rangeString, err)
+	}
+	if start < 1 || end < 1 || start > len(record.Sequence) || end > len(record.Sequence) {
+		return genbank.Location{}, fmt.Errorf("range %q is outside of the sequence of length %d", rangeString, len(record.Sequence))
+	}
+	if start <= end {
+		return genbank.Location{Start: start - 1, End: end}, nil
+	}
+	if !record.Meta.Locus.Circular {
+		return genbank.Location{}, fmt.Errorf("range %q spans the origin of a linear sequence", rangeString)
+	}
+	return genbank.Location{Join: true, SubLocations: []genbank.Location{
+		{Start: start - 1, End: len(record.Sequence)},
+		{Start: 0, End: end},
+	}}, nil
+}
+
+// joinLocations joins the locations of the segments of a feature.
+func joinLocations(locations []genbank.Location) genbank.Location {
+	if len(locations) == 1 {
+		return locations[0]
+	}
+	joined := genbank.Location{Join: true}
+	for _, location := range locations {
+		if location.Join {
+			joined.SubLocations = append(joined.SubLocations, location.SubLocations...)
+			continue
+		}
+		joined.SubLocations = append(joined.SubLocations, location)
+	}
+	return joined
+}
+
+// stripHTML removes the HTML formatting SnapGene adds to notes and descriptions.
+func stripHTML(text string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	return html.UnescapeString(strings.TrimSpace(htmlTagRegex.ReplaceAllString(text, "")))
+}
+
+// Read reads a SnapGene .dna file from path and returns a Genbank struct. If
+// the file has no custom map label, its name is used as the locus name.
+func Read(path string) (genbank.Genbank, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	defer file.Close()
+	record, err := Parse(file)
+	if err != nil {
+		return genbank.Genbank{}, err
+	}
+	if record.Meta.Locus.Name == "" {
+		record.Meta.Locus.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return record, nil
+}
@@ -0,0 +1,130 @@
+package snapgene
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bebop/poly/io/genbank"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestReadMatchesGenbankExport(t *testing.T) {
+	record, err := Read("data/puc19.dna")
+	if err != nil {
+		t.Fatalf("Failed to read puc19.dna: %s", err)
+	}
+	export, err := genbank.Read("../../data/puc19_snapgene.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Sequence != export.Sequence {
+		t.Errorf("Sequence does not match the GenBank export")
+	}
+	wantLocus := genbank.Locus{Name: "puc19", SequenceLength: "2686", MoleculeType: "ds-DNA", GenbankDivision: "SYN", ModificationDate: "22-OCT-2019", SequenceCoding: "bp", Circular: true}
+	if diff := cmp.Diff(wantLocus, record.Meta.Locus); diff != "" {
+		t.Errorf("Locus mismatch (-want +got):\n%s", diff)
+	}
+	if record.Meta.Definition != export.Meta.Definition {
+		t.Errorf("Definition = %q, want %q", record.Meta.Definition, export.Meta.Definition)
+	}
+	if record.Meta.Organism != export.Meta.Organism {
+		t.Errorf("Organism = %q, want %q", record.Meta.Organism, export.Meta.Organism)
+	}
+	if diff := cmp.Diff(export.Meta.References[0], record.Meta.References[0]); diff != "" {
+		t.Errorf("Reference mismatch (-export +snapgene):\n%s", diff)
+	}
+
+	// primers are stored separately from features in .dna files, so the order of the features differs.
+	type featureKey struct{ featureType, location, label string }
+	features := make(map[featureKey]genbank.Feature)
+	for _, feature := range record.Features {
+		if feature.Type != "primer_bind" && feature.Attributes()["color"] == "" {
+			t.Errorf("Feature %s has no color", feature.Attributes()["label"])
+		}
+		feature.RemoveQualifier("color")
+		features[featureKey{feature.Type, genbank.BuildLocationString(feature.Location), feature.Attributes()["label"]}] = feature
+	}
+	if len(record.Features) != len(export.Features) {
+		t.Errorf("Got %d features, want %d", len(record.Features), len(export.Features))
+	}
+	for _, want := range export.Features {
+		key := featureKey{want.Type, genbank.BuildLocationString(want.Location), want.Attributes()["label"]}
+		got, ok := features[key]
+		if !ok {
+			t.Errorf("Missing feature %v", key)
+			continue
+		}
+		// the genbank parser sometimes drops the space at the end of wrapped qualifier lines, so spaces are ignored.
+		if diff := cmp.Diff(want.Attributes(), got.Attributes(), cmpopts.AcyclicTransformer("removeSpaces", func(value string) string { return strings.Join(strings.Fields(value), "") })); diff != "" {
+			t.Errorf("Attributes of %v mismatch (-export +snapgene):\n%s", key, diff)
+		}
+		wantSequence, _ := want.GetSequence()
+		gotSequence, _ := got.GetSequence()
+		if gotSequence != wantSequence {
+			t.Errorf("Sequence of %v is %q, want %q", key, gotSequence, wantSequence)
+		}
+	}
+}
+
+// packet builds a single SnapGene packet.
+func packet(packetType byte, data string) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteByte(packetType)
+	_ = binary.Write(&buffer, binary.BigEndian, uint32(len(data)))
+	buffer.WriteString(data)
+	return buffer.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	cookie := packet(packetCookie, "SnapGene\x00\x01\x00\x0f\x00\x13")
+	linearDNA := packet(packetDNA, "\x00ATGCATGCAT")
+	features := packet(packetFeatures, `<Features><Feature name="split" directionality="2" type="misc_feature"><Segment range="1-2" color="#ffffff"/><Segment range="3-4" type="gap"/><Segment range="5-7" color="#000000"/><Q name="note"><V text="first"/><V predef="second"/></Q></Feature></Features>`)
+	data := bytes.Join([][]byte{cookie, linearDNA, features}, nil)
+	record, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Meta.Locus.Circular || record.Meta.Locus.MoleculeType != "DNA" {
+		t.Errorf("Unexpected locus %+v", record.Meta.Locus)
+	}
+	feature := record.Features[0]
+	if location := genbank.BuildLocationString(feature.Location); location != "complement(join(1..2,5..7))" {
+		t.Errorf("Location = %s, want complement(join(1..2,5..7))", location)
+	}
+	if sequence, _ := feature.GetSequence(); sequence != "CATAT" {
+		t.Errorf("Sequence = %s, want CATAT", sequence)
+	}
+	wantAttributes := map[string]string{"label": "split", "color": "#ffffff", "note": "first,second"}
+	if diff := cmp.Diff(wantAttributes, feature.Attributes()); diff != "" {
+		t.Errorf("Attributes mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		name string
+		data [][]byte
+	}{
+		{"no cookie", [][]byte{linearDNA}},
+		{"no sequence", [][]byte{cookie}},
+		{"protein file", [][]byte{packet(packetCookie, "SnapGene\x00\x02\x00\x0f\x00\x13"), linearDNA}},
+		{"truncated packet", [][]byte{cookie, linearDNA[:5]}},
+		{"origin spanning range of linear sequence", [][]byte{cookie, linearDNA, packet(packetPrimers, `<Primers><Primer name="p"><BindingSite location="9-2" boundStrand="0"/></Primer></Primers>`)}},
+		{"range outside of sequence", [][]byte{cookie, linearDNA, packet(packetFeatures, `<Features><Feature name="f" type="misc_feature"><Segment range="1-20"/></Feature></Features>`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(bytes.Join(tt.data, nil))); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	if _, err := Read("data/does_not_exist.dna"); !os.IsNotExist(err) {
+		t.Errorf("Expected file not found error, got %v", err)
+	}
+}
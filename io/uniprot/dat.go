@@ -0,0 +1,851 @@
+package uniprot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+Flat file parsing begins here.
+
+Besides the XML dump, Uniprot distributes its databases as flat files
+(uniprot_sprot.dat.gz and uniprot_trembl.dat.gz). The flat files are several
+times smaller than the XML dump and a lot faster to parse, since they don't
+need an XML decoder.
+
+Every line of a flat file starts with a two letter line code, followed by
+three spaces and the data of the line. Entries end with a // line. The line
+codes parsed into an Entry are:
+
+	ID  name of the entry and whether it is reviewed
+	AC  accessions
+	DT  dates the entry and its sequence were created and last modified
+	DE  protein names, including the names of components and domains
+	GN  gene names
+	OS  organism names
+	OC  organism lineage
+	OX  NCBI taxonomy identifier of the organism
+	OH  hosts of the organism, for viruses
+	DR  database cross-references
+	PE  protein existence
+	KW  keywords
+	FT  features
+	SQ  sequence
+
+References (RN to RL lines) and comments (CC lines) are skipped. The flat
+file format is described in the Uniprot user manual(1).
+
+Flat files don't hold the evidence keys of the XML dump, so evidence tags like
+{ECO:0000255} are dropped.
+
+(1) https://web.expasy.org/docs/userman.html
+
+******************************************************************************/
+
+// ReadDat reads a gzipped Uniprot flat file dump. Failing to open the dump
+// gives a single error, while errors encountered while parsing the dump are
+// added to the errors channel.
+func ReadDat(path string) (chan Entry, chan error, error) {
+	entries := make(chan Entry, 100) // if you don't have a buffered channel, nothing will be read in loops on the channel.
+	parserErrors := make(chan error, 100)
+	datFile, err := os.Open(path)
+	if err != nil {
+		return entries, parserErrors, err
+	}
+	unzippedBytes, err := gzip.NewReader(datFile)
+	if err != nil {
+		datFile.Close()
+		return entries, parserErrors, err
+	}
+	go func() {
+		defer datFile.Close()
+		ParseDat(unzippedBytes, entries, parserErrors)
+	}()
+	return entries, parserErrors, nil
+}
+
+// ParseDat parses Uniprot flat file entries into a channel. Entries that fail
+// to parse are skipped and their error is added to the errors channel. Errors
+// reading r stop the parsing.
+func ParseDat(r io.Reader, entries chan<- Entry, parserErrors chan<- error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	parser := NewDatParser(r, maxLineSize)
+	for {
+		lines, err := parser.readEntry()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				parserErrors <- err
+			}
+			break
+		}
+		entry, err := parseDatEntry(lines)
+		if err != nil {
+			parserErrors <- err
+			continue
+		}
+		entries <- entry
+	}
+	close(entries)
+	close(parserErrors)
+}
+
+// DatParser is a parser for Uniprot flat files, which reads a single entry at
+// a time. It is initialized with NewDatParser.
+type DatParser struct {
+	// reader keeps state of current reader.
+	reader bufio.Reader
+	line   uint
+}
+
+// NewDatParser returns a DatParser that uses r as the source from which to
+// parse Uniprot flat file entries.
+func NewDatParser(r io.Reader, maxLineSize int) *DatParser {
+	return &DatParser{
+		reader: *bufio.NewReaderSize(r, maxLineSize),
+	}
+}
+
+// ParseAll parses all entries in underlying reader only returning non-EOF errors.
+// It returns all valid entries up to error if encountered.
+func (parser *DatParser) ParseAll() ([]Entry, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// ParseN parses up to maxEntries entries from the DatParser's underlying reader.
+// ParseN does not return EOF if encountered.
+// If an non-EOF error is encountered it returns it and all correctly parsed entries up to then.
+func (parser *DatParser) ParseN(maxEntries int) (entries []Entry, err error) {
+	for counter := 0; counter < maxEntries; counter++ {
+		entry, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ParseNext reads the next entry in underlying reader. It returns EOF if
+// called after the reader has been exhausted. Entries are read up to their
+// closing // line before they are parsed, so parsing can continue with the
+// next entry after an entry fails to parse.
+func (parser *DatParser) ParseNext() (Entry, error) {
+	lines, err := parser.readEntry()
+	if err != nil {
+		return Entry{}, err
+	}
+	return parseDatEntry(lines)
+}
+
+// Reset discards all data in buffer and resets state.
+func (parser *DatParser) Reset(r io.Reader) {
+	parser.reader.Reset(r)
+	parser.line = 0
+}
+
+// datLine is a single line of a flat file, split into its line code and its
+// data.
+type datLine struct {
+	code   string
+	data   string
+	number uint
+}
+
+// readEntry reads the lines of the next entry, up to its closing // line.
+func (parser *DatParser) readEntry() ([]datLine, error) {
+	var lines []datLine
+	for {
+		line, err := parser.reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("line %d too large for buffer, use larger maxLineSize: %w", parser.line+1, err)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(line) > 0 {
+			parser.line++
+		}
+		text := strings.TrimRight(string(line), "\r\n")
+		switch {
+		case text == "//":
+			return lines, nil
+		case strings.TrimSpace(text) == "":
+		default:
+			dataLine := datLine{code: text[:min(2, len(text))], number: parser.line}
+			if len(text) > 5 {
+				dataLine.data = text[5:]
+			}
+			lines = append(lines, dataLine)
+		}
+		if err != nil {
+			if len(lines) > 0 {
+				return nil, fmt.Errorf("entry starting on line %d is missing its closing //", lines[0].number)
+			}
+			return nil, err
+		}
+	}
+}
+
+// evidenceRegex matches the evidence tags at the end of many values, like
+// {ECO:0000255|HAMAP-Rule:MF_00001}.
+var evidenceRegex = regexp.MustCompile(`\s*\{ECO:[^}]*\}`)
+
+// removeEvidence removes all evidence tags from a value.
+func removeEvidence(value string) string {
+	return evidenceRegex.ReplaceAllString(value, "")
+}
+
+// joinLines joins the data of a set of lines that continue each other.
+func joinLines(lines []datLine) string {
+	data := make([]string, len(lines))
+	for index, line := range lines {
+		data[index] = strings.TrimSpace(line.data)
+	}
+	return strings.Join(data, " ")
+}
+
+// splitList splits a list like the KW and OC lines into its items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(strings.TrimSuffix(removeEvidence(list), "."), ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDatEntry parses the lines of a single entry into an Entry.
+func parseDatEntry(lines []datLine) (Entry, error) {
+	var entry Entry
+	// Lines that are only parsed once all of them have been read.
+	linesByCode := make(map[string][]datLine)
+	foundID := false
+	for _, line := range lines {
+		var err error
+		switch line.code {
+		case "ID":
+			err = parseID(&entry, line.data)
+			foundID = true
+		case "AC":
+			entry.Accession = append(entry.Accession, splitList(line.data)...)
+		case "DT":
+			err = parseDate(&entry, line.data)
+		case "OX":
+			var taxonomy DbReferenceType
+			taxonomy, err = parseTaxonomy(line.data)
+			entry.Organism.DbReference = append(entry.Organism.DbReference, taxonomy)
+		case "OH":
+			var host OrganismType
+			host, err = parseHost(line.data)
+			entry.OrganismHost = append(entry.OrganismHost, host)
+		case "DR":
+			var reference DbReferenceType
+			reference, err = parseDbReference(line.data)
+			entry.DbReference = append(entry.DbReference, reference)
+		case "PE":
+			err = parseProteinExistence(&entry, line.data)
+		case "SQ":
+			err = parseSequenceHeader(&entry, line.data)
+		case "  ":
+			entry.Sequence.Value += strings.ReplaceAll(line.data, " ", "")
+		case "DE", "GN", "OS", "OC", "KW", "FT":
+			linesByCode[line.code] = append(linesByCode[line.code], line)
+		}
+		if err != nil {
+			return Entry{}, fmt.Errorf("Error on line %d: %w", line.number, err)
+		}
+	}
+	if !foundID {
+		if len(lines) > 0 {
+			return Entry{}, fmt.Errorf("entry starting on line %d is missing its ID line", lines[0].number)
+		}
+		return Entry{}, errors.New("entry is missing its ID line")
+	}
+
+	if err := parseDescription(&entry, linesByCode["DE"]); err != nil {
+		return Entry{}, err
+	}
+	if err := parseGenes(&entry, linesByCode["GN"]); err != nil {
+		return Entry{}, err
+	}
+	entry.Organism.Name = parseOrganismNames(joinLines(linesByCode["OS"]))
+	entry.Organism.Lineage.Taxon = splitList(joinLines(linesByCode["OC"]))
+	for _, keyword := range splitList(joinLines(linesByCode["KW"])) {
+		entry.Keyword = append(entry.Keyword, KeywordType{Value: keyword})
+	}
+	if err := parseFeatures(&entry, linesByCode["FT"]); err != nil {
+		return Entry{}, err
+	}
+	if len(entry.Sequence.Value) != entry.Sequence.Length {
+		return Entry{}, fmt.Errorf("sequence of %s has %d residues, expected %d", strings.Join(entry.Name, ""), len(entry.Sequence.Value), entry.Sequence.Length)
+	}
+	return entry, nil
+}
+
+// parseID parses an ID line, like:
+//
+//	1001R_ASFK5             Reviewed;         122 AA.
+func parseID(entry *Entry, data string) error {
+	fields := strings.Fields(data)
+	if len(fields) < 2 {
+		return fmt.Errorf("expected an entry name and status, got: %s", data)
+	}
+	entry.Name = []string{fields[0]}
+	switch strings.TrimSuffix(fields[1], ";") {
+	case "Reviewed":
+		entry.Dataset = "Swiss-Prot"
+	case "Unreviewed":
+		entry.Dataset = "TrEMBL"
+	default:
+		return fmt.Errorf("unknown entry status %q", fields[1])
+	}
+	return nil
+}
+
+// parseDate parses a DT line, like:
+//
+//	05-MAY-2009, integrated into UniProtKB/Swiss-Prot.
+//	05-MAY-2009, sequence version 1.
+//	12-AUG-2020, entry version 9.
+func parseDate(entry *Entry, data string) error {
+	dateText, event, ok := strings.Cut(data, ", ")
+	if !ok {
+		return fmt.Errorf("expected a date and an event, got: %s", data)
+	}
+	date, err := time.Parse("02-Jan-2006", dateText)
+	if err != nil {
+		return err
+	}
+	event = strings.TrimSuffix(event, ".")
+	switch {
+	case strings.HasPrefix(event, "integrated into"):
+		entry.Created = date
+	case strings.HasPrefix(event, "sequence version "):
+		entry.Sequence.Modified = date
+		entry.Sequence.Version, err = strconv.Atoi(strings.TrimPrefix(event, "sequence version "))
+	case strings.HasPrefix(event, "entry version "):
+		entry.Modified = date
+		entry.Version, err = strconv.Atoi(strings.TrimPrefix(event, "entry version "))
+	}
+	return err
+}
+
+// proteinNames points to the names of either a protein, or one of its
+// components or domains, which all have the same set of names.
+type proteinNames struct {
+	recommendedName *RecommendedName
+	alternativeName *[]AlternativeName
+	submittedName   *[]SubmittedName
+	allergenName    *EvidencedStringType
+	biotechName     *EvidencedStringType
+	cdAntigenName   *[]EvidencedStringType
+	innName         *[]EvidencedStringType
+}
+
+// parseDescription parses the DE lines of an entry, like:
+//
+//	RecName: Full=Cytochrome c oxidase subunit 1;
+//	         Short=COX1;
+//	         EC=7.1.1.9;
+//	AltName: Full=Cytochrome c oxidase polypeptide I;
+//	Flags: Precursor;
+//
+// Names following a Contains: line belong to the components of the protein,
+// names following an Includes: line to its domains. Every component and
+// domain starts with a RecName.
+func parseDescription(entry *Entry, lines []datLine) error {
+	protein := &entry.Protein
+	names := proteinNames{&protein.RecommendedName, &protein.AlternativeName, &protein.SubmittedName, &protein.AllergenName, &protein.BiotechName, &protein.CdAntigenName, &protein.InnName}
+	section := ""
+	// category is the kind of name the current line belongs to.
+	category := ""
+	for _, line := range lines {
+		text := strings.TrimSpace(removeEvidence(line.data))
+		switch {
+		case text == "Contains:" || text == "Includes:":
+			section = text
+			continue
+		case strings.HasPrefix(text, "Flags:"):
+			for _, flag := range splitList(strings.TrimPrefix(text, "Flags:")) {
+				switch flag {
+				case "Precursor":
+					entry.Sequence.Precursor = true
+				case "Fragment":
+					entry.Sequence.Fragment = "single"
+				case "Fragments":
+					entry.Sequence.Fragment = "multiple"
+				}
+			}
+			continue
+		}
+		if prefix, rest, ok := strings.Cut(text, ": "); ok && (prefix == "RecName" || prefix == "AltName" || prefix == "SubName") {
+			category = prefix
+			text = rest
+			if prefix == "RecName" && section == "Contains:" {
+				protein.Component = append(protein.Component, Component{})
+				component := &protein.Component[len(protein.Component)-1]
+				names = proteinNames{&component.RecommendedName, &component.AlternativeName, &component.SubmittedName, &component.AllergenName, &component.BiotechName, &component.CdAntigenName, &component.InnName}
+			} else if prefix == "RecName" && section == "Includes:" {
+				protein.Domain = append(protein.Domain, Domain{})
+				domain := &protein.Domain[len(protein.Domain)-1]
+				names = proteinNames{&domain.RecommendedName, &domain.AlternativeName, &domain.SubmittedName, &domain.AllergenName, &domain.BiotechName, &domain.CdAntigenName, &domain.InnName}
+			}
+			if prefix == "AltName" && (strings.HasPrefix(text, "Full=") || strings.HasPrefix(text, "Short=")) {
+				*names.alternativeName = append(*names.alternativeName, AlternativeName{})
+			}
+			if prefix == "SubName" {
+				*names.submittedName = append(*names.submittedName, SubmittedName{})
+			}
+		}
+		field, value, ok := strings.Cut(strings.TrimSuffix(text, ";"), "=")
+		if !ok {
+			return fmt.Errorf("Error on line %d: expected a name, got: %s", line.number, line.data)
+		}
+		name := EvidencedStringType{Value: value}
+		switch {
+		case field == "Allergen":
+			*names.allergenName = name
+		case field == "Biotech":
+			*names.biotechName = name
+		case field == "CD_antigen":
+			*names.cdAntigenName = append(*names.cdAntigenName, name)
+		case field == "INN":
+			*names.innName = append(*names.innName, name)
+		case category == "RecName":
+			recommended := names.recommendedName
+			switch field {
+			case "Full":
+				recommended.FullName = name
+			case "Short":
+				recommended.ShortName = append(recommended.ShortName, name)
+			case "EC":
+				recommended.EcNumber = append(recommended.EcNumber, name)
+			}
+		case category == "AltName" && len(*names.alternativeName) > 0:
+			alternative := &(*names.alternativeName)[len(*names.alternativeName)-1]
+			switch field {
+			case "Full":
+				alternative.FullName = name
+			case "Short":
+				alternative.ShortName = append(alternative.ShortName, name)
+			case "EC":
+				alternative.EcNumber = append(alternative.EcNumber, name)
+			}
+		case category == "SubName" && len(*names.submittedName) > 0:
+			submitted := &(*names.submittedName)[len(*names.submittedName)-1]
+			switch field {
+			case "Full":
+				submitted.FullName = name
+			case "EC":
+				submitted.EcNumber = append(submitted.EcNumber, name)
+			}
+		default:
+			return fmt.Errorf("Error on line %d: name %q doesn't belong to a RecName, AltName or SubName", line.number, field)
+		}
+	}
+	return nil
+}
+
+// geneNameTypes maps the fields of GN lines to the types of gene names.
+var geneNameTypes = map[string]Type{
+	"Name":              "primary",
+	"Synonyms":          "synonym",
+	"OrderedLocusNames": "ordered locus",
+	"ORFNames":          "ORF",
+}
+
+// parseGenes parses the GN lines of an entry, like:
+//
+//	Name=Jon99Cii; Synonyms=SER1, SER5, Ser99Da; ORFNames=CG7877;
+//	and
+//	Name=Jon99Ciii; Synonyms=SER2, SER5, Ser99Db; ORFNames=CG15519;
+//
+// Lines holding only "and" separate the names of different genes.
+func parseGenes(entry *Entry, lines []datLine) error {
+	var geneLines [][]datLine
+	start := true
+	for _, line := range lines {
+		if strings.TrimSpace(line.data) == "and" {
+			start = true
+			continue
+		}
+		if start {
+			geneLines = append(geneLines, nil)
+			start = false
+		}
+		geneLines[len(geneLines)-1] = append(geneLines[len(geneLines)-1], line)
+	}
+	for _, gene := range geneLines {
+		var parsed GeneType
+		for _, field := range splitList(joinLines(gene)) {
+			key, values, ok := strings.Cut(field, "=")
+			nameType, known := geneNameTypes[key]
+			if !ok || !known {
+				return fmt.Errorf("Error on line %d: unknown gene name %q", gene[0].number, field)
+			}
+			for _, value := range strings.Split(values, ",") {
+				parsed.Name = append(parsed.Name, GeneNameType{Value: strings.TrimSpace(value), Type: nameType})
+			}
+		}
+		entry.Gene = append(entry.Gene, parsed)
+	}
+	return nil
+}
+
+// organismQualifiers are the words that start the parts of an organism name
+// in parentheses that belong to its scientific name, like "(strain K12)".
+var organismQualifiers = []string{"strain ", "isolate ", "substrain ", "subsp. ", "var. ", "cv. ", "cultivar ", "serotype ", "serovar ", "biovar ", "pathovar ", "pv. ", "clone ", "subtype ", "subgroup ", "genotype ", "ecotype ", "forma "}
+
+// parseOrganismNames parses the names of an OS or OH line, like:
+//
+//	Saccharomyces cerevisiae (strain ATCC 204508 / S288c) (Baker's yeast).
+//
+// The first name in parentheses at the end of the line is the common name of
+// the organism and any following names are synonyms, unless they qualify the
+// scientific name, like a strain or isolate does.
+func parseOrganismNames(text string) []OrganismNameType {
+	text = strings.TrimSuffix(strings.TrimSpace(text), ".")
+	if text == "" {
+		return nil
+	}
+	var otherNames []string
+	for strings.HasSuffix(text, ")") {
+		start := openingParenthesis(text)
+		if start <= 0 {
+			break
+		}
+		name := text[start+1 : len(text)-1]
+		if isOrganismQualifier(name) {
+			break
+		}
+		otherNames = append([]string{name}, otherNames...)
+		text = strings.TrimSpace(text[:start])
+	}
+	names := []OrganismNameType{{Value: text, Type: "scientific"}}
+	for index, name := range otherNames {
+		nameType := Type("synonym")
+		if index == 0 {
+			nameType = "common"
+		}
+		names = append(names, OrganismNameType{Value: name, Type: nameType})
+	}
+	return names
+}
+
+// openingParenthesis returns the index of the parenthesis opening the one
+// closing text, or -1 if it is missing.
+func openingParenthesis(text string) int {
+	depth := 0
+	for index := len(text) - 1; index >= 0; index-- {
+		switch text[index] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// isOrganismQualifier checks if the part of an organism name in parentheses
+// qualifies the scientific name.
+func isOrganismQualifier(name string) bool {
+	for _, qualifier := range organismQualifiers {
+		if strings.HasPrefix(name, qualifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTaxonomy parses an OX line, like:
+//
+//	NCBI_TaxID=9606;
+func parseTaxonomy(data string) (DbReferenceType, error) {
+	database, id, ok := strings.Cut(strings.TrimSuffix(strings.TrimSpace(removeEvidence(data)), ";"), "=")
+	if !ok || database != "NCBI_TaxID" {
+		return DbReferenceType{}, fmt.Errorf("expected NCBI_TaxID=<id>, got: %s", data)
+	}
+	return DbReferenceType{Type: "NCBI Taxonomy", Id: id}, nil
+}
+
+// parseHost parses an OH line, like:
+//
+//	NCBI_TaxID=9823; Sus scrofa (Pig).
+func parseHost(data string) (OrganismType, error) {
+	taxonomyText, names, ok := strings.Cut(data, ";")
+	if !ok {
+		return OrganismType{}, fmt.Errorf("expected NCBI_TaxID=<id>; <name>, got: %s", data)
+	}
+	taxonomy, err := parseTaxonomy(taxonomyText)
+	if err != nil {
+		return OrganismType{}, err
+	}
+	return OrganismType{Name: parseOrganismNames(names), DbReference: []DbReferenceType{taxonomy}}, nil
+}
+
+// dbReferenceProperties maps databases to the names of the properties of
+// their DR lines, as they are named in the XML dump. Properties of other
+// databases are named "property 1", "property 2" and so on.
+var dbReferenceProperties = map[string][]string{
+	"EMBL":      {"protein sequence ID", "status", "molecule type"},
+	"RefSeq":    {"nucleotide sequence ID"},
+	"PDB":       {"method", "resolution", "chains"},
+	"GO":        {"term", "evidence"},
+	"InterPro":  {"entry name"},
+	"Pfam":      {"entry name", "match status"},
+	"PROSITE":   {"entry name", "match status"},
+	"SMART":     {"entry name", "match status"},
+	"Proteomes": {"component"},
+	"eggNOG":    {"taxonomic scope"},
+}
+
+// parseDbReference parses a DR line, like:
+//
+//	EMBL; CR940353; CAI76474.1; -; Genomic_DNA.
+//	RefSeq; NP_000537.3; NM_000546.5. [P04637-1]
+//
+// Properties that are - are left out. References to a single isoform end
+// with the isoform in brackets, which is stored as the molecule.
+func parseDbReference(data string) (DbReferenceType, error) {
+	text := strings.TrimSpace(removeEvidence(data))
+	var reference DbReferenceType
+	if strings.HasSuffix(text, "]") {
+		if start := strings.LastIndex(text, " ["); start != -1 {
+			reference.Molecule = text[start+2 : len(text)-1]
+			text = text[:start]
+		}
+	}
+	fields := strings.Split(strings.TrimSuffix(text, "."), "; ")
+	if len(fields) < 2 {
+		return DbReferenceType{}, fmt.Errorf("expected a database and an identifier, got: %s", data)
+	}
+	reference.Type = fields[0]
+	reference.Id = fields[1]
+	propertyNames := dbReferenceProperties[reference.Type]
+	for index, value := range fields[2:] {
+		if value == "-" {
+			continue
+		}
+		name := fmt.Sprintf("property %d", index+1)
+		if index < len(propertyNames) {
+			name = propertyNames[index]
+		}
+		reference.Property = append(reference.Property, PropertyType{Type: name, Value: value})
+	}
+	return reference, nil
+}
+
+// parseProteinExistence parses a PE line, like:
+//
+//	1: Evidence at protein level;
+func parseProteinExistence(entry *Entry, data string) error {
+	_, existence, ok := strings.Cut(data, ": ")
+	if !ok {
+		return fmt.Errorf("expected <level>: <protein existence>, got: %s", data)
+	}
+	entry.ProteinExistence.Type = Type(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(existence), ";")))
+	return nil
+}
+
+// parseSequenceHeader parses an SQ line, like:
+//
+//	SEQUENCE   122 AA;  14969 MW;  C5E63C34B941711C CRC64;
+func parseSequenceHeader(entry *Entry, data string) error {
+	fields := strings.Fields(data)
+	if len(fields) != 7 || fields[0] != "SEQUENCE" {
+		return fmt.Errorf("expected SEQUENCE <length> AA; <mass> MW; <checksum> CRC64;, got: %s", data)
+	}
+	var err error
+	if entry.Sequence.Length, err = strconv.Atoi(fields[1]); err != nil {
+		return err
+	}
+	if entry.Sequence.Mass, err = strconv.Atoi(fields[3]); err != nil {
+		return err
+	}
+	entry.Sequence.Checksum = fields[5]
+	return nil
+}
+
+// featureTypes maps the keys of FT lines to the types of features in the XML
+// dump. Unknown keys are lowercased.
+var featureTypes = map[string]Type{
+	"INIT_MET": "initiator methionine",
+	"SIGNAL":   "signal peptide",
+	"PROPEP":   "propeptide",
+	"TRANSIT":  "transit peptide",
+	"CHAIN":    "chain",
+	"PEPTIDE":  "peptide",
+	"TOPO_DOM": "topological domain",
+	"TRANSMEM": "transmembrane region",
+	"INTRAMEM": "intramembrane region",
+	"DOMAIN":   "domain",
+	"REPEAT":   "repeat",
+	"CA_BIND":  "calcium-binding region",
+	"ZN_FING":  "zinc finger region",
+	"DNA_BIND": "DNA-binding region",
+	"NP_BIND":  "nucleotide phosphate-binding region",
+	"REGION":   "region of interest",
+	"COILED":   "coiled-coil region",
+	"MOTIF":    "short sequence motif",
+	"COMPBIAS": "compositionally biased region",
+	"ACT_SITE": "active site",
+	"METAL":    "metal ion-binding site",
+	"BINDING":  "binding site",
+	"SITE":     "site",
+	"NON_STD":  "non-standard amino acid",
+	"MOD_RES":  "modified residue",
+	"LIPID":    "lipid moiety-binding region",
+	"CARBOHYD": "glycosylation site",
+	"DISULFID": "disulfide bond",
+	"CROSSLNK": "cross-link",
+	"VAR_SEQ":  "splice variant",
+	"VARIANT":  "sequence variant",
+	"MUTAGEN":  "mutagenesis site",
+	"UNSURE":   "unsure residue",
+	"CONFLICT": "sequence conflict",
+	"NON_CONS": "non-consecutive residues",
+	"NON_TER":  "non-terminal residue",
+	"HELIX":    "helix",
+	"STRAND":   "strand",
+	"TURN":     "turn",
+}
+
+// variationTypes are the types of features that change the sequence.
+var variationTypes = map[Type]bool{"splice variant": true, "sequence variant": true, "mutagenesis site": true, "sequence conflict": true}
+
+// variationRegex matches the notes of features that change the sequence,
+// like "K -> E or Q (in dbSNP:rs1057519998)".
+var variationRegex = regexp.MustCompile(`^([A-Z ]+) -> ([A-Z ]+(?: or [A-Z ]+)*?)(?: \((.*)\))?$`)
+
+// parseFeatures parses the FT lines of an entry, like:
+//
+//	CHAIN           20..873
+//	                /note="104 kDa microneme/rhoptry antigen"
+//	                /id="PRO_0000232680"
+//
+// The note of a feature becomes its description. Notes of splice variants,
+// variants, conflicts and mutagenesis sites that change the sequence are split into
+// the original and variant sequences and a description.
+func parseFeatures(entry *Entry, lines []datLine) error {
+	var qualifiers []string
+	finishFeature := func() {
+		if len(entry.Feature) == 0 {
+			return
+		}
+		feature := &entry.Feature[len(entry.Feature)-1]
+		for _, qualifier := range qualifiers {
+			name, value, _ := strings.Cut(qualifier, "=")
+			value = strings.Trim(value, `"`)
+			switch name {
+			case "/note":
+				feature.Description = value
+			case "/id":
+				feature.Id = value
+			}
+		}
+		qualifiers = nil
+		if !variationTypes[feature.Type] {
+			return
+		}
+		if match := variationRegex.FindStringSubmatch(feature.Description); match != nil {
+			feature.Original = strings.ReplaceAll(match[1], " ", "")
+			for _, variation := range strings.Split(match[2], " or ") {
+				feature.Variation = append(feature.Variation, strings.ReplaceAll(variation, " ", ""))
+			}
+			feature.Description = match[3]
+		}
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line.data, " ") {
+			finishFeature()
+			fields := strings.Fields(line.data)
+			if len(fields) != 2 {
+				return fmt.Errorf("Error on line %d: expected a feature key and location, got: %s", line.number, line.data)
+			}
+			featureType, ok := featureTypes[fields[0]]
+			if !ok {
+				featureType = Type(strings.ToLower(fields[0]))
+			}
+			location, err := parseLocation(fields[1])
+			if err != nil {
+				return fmt.Errorf("Error on line %d: %w", line.number, err)
+			}
+			entry.Feature = append(entry.Feature, FeatureType{Type: featureType, Location: location})
+			continue
+		}
+		text := strings.TrimSpace(line.data)
+		switch {
+		case len(entry.Feature) == 0:
+			return fmt.Errorf("Error on line %d: expected a feature key, got: %s", line.number, line.data)
+		case strings.HasPrefix(text, "/"):
+			qualifiers = append(qualifiers, text)
+		case len(qualifiers) > 0:
+			qualifiers[len(qualifiers)-1] += " " + text
+		}
+	}
+	finishFeature()
+	return nil
+}
+
+// parseLocation parses the location of a feature, like 1..122, 873, <1..?
+// or P04637-2:5..10 for features of another isoform.
+func parseLocation(text string) (LocationType, error) {
+	var location LocationType
+	if isoform, rest, ok := strings.Cut(text, ":"); ok {
+		location.Sequence = isoform
+		text = rest
+	}
+	var err error
+	if begin, end, ok := strings.Cut(text, ".."); ok {
+		if location.Begin, err = parsePosition(begin); err != nil {
+			return LocationType{}, err
+		}
+		location.End, err = parsePosition(end)
+	} else {
+		location.Position, err = parsePosition(text)
+	}
+	return location, err
+}
+
+// parsePosition parses a single position of a location. ? marks an unknown
+// position, ?5 an uncertain one and <1 or >122 one beyond the sequence.
+func parsePosition(text string) (PositionType, error) {
+	var position PositionType
+	switch {
+	case text == "?":
+		position.Status = "unknown"
+		return position, nil
+	case strings.HasPrefix(text, "?"):
+		position.Status = "uncertain"
+	case strings.HasPrefix(text, "<"):
+		position.Status = "less than"
+	case strings.HasPrefix(text, ">"):
+		position.Status = "greater than"
+	}
+	value, err := strconv.ParseUint(strings.TrimLeft(text, "?<>"), 10, 64)
+	if err != nil {
+		return PositionType{}, fmt.Errorf("invalid feature position %q", text)
+	}
+	position.Position = value
+	return position, nil
+}
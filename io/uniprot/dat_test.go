@@ -0,0 +1,272 @@
+package uniprot
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collectEntries reads all entries and errors of a pair of channels.
+func collectEntries(t *testing.T, entries chan Entry, parserErrors chan error) ([]Entry, []error) {
+	t.Helper()
+	var parsed []Entry
+	for entry := range entries {
+		parsed = append(parsed, entry)
+	}
+	var errs []error
+	for err := range parserErrors {
+		errs = append(errs, err)
+	}
+	return parsed, errs
+}
+
+func TestReadDat(t *testing.T) {
+	entries, parserErrors, err := ReadDat("data/uniprot_sprot_mini.dat.gz")
+	assert.NoError(t, err)
+	datEntries, errs := collectEntries(t, entries, parserErrors)
+	assert.Empty(t, errs)
+	assert.Len(t, datEntries, 2)
+
+	// The flat file holds the same entries as the XML dump.
+	xmlEntries := make(map[string]Entry)
+	entries, parserErrors, err = Read("data/uniprot_sprot_mini.xml.gz")
+	assert.NoError(t, err)
+	parsedXML, _ := collectEntries(t, entries, parserErrors)
+	for _, entry := range parsedXML {
+		xmlEntries[entry.Accession[0]] = entry
+	}
+	for _, datEntry := range datEntries {
+		xmlEntry := xmlEntries[datEntry.Accession[0]]
+		assert.Equal(t, xmlEntry.Accession, datEntry.Accession)
+		assert.Equal(t, xmlEntry.Name, datEntry.Name)
+		assert.Equal(t, xmlEntry.Dataset, datEntry.Dataset)
+		assert.Equal(t, xmlEntry.Created, datEntry.Created)
+		assert.Equal(t, xmlEntry.Modified, datEntry.Modified)
+		assert.Equal(t, xmlEntry.Version, datEntry.Version)
+		assert.Equal(t, xmlEntry.Protein, datEntry.Protein)
+		assert.Equal(t, xmlEntry.Gene, datEntry.Gene)
+		assert.Equal(t, xmlEntry.Organism, datEntry.Organism)
+		assert.Equal(t, xmlEntry.OrganismHost, datEntry.OrganismHost)
+		assert.Equal(t, xmlEntry.ProteinExistence, datEntry.ProteinExistence)
+		assert.Equal(t, xmlEntry.Keyword, datEntry.Keyword)
+		assert.Equal(t, xmlEntry.Sequence, datEntry.Sequence)
+
+		assert.Len(t, datEntry.DbReference, len(xmlEntry.DbReference))
+		for index, reference := range datEntry.DbReference {
+			assert.Equal(t, xmlEntry.DbReference[index].Type, reference.Type)
+			assert.Equal(t, xmlEntry.DbReference[index].Id, reference.Id)
+			// GO evidence is written differently in both formats.
+			if reference.Type != "GO" {
+				assert.Equal(t, xmlEntry.DbReference[index].Property, reference.Property)
+			}
+		}
+
+		// Flat files don't hold evidence keys.
+		for index := range xmlEntry.Feature {
+			xmlEntry.Feature[index].Evidence = nil
+		}
+		assert.Equal(t, xmlEntry.Feature, datEntry.Feature)
+	}
+
+	_, _, err = ReadDat("data/test")
+	assert.Error(t, err, "Failed to fail on non-gzipped file")
+
+	_, _, err = ReadDat("data/FAKE")
+	assert.Error(t, err, "Failed to fail on missing file")
+}
+
+const datEntry = `ID   TEST_HUMAN              Unreviewed;        12 AA.
+AC   Q00001; Q00002;
+AC   Q00003;
+DT   01-JAN-2020, integrated into UniProtKB/TrEMBL.
+DT   01-FEB-2020, sequence version 2.
+DT   01-MAR-2021, entry version 7.
+DE   RecName: Full=Polyprotein {ECO:0000313|EMBL:AAA00001.1};
+DE            Short=PP;
+DE            EC=3.4.21.- {ECO:0000256|ARBA:ARBA00001};
+DE   AltName: Short=PPX;
+DE   AltName: CD_antigen=CD99;
+DE   Contains:
+DE     RecName: Full=Peptide A;
+DE     AltName: Full=Alpha peptide;
+DE   Contains:
+DE     RecName: Full=Peptide B;
+DE   Includes:
+DE     RecName: Full=Protease domain;
+DE              EC=3.4.21.1;
+DE   Flags: Precursor; Fragments;
+GN   Name=ABC1 {ECO:0000313|HGNC:HGNC:1}; Synonyms=XYZ1, XYZ2;
+GN   ORFNames=OR1;
+GN   and
+GN   Name=ABC2;
+OS   Saccharomyces cerevisiae (strain ATCC 204508 / S288c) (Baker's yeast)
+OS   (Yeast).
+OC   Eukaryota; Fungi.
+OX   NCBI_TaxID=559292 {ECO:0000313|EMBL:AAA00001.1};
+DR   RefSeq; NP_000537.3; NM_000546.5. [Q00001-2]
+DR   Pfam; PF00001; 7tm_1; 1.
+DR   Example; EX1; first; -; third.
+PE   4: Predicted;
+KW   Protease {ECO:0000256|ARBA:ARBA00001}; Serine
+KW   protease.
+FT   VARIANT         3
+FT                   /note="K -> E or Q (in dbSNP:rs1 and a long description
+FT                   that continues)"
+FT   CONFLICT        5..6
+FT                   /note="AG -> GA"
+FT   REGION          <1..?
+FT   NEW_KEY         Q00001-2:?4..>12
+SQ   SEQUENCE   12 AA;  1337 MW;  0123456789ABCDEF CRC64;
+     MAKLAGIVKR EE
+//
+`
+
+func TestDatParser_ParseNext(t *testing.T) {
+	parser := NewDatParser(strings.NewReader(datEntry), 1024)
+	entry, err := parser.ParseNext()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"TEST_HUMAN"}, entry.Name)
+	assert.Equal(t, Dataset("TrEMBL"), entry.Dataset)
+	assert.Equal(t, []string{"Q00001", "Q00002", "Q00003"}, entry.Accession)
+	assert.Equal(t, 2021, entry.Modified.Year())
+	assert.Equal(t, 7, entry.Version)
+	assert.Equal(t, 2, entry.Sequence.Version)
+
+	protein := entry.Protein
+	assert.Equal(t, RecommendedName{
+		FullName:  EvidencedStringType{Value: "Polyprotein"},
+		ShortName: []EvidencedStringType{{Value: "PP"}},
+		EcNumber:  []EvidencedStringType{{Value: "3.4.21.-"}},
+	}, protein.RecommendedName)
+	assert.Equal(t, []AlternativeName{{ShortName: []EvidencedStringType{{Value: "PPX"}}}}, protein.AlternativeName)
+	assert.Equal(t, []EvidencedStringType{{Value: "CD99"}}, protein.CdAntigenName)
+	assert.Len(t, protein.Component, 2)
+	assert.Equal(t, "Peptide A", protein.Component[0].RecommendedName.FullName.Value)
+	assert.Equal(t, "Alpha peptide", protein.Component[0].AlternativeName[0].FullName.Value)
+	assert.Equal(t, "Peptide B", protein.Component[1].RecommendedName.FullName.Value)
+	assert.Len(t, protein.Domain, 1)
+	assert.Equal(t, "3.4.21.1", protein.Domain[0].RecommendedName.EcNumber[0].Value)
+	assert.True(t, entry.Sequence.Precursor)
+	assert.Equal(t, Fragment("multiple"), entry.Sequence.Fragment)
+
+	assert.Equal(t, []GeneType{
+		{Name: []GeneNameType{{Value: "ABC1", Type: "primary"}, {Value: "XYZ1", Type: "synonym"}, {Value: "XYZ2", Type: "synonym"}, {Value: "OR1", Type: "ORF"}}},
+		{Name: []GeneNameType{{Value: "ABC2", Type: "primary"}}},
+	}, entry.Gene)
+
+	assert.Equal(t, []OrganismNameType{
+		{Value: "Saccharomyces cerevisiae (strain ATCC 204508 / S288c)", Type: "scientific"},
+		{Value: "Baker's yeast", Type: "common"},
+		{Value: "Yeast", Type: "synonym"},
+	}, entry.Organism.Name)
+	assert.Equal(t, []DbReferenceType{{Type: "NCBI Taxonomy", Id: "559292"}}, entry.Organism.DbReference)
+
+	assert.Equal(t, []DbReferenceType{
+		{Molecule: "Q00001-2", Type: "RefSeq", Id: "NP_000537.3", Property: []PropertyType{{Type: "nucleotide sequence ID", Value: "NM_000546.5"}}},
+		{Type: "Pfam", Id: "PF00001", Property: []PropertyType{{Type: "entry name", Value: "7tm_1"}, {Type: "match status", Value: "1"}}},
+		{Type: "Example", Id: "EX1", Property: []PropertyType{{Type: "property 1", Value: "first"}, {Type: "property 3", Value: "third"}}},
+	}, entry.DbReference)
+
+	assert.Equal(t, Type("predicted"), entry.ProteinExistence.Type)
+	assert.Equal(t, []KeywordType{{Value: "Protease"}, {Value: "Serine protease"}}, entry.Keyword)
+
+	assert.Equal(t, []FeatureType{
+		{
+			Type:        "sequence variant",
+			Original:    "K",
+			Variation:   []string{"E", "Q"},
+			Description: "in dbSNP:rs1 and a long description that continues",
+			Location:    LocationType{Position: PositionType{Position: 3}},
+		},
+		{
+			Type:      "sequence conflict",
+			Original:  "AG",
+			Variation: []string{"GA"},
+			Location:  LocationType{Begin: PositionType{Position: 5}, End: PositionType{Position: 6}},
+		},
+		{
+			Type:     "region of interest",
+			Location: LocationType{Begin: PositionType{Position: 1, Status: "less than"}, End: PositionType{Status: "unknown"}},
+		},
+		{
+			Type:     "new_key",
+			Location: LocationType{Sequence: "Q00001-2", Begin: PositionType{Position: 4, Status: "uncertain"}, End: PositionType{Position: 12, Status: "greater than"}},
+		},
+	}, entry.Feature)
+
+	assert.Equal(t, "MAKLAGIVKREE", entry.Sequence.Value)
+	assert.Equal(t, 12, entry.Sequence.Length)
+	assert.Equal(t, 1337, entry.Sequence.Mass)
+	assert.Equal(t, "0123456789ABCDEF", entry.Sequence.Checksum)
+
+	_, err = parser.ParseNext()
+	assert.True(t, errors.Is(err, io.EOF))
+
+	parser.Reset(strings.NewReader(datEntry + datEntry))
+	entries, err := parser.ParseAll()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestDatParser_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+		err   string
+	}{
+		{"missing closing line", "ID   TEST_HUMAN Reviewed; 0 AA.\n", "entry starting on line 1 is missing its closing //"},
+		{"missing ID", "AC   Q00001;\n//\n", "entry starting on line 1 is missing its ID line"},
+		{"unknown status", "ID   TEST_HUMAN Maybe; 0 AA.\n//\n", `Error on line 1: unknown entry status "Maybe;"`},
+		{"invalid date", "ID   TEST_HUMAN Reviewed; 0 AA.\nDT   yesterday, entry version 1.\n//\n", `Error on line 2: parsing time "yesterday" as "02-Jan-2006": cannot parse "yesterday" as "02"`},
+		{"invalid taxonomy", "ID   TEST_HUMAN Reviewed; 0 AA.\nOX   TaxID=1;\n//\n", "Error on line 2: expected NCBI_TaxID=<id>, got: TaxID=1;"},
+		{"invalid name", "ID   TEST_HUMAN Reviewed; 0 AA.\nDE   Full=Orphan;\n//\n", `Error on line 2: name "Full" doesn't belong to a RecName, AltName or SubName`},
+		{"invalid gene", "ID   TEST_HUMAN Reviewed; 0 AA.\nGN   Gene=ABC1;\n//\n", `Error on line 2: unknown gene name "Gene=ABC1"`},
+		{"invalid feature", "ID   TEST_HUMAN Reviewed; 0 AA.\nFT   CHAIN           one..5\n//\n", `Error on line 2: invalid feature position "one"`},
+		{"invalid sequence header", "ID   TEST_HUMAN Reviewed; 0 AA.\nSQ   SEQUENCE 0 AA;\n//\n", "Error on line 2: expected SEQUENCE <length> AA; <mass> MW; <checksum> CRC64;, got: SEQUENCE 0 AA;"},
+		{"wrong sequence length", "ID   TEST_HUMAN Reviewed; 3 AA.\nSQ   SEQUENCE   3 AA;  1 MW;  0 CRC64;\n     MA\n//\n", "sequence of TEST_HUMAN has 2 residues, expected 3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewDatParser(strings.NewReader(test.entry), 1024).ParseNext()
+			assert.EqualError(t, err, test.err)
+		})
+	}
+
+	t.Run("line too long", func(t *testing.T) {
+		_, err := NewDatParser(strings.NewReader(datEntry), 16).ParseNext()
+		assert.Contains(t, err.Error(), "line 1 too large for buffer")
+	})
+}
+
+func TestParseDat(t *testing.T) {
+	t.Run("continues after an invalid entry", func(t *testing.T) {
+		entries := make(chan Entry, 100)
+		parserErrors := make(chan error, 100)
+		ParseDat(strings.NewReader("ID   TEST_HUMAN Maybe; 0 AA.\n//\n"+datEntry), entries, parserErrors)
+		parsed, errs := collectEntries(t, entries, parserErrors)
+		assert.Len(t, parsed, 1)
+		assert.Len(t, errs, 1)
+	})
+
+	t.Run("stops on read errors", func(t *testing.T) {
+		entries := make(chan Entry, 100)
+		parserErrors := make(chan error, 100)
+		readErr := errors.New("read error")
+		ParseDat(io.MultiReader(strings.NewReader(datEntry), &errorReader{readErr}), entries, parserErrors)
+		parsed, errs := collectEntries(t, entries, parserErrors)
+		assert.Len(t, parsed, 1)
+		assert.Equal(t, []error{readErr}, errs)
+	})
+}
+
+type errorReader struct {
+	err error
+}
+
+func (reader *errorReader) Read(p []byte) (int, error) {
+	return 0, reader.err
+}
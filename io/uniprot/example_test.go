@@ -19,3 +19,60 @@ func Example_basic() {
 	fmt.Println(entry.Accession[0])
 	// Output: O55723
 }
+
+// This example shows how to read a gzipped Uniprot flat file dump, which
+// fills the same Entry struct as the XML dump.
+func ExampleReadDat() {
+	entries, _, _ := uniprot.ReadDat("data/uniprot_sprot_mini.dat.gz")
+
+	for entry := range entries {
+		fmt.Println(entry.Accession[0], entry.Protein.RecommendedName.FullName.Value)
+	}
+	// Output:
+	// P0C9F0 Protein MGF 100-1R
+	// Q4U9M9 104 kDa microneme/rhoptry antigen
+}
+
+// This example shows how to read a gzipped Uniprot FASTA dump with the fields
+// of the headers split out.
+func ExampleReadFasta() {
+	entries, _, _ := uniprot.ReadFasta("data/uniprot_sprot_mini.fasta.gz")
+
+	for entry := range entries {
+		fmt.Println(entry.Accession, entry.OrganismID, entry.GeneName, len(entry.Sequence))
+	}
+	// Output:
+	// P0C9F0 561445 Ken-018 122
+	// Q4U9M9 5874 TA08425 893
+}
+
+func ExampleParseFastaHeader() {
+	header, _ := uniprot.ParseFastaHeader(">sp|P04637|P53_HUMAN Cellular tumor antigen p53 OS=Homo sapiens OX=9606 GN=TP53 PE=1 SV=4")
+
+	fmt.Println(header.ProteinName)
+	fmt.Println(header.OrganismName, header.OrganismID)
+	fmt.Println(header.GeneName, header.ProteinExistence, header.SequenceVersion)
+	// Output:
+	// Cellular tumor antigen p53
+	// Homo sapiens 9606
+	// TP53 1 4
+}
+
+// This example shows how to decode a Uniprot XML dump on several goroutines,
+// keeping only the entries of a single organism.
+func ExampleReadFiltered() {
+	options := uniprot.ParseOptions{
+		Filter:  uniprot.All(uniprot.TaxonomyID(5874), uniprot.Reviewed(true)),
+		Ordered: true,
+	}
+	results, _ := uniprot.ReadFiltered("data/uniprot_sprot_mini.xml.gz", options)
+
+	for result := range results {
+		if result.Err != nil {
+			fmt.Println(result.Err)
+			continue
+		}
+		fmt.Println(result.Entry.Accession[0], result.Entry.Organism.Name[0].Value)
+	}
+	// Output: Q4U9M9 Theileria annulata
+}
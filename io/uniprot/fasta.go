@@ -0,0 +1,203 @@
+package uniprot
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bebop/poly/io/fasta"
+)
+
+/******************************************************************************
+Oct 16, 2026
+
+FASTA parsing begins here.
+
+The FASTA dumps of Uniprot (uniprot_sprot.fasta.gz and
+uniprot_trembl.fasta.gz) only hold the sequence of every entry, but pack a
+few of its fields into the header(1):
+
+	>db|UniqueIdentifier|EntryName ProteinName OS=OrganismName OX=OrganismIdentifier [GN=GeneName ]PE=ProteinExistence SV=SequenceVersion
+
+db is sp for Swiss-Prot and tr for TrEMBL. The headers of isoforms leave out
+PE and SV.
+
+ParseFasta parses those fields into a FastaEntry, which can be converted into
+an Entry with the few fields that are known.
+
+(1) https://www.uniprot.org/help/fasta-headers
+
+******************************************************************************/
+
+// FastaHeader holds the fields of the header of a Uniprot FASTA sequence.
+type FastaHeader struct {
+	// Database is sp for Swiss-Prot and tr for TrEMBL.
+	Database     string `json:"database"`
+	Accession    string `json:"accession"`
+	EntryName    string `json:"entry_name"`
+	ProteinName  string `json:"protein_name"`
+	OrganismName string `json:"organism_name"`
+	// OrganismID is the NCBI taxonomy identifier of the organism.
+	OrganismID int    `json:"organism_id"`
+	GeneName   string `json:"gene_name"`
+	// ProteinExistence is the level of evidence for the existence of the
+	// protein, from 1 (evidence at protein level) to 5 (uncertain). It is 0
+	// for headers without PE, like those of isoforms.
+	ProteinExistence int `json:"protein_existence"`
+	SequenceVersion  int `json:"sequence_version"`
+}
+
+// FastaEntry is a single sequence of a Uniprot FASTA dump.
+type FastaEntry struct {
+	FastaHeader
+	Sequence string `json:"sequence"`
+}
+
+// proteinExistenceTypes are the protein existence types of the XML dump, by
+// their level.
+var proteinExistenceTypes = []Type{
+	"evidence at protein level",
+	"evidence at transcript level",
+	"inferred from homology",
+	"predicted",
+	"uncertain",
+}
+
+// fastaFieldRegex matches the start of the fields following the protein name
+// of a header.
+var fastaFieldRegex = regexp.MustCompile(` (OS|OX|GN|PE|SV)=`)
+
+// ParseFastaHeader parses the header of a Uniprot FASTA sequence, with or
+// without its leading >.
+func ParseFastaHeader(header string) (FastaHeader, error) {
+	identifier, description, _ := strings.Cut(strings.TrimPrefix(header, ">"), " ")
+	ids := strings.Split(identifier, "|")
+	if len(ids) != 3 {
+		return FastaHeader{}, fmt.Errorf("expected db|UniqueIdentifier|EntryName, got: %s", identifier)
+	}
+	parsed := FastaHeader{Database: ids[0], Accession: ids[1], EntryName: ids[2]}
+
+	// Every field runs up to the start of the next one.
+	fields := fastaFieldRegex.FindAllStringSubmatchIndex(description, -1)
+	end := len(description)
+	if len(fields) > 0 {
+		end = fields[0][0]
+	}
+	parsed.ProteinName = strings.TrimSpace(description[:end])
+	for index, field := range fields {
+		end := len(description)
+		if index+1 < len(fields) {
+			end = fields[index+1][0]
+		}
+		key := description[field[2]:field[3]]
+		value := strings.TrimSpace(description[field[1]:end])
+		var err error
+		switch key {
+		case "OS":
+			parsed.OrganismName = value
+		case "OX":
+			parsed.OrganismID, err = strconv.Atoi(value)
+		case "GN":
+			parsed.GeneName = value
+		case "PE":
+			parsed.ProteinExistence, err = strconv.Atoi(value)
+		case "SV":
+			parsed.SequenceVersion, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return FastaHeader{}, fmt.Errorf("invalid %s=%q in header of %s", key, value, parsed.Accession)
+		}
+	}
+	return parsed, nil
+}
+
+// ReadFasta reads a gzipped Uniprot FASTA dump. Failing to open the dump
+// gives a single error, while errors encountered while parsing the dump are
+// added to the errors channel.
+func ReadFasta(path string) (chan FastaEntry, chan error, error) {
+	entries := make(chan FastaEntry, 100) // if you don't have a buffered channel, nothing will be read in loops on the channel.
+	parserErrors := make(chan error, 100)
+	fastaFile, err := os.Open(path)
+	if err != nil {
+		return entries, parserErrors, err
+	}
+	unzippedBytes, err := gzip.NewReader(fastaFile)
+	if err != nil {
+		fastaFile.Close()
+		return entries, parserErrors, err
+	}
+	go func() {
+		defer fastaFile.Close()
+		ParseFasta(unzippedBytes, entries, parserErrors)
+	}()
+	return entries, parserErrors, nil
+}
+
+// ParseFasta parses the sequences of a Uniprot FASTA dump into a channel.
+// Sequences with a header that fails to parse are skipped and their error is
+// added to the errors channel. Errors reading r stop the parsing.
+func ParseFasta(r io.Reader, entries chan<- FastaEntry, parserErrors chan<- error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	parser := fasta.NewParser(r, maxLineSize)
+	for {
+		record, _, err := parser.ParseNext()
+		// The last sequence comes with an EOF if the dump doesn't end with a
+		// newline.
+		if err != nil && !(errors.Is(err, io.EOF) && record.Name != "") {
+			if !errors.Is(err, io.EOF) {
+				parserErrors <- err
+			}
+			break
+		}
+		header, headerErr := ParseFastaHeader(record.Name)
+		if headerErr != nil {
+			parserErrors <- headerErr
+		} else {
+			entries <- FastaEntry{FastaHeader: header, Sequence: record.Sequence}
+		}
+		if err != nil {
+			break
+		}
+	}
+	close(entries)
+	close(parserErrors)
+}
+
+// Entry converts a FastaEntry into an Entry, filling in the fields known from
+// the header. The protein name is a recommended name for Swiss-Prot entries
+// and a submitted name for TrEMBL entries.
+func (entry FastaEntry) Entry() Entry {
+	converted := Entry{
+		Accession: []string{entry.Accession},
+		Name:      []string{entry.EntryName},
+		Sequence:  SequenceType{Value: entry.Sequence, Length: len(entry.Sequence), Version: entry.SequenceVersion},
+	}
+	proteinName := EvidencedStringType{Value: entry.ProteinName}
+	switch entry.Database {
+	case "sp":
+		converted.Dataset = "Swiss-Prot"
+		converted.Protein.RecommendedName.FullName = proteinName
+	case "tr":
+		converted.Dataset = "TrEMBL"
+		converted.Protein.SubmittedName = []SubmittedName{{FullName: proteinName}}
+	}
+	if entry.GeneName != "" {
+		converted.Gene = []GeneType{{Name: []GeneNameType{{Value: entry.GeneName, Type: "primary"}}}}
+	}
+	if entry.OrganismName != "" {
+		converted.Organism.Name = []OrganismNameType{{Value: entry.OrganismName, Type: "scientific"}}
+	}
+	if entry.OrganismID != 0 {
+		converted.Organism.DbReference = []DbReferenceType{{Type: "NCBI Taxonomy", Id: strconv.Itoa(entry.OrganismID)}}
+	}
+	if entry.ProteinExistence >= 1 && entry.ProteinExistence <= len(proteinExistenceTypes) {
+		converted.ProteinExistence.Type = proteinExistenceTypes[entry.ProteinExistence-1]
+	}
+	return converted
+}
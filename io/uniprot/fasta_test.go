@@ -0,0 +1,145 @@
+package uniprot
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFastaHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   FastaHeader
+		err    string
+	}{
+		{
+			name:   "Swiss-Prot",
+			header: ">sp|P04637|P53_HUMAN Cellular tumor antigen p53 OS=Homo sapiens OX=9606 GN=TP53 PE=1 SV=4",
+			want:   FastaHeader{Database: "sp", Accession: "P04637", EntryName: "P53_HUMAN", ProteinName: "Cellular tumor antigen p53", OrganismName: "Homo sapiens", OrganismID: 9606, GeneName: "TP53", ProteinExistence: 1, SequenceVersion: 4},
+		},
+		{
+			name:   "isoform",
+			header: "sp|P04637-2|P53_HUMAN Isoform 2 of Cellular tumor antigen p53 OS=Homo sapiens OX=9606 GN=TP53",
+			want:   FastaHeader{Database: "sp", Accession: "P04637-2", EntryName: "P53_HUMAN", ProteinName: "Isoform 2 of Cellular tumor antigen p53", OrganismName: "Homo sapiens", OrganismID: 9606, GeneName: "TP53"},
+		},
+		{
+			name:   "TrEMBL without gene",
+			header: ">tr|A0A000|A0A000_9ACTN Uncharacterized protein (Fragment) OS=Streptomyces sp. (strain X) OX=1 PE=4 SV=1",
+			want:   FastaHeader{Database: "tr", Accession: "A0A000", EntryName: "A0A000_9ACTN", ProteinName: "Uncharacterized protein (Fragment)", OrganismName: "Streptomyces sp. (strain X)", OrganismID: 1, ProteinExistence: 4, SequenceVersion: 1},
+		},
+		{
+			name:   "missing identifiers",
+			header: ">P04637 Cellular tumor antigen p53",
+			err:    "expected db|UniqueIdentifier|EntryName, got: P04637",
+		},
+		{
+			name:   "invalid organism identifier",
+			header: ">sp|P04637|P53_HUMAN Cellular tumor antigen p53 OS=Homo sapiens OX=human",
+			err:    `invalid OX="human" in header of P04637`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header, err := ParseFastaHeader(test.header)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, header)
+		})
+	}
+}
+
+func TestReadFasta(t *testing.T) {
+	fastaEntries, parserErrors, err := ReadFasta("data/uniprot_sprot_mini.fasta.gz")
+	assert.NoError(t, err)
+	var parsed []FastaEntry
+	for entry := range fastaEntries {
+		parsed = append(parsed, entry)
+	}
+	for err := range parserErrors {
+		t.Errorf("Failed during parsing with error: %v", err)
+	}
+
+	// The FASTA dump holds the same sequences as the flat file.
+	entries, parserErrors, err := ReadDat("data/uniprot_sprot_mini.dat.gz")
+	assert.NoError(t, err)
+	datEntries, _ := collectEntries(t, entries, parserErrors)
+	assert.Len(t, parsed, len(datEntries))
+	for index, fastaEntry := range parsed {
+		datEntry := datEntries[index]
+		entry := fastaEntry.Entry()
+		assert.Equal(t, datEntry.Accession, entry.Accession)
+		assert.Equal(t, datEntry.Name, entry.Name)
+		assert.Equal(t, datEntry.Dataset, entry.Dataset)
+		assert.Equal(t, datEntry.Protein.RecommendedName.FullName, entry.Protein.RecommendedName.FullName)
+		assert.Equal(t, datEntry.Gene[0].Name[0].Value, entry.Gene[0].Name[0].Value)
+		assert.Equal(t, datEntry.Organism.DbReference, entry.Organism.DbReference)
+		assert.Equal(t, datEntry.ProteinExistence, entry.ProteinExistence)
+		assert.Equal(t, datEntry.Sequence.Value, entry.Sequence.Value)
+		assert.Equal(t, datEntry.Sequence.Version, entry.Sequence.Version)
+	}
+
+	_, _, err = ReadFasta("data/test")
+	assert.Error(t, err, "Failed to fail on non-gzipped file")
+
+	_, _, err = ReadFasta("data/FAKE")
+	assert.Error(t, err, "Failed to fail on missing file")
+}
+
+func TestParseFasta(t *testing.T) {
+	parse := func(r io.Reader) ([]FastaEntry, []error) {
+		entries := make(chan FastaEntry, 100)
+		parserErrors := make(chan error, 100)
+		ParseFasta(r, entries, parserErrors)
+		var parsed []FastaEntry
+		for entry := range entries {
+			parsed = append(parsed, entry)
+		}
+		var errs []error
+		for err := range parserErrors {
+			errs = append(errs, err)
+		}
+		return parsed, errs
+	}
+
+	t.Run("no trailing newline", func(t *testing.T) {
+		parsed, errs := parse(strings.NewReader(">sp|P1|A_HUMAN A OS=Homo sapiens OX=9606\nMA\n>tr|P2|B_HUMAN B OS=Homo sapiens OX=9606\nMK"))
+		assert.Empty(t, errs)
+		assert.Len(t, parsed, 2)
+		assert.Equal(t, "MK", parsed[1].Sequence)
+	})
+
+	t.Run("continues after an invalid header", func(t *testing.T) {
+		parsed, errs := parse(strings.NewReader(">P1 A\nMA\n>tr|P2|B_HUMAN B\nMK\n"))
+		assert.Len(t, errs, 1)
+		assert.Len(t, parsed, 1)
+		assert.Equal(t, "P2", parsed[0].Accession)
+	})
+
+	t.Run("stops on read errors", func(t *testing.T) {
+		readErr := errors.New("read error")
+		parsed, errs := parse(io.MultiReader(strings.NewReader(">sp|P1|A_HUMAN A\nMA\n"), &errorReader{readErr}))
+		assert.Empty(t, parsed)
+		assert.Equal(t, []error{readErr}, errs)
+	})
+}
+
+func TestFastaEntry_Entry(t *testing.T) {
+	entry := FastaEntry{
+		FastaHeader: FastaHeader{Database: "tr", Accession: "A0A000", EntryName: "A0A000_9ACTN", ProteinName: "Uncharacterized protein", ProteinExistence: 4},
+		Sequence:    "MAK",
+	}.Entry()
+	assert.Equal(t, Dataset("TrEMBL"), entry.Dataset)
+	assert.Equal(t, []SubmittedName{{FullName: EvidencedStringType{Value: "Uncharacterized protein"}}}, entry.Protein.SubmittedName)
+	assert.Equal(t, Type("predicted"), entry.ProteinExistence.Type)
+	assert.Equal(t, 3, entry.Sequence.Length)
+	assert.Nil(t, entry.Gene)
+	assert.Nil(t, entry.Organism.Name)
+	assert.Nil(t, entry.Organism.DbReference)
+}
@@ -0,0 +1,104 @@
+package uniprot
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Filter reports whether an entry should be kept. Filters can be combined
+// with All, Any and Not.
+type Filter func(Entry) bool
+
+// TaxonomyID keeps entries of organisms with one of the NCBI taxonomy
+// identifiers, like 9606 for Homo sapiens.
+func TaxonomyID(ids ...int) Filter {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[strconv.Itoa(id)] = true
+	}
+	return func(entry Entry) bool {
+		for _, reference := range entry.Organism.DbReference {
+			if reference.Type == "NCBI Taxonomy" && wanted[reference.Id] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasKeyword keeps entries with at least one of the keywords, like
+// "Reference proteome". Keywords are compared case-insensitively.
+func HasKeyword(keywords ...string) Filter {
+	return func(entry Entry) bool {
+		for _, keyword := range entry.Keyword {
+			for _, wanted := range keywords {
+				if strings.EqualFold(keyword.Value, wanted) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// HasFeature keeps entries with at least one feature of one of the types,
+// like "signal peptide" or "transmembrane region".
+func HasFeature(featureTypes ...Type) Filter {
+	return func(entry Entry) bool {
+		for _, feature := range entry.Feature {
+			for _, wanted := range featureTypes {
+				if feature.Type == wanted {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// SequenceLength keeps entries with a sequence of minimum up to and including
+// maximum residues.
+func SequenceLength(minimum, maximum int) Filter {
+	return func(entry Entry) bool {
+		return minimum <= entry.Sequence.Length && entry.Sequence.Length <= maximum
+	}
+}
+
+// Reviewed keeps the reviewed Swiss-Prot entries if reviewed is true, and the
+// unreviewed TrEMBL entries otherwise.
+func Reviewed(reviewed bool) Filter {
+	return func(entry Entry) bool {
+		return (entry.Dataset == "Swiss-Prot") == reviewed
+	}
+}
+
+// All keeps entries that are kept by all filters.
+func All(filters ...Filter) Filter {
+	return func(entry Entry) bool {
+		for _, filter := range filters {
+			if !filter(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any keeps entries that are kept by at least one of the filters.
+func Any(filters ...Filter) Filter {
+	return func(entry Entry) bool {
+		for _, filter := range filters {
+			if filter(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not keeps entries that are not kept by filter.
+func Not(filter Filter) Filter {
+	return func(entry Entry) bool {
+		return !filter(entry)
+	}
+}
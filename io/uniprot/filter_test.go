@@ -0,0 +1,51 @@
+package uniprot
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilters(t *testing.T) {
+	entry := Entry{
+		Dataset:  "Swiss-Prot",
+		Organism: OrganismType{DbReference: []DbReferenceType{{Type: "NCBI Taxonomy", Id: "9606"}}},
+		Keyword:  []KeywordType{{Value: "Reference proteome"}, {Value: "Signal"}},
+		Feature:  []FeatureType{{Type: "chain"}, {Type: "signal peptide"}},
+		Sequence: SequenceType{Length: 393},
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		keep   bool
+	}{
+		{"taxonomy ID", TaxonomyID(10090, 9606), true},
+		{"other taxonomy ID", TaxonomyID(10090), false},
+		{"keyword", HasKeyword("signal"), true},
+		{"missing keyword", HasKeyword("Membrane"), false},
+		{"feature", HasFeature("signal peptide"), true},
+		{"missing feature", HasFeature("transmembrane region"), false},
+		{"sequence length", SequenceLength(393, 393), true},
+		{"sequence length without maximum", SequenceLength(100, math.MaxInt), true},
+		{"sequence too short", SequenceLength(400, 500), false},
+		{"reviewed", Reviewed(true), true},
+		{"unreviewed", Reviewed(false), false},
+		{"all", All(TaxonomyID(9606), HasKeyword("Signal")), true},
+		{"not all", All(TaxonomyID(9606), HasKeyword("Membrane")), false},
+		{"all without filters", All(), true},
+		{"any", Any(TaxonomyID(10090), HasKeyword("Signal")), true},
+		{"not any", Any(TaxonomyID(10090), HasKeyword("Membrane")), false},
+		{"not", Not(Reviewed(true)), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.keep, test.filter(entry))
+		})
+	}
+
+	t.Run("host taxonomy ID", func(t *testing.T) {
+		virus := Entry{OrganismHost: []OrganismType{{DbReference: []DbReferenceType{{Type: "NCBI Taxonomy", Id: "9606"}}}}}
+		assert.False(t, TaxonomyID(9606)(virus))
+	})
+}
@@ -0,0 +1,185 @@
+package uniprot
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readMiniDump reads the entries of the test dump with Parse.
+func readMiniDump(t *testing.T) []Entry {
+	t.Helper()
+	entries, decoderErrors, err := Read("data/uniprot_sprot_mini.xml.gz")
+	assert.NoError(t, err)
+	var parsed []Entry
+	for entry := range entries {
+		parsed = append(parsed, entry)
+	}
+	for err := range decoderErrors {
+		t.Errorf("Failed during parsing with error: %v", err)
+	}
+	return parsed
+}
+
+// collectResults reads all results of ParseFiltered.
+func collectResults(r io.Reader, options ParseOptions) []Result {
+	results := make(chan Result, 100)
+	go ParseFiltered(r, results, options)
+	var collected []Result
+	for result := range results {
+		collected = append(collected, result)
+	}
+	return collected
+}
+
+func TestReadFiltered(t *testing.T) {
+	expected := readMiniDump(t)
+
+	t.Run("ordered", func(t *testing.T) {
+		results, err := ReadFiltered("data/uniprot_sprot_mini.xml.gz", ParseOptions{Workers: 4, Ordered: true})
+		assert.NoError(t, err)
+		var entries []Entry
+		for result := range results {
+			assert.NoError(t, result.Err)
+			entries = append(entries, result.Entry)
+		}
+		assert.Equal(t, expected, entries)
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		results, err := ReadFiltered("data/uniprot_sprot_mini.xml.gz", ParseOptions{Workers: 4})
+		assert.NoError(t, err)
+		var entries []Entry
+		for result := range results {
+			assert.NoError(t, result.Err)
+			entries = append(entries, result.Entry)
+		}
+		assert.ElementsMatch(t, expected, entries)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		filter := Any(TaxonomyID(561445), HasKeyword("Signal"))
+		var want []Entry
+		for _, entry := range expected {
+			if filter(entry) {
+				want = append(want, entry)
+			}
+		}
+		assert.NotEmpty(t, want)
+		assert.Less(t, len(want), len(expected))
+
+		results, err := ReadFiltered("data/uniprot_sprot_mini.xml.gz", ParseOptions{Filter: filter, Ordered: true})
+		assert.NoError(t, err)
+		var entries []Entry
+		for result := range results {
+			entries = append(entries, result.Entry)
+		}
+		assert.Equal(t, want, entries)
+	})
+
+	_, err := ReadFiltered("data/test", ParseOptions{})
+	assert.Error(t, err, "Failed to fail on non-gzipped file")
+
+	_, err = ReadFiltered("data/FAKE", ParseOptions{})
+	assert.Error(t, err, "Failed to fail on missing file")
+}
+
+const brokenDump = `<?xml version="1.0" encoding="UTF-8"?>
+<uniprot xmlns="http://uniprot.org/uniprot">
+<entry dataset="Swiss-Prot" created="2009-05-05" modified="2020-08-12" version="9">
+  <accession>P00001</accession>
+  <name>FIRST_HUMAN</name>
+</entry>
+<entry dataset="Swiss-Prot" created="2009-05-05" modified="2020-08-12" version="9">
+  <accession>P00002</accession>
+  <sequence length="many">MAK</sequence>
+</entry>
+<entry dataset="Swiss-Prot" created="2009-05-05" modified="2020-08-12" version="9">
+  <accession>P00003</accession>
+  <name>THIRD_HUMAN</name>
+</entry>
+<entry dataset="TrEMBL" created="2009-05-05" modified="2020-08-12" version="9">
+  <accession>P00004</accession>
+  <name>FOURTH_HUMAN</name>
+`
+
+func TestParseFiltered(t *testing.T) {
+	t.Run("errors carry the accession", func(t *testing.T) {
+		results := collectResults(strings.NewReader(brokenDump), ParseOptions{Workers: 2, Ordered: true})
+		assert.Len(t, results, 4)
+
+		// Entries without their own namespace still decode.
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, []string{"FIRST_HUMAN"}, results[0].Entry.Name)
+
+		var entryErr *EntryError
+		assert.True(t, errors.As(results[1].Err, &entryErr))
+		assert.Equal(t, "P00002", entryErr.Accession)
+		assert.Equal(t, 1, entryErr.Index)
+		assert.Contains(t, results[1].Err.Error(), "entry P00002: ")
+
+		assert.NoError(t, results[2].Err)
+		assert.Equal(t, []string{"THIRD_HUMAN"}, results[2].Entry.Name)
+
+		assert.True(t, errors.As(results[3].Err, &entryErr))
+		assert.Equal(t, "P00004", entryErr.Accession)
+		assert.True(t, errors.Is(results[3].Err, io.ErrUnexpectedEOF))
+	})
+
+	t.Run("errors pass filters", func(t *testing.T) {
+		results := collectResults(strings.NewReader(brokenDump), ParseOptions{Filter: Reviewed(false), Ordered: true})
+		assert.Len(t, results, 2)
+		assert.Error(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+	})
+
+	t.Run("read errors stop parsing", func(t *testing.T) {
+		readErr := errors.New("read error")
+		results := collectResults(io.MultiReader(strings.NewReader(brokenDump[:strings.Index(brokenDump, "<entry")]), &errorReader{readErr}), ParseOptions{})
+		assert.Equal(t, []Result{{Err: readErr}}, results)
+	})
+
+	t.Run("read errors within an entry", func(t *testing.T) {
+		readErr := errors.New("read error")
+		results := collectResults(io.MultiReader(strings.NewReader(brokenDump[:strings.Index(brokenDump, "<name>")]), &errorReader{readErr}), ParseOptions{})
+		assert.Len(t, results, 1)
+		assert.EqualError(t, results[0].Err, "entry P00001: read error")
+	})
+
+	t.Run("empty dump", func(t *testing.T) {
+		assert.Empty(t, collectResults(strings.NewReader(""), ParseOptions{}))
+	})
+}
+
+func TestEntryError(t *testing.T) {
+	err := &EntryError{Index: 3, Err: io.ErrUnexpectedEOF}
+	assert.EqualError(t, err, "entry 3: unexpected EOF")
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		xmlFile, _ := os.Open("data/uniprot_sprot_mini.xml.gz")
+		unzippedBytes, _ := gzip.NewReader(xmlFile)
+		entries := make(chan Entry, 100)
+		decoderErrors := make(chan error, 100)
+		go Parse(xml.NewDecoder(unzippedBytes), entries, decoderErrors)
+		for range entries {
+		}
+		xmlFile.Close()
+	}
+}
+
+func BenchmarkParseFiltered(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		results, _ := ReadFiltered("data/uniprot_sprot_mini.xml.gz", ParseOptions{})
+		for range results {
+		}
+	}
+}
@@ -1,12 +1,12 @@
 /*
-Package uniprot provides an XML parser for Uniprot data dumps.
+Package uniprot provides XML, flat file and FASTA parsers for Uniprot data dumps.
 
 Uniprot is comprehensive, high-quality and freely accessible resource of protein
 sequence and functional information. It is the best(1) protein database out there.
 
-Uniprot database dumps are available as gzipped FASTA files or gzipped XML files.
-The XML files have significantly more information than the FASTA files, and this
-parser specifically works on the gzipped XML files from Uniprot.
+Uniprot database dumps are available as gzipped FASTA files, gzipped flat
+files (.dat) or gzipped XML files. The XML and flat files have significantly
+more information than the FASTA files.
 
 Uniprot provides an XML schema of their data dumps(3), which is useful for
 autogeneration of Golang structs. xsdgen was used to automatically generate
@@ -17,6 +17,17 @@ Each protein in Uniprot is known as an "Entry" (as defined in xml.go).
 The function Parse stream-reads Uniprot into an Entry channel, from which you
 can use the entries however you want. Read simplifies reading gzipped files
 from a disk into an Entry channel.
+
+ParseFiltered and ReadFiltered decode the entries of XML files on several
+goroutines, keeping only the entries that pass a Filter, like TaxonomyID or
+HasKeyword. Errors of single entries come with their accession.
+
+ParseDat and ReadDat do the same as Parse and Read for flat files, which are
+faster to parse than the XML files and fill the same Entry struct. DatParser
+reads flat files one entry at a time.
+
+ParseFasta and ReadFasta read the FASTA files into FastaEntry channels, with
+the fields of the Uniprot FASTA headers (OS, OX, GN, PE and SV) split out.
 */
 package uniprot
 
@@ -129,6 +129,7 @@ type DbReferenceType struct {
 	Molecule string         `xml:"http://uniprot.org/uniprot molecule,omitempty"`
 	Property []PropertyType `xml:"http://uniprot.org/uniprot property,omitempty"`
 	Type     string         `xml:"type,attr"`
+	Id       string         `xml:"id,attr"`
 	Evidence IntListType    `xml:"evidence,attr,omitempty"`
 }
 
@@ -213,6 +214,7 @@ type FeatureType struct {
 	Variation   []string     `xml:"http://uniprot.org/uniprot variation,omitempty"`
 	Location    LocationType `xml:"http://uniprot.org/uniprot location"`
 	Type        Type         `xml:"type,attr"`
+	Id          string       `xml:"id,attr,omitempty"`
 	Description string       `xml:"description,attr,omitempty"`
 	Evidence    IntListType  `xml:"evidence,attr,omitempty"`
 }
@@ -0,0 +1,65 @@
+package vcf_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bebop/poly/io/vcf"
+)
+
+// ExampleRead shows basic usage for Read.
+func ExampleRead() {
+	header, records, _ := vcf.Read("data/example.vcf")
+	fmt.Println(header.Samples)
+	fmt.Println(records[2].Position, records[2].Alleles())
+	//Output:
+	//[NA00001 NA00002 NA00003]
+	//1110696 [A G T]
+}
+
+func ExampleParser() {
+	file, _ := os.Open("data/example.vcf")
+	defer file.Close()
+	parser, header, _ := vcf.NewParser(file, 2*32*1024)
+	for {
+		record, err := parser.ParseNext()
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		genotype, _ := record.Genotype(0)
+		fmt.Println(record.Position, header.Samples[0], genotype)
+	}
+	//Output:
+	//14370 NA00001 0|0
+	//17330 NA00001 0|0
+	//1110696 NA00001 1|2
+	//1230237 NA00001 0|0
+	//1234567 NA00001 0/1
+	//EOF
+}
+
+// ExampleBuild shows how to build a VCF file from scratch.
+func ExampleBuild() {
+	header := vcf.Header{
+		MetaInformation: []vcf.MetaInformation{
+			{Key: "INFO", Fields: []vcf.MetaField{{Key: "ID", Value: "DP"}, {Key: "Number", Value: "1"}, {Key: "Type", Value: "Integer"}, {Key: "Description", Value: "Total Depth"}}},
+		},
+	}
+	record := vcf.Record{
+		Chromosome: "pOpen_v3",
+		Position:   42,
+		Reference:  "G",
+		Alternates: []string{"A"},
+		Filters:    []string{"PASS"},
+		Info:       []vcf.InfoField{{Key: "DP", Values: []string{"120"}}},
+	}
+	vcfBytes, _ := vcf.Build(header, []vcf.Record{record})
+	fmt.Print(strings.ReplaceAll(string(vcfBytes), "\t", " "))
+	//Output:
+	//##fileformat=VCFv4.3
+	//##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">
+	//#CHROM POS ID REF ALT QUAL FILTER INFO
+	//pOpen_v3 42 . G A . PASS DP=120
+}
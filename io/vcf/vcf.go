@@ -0,0 +1,694 @@
+/*
+Package vcf contains VCF parsers and writers.
+
+VCF (Variant Call Format) is a text file format for storing variants, like
+SNPs and indels, found by comparing sequencing data against a reference
+sequence. It is the de facto standard for exchanging variant calls.
+
+A VCF file starts with meta-information lines (starting with ##), which define
+things like the INFO and FORMAT fields used in the file. They are followed by a
+header line (starting with #CHROM) naming the columns and samples, and then one
+tab separated line per variant:
+
+	```
+	##fileformat=VCFv4.3
+	##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">
+	##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">
+	#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	NA00001
+	20	14370	rs6054257	G	A	29	PASS	DP=14	GT	0|1
+	```
+
+	1.  CHROM: The reference sequence identifier
+	2.  POS: Position of the variant in the reference sequence (indexed at 1)
+	3.  ID: Semicolon separated identifiers of the variant
+	4.  REF: Reference base(s)
+	5.  ALT: Comma separated alternate alleles
+	6.  QUAL: Phred scaled quality of the ALT alleles
+	7.  FILTER: PASS, or semicolon separated filters that failed
+	8.  INFO: Semicolon separated key=value pairs describing the variant
+	9.  FORMAT: Colon separated keys of the sample columns
+	10. Samples: Colon separated values of each sample, like its genotype
+
+The specification can be found here: https://samtools.github.io/hts-specs/VCFv4.3.pdf
+
+This package provides a parser and writer for VCF 4.x files.
+*/
+package vcf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// missingValue is used by VCF for any missing value.
+const missingValue = "."
+
+// requiredColumns are the columns every VCF header line starts with.
+var requiredColumns = []string{"#CHROM", "POS", "ID", "REF", "ALT", "QUAL", "FILTER", "INFO"}
+
+// MetaField is a single key=value pair of a structured meta-information line.
+type MetaField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// MetaInformation is a single ## line of the header. Lines like
+// ##source=myProgram store their value in Value, structured lines like
+// ##INFO=<ID=DP,...> store their fields in order in Fields.
+type MetaInformation struct {
+	Key    string      `json:"key"`
+	Value  string      `json:"value"`
+	Fields []MetaField `json:"fields"`
+}
+
+// Get returns the value of a field of a structured meta-information line.
+func (meta MetaInformation) Get(key string) (string, bool) {
+	for _, field := range meta.Fields {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// Definition describes an INFO or FORMAT field.
+//
+// Number is the amount of values of the field: an integer, A (one per
+// alternate allele), R (one per allele, including the reference), G (one
+// per possible genotype) or . (unknown). Type is one of Integer, Float, Flag,
+// Character or String.
+type Definition struct {
+	ID          string `json:"id"`
+	Number      string `json:"number"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// Header is the header of a VCF file.
+type Header struct {
+	FileFormat      string            `json:"file_format"` // like VCFv4.3
+	MetaInformation []MetaInformation `json:"meta_information"`
+	Samples         []string          `json:"samples"`
+}
+
+// Info returns the definition of an INFO field.
+func (header Header) Info(id string) (Definition, bool) {
+	return header.definition("INFO", id)
+}
+
+// Format returns the definition of a FORMAT field.
+func (header Header) Format(id string) (Definition, bool) {
+	return header.definition("FORMAT", id)
+}
+
+func (header Header) definition(key string, id string) (Definition, bool) {
+	for _, meta := range header.MetaInformation {
+		if metaID, _ := meta.Get("ID"); meta.Key == key && metaID == id {
+			return newDefinition(meta), true
+		}
+	}
+	return Definition{}, false
+}
+
+func newDefinition(meta MetaInformation) Definition {
+	var definition Definition
+	definition.ID, _ = meta.Get("ID")
+	definition.Number, _ = meta.Get("Number")
+	definition.Type, _ = meta.Get("Type")
+	definition.Description, _ = meta.Get("Description")
+	return definition
+}
+
+// InfoField is a single key=value pair of the INFO column. Flags have no Values.
+type InfoField struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// Record is a single variant of a VCF file. Missing values (.) are stored as
+// empty slices, except inside Samples where they are kept as ".".
+type Record struct {
+	Chromosome string      `json:"chromosome"`
+	Position   int         `json:"position"` // 1-based
+	IDs        []string    `json:"ids"`
+	Reference  string      `json:"reference"`
+	Alternates []string    `json:"alternates"`
+	Quality    float64     `json:"quality"`
+	HasQuality bool        `json:"has_quality"` // false if QUAL is missing
+	Filters    []string    `json:"filters"`     // PASS, or the filters that failed
+	Info       []InfoField `json:"info"`
+	Format     []string    `json:"format"`
+	Samples    [][]string  `json:"samples"` // the values of every sample, in Format order. Trailing values may be dropped.
+}
+
+// Alleles returns the reference allele followed by the alternate alleles, so
+// that genotype allele numbers can be used to index it.
+func (record Record) Alleles() []string {
+	return append([]string{record.Reference}, record.Alternates...)
+}
+
+// GetInfo returns the values of an INFO field, and whether it exists.
+func (record Record) GetInfo(key string) ([]string, bool) {
+	for _, info := range record.Info {
+		if info.Key == key {
+			return info.Values, true
+		}
+	}
+	return nil, false
+}
+
+// SampleValue returns the value of a FORMAT key for a sample. Values dropped
+// at the end of a sample column are returned as missing (.).
+func (record Record) SampleValue(sampleIndex int, key string) (string, bool) {
+	if sampleIndex < 0 || sampleIndex >= len(record.Samples) {
+		return "", false
+	}
+	for formatIndex, formatKey := range record.Format {
+		if formatKey != key {
+			continue
+		}
+		if formatIndex >= len(record.Samples[sampleIndex]) {
+			return missingValue, true
+		}
+		return record.Samples[sampleIndex][formatIndex], true
+	}
+	return "", false
+}
+
+// Genotype returns the parsed GT value of a sample.
+func (record Record) Genotype(sampleIndex int) (Genotype, error) {
+	value, ok := record.SampleValue(sampleIndex, "GT")
+	if !ok {
+		return Genotype{}, fmt.Errorf("sample %d of record %s:%d has no GT value", sampleIndex, record.Chromosome, record.Position)
+	}
+	return ParseGenotype(value)
+}
+
+// Genotype is a called genotype, like 0|1. Alleles are indexes into the
+// Record's Alleles, with -1 for missing alleles.
+type Genotype struct {
+	Alleles []int `json:"alleles"`
+	Phased  bool  `json:"phased"`
+}
+
+// ParseGenotype parses a GT value, like 0/1, 1|2 or ./.
+func ParseGenotype(value string) (Genotype, error) {
+	var genotype Genotype
+	separators := 0
+	for _, separator := range []string{"/", "|"} {
+		if strings.Contains(value, separator) {
+			separators++
+			genotype.Phased = separator == "|"
+		}
+	}
+	if separators > 1 {
+		// Mixed phasing, like 0/1|2, is allowed by the spec but rare. The
+		// genotype is only considered phased if all alleles are.
+		genotype.Phased = false
+	}
+	for _, allele := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '|' }) {
+		if allele == missingValue {
+			genotype.Alleles = append(genotype.Alleles, -1)
+			continue
+		}
+		alleleIndex, err := strconv.Atoi(allele)
+		if err != nil || alleleIndex < 0 {
+			return Genotype{}, fmt.Errorf("invalid genotype '%s'", value)
+		}
+		genotype.Alleles = append(genotype.Alleles, alleleIndex)
+	}
+	if len(genotype.Alleles) == 0 {
+		return Genotype{}, fmt.Errorf("invalid genotype '%s'", value)
+	}
+	return genotype, nil
+}
+
+// String returns the genotype in GT format.
+func (genotype Genotype) String() string {
+	separator := "/"
+	if genotype.Phased {
+		separator = "|"
+	}
+	alleles := make([]string, len(genotype.Alleles))
+	for index, allele := range genotype.Alleles {
+		alleles[index] = missingValue
+		if allele >= 0 {
+			alleles[index] = strconv.Itoa(allele)
+		}
+	}
+	return strings.Join(alleles, separator)
+}
+
+// Parse parses a VCF file into a Header and a list of Records.
+func Parse(r io.Reader) (Header, []Record, error) {
+	// 32kB is a magic number often used by the Go stdlib for parsing. We multiply it by two.
+	const maxLineSize = 2 * 32 * 1024
+	parser, header, err := NewParser(r, maxLineSize)
+	if err != nil {
+		return header, nil, err
+	}
+	records, err := parser.ParseAll()
+	return header, records, err
+}
+
+// Parser is a flexible parser that provides ample
+// control over reading VCF records.
+// It is initialized with NewParser.
+type Parser struct {
+	// reader keeps state of current reader.
+	reader  bufio.Reader
+	line    uint
+	samples int
+	info    map[string]Definition
+	format  map[string]Definition
+}
+
+// NewParser parses the header of a VCF file and returns a Parser for its records.
+func NewParser(r io.Reader, maxLineSize int) (*Parser, Header, error) {
+	parser := &Parser{
+		reader: *bufio.NewReaderSize(r, maxLineSize),
+		info:   make(map[string]Definition),
+		format: make(map[string]Definition),
+	}
+	var header Header
+	for {
+		line, err := parser.readLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("VCF file ended before the #CHROM header line")
+			}
+			return parser, Header{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+		}
+		if parser.line == 1 {
+			fileFormat, found := strings.CutPrefix(line, "##fileformat=")
+			if !found {
+				return parser, Header{}, fmt.Errorf("Error on line 1: VCF files must start with ##fileformat. Got: %s", line)
+			}
+			header.FileFormat = fileFormat
+			continue
+		}
+		if strings.HasPrefix(line, "##") {
+			meta, err := parseMetaInformation(line)
+			if err != nil {
+				return parser, Header{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+			}
+			switch meta.Key {
+			case "INFO":
+				parser.info[newDefinition(meta).ID] = newDefinition(meta)
+			case "FORMAT":
+				parser.format[newDefinition(meta).ID] = newDefinition(meta)
+			}
+			header.MetaInformation = append(header.MetaInformation, meta)
+			continue
+		}
+		columns := strings.Split(line, "\t")
+		if len(columns) < len(requiredColumns) || strings.Join(columns[:len(requiredColumns)], "\t") != strings.Join(requiredColumns, "\t") {
+			return parser, Header{}, fmt.Errorf("Error on line %d: expected header line starting with %s. Got: %s", parser.line, strings.Join(requiredColumns, " "), line)
+		}
+		if len(columns) > len(requiredColumns) {
+			if columns[len(requiredColumns)] != "FORMAT" {
+				return parser, Header{}, fmt.Errorf("Error on line %d: expected FORMAT column after INFO. Got: %s", parser.line, columns[len(requiredColumns)])
+			}
+			header.Samples = columns[len(requiredColumns)+1:]
+		}
+		parser.samples = len(header.Samples)
+		return parser, header, nil
+	}
+}
+
+// readLine reads a single line without its line ending.
+func (parser *Parser) readLine() (string, error) {
+	if _, err := parser.reader.Peek(1); err != nil {
+		return "", err
+	}
+	lineBytes, err := parser.reader.ReadSlice('\n')
+	parser.line++
+	if err != nil && !errors.Is(err, io.EOF) {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return "", fmt.Errorf("line %d too large for buffer, use larger maxLineSize: %w", parser.line, err)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(lineBytes), "\r\n"), nil
+}
+
+// parseMetaInformation parses a ## line.
+func parseMetaInformation(line string) (MetaInformation, error) {
+	key, value, found := strings.Cut(line[2:], "=")
+	if !found {
+		return MetaInformation{}, fmt.Errorf("meta-information line is not in ##key=value format: %s", line)
+	}
+	meta := MetaInformation{Key: key}
+	if !strings.HasPrefix(value, "<") || !strings.HasSuffix(value, ">") {
+		meta.Value = value
+		return meta, nil
+	}
+	value = value[1 : len(value)-1]
+	for len(value) > 0 {
+		fieldKey, rest, found := strings.Cut(value, "=")
+		if !found {
+			return MetaInformation{}, fmt.Errorf("structured meta-information field is not in key=value format: %s", value)
+		}
+		var fieldValue string
+		if strings.HasPrefix(rest, `"`) {
+			// Quoted values may contain commas and escaped quotes.
+			var unquoted strings.Builder
+			index := 1
+			for ; index < len(rest) && rest[index] != '"'; index++ {
+				if rest[index] == '\\' && index+1 < len(rest) {
+					index++
+				}
+				unquoted.WriteByte(rest[index])
+			}
+			if index == len(rest) {
+				return MetaInformation{}, fmt.Errorf("unterminated quote in meta-information line: %s", line)
+			}
+			fieldValue = unquoted.String()
+			rest = rest[index+1:]
+		} else {
+			fieldValue, rest, _ = strings.Cut(rest, ",")
+			rest = "," + rest
+		}
+		meta.Fields = append(meta.Fields, MetaField{Key: fieldKey, Value: fieldValue})
+		if len(rest) > 0 && rest[0] != ',' {
+			return MetaInformation{}, fmt.Errorf("expected comma after %s in meta-information line: %s", fieldKey, line)
+		}
+		value = strings.TrimPrefix(rest, ",")
+	}
+	return meta, nil
+}
+
+// ParseAll parses all records in underlying reader only returning non-EOF errors.
+// It returns all valid records up to error if encountered.
+func (parser *Parser) ParseAll() ([]Record, error) {
+	return parser.ParseN(math.MaxInt)
+}
+
+// ParseN parses up to maxRecords records from the Parser's underlying reader.
+// ParseN does not return EOF if encountered.
+// If an non-EOF error is encountered it returns it and all correctly parsed records up to then.
+func (parser *Parser) ParseN(maxRecords int) (records []Record, err error) {
+	for counter := 0; counter < maxRecords; counter++ {
+		record, err := parser.ParseNext()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // EOF not treated as parsing error.
+			}
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ParseNext parses the next record from the Parser's underlying reader.
+// ParseNext returns an EOF if encountered.
+//
+// INFO and FORMAT values are checked against their definitions in the header.
+// Fields without a definition are accepted as is.
+func (parser *Parser) ParseNext() (Record, error) {
+	var line string
+	var err error
+	for line == "" {
+		if line, err = parser.readLine(); err != nil {
+			return Record{}, err
+		}
+	}
+	record, err := parser.parseRecord(line)
+	if err != nil {
+		return Record{}, fmt.Errorf("Error on line %d: %w", parser.line, err)
+	}
+	return record, nil
+}
+
+// parseRecord parses a single record line.
+func (parser *Parser) parseRecord(line string) (Record, error) {
+	values := strings.Split(line, "\t")
+	expectedValues := len(requiredColumns)
+	if parser.samples > 0 {
+		expectedValues += 1 + parser.samples
+	}
+	if len(values) != expectedValues {
+		return Record{}, fmt.Errorf("Got %d values, expected %d.", len(values), expectedValues)
+	}
+	var record Record
+	var err error
+	record.Chromosome = values[0]
+	if record.Position, err = strconv.Atoi(values[1]); err != nil {
+		return Record{}, fmt.Errorf("Failed to convert POS '%s': %w", values[1], err)
+	}
+	record.IDs = splitMissing(values[2], ";")
+	record.Reference = values[3]
+	record.Alternates = splitMissing(values[4], ",")
+	if values[5] != missingValue {
+		if record.Quality, err = strconv.ParseFloat(values[5], 64); err != nil {
+			return Record{}, fmt.Errorf("Failed to convert QUAL '%s': %w", values[5], err)
+		}
+		record.HasQuality = true
+	}
+	record.Filters = splitMissing(values[6], ";")
+	for _, infoString := range splitMissing(values[7], ";") {
+		key, value, found := strings.Cut(infoString, "=")
+		info := InfoField{Key: key}
+		if found {
+			info.Values = strings.Split(value, ",")
+		}
+		if definition, ok := parser.info[key]; ok {
+			if err = checkValues(definition, info.Values, len(record.Alternates)); err != nil {
+				return Record{}, fmt.Errorf("INFO field %s: %w", key, err)
+			}
+		}
+		record.Info = append(record.Info, info)
+	}
+	if parser.samples == 0 {
+		return record, nil
+	}
+	record.Format = splitMissing(values[8], ":")
+	for sampleIndex, sampleString := range values[9:] {
+		sample := strings.Split(sampleString, ":")
+		if len(sample) > len(record.Format) {
+			return Record{}, fmt.Errorf("sample %d has %d values, but FORMAT only has %d keys", sampleIndex+1, len(sample), len(record.Format))
+		}
+		for valueIndex, value := range sample {
+			definition, ok := parser.format[record.Format[valueIndex]]
+			if !ok || value == missingValue {
+				continue
+			}
+			if err = checkValues(definition, strings.Split(value, ","), len(record.Alternates)); err != nil {
+				return Record{}, fmt.Errorf("FORMAT field %s of sample %d: %w", definition.ID, sampleIndex+1, err)
+			}
+		}
+		record.Samples = append(record.Samples, sample)
+	}
+	return record, nil
+}
+
+// splitMissing splits a value, returning nil if the value is missing.
+func splitMissing(value string, separator string) []string {
+	if value == missingValue || value == "" {
+		return nil
+	}
+	return strings.Split(value, separator)
+}
+
+// checkValues checks the amount and type of the values of an INFO or FORMAT field.
+// Number=G is not checked, since it depends on the ploidy.
+func checkValues(definition Definition, values []string, alternates int) error {
+	if definition.Type == "Flag" {
+		if len(values) != 0 {
+			return errors.New("Flag must not have a value")
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%s must have a value", definition.Type)
+	}
+	expected := -1
+	switch definition.Number {
+	case "A":
+		expected = alternates
+	case "R":
+		expected = alternates + 1
+	case "G", missingValue:
+	default:
+		if number, err := strconv.Atoi(definition.Number); err == nil {
+			expected = number
+		}
+	}
+	// A single . means that the whole field is missing.
+	if expected != -1 && len(values) != expected && !(len(values) == 1 && values[0] == missingValue) {
+		return fmt.Errorf("expected %d values, got %d", expected, len(values))
+	}
+	for _, value := range values {
+		if value == missingValue {
+			continue
+		}
+		var err error
+		switch definition.Type {
+		case "Integer":
+			_, err = strconv.Atoi(value)
+		case "Float":
+			_, err = strconv.ParseFloat(value, 64)
+		case "Character":
+			if len(value) != 1 {
+				err = fmt.Errorf("'%s' is not a single character", value)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s value: %w", definition.Type, err)
+		}
+	}
+	return nil
+}
+
+/******************************************************************************
+
+Start of  Read functions
+
+******************************************************************************/
+
+// Read reads a VCF file into a Header and a list of Records.
+func Read(path string) (Header, []Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+/******************************************************************************
+
+Start of  Write functions
+
+******************************************************************************/
+
+// Writer writes records in the VCF format.
+// It is initialized with NewWriter.
+type Writer struct {
+	writer  io.Writer
+	samples int
+}
+
+// NewWriter writes the header to w and returns a Writer for the records.
+// If the header has no FileFormat, VCFv4.3 is written.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	var headerBuffer bytes.Buffer
+	fileFormat := header.FileFormat
+	if fileFormat == "" {
+		fileFormat = "VCFv4.3"
+	}
+	headerBuffer.WriteString("##fileformat=" + fileFormat + "\n")
+	for _, meta := range header.MetaInformation {
+		headerBuffer.WriteString("##" + meta.Key + "=")
+		if meta.Fields == nil {
+			headerBuffer.WriteString(meta.Value + "\n")
+			continue
+		}
+		fields := make([]string, len(meta.Fields))
+		for fieldIndex, field := range meta.Fields {
+			fields[fieldIndex] = field.Key + "=" + quoteMetaValue(field.Key, field.Value)
+		}
+		headerBuffer.WriteString("<" + strings.Join(fields, ",") + ">\n")
+	}
+	columns := requiredColumns
+	if len(header.Samples) > 0 {
+		columns = append(append(append([]string{}, requiredColumns...), "FORMAT"), header.Samples...)
+	}
+	headerBuffer.WriteString(strings.Join(columns, "\t") + "\n")
+	_, err := w.Write(headerBuffer.Bytes())
+	return &Writer{writer: w, samples: len(header.Samples)}, err
+}
+
+// quoteMetaValue quotes the value of a structured meta-information field if
+// the spec requires it or if it couldn't be parsed otherwise.
+func quoteMetaValue(key string, value string) string {
+	switch key {
+	case "Description", "Source", "Version":
+	default:
+		if !strings.ContainsAny(value, ",\"<> ") {
+			return value
+		}
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// Write writes a single record.
+func (writer *Writer) Write(record Record) error {
+	if len(record.Samples) != writer.samples {
+		return fmt.Errorf("record %s:%d has %d samples, header has %d", record.Chromosome, record.Position, len(record.Samples), writer.samples)
+	}
+	_, err := writer.writer.Write(buildRecord(record))
+	return err
+}
+
+// buildRecord builds a single record line.
+func buildRecord(record Record) []byte {
+	quality := missingValue
+	if record.HasQuality {
+		quality = strconv.FormatFloat(record.Quality, 'f', -1, 64)
+	}
+	infos := make([]string, len(record.Info))
+	for infoIndex, info := range record.Info {
+		infos[infoIndex] = info.Key
+		if len(info.Values) > 0 {
+			infos[infoIndex] += "=" + strings.Join(info.Values, ",")
+		}
+	}
+	fields := []string{
+		record.Chromosome,
+		strconv.Itoa(record.Position),
+		joinMissing(record.IDs, ";"),
+		record.Reference,
+		joinMissing(record.Alternates, ","),
+		quality,
+		joinMissing(record.Filters, ";"),
+		joinMissing(infos, ";"),
+	}
+	if len(record.Samples) > 0 {
+		fields = append(fields, joinMissing(record.Format, ":"))
+		for _, sample := range record.Samples {
+			fields = append(fields, joinMissing(sample, ":"))
+		}
+	}
+	return []byte(strings.Join(fields, "\t") + "\n")
+}
+
+// joinMissing joins values, returning . if there are none.
+func joinMissing(values []string, separator string) string {
+	if len(values) == 0 {
+		return missingValue
+	}
+	return strings.Join(values, separator)
+}
+
+// Build builds a VCF file from a header and a list of records.
+func Build(header Header, records []Record) ([]byte, error) {
+	var vcfBuffer bytes.Buffer
+	writer, err := NewWriter(&vcfBuffer, header)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if err = writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	return vcfBuffer.Bytes(), nil
+}
+
+// Write writes a header and a list of records to a VCF file.
+func Write(header Header, records []Record, path string) error {
+	vcfBytes, err := Build(header, records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, vcfBytes, 0644)
+}
@@ -0,0 +1,192 @@
+package vcf
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	header, records, err := Read("data/example.vcf")
+	if err != nil {
+		t.Fatalf("Failed to read example.vcf. Got error: %s", err)
+	}
+	if header.FileFormat != "VCFv4.3" {
+		t.Errorf("Expected file format VCFv4.3. Got: %s", header.FileFormat)
+	}
+	if len(header.MetaInformation) != 17 {
+		t.Errorf("Expected 17 meta-information lines. Got: %d", len(header.MetaInformation))
+	}
+	if !reflect.DeepEqual(header.Samples, []string{"NA00001", "NA00002", "NA00003"}) {
+		t.Errorf("Unexpected samples: %v", header.Samples)
+	}
+	expectedDefinition := Definition{ID: "DB", Number: "0", Type: "Flag", Description: "dbSNP membership, build 129"}
+	if definition, ok := header.Info("DB"); !ok || definition != expectedDefinition {
+		t.Errorf("Expected INFO definition %v. Got: %v", expectedDefinition, definition)
+	}
+	if definition, ok := header.Format("HQ"); !ok || definition.Number != "2" {
+		t.Errorf("Expected FORMAT definition for HQ with Number 2. Got: %v", definition)
+	}
+	if species, _ := header.MetaInformation[3].Get("species"); species != "Homo sapiens" {
+		t.Errorf("Expected contig species 'Homo sapiens'. Got: %s", species)
+	}
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records. Got: %d", len(records))
+	}
+
+	first := records[0]
+	if first.Chromosome != "20" || first.Position != 14370 || first.Quality != 29 || !first.HasQuality {
+		t.Errorf("Wrong fields parsed for first record: %+v", first)
+	}
+	if _, ok := first.GetInfo("H2"); !ok {
+		t.Errorf("Expected H2 flag in first record")
+	}
+	genotype, err := first.Genotype(1)
+	if err != nil || !reflect.DeepEqual(genotype, Genotype{Alleles: []int{1, 0}, Phased: true}) {
+		t.Errorf("Expected genotype 1|0. Got: %v, %v", genotype, err)
+	}
+
+	// multi-allelic record
+	multiAllelic := records[2]
+	if !reflect.DeepEqual(multiAllelic.Alleles(), []string{"A", "G", "T"}) {
+		t.Errorf("Unexpected alleles: %v", multiAllelic.Alleles())
+	}
+	if frequencies, _ := multiAllelic.GetInfo("AF"); !reflect.DeepEqual(frequencies, []string{"0.333", "0.667"}) {
+		t.Errorf("Unexpected allele frequencies: %v", frequencies)
+	}
+	genotype, _ = multiAllelic.Genotype(2)
+	if genotype.String() != "2/2" || multiAllelic.Alleles()[genotype.Alleles[0]] != "T" {
+		t.Errorf("Expected genotype 2/2 of allele T. Got: %s", genotype)
+	}
+	// HQ was dropped at the end of the sample column.
+	if value, ok := multiAllelic.SampleValue(2, "HQ"); !ok || value != "." {
+		t.Errorf("Expected dropped HQ value to be missing. Got: %s", value)
+	}
+
+	noAlternate := records[3]
+	if noAlternate.Alternates != nil || noAlternate.IDs != nil {
+		t.Errorf("Expected missing ALT and ID. Got: %v and %v", noAlternate.Alternates, noAlternate.IDs)
+	}
+}
+
+func TestParseGenotype(t *testing.T) {
+	genotypes := map[string]Genotype{
+		"0/1":   {Alleles: []int{0, 1}},
+		"1|2":   {Alleles: []int{1, 2}, Phased: true},
+		"./.":   {Alleles: []int{-1, -1}},
+		"1":     {Alleles: []int{1}},
+		"0/1|2": {Alleles: []int{0, 1, 2}},
+	}
+	for value, expected := range genotypes {
+		genotype, err := ParseGenotype(value)
+		if err != nil || !reflect.DeepEqual(genotype, expected) {
+			t.Errorf("Expected genotype %v for %s. Got: %v, %v", expected, value, genotype, err)
+		}
+	}
+	for _, value := range []string{"", "a/1", "-1/0"} {
+		if _, err := ParseGenotype(value); err == nil {
+			t.Errorf("Test should have failed parsing genotype %q", value)
+		}
+	}
+}
+
+func TestParseExceptions(t *testing.T) {
+	header := strings.Join([]string{
+		"##fileformat=VCFv4.3",
+		`##INFO=<ID=DP,Number=1,Type=Integer,Description="Total Depth">`,
+		`##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">`,
+		`##INFO=<ID=DB,Number=0,Type=Flag,Description="dbSNP membership">`,
+		`##FORMAT=<ID=AD,Number=R,Type=Integer,Description="Allelic depths">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\ts1",
+	}, "\n") + "\n"
+	badRecords := []string{
+		"1\t10\t.\tA\tG\t.\t.\tDP=4\tAD\n",            // missing sample
+		"1\tten\t.\tA\tG\t.\t.\tDP=4\tAD\t1,3\n",      // bad position
+		"1\t10\t.\tA\tG\tq\t.\tDP=4\tAD\t1,3\n",       // bad quality
+		"1\t10\t.\tA\tG\t.\t.\tDP=four\tAD\t1,3\n",    // bad integer
+		"1\t10\t.\tA\tG,T\t.\t.\tAF=0.5\tAD\t1,3,0\n", // Number=A mismatch
+		"1\t10\t.\tA\tG\t.\t.\tDB=1\tAD\t1,3\n",       // flag with value
+		"1\t10\t.\tA\tG\t.\t.\tDP=4\tAD\t1,3,4\n",     // Number=R mismatch
+		"1\t10\t.\tA\tG\t.\t.\tDP=4\tAD\t1,3:5\n",     // more values than FORMAT keys
+	}
+	for _, badRecord := range badRecords {
+		_, _, err := Parse(strings.NewReader(header + badRecord))
+		if err == nil {
+			t.Errorf("Test should have failed parsing %q", badRecord)
+		}
+	}
+	_, records, err := Parse(strings.NewReader(header + "1\t10\t.\tA\tG,T\t.\t.\tAF=.;UNDEFINED=x\tAD\t.\n"))
+	if err != nil || len(records) != 1 {
+		t.Errorf("Failed to parse record with missing and undefined values. Got error: %s", err)
+	}
+
+	badHeaders := []string{
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n",                           // no fileformat
+		"##fileformat=VCFv4.3\n##INFO\n",                                            // meta line without =
+		"##fileformat=VCFv4.3\n##INFO=<ID=DP,Description=\"x>\n",                    // unterminated quote
+		"##fileformat=VCFv4.3\n#CHROM\tPOS\n",                                       // missing columns
+		"##fileformat=VCFv4.3\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\ts1\n", // samples without FORMAT
+		"##fileformat=VCFv4.3\n",                                                    // no header line
+	}
+	for _, badHeader := range badHeaders {
+		if _, _, err = Parse(strings.NewReader(badHeader)); err == nil {
+			t.Errorf("Test should have failed parsing header %q", badHeader)
+		}
+	}
+
+	file, _ := os.Open("data/example.vcf")
+	defer file.Close()
+	if _, _, err = NewParser(file, 64); err == nil {
+		t.Errorf("Should have encountered a maxLine error")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	vcfBytes, err := os.ReadFile("data/example.vcf")
+	if err != nil {
+		t.Fatalf("Failed to read example.vcf. Got error: %s", err)
+	}
+	header, records, err := Parse(strings.NewReader(string(vcfBytes)))
+	if err != nil {
+		t.Fatalf("Failed to parse example.vcf. Got error: %s", err)
+	}
+	builtBytes, err := Build(header, records)
+	if err != nil {
+		t.Fatalf("Failed to build vcf. Got error: %s", err)
+	}
+	if string(builtBytes) != string(vcfBytes) {
+		t.Errorf("Built vcf differs from example.vcf. Got:\n%s", builtBytes)
+	}
+
+	// Escaped quotes survive a round trip.
+	header = Header{MetaInformation: []MetaInformation{{Key: "INFO", Fields: []MetaField{{Key: "ID", Value: "X"}, {Key: "Description", Value: `a "quoted", \ value`}}}}}
+	builtBytes, _ = Build(header, nil)
+	parsedHeader, _, err := Parse(strings.NewReader(string(builtBytes)))
+	if err != nil || !reflect.DeepEqual(parsedHeader.MetaInformation, header.MetaInformation) {
+		t.Errorf("Quoted description changed after round trip. Got: %v, %v", parsedHeader.MetaInformation, err)
+	}
+
+	_, err = Build(Header{Samples: []string{"s1"}}, []Record{{Chromosome: "1", Position: 1, Reference: "A"}})
+	if err == nil {
+		t.Errorf("Test should have failed building a record with the wrong amount of samples")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	header, records, _ := Read("data/example.vcf")
+	path := t.TempDir() + "/example.vcf"
+	if err := Write(header, records, path); err != nil {
+		t.Fatalf("Failed to write vcf. Got error: %s", err)
+	}
+	writtenHeader, writtenRecords, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read written vcf. Got error: %s", err)
+	}
+	if !reflect.DeepEqual(header, writtenHeader) || !reflect.DeepEqual(records, writtenRecords) {
+		t.Errorf("Records changed after writing and reading")
+	}
+	if _, _, err = Read("data/doesntexist.vcf"); err == nil {
+		t.Errorf("Should have failed to read non-existent file")
+	}
+}
@@ -26,7 +26,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/io/annotation"
 	weightedRand "github.com/mroth/weightedrand"
 )
 
@@ -48,7 +48,7 @@ File is structured as so:
 
     TranslationTable.Optimize - will return a set of codons which can be used to encode the given amino acid sequence. The codons picked are weighted according to the computed translation table's weights
 
-    TranslationTable.UpdateWeightsWithSequence - will look at the coding regions in the given genbank data, and use those to generate new weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated weights.
+    TranslationTable.UpdateWeightsWithSequence - will look at the coding regions in the given annotated sequence (genbank, gff or polyjson), and use those to generate new weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated weights.
 
 		TranslationTable.Stats - a set of statistics we maintain throughout the translation table's lifetime. For example we track the start codons observed when we update the codon table's weights with other DNA sequences
 ******************************************************************************/
@@ -225,13 +225,13 @@ func (table *TranslationTable) UpdateWeights(aminoAcids []AminoAcid) error {
 	return nil
 }
 
-// UpdateWeightsWithSequence will look at the coding regions in the given genbank data, and use those to generate new
-// weights for the codons in the translation table. The next time a sequence is optimised, it will use those updated
-// weights.
+// UpdateWeightsWithSequence will look at the coding regions in the given annotated sequence, like a genbank.Genbank,
+// gff.Gff or polyjson.Poly, and use those to generate new weights for the codons in the translation table. The next
+// time a sequence is optimised, it will use those updated weights.
 //
 // This can be used to, for example, figure out which DNA sequence is needed to give the best yield of protein when
 // trying to express a protein across different species
-func (table *TranslationTable) UpdateWeightsWithSequence(data genbank.Genbank) error {
+func (table *TranslationTable) UpdateWeightsWithSequence(data annotation.AnnotatedSequence) error {
 	codingRegions, err := extractCodingRegion(data)
 	if err != nil {
 		return err
@@ -294,14 +294,17 @@ func weightAminoAcids(sequence string, aminoAcids []AminoAcid) []AminoAcid {
 	return aminoAcids
 }
 
-// extractCodingRegion loops through genbank data to find all CDS (coding sequences)
-func extractCodingRegion(data genbank.Genbank) ([]string, error) {
+// extractCodingRegion loops through annotated sequence data to find all CDS (coding sequences)
+func extractCodingRegion(data annotation.AnnotatedSequence) ([]string, error) {
 	codingRegions := []string{}
 
-	// iterate through the features of the genbank file and if the feature is a coding region, append the sequence to the string builder
-	for _, feature := range data.Features {
-		if feature.Type == "CDS" {
-			sequence, err := feature.GetSequence()
+	// iterate through the features of every record and if the feature is a coding region, append the sequence to the string builder
+	for _, record := range data.Records() {
+		for _, feature := range record.Features {
+			if feature.Type != "CDS" {
+				continue
+			}
+			sequence, err := record.GetSequence(feature)
 			if err != nil {
 				return nil, err
 			}
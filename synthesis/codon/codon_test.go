@@ -6,7 +6,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bebop/poly/io/annotation"
 	"github.com/bebop/poly/io/genbank"
+	"github.com/bebop/poly/io/gff"
+	"github.com/bebop/poly/io/polyjson"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	weightedRand "github.com/mroth/weightedrand"
@@ -95,6 +98,40 @@ func TestOptimize(t *testing.T) {
 	}
 }
 
+func TestUpdateWeightsWithSequence_formats(t *testing.T) {
+	sequence, err := genbank.Read("../../data/puc19.gbk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gffSequence := gff.FromRecords(sequence.Records()...)
+	polySequence := polyjson.FromRecord(sequence.Records()[0])
+
+	genbankTable, err := NewTranslationTable(11)
+	if err != nil {
+		t.Fatalf("failed to initialise codon table: %s", err)
+	}
+	if err = genbankTable.UpdateWeightsWithSequence(sequence); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, annotated := range []annotation.AnnotatedSequence{gffSequence, polySequence} {
+		table, err := NewTranslationTable(11)
+		if err != nil {
+			t.Fatalf("failed to initialise codon table: %s", err)
+		}
+		if err = table.UpdateWeightsWithSequence(annotated); err != nil {
+			t.Fatalf("UpdateWeightsWithSequence(%T) failed: %s", annotated, err)
+		}
+		sortAminoAcids := cmpopts.SortSlices(func(a, b AminoAcid) bool { return a.Letter < b.Letter })
+		if diff := cmp.Diff(genbankTable.AminoAcids, table.AminoAcids, sortAminoAcids); diff != "" {
+			t.Errorf("%T weights differ from genbank weights (-genbank +got):\n%s", annotated, diff)
+		}
+		if diff := cmp.Diff(genbankTable.Stats, table.Stats); diff != "" {
+			t.Errorf("%T stats differ from genbank stats (-genbank +got):\n%s", annotated, diff)
+		}
+	}
+}
+
 func TestOptimizeSameSeed(t *testing.T) {
 	var gfpTranslation = "MASKGEELFTGVVPILVELDGDVNGHKFSVSGEGEGDATYGKLTLKFICTTGKLPVPWPTLVTTFSYGVQCFSRYPDHMKRHDFFKSAMPEGYVQERTISFKDDGNYKTRAEVKFEGDTLVNRIELKGIDFKEDGNILGHKLEYNYNSHNVYITADKQKNGIKANFKIRHNIEDGSVQLADHYQQNTPIGDGPVLLPDNHYLSTQSALSKDPNEKRDHMVLLEFVTAAGITHGMDELYK*"
 	var sequence, _ = genbank.Read("../../data/puc19.gbk")